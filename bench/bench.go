@@ -0,0 +1,189 @@
+// Package bench measures the throughput of objectify scans. It generates
+// synthetic directory trees with configurable file counts and sizes, then scans
+// them with each of a list of Sets combinations, so performance regressions can
+// be measured rather than guessed at.
+package bench
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	objf "github.com/orme292/objectify"
+)
+
+// Tree describes a synthetic directory tree built by Generate. The tree has
+// Dirs directories, nested Depth levels deep, each holding FilesPerDir files
+// whose sizes are spread evenly between MinSize and MaxSize bytes.
+type Tree struct {
+	Dirs        int
+	Depth       int
+	FilesPerDir int
+	MinSize     int64
+	MaxSize     int64
+
+	// Seed makes the generated content and sizes reproducible.
+	Seed int64
+}
+
+// DefaultTree is a small tree of 1,000 files, from empty to 256 KiB.
+var DefaultTree = Tree{
+	Dirs:        10,
+	Depth:       2,
+	FilesPerDir: 100,
+	MinSize:     0,
+	MaxSize:     256 * 1024,
+	Seed:        1,
+}
+
+// Files returns the number of files the Tree holds.
+func (t Tree) Files() int {
+	return t.Dirs * t.FilesPerDir
+}
+
+// Generate creates the Tree under root, which is created if it does not exist.
+// It returns the total number of bytes written.
+func Generate(root string, t Tree) (int64, error) {
+
+	if t.Dirs < 1 || t.FilesPerDir < 0 || t.MinSize < 0 || t.MaxSize < t.MinSize {
+		return 0, fmt.Errorf("invalid tree: %+v", t)
+	}
+
+	rng := rand.New(rand.NewSource(t.Seed))
+
+	var total int64
+	for d := 0; d < t.Dirs; d++ {
+
+		dir := root
+		for level := 0; level < max(t.Depth, 1); level++ {
+			dir = filepath.Join(dir, fmt.Sprintf("d%02d-%d", level, d))
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return total, err
+		}
+
+		for f := 0; f < t.FilesPerDir; f++ {
+
+			size := t.MinSize
+			if t.MaxSize > t.MinSize {
+				size += rng.Int63n(t.MaxSize - t.MinSize + 1)
+			}
+
+			n, err := writeFile(filepath.Join(dir, fmt.Sprintf("f%05d.bin", f)), rng, size)
+			total += n
+			if err != nil {
+				return total, err
+			}
+
+		}
+
+	}
+
+	return total, nil
+
+}
+
+// writeFile writes size pseudo-random bytes from rng to a new file at path.
+func writeFile(path string, rng *rand.Rand, size int64) (n int64, err error) {
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if cErr := f.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}()
+
+	return io.CopyN(f, rng, size)
+
+}
+
+// Case is a named Sets combination to benchmark.
+type Case struct {
+	Name string
+	Sets objf.Sets
+}
+
+// DefaultCases benchmarks metadata only, each checksum alone, and everything.
+func DefaultCases() []Case {
+	return []Case{
+		{"none", objf.SetsNone()},
+		{"nochecksums", objf.SetsAllNoChecksums()},
+		{"md5", objf.SetsAllMD5()},
+		{"sha256", objf.SetsAllSHA256()},
+		{"all", objf.SetsAll()},
+	}
+}
+
+// Result is the outcome of benchmarking one Case. Stats are those of the
+// fastest run.
+type Result struct {
+	Case Case
+	Runs int
+
+	Stats objf.ScanStats
+
+	// BytesPerSecond is Stats.BytesHashed divided by Stats.Duration.
+	BytesPerSecond float64
+}
+
+// Run scans root recursively runs times for each case, with the given Options
+// added, and returns a Result per case. runs less than 1 is treated as 1. The
+// first error ends the benchmark.
+func Run(root string, cases []Case, runs int, opts ...objf.Option) ([]Result, error) {
+
+	if runs < 1 {
+		runs = 1
+	}
+	opts = append([]objf.Option{objf.WithRecursive()}, opts...)
+
+	var results []Result
+	for _, c := range cases {
+
+		r := Result{Case: c, Runs: runs}
+		for i := 0; i < runs; i++ {
+
+			res, err := objf.Scan(root, c.Sets, opts...)
+			if err != nil {
+				return results, fmt.Errorf("%s: %w", c.Name, err)
+			}
+			if i == 0 || res.Stats.Duration < r.Stats.Duration {
+				r.Stats = res.Stats
+			}
+
+		}
+		if secs := r.Stats.Duration.Seconds(); secs > 0 {
+			r.BytesPerSecond = float64(r.Stats.BytesHashed) / secs
+		}
+		results = append(results, r)
+
+	}
+
+	return results, nil
+
+}
+
+// WriteText writes the results as a table, e.g.:
+//
+//	CASE    ENTRIES  TIME   FILES/S  HASHED/S    SYSCALLS
+//	none    1000     12ms   83333.3  0 B         3030
+//	sha256  1000     310ms  3225.8   411.29 MiB  12080
+func WriteText(w io.Writer, results []Result) error {
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CASE\tENTRIES\tTIME\tFILES/S\tHASHED/S\tSYSCALLS")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%.1f\t%s\t%d\n",
+			r.Case.Name, r.Stats.Entries, r.Stats.Duration.Round(time.Millisecond), r.Stats.FilesPerSecond,
+			objf.FormatSize(int64(r.BytesPerSecond), objf.SizeBinary, 2), r.Stats.Syscalls)
+	}
+
+	return tw.Flush()
+
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	objf "github.com/orme292/objectify"
+	"github.com/orme292/objectify/bench"
+)
+
+// runBench generates a synthetic tree (or uses the given directory) and
+// prints the scan throughput for each Sets combination.
+func runBench(args []string) int {
+
+	t := bench.DefaultTree
+	var runs, jobs int
+	var cases string
+
+	fl := newFlagSet("bench", "[DIR]")
+	fl.IntVar(&t.Dirs, "dirs", t.Dirs, "number of directories to generate")
+	fl.IntVar(&t.Depth, "depth", t.Depth, "nesting depth of the generated directories")
+	fl.IntVar(&t.FilesPerDir, "files", t.FilesPerDir, "number of files per generated directory")
+	fl.Int64Var(&t.MinSize, "min-size", t.MinSize, "minimum generated file size in bytes")
+	fl.Int64Var(&t.MaxSize, "max-size", t.MaxSize, "maximum generated file size in bytes")
+	fl.IntVar(&runs, "runs", 3, "scans per case; the fastest is reported")
+	fl.IntVar(&jobs, "j", objf.DefaultConcurrency, "number of entries to objectify at once")
+	fl.StringVar(&cases, "sets", "none,nochecksums,md5,sha256,all", "comma-separated Sets presets to benchmark")
+	if err := fl.Parse(args); err != nil || fl.NArg() > 1 {
+		fl.Usage()
+		return exitUsage
+	}
+
+	var bc []bench.Case
+	for _, name := range strings.Split(cases, ",") {
+		preset, ok := setsPresets[name]
+		if !ok {
+			return fail(fmt.Errorf("unknown -sets value %q (want %s)", name, presetNames()))
+		}
+		bc = append(bc, bench.Case{Name: name, Sets: preset()})
+	}
+
+	root := fl.Arg(0)
+	if root == "" {
+
+		dir, err := os.MkdirTemp("", "objectify-bench-")
+		if err != nil {
+			return fail(err)
+		}
+		defer os.RemoveAll(dir)
+
+		n, err := bench.Generate(dir, t)
+		if err != nil {
+			return fail(err)
+		}
+		fmt.Fprintf(os.Stderr, "generated %d files (%s) in %s\n", t.Files(), objf.FormatSize(n, objf.SizeBinary, 2), dir)
+		root = dir
+
+	}
+
+	var opts []objf.Option
+	if jobs > 1 {
+		opts = append(opts, objf.WithConcurrency(jobs))
+	}
+
+	results, err := bench.Run(root, bc, runs, opts...)
+	if err != nil {
+		return fail(err)
+	}
+	if err := bench.WriteText(os.Stdout, results); err != nil {
+		return fail(err)
+	}
+
+	return exitOK
+
+}
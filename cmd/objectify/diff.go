@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	objf "github.com/orme292/objectify"
+)
+
+// runDiff scans two directory trees and reports entries which were added,
+// removed, or changed in NEW relative to OLD. It exits with exitFailed if the
+// trees differ.
+func runDiff(args []string) int {
+
+	var sf scanFlags
+
+	fl := newFlagSet("diff", "OLD NEW")
+	sf.register(fl, "sha256")
+	if err := fl.Parse(args); err != nil || fl.NArg() != 2 {
+		fl.Usage()
+		return exitUsage
+	}
+
+	sets, opts, err := sf.options()
+	if err != nil {
+		return fail(err)
+	}
+
+	oldRoot, newRoot := absPath(fl.Arg(0)), absPath(fl.Arg(1))
+
+	olds, err := objf.Path(oldRoot, sets, opts...)
+	if err != nil {
+		return fail(err)
+	}
+	news, err := objf.Path(newRoot, sets, opts...)
+	if err != nil {
+		return fail(err)
+	}
+
+	news.Rebase(newRoot, oldRoot)
+	d := olds.Diff(news)
+
+	if err := writeStatus(os.Stdout, diffRows(d, oldRoot), sf.format); err != nil {
+		return fail(err)
+	}
+
+	if !d.Empty() {
+		return exitFailed
+	}
+
+	return exitOK
+
+}
+
+// diffRows converts a Diff into status rows with paths relative to root.
+func diffRows(d objf.Diff, root string) []statusRow {
+
+	var rows []statusRow
+
+	for _, fo := range d.Added {
+		rows = append(rows, statusRow{Status: "added", Path: relPath(root, fo.FullPath())})
+	}
+	for _, fo := range d.Removed {
+		rows = append(rows, statusRow{Status: "removed", Path: relPath(root, fo.FullPath())})
+	}
+	for _, c := range d.Changed {
+		rows = append(rows, statusRow{Status: "changed", Path: relPath(root, c.New.FullPath()), Detail: changeDetail(c)})
+	}
+
+	return rows
+
+}
+
+// changeDetail describes which fields differ between the old and new FileObj.
+func changeDetail(c objf.Change) string {
+
+	var parts []string
+
+	if c.Old.SizeBytes != c.New.SizeBytes {
+		parts = append(parts, fmt.Sprintf("size %d -> %d", c.Old.SizeBytes, c.New.SizeBytes))
+	}
+	if c.Old.Mode != c.New.Mode {
+		parts = append(parts, fmt.Sprintf("mode %s -> %s", c.Old.Mode, c.New.Mode))
+	}
+	if c.Old.ChecksumSHA256 != c.New.ChecksumSHA256 || c.Old.ChecksumMD5 != c.New.ChecksumMD5 {
+		parts = append(parts, "content")
+	}
+	if c.Old.Target != c.New.Target {
+		parts = append(parts, "target")
+	}
+	if !c.Old.ModTime().Equal(c.New.ModTime()) {
+		parts = append(parts, "mtime")
+	}
+
+	return strings.Join(parts, ", ")
+
+}
+
+// absPath returns the absolute form of path, or path itself on error.
+func absPath(path string) string {
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return abs
+
+}
+
+// relPath returns path relative to root, or path itself on error.
+func relPath(root, path string) string {
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+
+	return rel
+
+}
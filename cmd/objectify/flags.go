@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	objf "github.com/orme292/objectify"
+)
+
+// setsPresets maps -sets flag values to Sets builder functions.
+var setsPresets = map[string]func() objf.Sets{
+	"all":         objf.SetsAll,
+	"none":        objf.SetsNone,
+	"nochecksums": objf.SetsAllNoChecksums,
+	"md5":         objf.SetsAllMD5,
+	"sha256":      objf.SetsAllSHA256,
+	"fast":        objf.SetsFast,
+	"sync":        objf.SetsSync,
+	"security":    objf.SetsSecurity,
+}
+
+// scanFlags holds the flags shared by commands which scan a directory.
+type scanFlags struct {
+	recursive bool
+	xdev      bool
+	sets      string
+	format    string
+	jobs      int
+	hashJobs  int
+	sorted    bool
+}
+
+// newFlagSet returns a flag.FlagSet for the named command which prints
+// usage with the given argument synopsis.
+func newFlagSet(name, synopsis string) *flag.FlagSet {
+
+	fl := flag.NewFlagSet(name, flag.ContinueOnError)
+	fl.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [flags] %s\n\nFlags:\n", programName, name, synopsis)
+		fl.PrintDefaults()
+	}
+
+	return fl
+
+}
+
+// register adds the shared scan flags to fl. The default Sets preset is
+// defaultSets.
+func (sf *scanFlags) register(fl *flag.FlagSet, defaultSets string) {
+
+	fl.BoolVar(&sf.recursive, "r", false, "descend into subdirectories")
+	fl.BoolVar(&sf.xdev, "xdev", false, "stay on the root path's filesystem")
+	fl.StringVar(&sf.sets, "sets", defaultSets, "fields to populate: "+presetNames())
+	fl.StringVar(&sf.format, "format", formatTable, "output format: table, json, or csv")
+	fl.IntVar(&sf.jobs, "j", objf.DefaultConcurrency, "number of entries to objectify at once")
+	fl.IntVar(&sf.hashJobs, "hash-j", 0, "number of hash workers reading file content, fed by the -j workers; 0 hashes in the -j workers")
+	fl.BoolVar(&sf.sorted, "sort", false, "sort entries by full path instead of walk order")
+
+}
+
+// options returns the Sets and Options selected by the flags.
+func (sf *scanFlags) options() (objf.Sets, []objf.Option, error) {
+
+	preset, ok := setsPresets[sf.sets]
+	if !ok {
+		return objf.Sets{}, nil, fmt.Errorf("unknown -sets value %q (want %s)", sf.sets, presetNames())
+	}
+	if !validFormat(sf.format) {
+		return objf.Sets{}, nil, fmt.Errorf("unknown -format value %q", sf.format)
+	}
+
+	var opts []objf.Option
+	if sf.recursive {
+		opts = append(opts, objf.WithRecursive())
+	}
+	if sf.xdev {
+		opts = append(opts, objf.WithOneFileSystem())
+	}
+	if sf.jobs > 1 {
+		opts = append(opts, objf.WithStatWorkers(sf.jobs))
+	}
+	if sf.hashJobs > 0 {
+		opts = append(opts, objf.WithHashWorkers(sf.hashJobs))
+	}
+	if sf.sorted {
+		opts = append(opts, objf.WithSortedPaths())
+	}
+
+	return preset(), opts, nil
+
+}
+
+// presetNames returns the valid -sets values.
+func presetNames() string {
+	return strings.Join([]string{"all", "none", "nochecksums", "md5", "sha256", "fast", "sync", "security"}, ", ")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	objf "github.com/orme292/objectify"
+)
+
+// runHash prints the checksum of each file argument. The table format matches
+// the output of sha256sum/md5sum, so it can be used as a manifest for verify.
+func runHash(args []string) int {
+
+	var algo, format string
+
+	fl := newFlagSet("hash", "FILE...")
+	fl.StringVar(&algo, "algo", "sha256", "checksum algorithm: sha256 or md5")
+	fl.StringVar(&format, "format", formatTable, "output format: table, json, or csv")
+	if err := fl.Parse(args); err != nil || fl.NArg() == 0 {
+		fl.Usage()
+		return exitUsage
+	}
+
+	var sets objf.Sets
+	switch algo {
+	case "sha256":
+		sets = objf.Sets{Size: true, ChecksumSHA256: true}
+	case "md5":
+		sets = objf.Sets{Size: true, ChecksumMD5: true}
+	default:
+		return fail(fmt.Errorf("unknown -algo value %q", algo))
+	}
+	if !validFormat(format) {
+		return fail(fmt.Errorf("unknown -format value %q", format))
+	}
+
+	code := exitOK
+	files := objf.Files{}
+
+	for _, path := range fl.Args() {
+
+		fo, err := objf.File(path, sets)
+		if err != nil || fo == nil {
+			fmt.Fprintf(os.Stderr, "%s: %s: not a readable file\n", programName, path)
+			code = exitFailed
+			continue
+		}
+		files = append(files, fo)
+
+	}
+
+	if format != formatTable {
+		if err := writeFiles(os.Stdout, files, format); err != nil {
+			return fail(err)
+		}
+		return code
+	}
+
+	for _, fo := range files {
+		sum := fo.ChecksumSHA256
+		if algo == "md5" {
+			sum = fo.ChecksumMD5
+		}
+		fmt.Printf("%s  %s\n", sum, fo.FullPath())
+	}
+
+	return code
+
+}
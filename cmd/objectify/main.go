@@ -0,0 +1,88 @@
+// Command objectify exposes the objectify package from the shell.
+//
+// Usage:
+//
+//	objectify scan    [flags] PATH           list entries with size, mode, and checksums
+//	objectify hash    [flags] FILE...        print checksums in sha256sum/md5sum format
+//	objectify diff    [flags] OLD NEW        compare two directory trees
+//	objectify verify  [flags] MANIFEST       verify files against a checksum manifest
+//	objectify watch   [flags] PATH           rescan periodically and print changes
+//	objectify bench   [flags] [DIR]          measure scan throughput on a synthetic tree
+//	objectify largest [flags] PATH           list the largest files, grouped by directory
+//	objectify stale   [flags] PATH           list files not modified in a number of days
+//
+// Run "objectify COMMAND -h" for the flags of each command.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	exitOK      = 0
+	exitFailed  = 1
+	exitUsage   = 2
+	programName = "objectify"
+)
+
+// command is a subcommand which receives its own arguments and returns
+// an exit code.
+type command func(args []string) int
+
+var commands = map[string]command{
+	"scan":    runScan,
+	"hash":    runHash,
+	"diff":    runDiff,
+	"verify":  runVerify,
+	"watch":   runWatch,
+	"bench":   runBench,
+	"largest": runLargest,
+	"stale":   runStale,
+}
+
+func main() {
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		if os.Args[1] != "-h" && os.Args[1] != "--help" && os.Args[1] != "help" {
+			fmt.Fprintf(os.Stderr, "%s: unknown command %q\n", programName, os.Args[1])
+		}
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	os.Exit(cmd(os.Args[2:]))
+
+}
+
+// usage prints the list of commands to stderr.
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s COMMAND [flags] ARGS
+
+Commands:
+  scan    PATH        list entries with size, mode, and checksums
+  hash    FILE...     print checksums in sha256sum/md5sum format
+  diff    OLD NEW     compare two directory trees
+  verify  MANIFEST    verify files against a checksum manifest
+  watch   PATH        rescan periodically and print changes
+  bench   [DIR]       measure scan throughput on a synthetic tree
+  largest PATH        list the largest files, grouped by directory
+  stale   PATH        list files not modified in a number of days
+
+Run "%s COMMAND -h" for the flags of each command.
+`, programName, programName)
+}
+
+// fail prints an error to stderr and returns exitFailed.
+func fail(err error) int {
+
+	fmt.Fprintf(os.Stderr, "%s: %v\n", programName, err)
+	return exitFailed
+
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	objf "github.com/orme292/objectify"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// validFormat returns true if f is a supported -format value.
+func validFormat(f string) bool {
+	return f == formatTable || f == formatJSON || f == formatCSV
+}
+
+// record is the serialized form of a FileObj used by the CLI's output.
+type record struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Mode    string `json:"mode"`
+	ModTime string `json:"mod_time"`
+	MD5     string `json:"md5,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+	Target  string `json:"target,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// newRecord returns the record for a FileObj.
+func newRecord(fo *objf.FileObj) record {
+
+	r := record{
+		Path:   fo.FullPath(),
+		Size:   fo.SizeBytes,
+		Mode:   fo.Mode.String(),
+		MD5:    fo.ChecksumMD5,
+		SHA256: fo.ChecksumSHA256,
+		Target: fo.Target,
+	}
+	if !fo.ModTime().IsZero() {
+		r.ModTime = fo.ModTime().Format(time.RFC3339)
+	}
+	if fo.Err != nil {
+		r.Error = fo.Err.Error()
+	}
+
+	return r
+
+}
+
+// fields returns the record's values in column order.
+func (r record) fields() []string {
+	return []string{r.Path, strconv.FormatInt(r.Size, 10), r.Mode, r.ModTime, r.MD5, r.SHA256, r.Target, r.Error}
+}
+
+var recordHeader = []string{"path", "size", "mode", "mod_time", "md5", "sha256", "target", "error"}
+
+// writeFiles writes files to w in the given format.
+func writeFiles(w io.Writer, files objf.Files, format string) error {
+
+	records := make([]record, 0, len(files))
+	for _, fo := range files {
+		records = append(records, newRecord(fo))
+	}
+
+	switch format {
+	case formatJSON:
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+
+	case formatCSV:
+
+		rows := [][]string{recordHeader}
+		for _, r := range records {
+			rows = append(rows, r.fields())
+		}
+		return writeCSV(w, rows)
+
+	default:
+
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "PATH\tSIZE\tMODE\tMODIFIED\tSHA256\tMD5")
+		for _, fo := range files {
+			r := newRecord(fo)
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Path, fo.SizeString(), r.Mode, r.ModTime, r.SHA256, r.MD5)
+		}
+		return tw.Flush()
+
+	}
+
+}
+
+// statusRow is a path paired with a status, used by diff, verify, and watch.
+type statusRow struct {
+	Status string `json:"status"`
+	Path   string `json:"path"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// writeStatus writes status rows to w in the given format.
+func writeStatus(w io.Writer, rows []statusRow, format string) error {
+
+	switch format {
+	case formatJSON:
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if rows == nil {
+			rows = []statusRow{}
+		}
+		return enc.Encode(rows)
+
+	case formatCSV:
+
+		out := [][]string{{"status", "path", "detail"}}
+		for _, r := range rows {
+			out = append(out, []string{r.Status, r.Path, r.Detail})
+		}
+		return writeCSV(w, out)
+
+	default:
+
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, r := range rows {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Status, r.Path, r.Detail)
+		}
+		return tw.Flush()
+
+	}
+
+}
+
+// writeCSV writes rows to w as CSV.
+func writeCSV(w io.Writer, rows [][]string) error {
+
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return cw.Error()
+
+}
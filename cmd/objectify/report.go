@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	objf "github.com/orme292/objectify"
+)
+
+// runLargest scans a directory and writes its largest files, grouped by
+// directory.
+func runLargest(args []string) int {
+
+	var sf scanFlags
+	var n int
+
+	fl := newFlagSet("largest", "PATH")
+	sf.register(fl, "fast")
+	fl.IntVar(&n, "n", 20, "number of files to list; 0 lists every file")
+
+	return runStorageReport(fl, &sf, args, func(files objf.Files) *objf.StorageReport {
+		return files.Largest(n)
+	})
+
+}
+
+// runStale scans a directory and writes the files not modified in the given
+// number of days, grouped by directory.
+func runStale(args []string) int {
+
+	var sf scanFlags
+	var days int
+
+	fl := newFlagSet("stale", "PATH")
+	sf.register(fl, "fast")
+	fl.IntVar(&days, "days", 365, "list files not modified in this many days")
+
+	return runStorageReport(fl, &sf, args, func(files objf.Files) *objf.StorageReport {
+		return files.Stale(time.Duration(days) * 24 * time.Hour)
+	})
+
+}
+
+// runStorageReport parses args with fl, scans the directory they name, and
+// writes the report built by report in the selected format.
+func runStorageReport(fl *flag.FlagSet, sf *scanFlags, args []string, report func(objf.Files) *objf.StorageReport) int {
+
+	if err := fl.Parse(args); err != nil || fl.NArg() != 1 {
+		fl.Usage()
+		return exitUsage
+	}
+
+	sets, opts, err := sf.options()
+	if err != nil {
+		return fail(err)
+	}
+
+	files, err := objf.Path(fl.Arg(0), sets, append(opts, objf.WithAllowEmpty())...)
+	if err != nil {
+		return fail(err)
+	}
+
+	r := report(files)
+	switch sf.format {
+	case formatJSON:
+		err = r.WriteJSON(os.Stdout)
+	case formatCSV:
+		err = r.WriteCSV(os.Stdout)
+	default:
+		err = r.WriteText(os.Stdout)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	return exitOK
+
+}
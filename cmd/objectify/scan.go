@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	objf "github.com/orme292/objectify"
+)
+
+// runScan objectifies a directory and writes its entries.
+func runScan(args []string) int {
+
+	var sf scanFlags
+	var stats, estimate bool
+	var tmpl string
+
+	fl := newFlagSet("scan", "PATH")
+	sf.register(fl, "all")
+	fl.BoolVar(&stats, "stats", false, "print scan statistics to stderr")
+	fl.BoolVar(&estimate, "estimate", false, "print the expected entries and bytes to hash to stderr before scanning")
+	fl.StringVar(&tmpl, "template", "", "Go text/template to print for each entry, e.g. '{{.FullPath}} {{.ChecksumSHA256}}' (overrides -format)")
+	if err := fl.Parse(args); err != nil || fl.NArg() != 1 {
+		fl.Usage()
+		return exitUsage
+	}
+
+	sets, opts, err := sf.options()
+	if err != nil {
+		return fail(err)
+	}
+
+	if estimate {
+		est, err := objf.EstimateScan(fl.Arg(0), sets, opts...)
+		if err != nil {
+			return fail(err)
+		}
+		fmt.Fprintln(os.Stderr, est)
+	}
+
+	res, err := objf.Scan(fl.Arg(0), sets, opts...)
+	if err != nil {
+		return fail(err)
+	}
+
+	if tmpl != "" {
+		err = res.Files.WriteTemplate(os.Stdout, tmpl)
+	} else {
+		err = writeFiles(os.Stdout, res.Files, sf.format)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if stats {
+		fmt.Fprintln(os.Stderr, res.Stats)
+	}
+
+	return exitOK
+
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	objf "github.com/orme292/objectify"
+)
+
+// runVerify checks files against a sha256sum/md5sum style manifest with
+// objectify.VerifyManifest. Relative paths in the manifest are resolved against
+// the manifest's directory, and BSD style lines are also accepted. It exits
+// with exitFailed if any file does not verify.
+func runVerify(args []string) int {
+
+	var format string
+	var quiet bool
+
+	fl := newFlagSet("verify", "MANIFEST")
+	fl.StringVar(&format, "format", formatTable, "output format: table, json, or csv")
+	fl.BoolVar(&quiet, "q", false, "only report files which do not verify")
+	if err := fl.Parse(args); err != nil || fl.NArg() != 1 {
+		fl.Usage()
+		return exitUsage
+	}
+	if !validFormat(format) {
+		return fail(fmt.Errorf("unknown -format value %q", format))
+	}
+
+	manifest := fl.Arg(0)
+	f, err := os.Open(manifest)
+	if err != nil {
+		return fail(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	report, err := objf.VerifyManifest(f, filepath.Dir(manifest))
+	if err != nil {
+		return fail(fmt.Errorf("%s: %w", manifest, err))
+	}
+	if quiet {
+		report.Results = report.Failures()
+	}
+
+	switch format {
+	case formatJSON:
+		err = report.WriteJSON(os.Stdout)
+	case formatCSV:
+		err = writeStatus(os.Stdout, verifyRows(report), format)
+	default:
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	if !report.Passed() {
+		return exitFailed
+	}
+
+	return exitOK
+
+}
+
+// verifyRows converts verification results into status rows.
+func verifyRows(report *objf.VerificationReport) []statusRow {
+
+	rows := make([]statusRow, 0, len(report.Results))
+	for _, res := range report.Results {
+		row := statusRow{Status: string(res.Status), Path: res.Path}
+		if res.Status == objf.VerifyMismatch {
+			row.Detail = res.Algorithm + " got " + res.Actual
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	objf "github.com/orme292/objectify"
+)
+
+// runWatch scans a directory, then rescans it on an interval and prints the
+// entries which were created, modified, deleted, or renamed since the previous
+// scan. It runs until interrupted.
+func runWatch(args []string) int {
+
+	var sf scanFlags
+	var interval time.Duration
+
+	fl := newFlagSet("watch", "PATH")
+	sf.register(fl, "nochecksums")
+	fl.DurationVar(&interval, "interval", 5*time.Second, "time between scans")
+	if err := fl.Parse(args); err != nil || fl.NArg() != 1 || interval <= 0 {
+		fl.Usage()
+		return exitUsage
+	}
+
+	sets, opts, err := sf.options()
+	if err != nil {
+		return fail(err)
+	}
+
+	root := absPath(fl.Arg(0))
+
+	prev, err := objf.Path(root, sets, opts...)
+	if err != nil {
+		return fail(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+
+		select {
+		case <-ctx.Done():
+			return exitOK
+		case <-ticker.C:
+		}
+
+		cur, err := objf.Path(root, sets, opts...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", programName, err)
+			continue
+		}
+
+		if events := prev.Events(cur); len(events) > 0 {
+			if err := writeStatus(os.Stdout, eventRows(events, root), sf.format); err != nil {
+				return fail(err)
+			}
+		}
+		prev = cur
+
+	}
+
+}
+
+// eventRows converts Events to status rows, with paths relative to root. The
+// status is the EventKind, and the detail of a renamed entry is its old path.
+func eventRows(events []objf.Event, root string) []statusRow {
+
+	rows := make([]statusRow, 0, len(events))
+	for _, e := range events {
+
+		row := statusRow{Status: e.Kind.String(), Path: relPath(root, e.Path())}
+		switch e.Kind {
+		case objf.EventRenamed:
+			row.Detail = "from " + relPath(root, e.Old.FullPath())
+		case objf.EventModified:
+			row.Detail = changeDetail(objf.Change{Old: e.Old, New: e.New})
+		}
+		rows = append(rows, row)
+
+	}
+
+	return rows
+
+}
@@ -0,0 +1,98 @@
+package objectify
+
+import (
+	"errors"
+	"io/fs"
+)
+
+var (
+	// ErrSymlinkCycle is recorded on a FileObj when resolving its final link
+	// target revisits a link or exceeds the configured maximum number of hops.
+	ErrSymlinkCycle = errors.New("symlink cycle detected")
+
+	// ErrNotLink is returned by link-specific methods called on a FileObj
+	// which does not represent a symlink.
+	ErrNotLink = errors.New("entry is not a symlink")
+
+	// ErrInvalidEncoding is returned when decoding a binary FileObj or Files
+	// snapshot which is truncated, corrupt, or of an unsupported version.
+	ErrInvalidEncoding = errors.New("invalid binary encoding")
+
+	// ErrInvalidManifest is returned by ParseManifest for a line which is not
+	// a recognized checksum line.
+	ErrInvalidManifest = errors.New("invalid checksum manifest")
+
+	// ErrInvalidSignature is returned by VerifySignedManifest when the
+	// signature block is missing or does not match the manifest and public
+	// key.
+	ErrInvalidSignature = errors.New("invalid manifest signature")
+
+	// ErrNoEntries is returned by Path and PathFS when a non-recursive scan
+	// finds no non-directory entries, or only entries left out by its skip
+	// and kind filters, unless WithAllowEmpty is set.
+	ErrNoEntries = errors.New("StartingPath has no non-directory entries")
+
+	// ErrFileTimeout is recorded on a FileObj when populating it takes longer
+	// than the duration set with WithPerFileTimeout.
+	ErrFileTimeout = errors.New("timed out reading file")
+
+	// ErrPanic is recorded on a FileObj when a panic occurs while populating
+	// it. The panic value is included in the error message.
+	ErrPanic = errors.New("panic while reading file")
+
+	// ErrInvalidSets is returned by Sets.Validate and SetsBuilder.Build for a
+	// contradictory combination of Sets.
+	ErrInvalidSets = errors.New("invalid sets")
+
+	// ErrArchiveLimit is recorded on the FileObj of an archive whose members
+	// exceed the ArchiveLimits set with WithArchiveLimits, such as a zip bomb.
+	ErrArchiveLimit = errors.New("archive exceeds traversal limits")
+
+	// ErrBlockSize is returned by Delta for Signatures made with different
+	// block sizes.
+	ErrBlockSize = errors.New("signatures have different block sizes")
+
+	// ErrDedupeChanged is returned in a DedupeResult when a file no longer
+	// matches the checksum recorded by the scan.
+	ErrDedupeChanged = errors.New("file changed since it was scanned")
+
+	// ErrDedupeLinked is returned in a DedupeResult when an extra copy is
+	// already a hard link to the kept file.
+	ErrDedupeLinked = errors.New("file is already a hard link to the kept file")
+
+	// ErrDedupeMismatch is returned in a DedupeResult when an extra copy
+	// matches the checksum recorded by the scan, but its SHA256 checksum does
+	// not match the kept file's, e.g. for a group matched by MD5 alone.
+	ErrDedupeMismatch = errors.New("file content does not match the kept file")
+
+	// ErrDedupeNotLocal is returned in a DedupeResult for files scanned from
+	// an fs.FS or through a FileSystem set by WithFileSystem.
+	ErrDedupeNotLocal = errors.New("dedupe actions require files from the OS filesystem")
+
+	// ErrDedupeSameEntry is returned in a DedupeResult when an extra copy is
+	// the kept file itself, reached through another path, e.g. a second root
+	// to the same tree or different case on a case-insensitive volume.
+	ErrDedupeSameEntry = errors.New("file is the kept file reached through another path")
+
+	// ErrUnsafeFile is recorded on a FileObj scanned with WithHardenedOpen
+	// when its content would be read through a symlink, or from a file which
+	// is not a regular file.
+	ErrUnsafeFile = errors.New("refusing to read a symlink or non-regular file")
+
+	// ErrSchemaMismatch is returned by ValidateJSON and ValidateNDJSON for
+	// data which does not match JSONSchema.
+	ErrSchemaMismatch = errors.New("JSON does not match the FileObj schema")
+
+	// ErrInjectedFault is the error of a Fault injected by WithFaults
+	// without an error of its own.
+	ErrInjectedFault = errors.New("injected fault")
+
+	// SkipDir is returned by a WalkFunc to skip the remaining entries of the
+	// directory holding the current entry, including its subdirectories not
+	// yet walked. It is fs.SkipDir.
+	SkipDir = fs.SkipDir
+
+	// Stop is returned by a WalkFunc to end the walk early. Walk then returns
+	// nil. It is fs.SkipAll.
+	Stop = fs.SkipAll
+)
@@ -1,3 +1,15 @@
 module github.com/orme292/objectify
 
 go 1.22.0
+
+require (
+	github.com/pkg/sftp v1.13.7
+	go.etcd.io/bbolt v1.3.11
+	golang.org/x/crypto v0.31.0
+	golang.org/x/sync v0.10.0
+	golang.org/x/sys v0.28.0
+	golang.org/x/text v0.21.0
+	google.golang.org/protobuf v1.34.2
+)
+
+require github.com/kr/fs v0.1.0 // indirect
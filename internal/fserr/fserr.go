@@ -0,0 +1,17 @@
+// Package fserr holds helpers for the errors of the fs.FS implementations in
+// the backend sub-packages.
+package fserr
+
+import "io/fs"
+
+// Unwrap returns the underlying error of an *fs.PathError, so it can be
+// wrapped again in a PathError with another operation, or err itself.
+func Unwrap(err error) error {
+
+	if pe, ok := err.(*fs.PathError); ok {
+		return pe.Err
+	}
+
+	return err
+
+}
@@ -0,0 +1,30 @@
+package fserr
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestUnwrap(t *testing.T) {
+
+	pe := &fs.PathError{Op: "stat", Path: "a", Err: fs.ErrNotExist}
+	other := errors.New("other")
+
+	tests := map[string]struct {
+		err, want error
+	}{
+		"path error":  {pe, fs.ErrNotExist},
+		"other error": {other, other},
+		"nil":         {nil, nil},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := Unwrap(tt.err); got != tt.want {
+				t.Errorf("Unwrap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+}
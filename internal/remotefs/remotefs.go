@@ -0,0 +1,108 @@
+// Package remotefs holds the fs.File implementations shared by the fs.FS
+// implementations of the remote backend sub-packages.
+package remotefs
+
+import (
+	"io"
+	"io/fs"
+)
+
+// File is an fs.File whose content is fetched on the first Read, so opening a
+// file only to stat it makes no request.
+type File struct {
+	name string
+	info fs.FileInfo
+	get  func(name string) (io.ReadCloser, error)
+	body io.ReadCloser
+}
+
+// NewFile returns a File for name, described by info, whose content is
+// fetched with get on the first Read.
+func NewFile(name string, info fs.FileInfo, get func(name string) (io.ReadCloser, error)) *File {
+	return &File{name: name, info: info, get: get}
+}
+
+func (fl *File) Stat() (fs.FileInfo, error) {
+	return fl.info, nil
+}
+
+func (fl *File) Read(p []byte) (int, error) {
+
+	if fl.body == nil {
+		body, err := fl.get(fl.name)
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: fl.name, Err: err}
+		}
+		fl.body = body
+	}
+
+	return fl.body.Read(p)
+
+}
+
+func (fl *File) Close() error {
+
+	if fl.body == nil {
+		return nil
+	}
+
+	return fl.body.Close()
+
+}
+
+// Dir is an fs.ReadDirFile for a remote directory. The directory is listed
+// on the first ReadDir, and later calls page through the listing.
+type Dir struct {
+	name    string
+	info    fs.FileInfo
+	list    func(name string) ([]fs.DirEntry, error)
+	entries []fs.DirEntry
+	offset  int
+	read    bool
+}
+
+// NewDir returns a Dir for name, described by info, which is listed with list.
+func NewDir(name string, info fs.FileInfo, list func(name string) ([]fs.DirEntry, error)) *Dir {
+	return &Dir{name: name, info: info, list: list}
+}
+
+func (d *Dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *Dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+
+func (d *Dir) Close() error {
+	return nil
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *Dir) ReadDir(n int) ([]fs.DirEntry, error) {
+
+	if !d.read {
+		entries, err := d.list(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.read = true
+	}
+
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+
+	return rest[:n], nil
+
+}
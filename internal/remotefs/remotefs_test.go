@@ -0,0 +1,75 @@
+package remotefs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFileFetchesOnRead(t *testing.T) {
+
+	gets := 0
+	get := func(name string) (io.ReadCloser, error) {
+		gets++
+		if name == "missing" {
+			return nil, fs.ErrNotExist
+		}
+		return io.NopCloser(strings.NewReader("content")), nil
+	}
+
+	f := NewFile("a", nil, get)
+	if err := f.Close(); err != nil || gets != 0 {
+		t.Fatalf("Close before Read: err %v, %d requests", err, gets)
+	}
+
+	f = NewFile("a", nil, get)
+	b, err := io.ReadAll(f)
+	if err != nil || string(b) != "content" || gets != 1 {
+		t.Errorf("ReadAll = %q, %v, %d requests", b, err, gets)
+	}
+
+	var pe *fs.PathError
+	if _, err := NewFile("missing", nil, get).Read(make([]byte, 1)); !errors.As(err, &pe) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Read of a missing file: %v", err)
+	}
+
+}
+
+func TestDirPages(t *testing.T) {
+
+	fsys := fstest.MapFS{"a": {}, "b": {}, "c": {}}
+	lists := 0
+	list := func(name string) ([]fs.DirEntry, error) {
+		lists++
+		return fs.ReadDir(fsys, name)
+	}
+
+	d := NewDir(".", nil, list)
+	var names []string
+	for {
+		entries, err := d.ReadDir(2)
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if strings.Join(names, ",") != "a,b,c" || lists != 1 {
+		t.Errorf("paged %v with %d listings", names, lists)
+	}
+
+	if rest, err := d.ReadDir(0); err != nil || len(rest) != 0 {
+		t.Errorf("ReadDir(0) after the end = %v, %v", rest, err)
+	}
+	if _, err := d.Read(nil); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Read = %v, want fs.ErrInvalid", err)
+	}
+
+}
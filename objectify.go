@@ -5,28 +5,27 @@ package objectify
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
 )
 
-// Path is a function that takes a rootPath and a Sets struct as parameters.
+// Path is a function that takes a rootPath, a Sets struct, and optional Option values as parameters.
 // It creates a worker instance and runs it using the run function to collect file information.
 // It returns a slice of FileObj structs and an error if any.
 // The Sets struct is used to specify which fields of the FileObj struct need to be populated.
-func Path(rootPath string, s Sets) (files Files, err error) {
+func Path(rootPath string, s Sets, opts ...Option) (files Files, err error) {
 
-	return run(newPathWorker(rootPath, s))
+	return run(newPathWorker(rootPath, s, newOptions(opts...)))
 
 }
 
-// File is a function that accepts a path and a Sets struct as parameters.
+// File is a function that accepts a path, a Sets struct, and optional Option values as parameters.
 // It creates a worker instance and runs it using the run function to collect file information.
 // It returns a slice of FileObj structs and an error if any. The returned slice should contain
 // a single FileObj, if not, nil and an error is returned.
 // As long as the files slice contains a single FileObj, it is returned.
-func File(path string, s Sets) (file *FileObj, err error) {
+func File(path string, s Sets, opts ...Option) (file *FileObj, err error) {
 
-	files, err := run(newFileWorker(path, s))
+	files, err := run(newFileWorker(path, s, newOptions(opts...)))
 	if err != nil || len(files) == 0 || len(files) > 1 {
 		return nil, err
 	}
@@ -35,28 +34,98 @@ func File(path string, s Sets) (file *FileObj, err error) {
 
 }
 
-// run is a function that takes a worker pointer w as a parameter. It first validates
-// the worker by calling its validate method. If the validation fails, it returns
-// an error indicating that the StartingPath is inaccessible. If the worker has no
-// non-directory entries, it returns an error indicating that the StartingPath has
-// no non-directory entries. It then initializes an empty slice of FileObj structs.
-// It reads the directory entries using os.ReadDir and iterates over each entry.
-// If the entry is a directory, it continues to the next one. If the entry is a symlink
-// and it leads to a directory, it continues to the next one. Otherwise, it creates
-// a new FileObj using the newFileObj function and appends it to the files slice.
-// Finally, it returns the files slice and any error that occurred during the process.
+// PathFS works like Path, but reads rootPath from fsys instead of the OS filesystem.
+// rootPath must be a valid fs.FS path (see fs.ValidPath); use "." for the root of fsys.
+// It can be used with embed.FS to objectify an application's embedded assets, e.g. to
+// publish a checksum manifest of what it ships. Entries are stat'ed with fs.Stat, so
+// no symlink information is collected, and all entries are treated as readable.
+// The WithOneFileSystem option has no effect.
+func PathFS(fsys fs.FS, rootPath string, s Sets, opts ...Option) (files Files, err error) {
+
+	o := newOptions(opts...)
+	o.fsys = fsys
+
+	return run(newPathWorker(rootPath, s, o))
+
+}
+
+// FileFS works like File, but reads path from fsys instead of the OS filesystem.
+// See PathFS.
+func FileFS(fsys fs.FS, path string, s Sets, opts ...Option) (file *FileObj, err error) {
+
+	o := newOptions(opts...)
+	o.fsys = fsys
+
+	files, err := run(newFileWorker(path, s, o))
+	if err != nil || len(files) == 0 || len(files) > 1 {
+		return nil, err
+	}
+
+	return files[0], nil
+
+}
+
+// FileFromURL builds a FileObj from the HTTP HEAD metadata of rawURL: SizeBytes from
+// Content-Length, ModTime from Last-Modified, ETag, and ContentType. The Filename is
+// the last element of the URL path. The body is only downloaded (with a GET request)
+// if the Sets request checksums, so remote resources can be pre-compared with local
+// files cheaply. If the HEAD request fails or the status is not 200 OK, nil and the
+// error are returned. http.DefaultClient is used for all requests. Update and
+// HasChanged make a new HEAD request each time.
+func FileFromURL(rawURL string, s Sets, opts ...Option) (file *FileObj, err error) {
+
+	fsys, err := newURLFS(rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	fsys.cache(true)
+	defer fsys.cache(false)
+
+	if _, err := fsys.Stat(fsys.name); err != nil {
+		return nil, err
+	}
+
+	return FileFS(fsys, fsys.name, s, opts...)
+
+}
+
+// run objectifies the entries of the worker w. It fails if the root is not
+// valid and, unless the worker is recursive, if the root cannot be read or has
+// no entry to objectify, in which case it returns ErrNoEntries (or empty Files
+// if the allowEmpty option is set).
+//
+// In single file mode, the root alone is objectified. Otherwise its entries
+// are read by readDir, which descends into subdirectories when the worker is
+// recursive. Entries resumed from a journal are skipped, and progress is
+// checkpointed to the journal if the journal option is set.
+//
+// Finally, the members of archives are added with the archives option, and
+// the entries are made relative to the root with the relativePaths option and
+// sorted by path with the sortPaths option. The error of the ctx option is
+// returned once it is done.
 func run(w *worker) (Files, error) {
 
 	// validate checks if there is a valid path provided.
 	if !w.validate() {
 		return nil, fmt.Errorf("StartingPath is not correct: %s", w.RootPath)
 	}
+	w.opts.tune(w.RootPath)
+
+	if w.opts.oneFileSystem && w.opts.fsys == nil && !w.singleFileMode {
+		w.rootDev, w.hasRootDev = deviceOf(w.opts.sys(), w.RootPath)
+	}
 
-	// checks to see that the provided path contains actual file entries.
-	// may be removed in the future.
-	if !w.singleFileMode {
-		if !w.hasEntries() {
-			return nil, fmt.Errorf("StartingPath has no non-directory entries: %s", w.RootPath)
+	// checks to see that the provided path contains actual file entries,
+	// unless the allowEmpty option is set.
+	if !w.singleFileMode && !w.opts.recursive {
+		ok, err := w.hasEntries()
+		switch {
+		case err != nil:
+			return nil, err
+		case !ok && w.opts.allowEmpty:
+			return Files{}, nil
+		case !ok:
+			return nil, fmt.Errorf("%w: %s", ErrNoEntries, w.RootPath)
 		}
 	}
 
@@ -64,36 +133,62 @@ func run(w *worker) (Files, error) {
 
 	if w.singleFileMode {
 
-		file := newFileObj(w.RootPath, w.setter)
+		file := newFileObj(w.RootPath, w.setter, w.opts)
+		if err := w.opts.ctxErr(); err != nil {
+			return nil, err
+		}
 		files = append(files, file)
+		if w.opts.relativePaths {
+			w.relativize(files)
+		}
 
 		return files, nil
 
 	}
 
-	dirents, err := os.ReadDir(w.RootPath)
+	if w.cp == nil {
+		w.cp = newCheckpoint(w)
+	}
+	if w.cp != nil {
+		w.RootPath = w.cp.root
+	}
+	defer w.cp.close()
+
+	release, err := w.holdRoot()
 	if err != nil {
 		return nil, err
 	}
+	defer release()
 
-	for _, ent := range dirents {
-
-		path := filepath.Join(w.RootPath, ent.Name())
+	files, err = w.collect(func(emit func(string) error) error {
+		return w.readDir(w.RootPath, emit, 0)
+	})
+	if err == nil {
+		err = w.opts.ctxErr()
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		if ent.IsDir() {
-			continue
-		}
-		if ent.Type()&os.ModeSymlink != 0 {
-			if linkLeadsToDir(path) {
-				continue
-			}
+	if w.cp != nil {
+		files = append(w.cp.resumed, files...)
+		if err := w.cp.finish(); err != nil {
+			return nil, err
 		}
+	}
 
-		file := newFileObj(path, w.setter)
-		files = append(files, file)
+	if w.opts.archives {
+		files = w.expandArchives(files)
+	}
+
+	if w.opts.relativePaths {
+		w.relativize(files)
+	}
 
+	if w.opts.sortPaths {
+		files.sortByPath()
 	}
 
-	return files, err
+	return files, nil
 
 }
@@ -0,0 +1,237 @@
+// Package objectifypb provides the protocol buffer representation of objectify scan
+// results. objectify.proto (in this directory) is the published schema, and
+// objectify.pb.go is generated from it by protoc-gen-go, so its messages are
+// proto.Message values which can be encoded with proto.Marshal, protojson, or
+// gRPC, and decoded by code generated from objectify.proto in any language.
+//
+// Use ToProto/FromProto and FilesToProto/FilesFromProto to convert between the
+// objectify types and their protocol buffer messages.
+package objectifypb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative objectify.proto
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"time"
+
+	objf "github.com/orme292/objectify"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ToProto converts a FileObj into its protocol buffer message.
+// It returns nil for a nil FileObj.
+func ToProto(fo *objf.FileObj) *FileObj {
+
+	if fo == nil {
+		return nil
+	}
+
+	p := &FileObj{
+		Root:        fo.Root,
+		Filename:    fo.Filename,
+		SizeBytes:   fo.SizeBytes,
+		Mode:        fo.Mode.String(),
+		ModTime:     timestampOf(fo.ModTime()),
+		UpdatedAt:   timestampOf(fo.UpdatedAt),
+		Md5:         fo.MD5,
+		Sha256:      fo.SHA256,
+		Etag:        fo.ETag,
+		ContentType: fo.ContentType,
+		Target:      fo.Target,
+		TargetFinal: fo.TargetFinal,
+		LinkPath:    fo.LinkPath,
+		IsLink:      fo.IsLink,
+		IsReadable:  fo.IsReadable,
+		IsExists:    fo.IsExists,
+		Perm:        uint32(fo.Perm),
+		Uid:         int64(fo.UID),
+		Gid:         int64(fo.GID),
+		Tags:        maps.Clone(fo.Tags),
+		Xattrs:      maps.Clone(fo.XAttrs),
+		HmacSha256:  fo.HMAC,
+		ImageHash:   fo.ImageHash,
+		Width:       int64(fo.Width),
+		Height:      int64(fo.Height),
+		Meta:        maps.Clone(fo.Meta),
+		Compression: string(fo.Compression),
+
+		DecompressedSha256: fo.DecompressedSHA256,
+		Container:          fo.Container,
+		ReparseTarget:      fo.ReparseTarget,
+		AllocatedBytes:     fo.AllocatedBytes,
+	}
+
+	if fo.Err != nil {
+		p.Error = fo.Err.Error()
+	}
+	for _, c := range fo.Chunks {
+		p.Chunks = append(p.Chunks, &Chunk{Offset: c.Offset, Size: c.Size, Sha256: c.SHA256})
+	}
+	for _, st := range fo.Streams {
+		p.Streams = append(p.Streams, &Stream{Name: st.Name, Size: st.Size, Sha256: st.SHA256})
+	}
+	if fo.Set != nil {
+		p.Sets = &Sets{
+			Size:            fo.Set.Size,
+			Modes:           fo.Set.Modes,
+			ChecksumMd5:     fo.Set.ChecksumMD5,
+			ChecksumSha256:  fo.Set.ChecksumSHA256,
+			LinkTarget:      fo.Set.LinkTarget,
+			LinkTargetFinal: fo.Set.LinkTargetFinal,
+			Xattrs:          fo.Set.XAttrs,
+			Dimensions:      fo.Set.Dimensions,
+			DocProps:        fo.Set.DocProps,
+			Compression:     fo.Set.Compression,
+		}
+	}
+
+	return p
+
+}
+
+// FromProto converts a protocol buffer message into a FileObj. The hex checksum
+// strings are derived from the raw digests. It returns nil for a nil message.
+func FromProto(p *FileObj) *objf.FileObj {
+
+	if p == nil {
+		return nil
+	}
+
+	fo := &objf.FileObj{
+		Root:        p.Root,
+		Filename:    p.Filename,
+		SizeBytes:   p.SizeBytes,
+		Mode:        objf.EntMode(p.Mode),
+		UpdatedAt:   timeOf(p.UpdatedAt),
+		MD5:         p.Md5,
+		SHA256:      p.Sha256,
+		ETag:        p.Etag,
+		ContentType: p.ContentType,
+		Target:      p.Target,
+		TargetFinal: p.TargetFinal,
+		LinkPath:    p.LinkPath,
+		IsLink:      p.IsLink,
+		IsReadable:  p.IsReadable,
+		IsExists:    p.IsExists,
+		Perm:        fs.FileMode(p.Perm),
+		UID:         int(p.Uid),
+		GID:         int(p.Gid),
+		Tags:        maps.Clone(p.Tags),
+		XAttrs:      maps.Clone(p.Xattrs),
+		HMAC:        p.HmacSha256,
+		ImageHash:   p.ImageHash,
+		Width:       int(p.Width),
+		Height:      int(p.Height),
+		Meta:        maps.Clone(p.Meta),
+		Compression: objf.Compression(p.Compression),
+
+		DecompressedSHA256: p.DecompressedSha256,
+		Container:          p.Container,
+		ReparseTarget:      p.ReparseTarget,
+		AllocatedBytes:     p.AllocatedBytes,
+	}
+	fo.SetModTime(timeOf(p.ModTime))
+
+	if len(p.Md5) > 0 {
+		fo.ChecksumMD5 = fmt.Sprintf("%x", p.Md5)
+	}
+	if len(p.Sha256) > 0 {
+		fo.ChecksumSHA256 = fmt.Sprintf("%x", p.Sha256)
+	}
+	if len(p.HmacSha256) > 0 {
+		fo.ChecksumHMAC = fmt.Sprintf("%x", p.HmacSha256)
+	}
+	if len(p.DecompressedSha256) > 0 {
+		fo.ChecksumDecompressed = fmt.Sprintf("%x", p.DecompressedSha256)
+	}
+	for _, c := range p.Chunks {
+		if c != nil {
+			fo.Chunks = append(fo.Chunks, objf.Chunk{Offset: c.Offset, Size: c.Size, SHA256: c.Sha256})
+		}
+	}
+	for _, st := range p.Streams {
+		if st != nil {
+			fo.Streams = append(fo.Streams, objf.Stream{Name: st.Name, Size: st.Size, SHA256: st.Sha256})
+		}
+	}
+	if p.Error != "" {
+		fo.Err = errors.New(p.Error)
+	}
+	if p.Sets != nil {
+		fo.Set = &objf.Sets{
+			Size:            p.Sets.Size,
+			Modes:           p.Sets.Modes,
+			ChecksumMD5:     p.Sets.ChecksumMd5,
+			ChecksumSHA256:  p.Sets.ChecksumSha256,
+			LinkTarget:      p.Sets.LinkTarget,
+			LinkTargetFinal: p.Sets.LinkTargetFinal,
+			XAttrs:          p.Sets.Xattrs,
+			Dimensions:      p.Sets.Dimensions,
+			DocProps:        p.Sets.DocProps,
+			Compression:     p.Sets.Compression,
+		}
+	}
+
+	return fo
+
+}
+
+// FilesToProto converts Files into its protocol buffer message. nil entries are skipped.
+func FilesToProto(files objf.Files) *Files {
+
+	p := &Files{Files: make([]*FileObj, 0, len(files)), ScanRoot: files.ScanRoot()}
+	for _, fo := range files {
+		if fo != nil {
+			p.Files = append(p.Files, ToProto(fo))
+		}
+	}
+
+	return p
+
+}
+
+// FilesFromProto converts a protocol buffer message into Files.
+func FilesFromProto(p *Files) objf.Files {
+
+	if p == nil {
+		return nil
+	}
+
+	files := make(objf.Files, 0, len(p.Files))
+	for _, fp := range p.Files {
+		if fp != nil {
+			files = append(files, FromProto(fp))
+		}
+	}
+	if p.ScanRoot != "" {
+		files.SetScanRoot(p.ScanRoot)
+	}
+
+	return files
+
+}
+
+// timestampOf converts a time.Time into a Timestamp, or nil for the zero time.
+func timestampOf(t time.Time) *timestamppb.Timestamp {
+
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+
+}
+
+// timeOf converts a Timestamp into a time.Time, or the zero time for nil.
+func timeOf(ts *timestamppb.Timestamp) time.Time {
+
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return time.Unix(ts.GetSeconds(), int64(ts.GetNanos()))
+
+}
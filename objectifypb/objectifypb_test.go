@@ -0,0 +1,52 @@
+package objectifypb
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	objf "github.com/orme292/objectify"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRoundTrip(t *testing.T) {
+
+	fo := &objf.FileObj{
+		Root:      "/data",
+		Filename:  "report.pdf",
+		SizeBytes: 1024,
+		Mode:      objf.EntModeRegular,
+		SHA256:    bytes.Repeat([]byte{0xab}, 32),
+		Tags:      map[string]string{"owner": "ops"},
+		Set:       &objf.Sets{Size: true, ChecksumSHA256: true},
+	}
+	fo.SetModTime(time.Unix(1700000000, 5))
+
+	b, err := proto.Marshal(FilesToProto(objf.Files{fo}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p Files
+	if err := proto.Unmarshal(b, &p); err != nil {
+		t.Fatal(err)
+	}
+
+	files := FilesFromProto(&p)
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want 1", len(files))
+	}
+	got := files[0]
+	if got.FullPath() != fo.FullPath() || got.SizeBytes != fo.SizeBytes || got.Mode != fo.Mode {
+		t.Errorf("got %s (%d bytes, %s), want %s (%d bytes, %s)", got.FullPath(), got.SizeBytes, got.Mode, fo.FullPath(), fo.SizeBytes, fo.Mode)
+	}
+	if !bytes.Equal(got.SHA256, fo.SHA256) || got.ChecksumSHA256 == "" {
+		t.Errorf("SHA256 = %x (%q), want %x", got.SHA256, got.ChecksumSHA256, fo.SHA256)
+	}
+	if !got.ModTime().Equal(fo.ModTime()) {
+		t.Errorf("ModTime = %v, want %v", got.ModTime(), fo.ModTime())
+	}
+	if got.Tags["owner"] != "ops" || !got.Set.ChecksumSHA256 {
+		t.Errorf("Tags = %v, Sets = %+v", got.Tags, got.Set)
+	}
+
+}
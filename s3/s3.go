@@ -0,0 +1,524 @@
+// Package s3 provides an fs.FS view of an S3 bucket, so a bucket or prefix can be
+// objectified with FS.Path and diffed against local scans. Listings are made with
+// ListObjectsV2, and during a scan by FS.Path the Size, ETag, and LastModified
+// values returned by the listing are reused for each FileObj, so no per-object
+// requests are issued unless checksums are requested: the MD5 encoded in the ETag costs a HEAD
+// request, which checks that the object is not encrypted with SSE-KMS or SSE-C,
+// and other checksums a GET request.
+//
+// Requests are signed with AWS Signature Version 4 using only the standard library.
+// Any S3-compatible endpoint (e.g. MinIO) can be used by setting Config.Endpoint.
+package s3
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	objf "github.com/orme292/objectify"
+	"github.com/orme292/objectify/internal/fserr"
+	"github.com/orme292/objectify/internal/remotefs"
+)
+
+const (
+	// DefaultRegion is used when Config.Region is empty.
+	DefaultRegion = "us-east-1"
+)
+
+// Config holds the connection settings for an S3 bucket.
+type Config struct {
+
+	// Region is the bucket's region. Defaults to DefaultRegion.
+	Region string
+
+	// Endpoint is the base URL of an S3-compatible service, e.g.
+	// "http://localhost:9000". When set, path-style requests are made.
+	// When empty, virtual-hosted requests are made to AWS.
+	Endpoint string
+
+	// AccessKeyID, SecretAccessKey, and SessionToken are the credentials
+	// used to sign requests. If AccessKeyID or SecretAccessKey is empty,
+	// requests are not signed.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// HTTPClient is used for all requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// FS is an fs.FS backed by an S3 bucket. Object keys are treated as slash
+// separated paths, and common prefixes are treated as directories.
+type FS struct {
+	bucket string
+	cfg    Config
+	ctx    context.Context
+
+	// infos caches the fs.FileInfo of the objects listed during a scan by
+	// Path. It is nil outside a scan, so the FileObjs of a scan which are
+	// updated later see the current state of their object.
+	mu    *sync.Mutex
+	infos map[string]*fileInfo
+}
+
+// New returns an FS for the given bucket and Config.
+func New(bucket string, cfg Config) *FS {
+
+	if cfg.Region == "" {
+		cfg.Region = DefaultRegion
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+
+	return &FS{
+		bucket: bucket,
+		cfg:    cfg,
+		ctx:    context.Background(),
+		mu:     &sync.Mutex{},
+	}
+
+}
+
+// WithContext returns a shallow copy of the FS whose requests use ctx.
+func (f *FS) WithContext(ctx context.Context) *FS {
+
+	c := *f
+	c.ctx = ctx
+	return &c
+
+}
+
+// Path objectifies the objects under prefix using objectify.PathFS. An empty
+// prefix scans the whole bucket. Use objectify.WithRecursive to descend into
+// common prefixes. The values of the listings are reused for the FileObjs
+// while the scan runs; FileObj.Update and HasChanged make a HEAD request
+// afterwards.
+func (f *FS) Path(prefix string, s objf.Sets, opts ...objf.Option) (objf.Files, error) {
+
+	root := strings.Trim(prefix, "/")
+	if root == "" {
+		root = "."
+	}
+
+	scan := *f
+	scan.mu = &sync.Mutex{}
+	scan.infos = make(map[string]*fileInfo)
+	defer scan.endScan()
+
+	return objf.PathFS(&scan, root, s, opts...)
+
+}
+
+// endScan drops the listing cache of a scan, and stops caching.
+func (f *FS) endScan() {
+
+	f.mu.Lock()
+	f.infos = nil
+	f.mu.Unlock()
+
+}
+
+// Open opens the named object or prefix. Object content is only requested
+// once the returned file is read.
+func (f *FS) Open(name string) (fs.File, error) {
+
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fserr.Unwrap(err)}
+	}
+
+	if info.IsDir() {
+		return remotefs.NewDir(name, info, f.ReadDir), nil
+	}
+
+	return remotefs.NewFile(name, info, f.get), nil
+
+}
+
+// Stat returns the fs.FileInfo of the named object or prefix. During a scan by
+// Path, values recorded by an earlier ReadDir are reused; otherwise a HEAD
+// request is made, falling back to a listing to detect a prefix.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		return &fileInfo{name: ".", dir: true}, nil
+	}
+
+	f.mu.Lock()
+	cached, ok := f.infos[name]
+	f.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	info, err := f.head(name)
+	if err == nil {
+		f.cache(name, info)
+		return info, nil
+	}
+	if err != fs.ErrNotExist {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+
+	list, err := f.list(name+"/", 1)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	if len(list.Contents) == 0 && len(list.CommonPrefixes) == 0 {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return &fileInfo{name: path.Base(name), dir: true}, nil
+
+}
+
+// ReadDir lists the objects and common prefixes directly under the named
+// prefix, sorted by name. During a scan by Path, the Size, ETag, and
+// LastModified of each object are cached for later Stat calls.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	prefix := ""
+	if name != "." {
+		prefix = name + "/"
+	}
+
+	var entries []fs.DirEntry
+	token := ""
+
+	for {
+
+		list, err := f.listPage(prefix, "/", token, 0)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+		}
+
+		for _, cp := range list.CommonPrefixes {
+			base := strings.TrimSuffix(strings.TrimPrefix(cp.Prefix, prefix), "/")
+			if base == "" {
+				continue
+			}
+			entries = append(entries, fs.FileInfoToDirEntry(&fileInfo{name: base, dir: true}))
+		}
+
+		for _, obj := range list.Contents {
+			base := strings.TrimPrefix(obj.Key, prefix)
+			if base == "" || strings.Contains(base, "/") {
+				continue
+			}
+			info := obj.info(base)
+			info.head = func() (*fileInfo, error) {
+				return f.head(obj.Key)
+			}
+			f.cache(obj.Key, info)
+			entries = append(entries, fs.FileInfoToDirEntry(info))
+		}
+
+		if !list.IsTruncated || list.NextContinuationToken == "" {
+			break
+		}
+		token = list.NextContinuationToken
+
+	}
+
+	if len(entries) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+
+}
+
+// cache records the fs.FileInfo of an object key, during a scan.
+func (f *FS) cache(key string, info *fileInfo) {
+
+	f.mu.Lock()
+	if f.infos != nil {
+		f.infos[key] = info
+	}
+	f.mu.Unlock()
+
+}
+
+// do builds, signs, and sends a request for the given key and query.
+func (f *FS) do(method, key string, query url.Values) (*http.Response, error) {
+
+	u, err := f.url(key, query)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(f.ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	f.sign(req, time.Now())
+
+	return f.cfg.HTTPClient.Do(req)
+
+}
+
+// get starts a GET request for the object content.
+func (f *FS) get(key string) (io.ReadCloser, error) {
+
+	resp, err := f.do(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseErr(resp)
+	}
+
+	return resp.Body, nil
+
+}
+
+// head makes a HEAD request for the object and returns its fs.FileInfo.
+// fs.ErrNotExist is returned for a missing object.
+func (f *FS) head(key string) (*fileInfo, error) {
+
+	resp, err := f.do(http.MethodHead, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fs.ErrNotExist
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseErr(resp)
+	}
+
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	mod, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return &fileInfo{
+		name:     path.Base(key),
+		size:     size,
+		modTime:  mod,
+		etag:     strings.Trim(resp.Header.Get("ETag"), `"`),
+		sse:      resp.Header.Get("x-amz-server-side-encryption"),
+		sseC:     resp.Header.Get("x-amz-server-side-encryption-customer-algorithm") != "",
+		encKnown: true,
+	}, nil
+
+}
+
+// list returns the first page of keys under prefix, without a delimiter.
+func (f *FS) list(prefix string, maxKeys int) (*listResult, error) {
+	return f.listPage(prefix, "", "", maxKeys)
+}
+
+// listPage makes a single ListObjectsV2 request.
+func (f *FS) listPage(prefix, delimiter, token string, maxKeys int) (*listResult, error) {
+
+	q := url.Values{}
+	q.Set("list-type", "2")
+	q.Set("prefix", prefix)
+	if delimiter != "" {
+		q.Set("delimiter", delimiter)
+	}
+	if token != "" {
+		q.Set("continuation-token", token)
+	}
+	if maxKeys > 0 {
+		q.Set("max-keys", strconv.Itoa(maxKeys))
+	}
+
+	resp, err := f.do(http.MethodGet, "", q)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseErr(resp)
+	}
+
+	list := &listResult{}
+	if err := xml.NewDecoder(resp.Body).Decode(list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+
+}
+
+// url returns the request URL for the key and query. Keys are escaped the same
+// way they are when the request is signed.
+func (f *FS) url(key string, query url.Values) (*url.URL, error) {
+
+	u := &url.URL{Scheme: "https"}
+
+	if f.cfg.Endpoint == "" {
+		u.Host = fmt.Sprintf("%s.s3.%s.amazonaws.com", f.bucket, f.cfg.Region)
+		u.Path = "/" + key
+	} else {
+		base, err := url.Parse(f.cfg.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		u.Scheme = base.Scheme
+		u.Host = base.Host
+		u.Path = strings.TrimSuffix(base.Path, "/") + "/" + f.bucket + "/" + key
+	}
+
+	u.RawPath = escapePath(u.Path)
+	u.RawQuery = canonicalQuery(query)
+
+	return u, nil
+
+}
+
+// responseErr builds an error from a non-successful response, using the S3
+// error code if the body contains one.
+func responseErr(resp *http.Response) error {
+
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	_ = xml.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&e)
+	_ = resp.Body.Close()
+
+	if e.Code != "" {
+		return fmt.Errorf("s3: %s: %s (%s)", resp.Status, e.Code, e.Message)
+	}
+
+	return fmt.Errorf("s3: %s", resp.Status)
+
+}
+
+// listResult is the ListObjectsV2 response body.
+type listResult struct {
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+	Contents              []object `xml:"Contents"`
+	CommonPrefixes        []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+// object is a single ListObjectsV2 Contents element.
+type object struct {
+	Key          string    `xml:"Key"`
+	LastModified time.Time `xml:"LastModified"`
+	ETag         string    `xml:"ETag"`
+	Size         int64     `xml:"Size"`
+}
+
+// info returns the fs.FileInfo for the listed object, using base as its name.
+func (o object) info(base string) *fileInfo {
+	return &fileInfo{
+		name:    base,
+		size:    o.Size,
+		modTime: o.LastModified,
+		etag:    strings.Trim(o.ETag, `"`),
+	}
+}
+
+// fileInfo implements fs.FileInfo, objectify.ETagInfo, and objectify.MD5Info
+// for an object or prefix.
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	etag    string
+	dir     bool
+
+	// sse is the x-amz-server-side-encryption of the object (e.g. "AES256"
+	// or "aws:kms"), and sseC is true if it is encrypted with a customer key
+	// (SSE-C). encKnown is true once they were read from a HEAD response;
+	// for a listed object, head makes that request on first use.
+	sse      string
+	sseC     bool
+	encKnown bool
+	head     func() (*fileInfo, error)
+	headOnce sync.Once
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.dir }
+func (fi *fileInfo) Sys() any           { return nil }
+
+// Mode returns fs.ModeDir for prefixes and a read-only mode for objects.
+func (fi *fileInfo) Mode() fs.FileMode {
+
+	if fi.dir {
+		return fs.ModeDir | 0555
+	}
+
+	return 0444
+
+}
+
+// ETag returns the object's ETag without quotes.
+func (fi *fileInfo) ETag() string {
+	return fi.etag
+}
+
+// MD5 returns the MD5 hash encoded in the ETag, or nil if the ETag is not a
+// plain MD5. S3 only sets the ETag of objects uploaded in a single part and
+// stored unencrypted or with SSE-S3 to the MD5 of their content, not that of
+// multipart uploads or of SSE-KMS or SSE-C objects. Listings do not report the
+// encryption of objects, so for a listed object a HEAD request is made to
+// read it; if that request fails, nil is returned.
+func (fi *fileInfo) MD5() []byte {
+
+	if len(fi.etag) != 32 || !fi.plainETag() {
+		return nil
+	}
+
+	sum, err := hex.DecodeString(fi.etag)
+	if err != nil {
+		return nil
+	}
+
+	return sum
+
+}
+
+// plainETag returns true if the encryption of the object is known, and is
+// none or SSE-S3, which leave the ETag the MD5 of its content.
+func (fi *fileInfo) plainETag() bool {
+
+	fi.headOnce.Do(func() {
+		if fi.encKnown || fi.head == nil {
+			return
+		}
+		if h, err := fi.head(); err == nil {
+			fi.sse, fi.sseC, fi.encKnown = h.sse, h.sseC, true
+		}
+	})
+
+	return fi.encKnown && !fi.sseC && (fi.sse == "" || fi.sse == "AES256")
+
+}
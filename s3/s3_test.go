@@ -0,0 +1,197 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	objf "github.com/orme292/objectify"
+)
+
+// testObject is an object served by newTestServer, with the value of its
+// x-amz-server-side-encryption and SSE-C algorithm headers, and the time it
+// was modified, 2024-01-01 if not set.
+type testObject struct {
+	body     string
+	sse      string
+	sseC     string
+	modified time.Time
+}
+
+// modTime returns the time the object was modified.
+func (o testObject) modTime() time.Time {
+
+	if o.modified.IsZero() {
+		return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return o.modified
+
+}
+
+// testServer serves objects from a single bucket, as listings, HEAD, and GET
+// requests. Every ETag is the MD5 of the object. It counts the HEAD requests.
+type testServer struct {
+	mu      sync.Mutex
+	objects map[string]testObject
+	heads   int
+}
+
+// newTestServer starts a testServer for objects, and returns an FS for it.
+func newTestServer(t *testing.T, objects map[string]testObject) (*FS, *testServer) {
+
+	ts := &testServer{objects: objects}
+	srv := httptest.NewServer(ts)
+	t.Cleanup(srv.Close)
+
+	return New("bucket", Config{Endpoint: srv.URL}), ts
+
+}
+
+// put stores an object, replacing any with the same key.
+func (ts *testServer) put(key string, o testObject) {
+
+	ts.mu.Lock()
+	ts.objects[key] = o
+	ts.mu.Unlock()
+
+}
+
+func etag(o testObject) string {
+
+	sum := md5.Sum([]byte(o.body))
+
+	return hex.EncodeToString(sum[:])
+
+}
+
+func (ts *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	if r.URL.Query().Get("list-type") == "2" {
+		var b bytes.Buffer
+		b.WriteString(`<ListBucketResult>`)
+		for k, o := range ts.objects {
+			fmt.Fprintf(&b, `<Contents><Key>%s</Key><LastModified>%s</LastModified><ETag>"%s"</ETag><Size>%d</Size></Contents>`,
+				k, o.modTime().Format(time.RFC3339), etag(o), len(o.body))
+		}
+		b.WriteString(`</ListBucketResult>`)
+		_, _ = w.Write(b.Bytes())
+		return
+	}
+
+	if r.Method == http.MethodHead {
+		ts.heads++
+	}
+	o, ok := ts.objects[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("ETag", `"`+etag(o)+`"`)
+	w.Header().Set("Content-Length", fmt.Sprint(len(o.body)))
+	w.Header().Set("Last-Modified", o.modTime().Format(http.TimeFormat))
+	if o.sse != "" {
+		w.Header().Set("x-amz-server-side-encryption", o.sse)
+	}
+	if o.sseC != "" {
+		w.Header().Set("x-amz-server-side-encryption-customer-algorithm", o.sseC)
+	}
+	if r.Method == http.MethodGet {
+		_, _ = w.Write([]byte(o.body))
+	}
+
+}
+
+func TestMD5Encryption(t *testing.T) {
+
+	objects := map[string]testObject{
+		"plain":   {body: "plain"},
+		"sse-s3":  {body: "sse-s3", sse: "AES256"},
+		"sse-kms": {body: "sse-kms", sse: "aws:kms"},
+		"sse-c":   {body: "sse-c", sseC: "AES256"},
+	}
+	trusted := map[string]bool{"plain": true, "sse-s3": true}
+
+	check := func(t *testing.T, name string, info fs.FileInfo) {
+		t.Helper()
+		got := info.(objf.MD5Info).MD5()
+		if !trusted[name] {
+			if got != nil {
+				t.Errorf("%s: MD5() = %x, want nil", name, got)
+			}
+			return
+		}
+		if want := md5.Sum([]byte(objects[name].body)); !bytes.Equal(got, want[:]) {
+			t.Errorf("%s: MD5() = %x, want %x", name, got, want)
+		}
+	}
+
+	t.Run("head", func(t *testing.T) {
+		f, _ := newTestServer(t, objects)
+		for name := range objects {
+			info, err := f.Stat(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			check(t, name, info)
+		}
+	})
+
+	t.Run("listing", func(t *testing.T) {
+		f, _ := newTestServer(t, objects)
+		entries, err := f.ReadDir(".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != len(objects) {
+			t.Fatalf("got %d entries, want %d", len(entries), len(objects))
+		}
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil {
+				t.Fatal(err)
+			}
+			check(t, e.Name(), info)
+		}
+	})
+
+}
+
+func TestPathListingCache(t *testing.T) {
+
+	f, ts := newTestServer(t, map[string]testObject{"a": {body: "a"}, "b": {body: "bb"}})
+
+	files, err := f.Path("", objf.Sets{Size: true, ChecksumSHA256: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2", len(files))
+	}
+	if ts.heads != 0 {
+		t.Errorf("scan made %d HEAD requests, want 0", ts.heads)
+	}
+
+	// Once the scan is done, the object is requested again.
+	ts.put("a", testObject{body: "changed", modified: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)})
+	if !files[0].HasChanged() {
+		t.Error("HasChanged() = false after the object changed")
+	}
+	files[0].Update()
+	if files[0].SizeBytes != int64(len("changed")) {
+		t.Errorf("SizeBytes = %d after Update, want %d", files[0].SizeBytes, len("changed"))
+	}
+
+}
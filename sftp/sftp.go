@@ -0,0 +1,169 @@
+// Package sftp provides an fs.FS view of a remote directory reached over SFTP, so it
+// can be objectified with objectify.PathFS and compared against local scans. Stat
+// metadata (size, mode, modification time) always comes from the remote server;
+// checksums are computed by reading file content over the SFTP connection, so they
+// are only transferred when the Sets request them.
+package sftp
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+
+	objf "github.com/orme292/objectify"
+	"github.com/orme292/objectify/internal/fserr"
+	"github.com/orme292/objectify/internal/remotefs"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// FS is an fs.FS rooted at a directory on an SFTP server. Symlinks are followed,
+// as an fs.FS has no notion of Lstat.
+type FS struct {
+	client *sftp.Client
+	root   string
+
+	// conn is set when the FS was created by Dial, and is closed by Close.
+	conn *ssh.Client
+}
+
+// New returns an FS which reads the remote root directory through client.
+// The caller remains responsible for closing client.
+func New(client *sftp.Client, root string) *FS {
+
+	if root == "" {
+		root = "."
+	}
+
+	return &FS{
+		client: client,
+		root:   root,
+	}
+
+}
+
+// Dial connects to the SSH server at addr (host:port), starts an SFTP session,
+// and returns an FS rooted at the remote root directory. Close must be called
+// to release the connection.
+func Dial(addr string, cfg *ssh.ClientConfig, root string) (*FS, error) {
+
+	conn, err := ssh.Dial("tcp", addr, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	f := New(client, root)
+	f.conn = conn
+
+	return f, nil
+
+}
+
+// Close closes the SFTP session and SSH connection if the FS was created by Dial.
+// It does nothing for an FS created by New.
+func (f *FS) Close() error {
+
+	if f.conn == nil {
+		return nil
+	}
+
+	_ = f.client.Close()
+	return f.conn.Close()
+
+}
+
+// Path objectifies the entries of dir, relative to the FS root, using
+// objectify.PathFS. An empty dir scans the root.
+func (f *FS) Path(dir string, s objf.Sets, opts ...objf.Option) (objf.Files, error) {
+
+	if dir == "" {
+		dir = "."
+	}
+
+	return objf.PathFS(f, dir, s, opts...)
+
+}
+
+// Open opens the named remote file or directory.
+func (f *FS) Open(name string) (fs.File, error) {
+
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fserr.Unwrap(err)}
+	}
+
+	if info.IsDir() {
+		return remotefs.NewDir(name, info, f.ReadDir), nil
+	}
+
+	rf, err := f.client.Open(f.remote(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &file{File: rf, info: info}, nil
+
+}
+
+// Stat returns the fs.FileInfo of the named remote entry, following symlinks.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	info, err := f.client.Stat(f.remote(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+
+	return info, nil
+
+}
+
+// ReadDir reads the named remote directory and returns its entries sorted by name.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	infos, err := f.client.ReadDir(f.remote(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(infos))
+	for _, info := range infos {
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+
+}
+
+// remote returns the remote path for an fs.FS name.
+func (f *FS) remote(name string) string {
+	return path.Join(f.root, name)
+}
+
+// file is an fs.File for a remote file. Stat returns the fs.FileInfo
+// recorded when the file was opened.
+type file struct {
+	*sftp.File
+	info fs.FileInfo
+}
+
+func (fl *file) Stat() (fs.FileInfo, error) {
+	return fl.info, nil
+}
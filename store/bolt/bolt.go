@@ -0,0 +1,453 @@
+// Package bolt persists objectify scans in a bbolt key-value database, for
+// deployments which need a pure Go store without a SQL driver.
+//
+// Each saved scan gets its own bucket mapping full path to the FileObj's binary
+// encoding, so earlier scans remain available to load and diff against. The
+// Store also implements objectify.HashCache, so checksums computed in one scan
+// are reused by the next for files whose size and modification time are unchanged.
+package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	objf "github.com/orme292/objectify"
+	bbolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketScans    = []byte("scans")
+	bucketScanInfo = []byte("scan-info")
+	bucketHashes   = []byte("hashes")
+)
+
+// flushAt is the number of buffered checksum entries which triggers a write.
+const flushAt = 1024
+
+// ErrScanNotFound is returned when a scan ID does not exist in the Store.
+var ErrScanNotFound = errors.New("bolt: scan not found")
+
+// Scan describes a saved scan.
+type Scan struct {
+
+	// ID identifies the scan. IDs increase with each saved scan.
+	ID uint64
+
+	// Time is when the scan was saved, and Count is the number of entries.
+	Time  time.Time
+	Count int
+}
+
+// Store reads and writes scans and cached checksums in a bbolt database.
+type Store struct {
+	db *bbolt.DB
+
+	// pending holds checksum entries not yet written to the hashes bucket.
+	mu      *sync.Mutex
+	pending map[string]hashEntry
+}
+
+// Open opens (or creates) the bbolt database at path and returns a Store
+// using it. Close the Store when done.
+func Open(path string) (*Store, error) {
+
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{bucketScans, bucketScanInfo, bucketHashes} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &Store{
+		db:      db,
+		mu:      &sync.Mutex{},
+		pending: make(map[string]hashEntry),
+	}, nil
+
+}
+
+// Close writes any buffered checksums and closes the database.
+func (s *Store) Close() error {
+
+	err := s.Flush()
+	if cErr := s.db.Close(); err == nil {
+		err = cErr
+	}
+
+	return err
+
+}
+
+// Flush writes the checksums buffered by PutChecksums to the database.
+func (s *Store) Flush() error {
+
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[string]hashEntry)
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketHashes)
+		for path, e := range pending {
+			if err := b.Put([]byte(path), e.encode()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+}
+
+// SaveScan stores files as a new scan and returns its description. nil
+// entries are skipped.
+func (s *Store) SaveScan(files objf.Files) (Scan, error) {
+
+	scan := Scan{Time: time.Now()}
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+
+		scans := tx.Bucket(bucketScans)
+
+		id, err := scans.NextSequence()
+		if err != nil {
+			return err
+		}
+		scan.ID = id
+
+		b, err := scans.CreateBucket(idKey(id))
+		if err != nil {
+			return err
+		}
+
+		for _, fo := range files {
+
+			if fo == nil {
+				continue
+			}
+
+			obj, err := fo.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(fo.FullPath()), obj); err != nil {
+				return fmt.Errorf("bolt: save %s: %w", fo.FullPath(), err)
+			}
+			scan.Count++
+
+		}
+
+		return tx.Bucket(bucketScanInfo).Put(idKey(id), scan.encode())
+
+	})
+
+	return scan, err
+
+}
+
+// Scans returns the saved scans, oldest first.
+func (s *Store) Scans() ([]Scan, error) {
+
+	var scans []Scan
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketScanInfo).ForEach(func(k, v []byte) error {
+			scan, err := decodeScan(k, v)
+			if err != nil {
+				return err
+			}
+			scans = append(scans, scan)
+			return nil
+		})
+	})
+
+	return scans, err
+
+}
+
+// Latest returns the most recently saved scan. ErrScanNotFound is returned if
+// there are none.
+func (s *Store) Latest() (Scan, error) {
+
+	var scan Scan
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+
+		k, v := tx.Bucket(bucketScanInfo).Cursor().Last()
+		if k == nil {
+			return ErrScanNotFound
+		}
+
+		var err error
+		scan, err = decodeScan(k, v)
+		return err
+
+	})
+
+	return scan, err
+
+}
+
+// LoadScan loads the entries of the scan with the given ID, ordered by full path.
+func (s *Store) LoadScan(id uint64) (objf.Files, error) {
+
+	files := objf.Files{}
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+
+		b := tx.Bucket(bucketScans).Bucket(idKey(id))
+		if b == nil {
+			return fmt.Errorf("%w: %d", ErrScanNotFound, id)
+		}
+
+		return b.ForEach(func(_, v []byte) error {
+			fo := &objf.FileObj{}
+			if err := fo.UnmarshalBinary(v); err != nil {
+				return err
+			}
+			files = append(files, fo)
+			return nil
+		})
+
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+
+}
+
+// Get loads the entry for path from the scan with the given ID. It returns
+// nil if the scan has no such entry.
+func (s *Store) Get(id uint64, path string) (*objf.FileObj, error) {
+
+	var fo *objf.FileObj
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+
+		b := tx.Bucket(bucketScans).Bucket(idKey(id))
+		if b == nil {
+			return fmt.Errorf("%w: %d", ErrScanNotFound, id)
+		}
+
+		v := b.Get([]byte(path))
+		if v == nil {
+			return nil
+		}
+
+		fo = &objf.FileObj{}
+		return fo.UnmarshalBinary(v)
+
+	})
+
+	return fo, err
+
+}
+
+// DeleteScan removes the scan with the given ID.
+func (s *Store) DeleteScan(id uint64) error {
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+
+		if err := tx.Bucket(bucketScans).DeleteBucket(idKey(id)); err != nil {
+			if errors.Is(err, bbolt.ErrBucketNotFound) {
+				return fmt.Errorf("%w: %d", ErrScanNotFound, id)
+			}
+			return err
+		}
+
+		return tx.Bucket(bucketScanInfo).Delete(idKey(id))
+
+	})
+
+}
+
+// Diff loads two saved scans and returns the changes from the scan oldID to
+// the scan newID (see objectify.Files.Diff).
+func (s *Store) Diff(oldID, newID uint64) (objf.Diff, error) {
+
+	older, err := s.LoadScan(oldID)
+	if err != nil {
+		return objf.Diff{}, err
+	}
+	newer, err := s.LoadScan(newID)
+	if err != nil {
+		return objf.Diff{}, err
+	}
+
+	return older.Diff(newer), nil
+
+}
+
+// GetChecksums implements objectify.HashCache.
+func (s *Store) GetChecksums(path string, size int64, modTime time.Time) (md5, sha256 []byte, ok bool) {
+
+	s.mu.Lock()
+	e, found := s.pending[path]
+	s.mu.Unlock()
+
+	if !found {
+		_ = s.db.View(func(tx *bbolt.Tx) error {
+
+			v := tx.Bucket(bucketHashes).Get([]byte(path))
+			if v == nil {
+				return nil
+			}
+
+			var err error
+			e, err = decodeHashEntry(v)
+			found = err == nil
+			return nil
+
+		})
+	}
+
+	if !found || e.size != size || e.modTime != modTime.UnixNano() {
+		return nil, nil, false
+	}
+
+	return e.md5, e.sha256, true
+
+}
+
+// PutChecksums implements objectify.HashCache. Entries are buffered and
+// written in batches, and by Flush and Close. Write errors are ignored, since a
+// missing cache entry only means the file is read again by the next scan.
+func (s *Store) PutChecksums(path string, size int64, modTime time.Time, md5, sha256 []byte) {
+
+	s.mu.Lock()
+	s.pending[path] = hashEntry{size: size, modTime: modTime.UnixNano(), md5: md5, sha256: sha256}
+	full := len(s.pending) >= flushAt
+	s.mu.Unlock()
+
+	if full {
+		_ = s.Flush()
+	}
+
+}
+
+// idKey returns the big-endian key of a scan ID, so scans sort in save order.
+func idKey(id uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, id)
+}
+
+// encode returns the value stored in the scan-info bucket.
+func (scan Scan) encode() []byte {
+
+	b := binary.AppendVarint(nil, scan.Time.UnixNano())
+	return binary.AppendUvarint(b, uint64(scan.Count))
+
+}
+
+// decodeScan decodes a scan-info key and value.
+func decodeScan(k, v []byte) (Scan, error) {
+
+	if len(k) != 8 {
+		return Scan{}, objf.ErrInvalidEncoding
+	}
+
+	r := bytes.NewReader(v)
+	ns, err := binary.ReadVarint(r)
+	if err != nil {
+		return Scan{}, objf.ErrInvalidEncoding
+	}
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return Scan{}, objf.ErrInvalidEncoding
+	}
+
+	return Scan{
+		ID:    binary.BigEndian.Uint64(k),
+		Time:  time.Unix(0, ns),
+		Count: int(count),
+	}, nil
+
+}
+
+// hashEntry is a value in the hashes bucket.
+type hashEntry struct {
+	size    int64
+	modTime int64
+	md5     []byte
+	sha256  []byte
+}
+
+func (e hashEntry) encode() []byte {
+
+	b := binary.AppendVarint(nil, e.size)
+	b = binary.AppendVarint(b, e.modTime)
+	b = appendDigest(b, e.md5)
+	return appendDigest(b, e.sha256)
+
+}
+
+func decodeHashEntry(v []byte) (hashEntry, error) {
+
+	var e hashEntry
+	var n int
+
+	if e.size, n = binary.Varint(v); n <= 0 {
+		return e, objf.ErrInvalidEncoding
+	}
+	v = v[n:]
+	if e.modTime, n = binary.Varint(v); n <= 0 {
+		return e, objf.ErrInvalidEncoding
+	}
+	v = v[n:]
+
+	var err error
+	if e.md5, v, err = readDigest(v); err != nil {
+		return e, err
+	}
+	if e.sha256, _, err = readDigest(v); err != nil {
+		return e, err
+	}
+
+	return e, nil
+
+}
+
+// appendDigest appends a length-prefixed digest; nil is stored as length 0.
+func appendDigest(b, d []byte) []byte {
+
+	b = binary.AppendUvarint(b, uint64(len(d)))
+	return append(b, d...)
+
+}
+
+// readDigest reads a length-prefixed digest and returns the remaining input.
+// An empty digest is returned as nil.
+func readDigest(v []byte) ([]byte, []byte, error) {
+
+	l, n := binary.Uvarint(v)
+	if n <= 0 || uint64(len(v)-n) < l {
+		return nil, nil, objf.ErrInvalidEncoding
+	}
+	v = v[n:]
+
+	if l == 0 {
+		return nil, v, nil
+	}
+
+	return append([]byte(nil), v[:l]...), v[l:], nil
+
+}
@@ -0,0 +1,189 @@
+package bolt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	objf "github.com/orme292/objectify"
+)
+
+// openStore opens a Store in a new temporary directory, closed at the end of
+// the test, and returns it with the path of its database.
+func openStore(t *testing.T) (*Store, string) {
+
+	path := filepath.Join(t.TempDir(), "scans.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s, path
+
+}
+
+// scanTree writes the files to a temporary directory and scans it.
+func scanTree(t *testing.T, dir string, files map[string]string) objf.Files {
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scan, err := objf.Path(dir, objf.Sets{Size: true, Modes: true, ChecksumSHA256: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return scan
+
+}
+
+func TestSaveLoadScan(t *testing.T) {
+
+	s, _ := openStore(t)
+	dir := t.TempDir()
+	files := scanTree(t, dir, map[string]string{"b": "second", "a": "first", "c": "third"})
+
+	saved, err := s.SaveScan(append(files, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.ID == 0 || saved.Count != 3 {
+		t.Fatalf("SaveScan = %+v, want a non-zero ID and 3 entries", saved)
+	}
+	if latest, err := s.Latest(); err != nil || latest.ID != saved.ID || latest.Count != 3 {
+		t.Errorf("Latest = %+v, %v, want %+v", latest, err, saved)
+	}
+
+	loaded, err := s.LoadScan(saved.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 3 {
+		t.Fatalf("LoadScan returned %d entries, want 3", len(loaded))
+	}
+	for i, name := range []string{"a", "b", "c"} {
+		fo := loaded[i]
+		if fo.FullPath() != filepath.Join(dir, name) {
+			t.Errorf("entry %d = %s, want %s", i, fo.FullPath(), name)
+		}
+	}
+	if d := files.Diff(loaded); !d.Empty() {
+		t.Errorf("loaded scan differs from the saved one: %+v", d)
+	}
+
+	fo, err := s.Get(saved.ID, filepath.Join(dir, "a"))
+	if err != nil || fo == nil || !bytes.Equal(fo.SHA256, loaded[0].SHA256) {
+		t.Errorf("Get = %v, %v", fo, err)
+	}
+	if fo, err := s.Get(saved.ID, filepath.Join(dir, "missing")); fo != nil || err != nil {
+		t.Errorf("Get of a missing path = %v, %v, want nil", fo, err)
+	}
+
+	if err := s.DeleteScan(saved.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoadScan(saved.ID); !errors.Is(err, ErrScanNotFound) {
+		t.Errorf("LoadScan of a deleted scan: err = %v, want ErrScanNotFound", err)
+	}
+	if _, err := s.Latest(); !errors.Is(err, ErrScanNotFound) {
+		t.Errorf("Latest with no scans: err = %v, want ErrScanNotFound", err)
+	}
+
+}
+
+func TestDiff(t *testing.T) {
+
+	s, _ := openStore(t)
+	dir := t.TempDir()
+
+	first, err := s.SaveScan(scanTree(t, dir, map[string]string{"same": "same", "changed": "old", "removed": "gone"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "removed")); err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.SaveScan(scanTree(t, dir, map[string]string{"changed": "new content", "added": "new"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scans, err := s.Scans()
+	if err != nil || len(scans) != 2 || scans[0].ID != first.ID || scans[1].ID != second.ID {
+		t.Fatalf("Scans = %+v, %v, want the two scans in save order", scans, err)
+	}
+
+	d, err := s.Diff(first.ID, second.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Added) != 1 || d.Added[0].Filename != "added" ||
+		len(d.Removed) != 1 || d.Removed[0].Filename != "removed" ||
+		len(d.Changed) != 1 || d.Changed[0].New.Filename != "changed" {
+		t.Errorf("Diff = %d added, %d removed, %d changed, want 1 of each", len(d.Added), len(d.Removed), len(d.Changed))
+	}
+
+	if _, err := s.Diff(first.ID, second.ID+1); !errors.Is(err, ErrScanNotFound) {
+		t.Errorf("Diff with a missing scan: err = %v, want ErrScanNotFound", err)
+	}
+
+}
+
+func TestChecksums(t *testing.T) {
+
+	s, path := openStore(t)
+	mod := time.Unix(1700000000, 123)
+	md5, sha := bytes.Repeat([]byte{1}, 16), bytes.Repeat([]byte{2}, 32)
+
+	s.PutChecksums("/a", 10, mod, md5, sha)
+	if gotMD5, gotSHA, ok := s.GetChecksums("/a", 10, mod); !ok || !bytes.Equal(gotMD5, md5) || !bytes.Equal(gotSHA, sha) {
+		t.Errorf("GetChecksums of a buffered entry = %x, %x, %v", gotMD5, gotSHA, ok)
+	}
+	if _, _, ok := s.GetChecksums("/a", 11, mod); ok {
+		t.Error("GetChecksums hit with a different size")
+	}
+	if _, _, ok := s.GetChecksums("/a", 10, mod.Add(time.Nanosecond)); ok {
+		t.Error("GetChecksums hit with a different modification time")
+	}
+	if _, _, ok := s.GetChecksums("/b", 10, mod); ok {
+		t.Error("GetChecksums hit for an unknown path")
+	}
+
+	// The buffer is written once it holds flushAt entries.
+	for i := 1; i < flushAt; i++ {
+		s.PutChecksums(fmt.Sprintf("/f%d", i), int64(i), mod, nil, sha)
+	}
+	s.mu.Lock()
+	pending := len(s.pending)
+	s.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("%d entries still buffered after %d puts, want 0", pending, flushAt)
+	}
+	if gotMD5, gotSHA, ok := s.GetChecksums("/f7", 7, mod); !ok || gotMD5 != nil || !bytes.Equal(gotSHA, sha) {
+		t.Errorf("GetChecksums of a written entry = %x, %x, %v", gotMD5, gotSHA, ok)
+	}
+
+	// Close writes what is still buffered.
+	s.PutChecksums("/last", 1, mod, md5, nil)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, p := range []string{"/a", "/last"} {
+		if _, _, ok := s.GetChecksums(p, map[string]int64{"/a": 10, "/last": 1}[p], mod); !ok {
+			t.Errorf("GetChecksums(%s) after reopening missed", p)
+		}
+	}
+
+}
@@ -0,0 +1,252 @@
+// Package sqlite persists objectify scans in a SQLite database, one row per file,
+// so large trees can be scanned incrementally and reported on with SQL.
+//
+// The package works with any database/sql SQLite driver; register one in your
+// program, e.g.:
+//
+//	import _ "modernc.org/sqlite"          // pure Go, driver name "sqlite"
+//	import _ "github.com/mattn/go-sqlite3" // cgo, driver name "sqlite3"
+//
+// Each row stores the commonly queried fields as columns (indexed on path and
+// sha256) along with the FileObj's binary encoding, which is used to load it
+// back without loss.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	objf "github.com/orme292/objectify"
+)
+
+// schema creates the files table and its indices.
+const schema = `
+CREATE TABLE IF NOT EXISTS files (
+	path        TEXT PRIMARY KEY,
+	root        TEXT NOT NULL,
+	filename    TEXT NOT NULL,
+	size        INTEGER NOT NULL,
+	mode        TEXT NOT NULL,
+	mod_time    INTEGER,
+	updated_at  INTEGER,
+	md5         TEXT,
+	sha256      TEXT,
+	target      TEXT,
+	is_exists   INTEGER NOT NULL,
+	is_readable INTEGER NOT NULL,
+	error       TEXT,
+	obj         BLOB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS files_root ON files (root);
+CREATE INDEX IF NOT EXISTS files_sha256 ON files (sha256);
+`
+
+const upsert = `
+INSERT INTO files (path, root, filename, size, mode, mod_time, updated_at, md5, sha256, target, is_exists, is_readable, error, obj)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (path) DO UPDATE SET
+	root = excluded.root, filename = excluded.filename, size = excluded.size, mode = excluded.mode,
+	mod_time = excluded.mod_time, updated_at = excluded.updated_at, md5 = excluded.md5,
+	sha256 = excluded.sha256, target = excluded.target, is_exists = excluded.is_exists,
+	is_readable = excluded.is_readable, error = excluded.error, obj = excluded.obj
+`
+
+// ErrNotFound is returned by Get when no row exists for the path.
+var ErrNotFound = errors.New("sqlite: file not found")
+
+// Store reads and writes FileObjs in a SQLite database.
+type Store struct {
+	db *sql.DB
+}
+
+// Open returns a Store using db, creating the files table and indices if
+// they do not exist. The caller remains responsible for closing db.
+func Open(ctx context.Context, db *sql.DB) (*Store, error) {
+
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		return nil, fmt.Errorf("sqlite: create schema: %w", err)
+	}
+
+	return &Store{db: db}, nil
+
+}
+
+// Save inserts or replaces a row for each FileObj, keyed by FullPath, in a
+// single transaction. nil entries are skipped.
+func (s *Store) Save(ctx context.Context, files objf.Files) error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, upsert)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	for _, fo := range files {
+
+		if fo == nil {
+			continue
+		}
+
+		obj, err := fo.MarshalBinary()
+		if err != nil {
+			return err
+		}
+
+		var errMsg sql.NullString
+		if fo.Err != nil {
+			errMsg = sql.NullString{String: fo.Err.Error(), Valid: true}
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			fo.FullPath(), fo.Root, fo.Filename, fo.SizeBytes, fo.Mode.String(),
+			unixNano(fo.ModTime().UnixNano(), fo.ModTime().IsZero()),
+			unixNano(fo.UpdatedAt.UnixNano(), fo.UpdatedAt.IsZero()),
+			nullString(fo.ChecksumMD5), nullString(fo.ChecksumSHA256), nullString(fo.Target),
+			fo.IsExists, fo.IsReadable, errMsg, obj,
+		)
+		if err != nil {
+			return fmt.Errorf("sqlite: save %s: %w", fo.FullPath(), err)
+		}
+
+	}
+
+	return tx.Commit()
+
+}
+
+// Delete removes the rows for the given paths.
+func (s *Store) Delete(ctx context.Context, paths ...string) error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, p := range paths {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM files WHERE path = ?`, p); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+
+}
+
+// Get loads the FileObj stored for path. ErrNotFound is returned if there is none.
+func (s *Store) Get(ctx context.Context, path string) (*objf.FileObj, error) {
+
+	files, err := s.query(ctx, `SELECT obj FROM files WHERE path = ?`, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+
+	return files[0], nil
+
+}
+
+// Load loads every stored FileObj, ordered by path.
+func (s *Store) Load(ctx context.Context) (objf.Files, error) {
+	return s.query(ctx, `SELECT obj FROM files ORDER BY path`)
+}
+
+// LoadRoot loads the FileObjs whose Root is root or a subdirectory of root,
+// ordered by path. This is the stored counterpart of a (recursive) scan of root,
+// and can be diffed against a fresh scan with Files.Diff.
+func (s *Store) LoadRoot(ctx context.Context, root string) (objf.Files, error) {
+
+	sub := strings.TrimSuffix(root, "/") + "/"
+
+	return s.query(ctx,
+		`SELECT obj FROM files WHERE root = ? OR substr(root, 1, length(?)) = ? ORDER BY path`,
+		root, sub, sub)
+
+}
+
+// BySHA256 loads the FileObjs whose SHA256 checksum is sum, ordered by path.
+func (s *Store) BySHA256(ctx context.Context, sum string) (objf.Files, error) {
+	return s.query(ctx, `SELECT obj FROM files WHERE sha256 = ? ORDER BY path`, strings.ToLower(sum))
+}
+
+// Sync brings the rows under root in line with a fresh scan of root: rows for
+// entries no longer present are deleted and the remaining entries are saved.
+// It returns the Diff between the stored and the fresh scan.
+func (s *Store) Sync(ctx context.Context, root string, files objf.Files) (objf.Diff, error) {
+
+	stored, err := s.LoadRoot(ctx, root)
+	if err != nil {
+		return objf.Diff{}, err
+	}
+
+	d := stored.Diff(files)
+
+	removed := make([]string, 0, len(d.Removed))
+	for _, fo := range d.Removed {
+		removed = append(removed, fo.FullPath())
+	}
+	if err := s.Delete(ctx, removed...); err != nil {
+		return d, err
+	}
+
+	return d, s.Save(ctx, files)
+
+}
+
+// query runs a query selecting the obj column and decodes each row.
+func (s *Store) query(ctx context.Context, q string, args ...any) (objf.Files, error) {
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	files := objf.Files{}
+	for rows.Next() {
+
+		var obj []byte
+		if err := rows.Scan(&obj); err != nil {
+			return nil, err
+		}
+
+		fo := &objf.FileObj{}
+		if err := fo.UnmarshalBinary(obj); err != nil {
+			return nil, err
+		}
+		files = append(files, fo)
+
+	}
+
+	return files, rows.Err()
+
+}
+
+// nullString returns a NULL for an empty string.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// unixNano returns a NULL for the zero time.
+func unixNano(ns int64, zero bool) sql.NullInt64 {
+	return sql.NullInt64{Int64: ns, Valid: !zero}
+}
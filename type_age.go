@@ -0,0 +1,44 @@
+package objectify
+
+import (
+	"time"
+)
+
+// Age returns the time elapsed since the FileObj's recorded modification time.
+// It returns 0 if no modification time has been recorded.
+func (fo *FileObj) Age() time.Duration {
+
+	modTime := fo.ModTime()
+	if modTime.IsZero() {
+		return 0
+	}
+
+	return fo.options().now().Sub(modTime)
+
+}
+
+// OlderThan returns true if the FileObj's recorded modification time is more
+// than d in the past. It returns false if no modification time has been
+// recorded, so entries of unknown age are never treated as stale.
+func (fo *FileObj) OlderThan(d time.Duration) bool {
+
+	age := fo.Age()
+
+	return !fo.modTime.IsZero() && age > d
+
+}
+
+// OlderThan returns the entries whose recorded modification time is more than
+// d in the past (see FileObj.OlderThan), in their original order.
+func (files Files) OlderThan(d time.Duration) Files {
+
+	var stale Files
+	for _, fo := range files {
+		if fo != nil && fo.OlderThan(d) {
+			stale = append(stale, fo)
+		}
+	}
+
+	return stale
+
+}
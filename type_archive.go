@@ -0,0 +1,738 @@
+package objectify
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ContainerSep separates the path of an archive from the path of a member in
+// the FullPath of a member FileObj, e.g. /srv/build/app.zip!/bin/app.
+const ContainerSep = "!"
+
+// ArchiveLimits bound the work done by WithArchiveMembers, so scanning
+// untrusted artifacts cannot exhaust memory. Members are never extracted to
+// disk, but a zip archive nested in another archive is read into memory, up
+// to MaxNestedZip.
+type ArchiveLimits struct {
+
+	// MaxDepth is the number of levels of archives expanded: 1 expands only
+	// the archives found by the scan, 2 also the archives among their
+	// members, and so on.
+	MaxDepth int
+
+	// MaxMembers is the number of members expanded from one archive.
+	MaxMembers int
+
+	// MaxSize is the total uncompressed size of the members expanded from
+	// one archive.
+	MaxSize int64
+
+	// MaxNestedZip is the size of the largest zip archive nested in another
+	// archive which is expanded. Its central directory is at its end, so it
+	// is read into memory whole.
+	MaxNestedZip int64
+
+	// MaxRatio is the largest ratio of uncompressed to compressed size, for
+	// each member of a zip archive and for all the members of an archive
+	// together. Zip bombs have ratios in the thousands.
+	MaxRatio float64
+}
+
+// DefaultArchiveLimits returns the ArchiveLimits used when WithArchiveLimits
+// is not: nested archives are not expanded, and an archive may hold up to
+// 100,000 members, 4 GiB of content, and a compression ratio of 100. Nested
+// zip archives are expanded up to 64 MiB.
+func DefaultArchiveLimits() ArchiveLimits {
+	return ArchiveLimits{
+		MaxDepth:     1,
+		MaxMembers:   100000,
+		MaxSize:      4 << 30,
+		MaxNestedZip: 64 << 20,
+		MaxRatio:     100,
+	}
+}
+
+// archiveFormat identifies the archive formats which can be expanded.
+type archiveFormat int
+
+const (
+	archiveNone archiveFormat = iota
+	archiveZip
+	archiveTar
+	archiveTarGzip
+)
+
+// detectArchive returns the archive format of the content starting with
+// header, which should hold at least the first 512 bytes of the file. A gzip
+// stream is a tar.gz archive if its decompressed content starts with a tar
+// header.
+func detectArchive(header []byte) archiveFormat {
+
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return archiveZip
+	case isTarHeader(header):
+		return archiveTar
+	case detectCompression(header) == CompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(header))
+		if err != nil {
+			return archiveNone
+		}
+		inner := make([]byte, 512)
+		n, _ := io.ReadFull(zr, inner)
+		if isTarHeader(inner[:n]) {
+			return archiveTarGzip
+		}
+	}
+
+	return archiveNone
+
+}
+
+// isTarHeader returns true if block holds the "ustar" magic of a POSIX or GNU
+// tar header.
+func isTarHeader(block []byte) bool {
+	return len(block) >= 262 && string(block[257:262]) == "ustar"
+}
+
+// archiveFS is a read-only fs.FS over the regular file members of a zip, tar,
+// or tar.gz archive. The archive is indexed once, when the archiveFS is
+// created; each Open reads the archive again, so member FileObjs can be
+// updated after the scan. Names are the archive path, ContainerSep, and the
+// slash-separated member path, e.g. "app.zip!/bin/app".
+type archiveFS struct {
+
+	// host are the options of the archive's FileObj, which open it, and
+	// seekable is true if the archive file it opens is an io.Seeker.
+	host     *options
+	path     string
+	format   archiveFormat
+	seekable bool
+
+	// stream, while streaming is set, is the tar reader which Open advances
+	// to the members opened in stream order (see openStreamed).
+	mu        sync.Mutex
+	streaming bool
+	stream    *tarStream
+
+	members map[string]*archiveMember
+
+	// limits bound the members indexed, and size and total are the size of
+	// the archive and the total size of the members indexed so far.
+	limits ArchiveLimits
+	size   int64
+	total  int64
+}
+
+// archiveMember records the fs.FileInfo of a member and, for a tar archive,
+// the index of its header in the archive and the offset of its content if the
+// archive is not compressed.
+type archiveMember struct {
+	info   fs.FileInfo
+	index  int
+	offset int64
+}
+
+// openArchive indexes the archive at p, opened with the host options.
+// It returns nil and no error if the file is not a zip, tar, or tar.gz archive.
+// Indexing stops at the first member which would exceed the limits, in which
+// case the archiveFS holds the members indexed so far and an error wrapping
+// ErrArchiveLimit is returned with it.
+func openArchive(host *options, p string, limits ArchiveLimits) (*archiveFS, error) {
+
+	f, err := host.open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReaderSize(f, 4096)
+	header, _ := br.Peek(4096)
+
+	_, seekable := f.(io.Seeker)
+	a := &archiveFS{
+		host:     host,
+		path:     p,
+		format:   detectArchive(header),
+		seekable: seekable,
+		members:  make(map[string]*archiveMember),
+		limits:   limits,
+		size:     info.Size(),
+	}
+
+	switch a.format {
+	case archiveZip:
+		err = a.indexZip()
+	case archiveTar, archiveTarGzip:
+		err = a.indexTar(br)
+	default:
+		return nil, nil
+	}
+	if errors.Is(err, ErrArchiveLimit) {
+		return a, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+
+	return a, nil
+
+}
+
+// indexZip records the regular file members of the zip archive. The archive
+// is opened again, since its start has already been read to detect it.
+func (a *archiveFS) indexZip() error {
+
+	f, err := a.host.open(a.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zr, err := openZip(f, a.limits.MaxNestedZip)
+	if err != nil {
+		return err
+	}
+
+	for _, zf := range zr.File {
+		name, ok := memberName(zf.Name)
+		if !ok || !zf.Mode().IsRegular() {
+			continue
+		}
+		if err := a.add(name, &archiveMember{info: zf.FileInfo(), offset: -1}, int64(zf.CompressedSize64)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+// indexTar records the regular file members of the tar archive read from r,
+// which is positioned at the start of the file. For an uncompressed archive,
+// the offset of each member's content is recorded, so it can be read without
+// reading the members before it.
+func (a *archiveFS) indexTar(r io.Reader) error {
+
+	cr := &countingReader{r: r}
+	var tr *tar.Reader
+	if a.format == archiveTarGzip {
+		zr, err := gzip.NewReader(cr)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		tr = tar.NewReader(zr)
+	} else {
+		tr = tar.NewReader(cr)
+	}
+
+	for index := 0; ; index++ {
+
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name, ok := memberName(hdr.Name)
+		if !ok || !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+
+		m := &archiveMember{info: hdr.FileInfo(), index: index, offset: -1}
+		if a.format == archiveTar {
+			m.offset = cr.n
+		}
+		if err := a.add(name, m, -1); err != nil {
+			return err
+		}
+
+	}
+
+}
+
+// add records member m as name, unless it would exceed the limits, in which
+// case an error wrapping ErrArchiveLimit is returned. compressed is the stored
+// size of the member, or -1 if it is unknown, as for tar members. Besides the
+// ratio of each member, the ratio of all members to the size of the archive is
+// checked, which catches tar.gz bombs.
+func (a *archiveFS) add(name string, m *archiveMember, compressed int64) error {
+
+	l := a.limits
+	size := m.info.Size()
+
+	switch {
+	case len(a.members) >= l.MaxMembers:
+		return fmt.Errorf("%w: %s: more than %d members", ErrArchiveLimit, a.path, l.MaxMembers)
+	case a.total+size > l.MaxSize:
+		return fmt.Errorf("%w: %s: members larger than %s", ErrArchiveLimit, a.path, FormatSize(l.MaxSize, SizeBinary, 2))
+	case compressed >= 0 && float64(size) > l.MaxRatio*float64(max(compressed, 1)):
+		return fmt.Errorf("%w: %s: member %s exceeds compression ratio %g", ErrArchiveLimit, a.path, name, l.MaxRatio)
+	case float64(a.total+size) > l.MaxRatio*float64(max(a.size, 1)):
+		return fmt.Errorf("%w: %s: members exceed compression ratio %g", ErrArchiveLimit, a.path, l.MaxRatio)
+	}
+
+	a.members[name] = m
+	a.total += size
+
+	return nil
+
+}
+
+// names returns the member names in lexical order, or in the order of the
+// archive if it is streamed.
+func (a *archiveFS) names() []string {
+
+	names := make([]string, 0, len(a.members))
+	for name := range a.members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if a.streamed() {
+		sort.SliceStable(names, func(i, j int) bool {
+			return a.members[names[i]].index < a.members[names[j]].index
+		})
+	}
+
+	return names
+
+}
+
+// streamed returns true if the members of the archive can only be reached by
+// reading the archive from the start: those of a tar.gz archive, and of a tar
+// archive which cannot seek, such as one nested in a tar.gz archive.
+func (a *archiveFS) streamed() bool {
+	return a.format == archiveTarGzip || a.format == archiveTar && !a.seekable
+}
+
+// memberPath returns the name of member in the archiveFS.
+func (a *archiveFS) memberPath(member string) string {
+	return a.path + ContainerSep + "/" + member
+}
+
+// lookup returns the member name and record for name, or an fs.PathError.
+func (a *archiveFS) lookup(op, name string) (string, *archiveMember, error) {
+
+	member, ok := strings.CutPrefix(name, a.path+ContainerSep+"/")
+	if m := a.members[member]; ok && m != nil {
+		return member, m, nil
+	}
+
+	return EMPTY, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+
+}
+
+// Stat returns the fs.FileInfo recorded for the member when the archive was indexed.
+func (a *archiveFS) Stat(name string) (fs.FileInfo, error) {
+
+	_, m, err := a.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.info, nil
+
+}
+
+// Open opens the archive and returns the content of the member as the file.
+func (a *archiveFS) Open(name string) (fs.File, error) {
+
+	member, m, err := a.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if f, ok, err := a.openStreamed(m); ok {
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return f, nil
+	}
+
+	f, err := a.host.open(a.path)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	r, closer, err := a.openMember(f, member, m)
+	if err != nil {
+		_ = f.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &archiveFile{r: r, info: m.info, closers: []io.Closer{closer, f}}, nil
+
+}
+
+// tarStream is a tar archive being read through, positioned at the content of
+// the member whose header has index pos, or before the first header if pos is
+// -1. busy is true while the content of that member is open.
+type tarStream struct {
+	tr      *tar.Reader
+	pos     int
+	busy    bool
+	closers []io.Closer
+}
+
+// newTarStream opens the tar archive, decompressing it if it is a tar.gz.
+func (a *archiveFS) newTarStream() (*tarStream, error) {
+
+	f, err := a.host.open(a.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.format == archiveTar {
+		return &tarStream{tr: tar.NewReader(f), pos: -1, closers: []io.Closer{f}}, nil
+	}
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return &tarStream{tr: tar.NewReader(zr), pos: -1, closers: []io.Closer{zr, f}}, nil
+
+}
+
+// close closes the archive the tarStream reads.
+func (s *tarStream) close() {
+
+	for _, c := range s.closers {
+		_ = c.Close()
+	}
+
+}
+
+// startStreaming makes Open read the members of a streamed archive (see
+// streamed) from a single pass over the archive, as long as they are opened
+// one at a time in the order of names. stopStreaming closes it again.
+func (a *archiveFS) startStreaming() {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.streaming = a.streamed()
+
+}
+
+// stopStreaming closes the tarStream of startStreaming, after which every Open
+// reads the archive from the start again.
+func (a *archiveFS) stopStreaming() {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.streaming = false
+	if a.stream != nil && !a.stream.busy {
+		a.stream.close()
+	}
+	a.stream = nil
+
+}
+
+// openStreamed returns the content of member m from the tarStream, advanced to
+// it, and true, if streaming is set and no other member of the stream is
+// open. The stream is read from the start again if m is behind it, e.g. if m
+// is opened twice.
+func (a *archiveFS) openStreamed(m *archiveMember) (fs.File, bool, error) {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.streaming || a.stream != nil && a.stream.busy {
+		return nil, false, nil
+	}
+
+	if a.stream != nil && a.stream.pos >= m.index {
+		a.stream.close()
+		a.stream = nil
+	}
+	if a.stream == nil {
+		s, err := a.newTarStream()
+		if err != nil {
+			return nil, true, err
+		}
+		a.stream = s
+	}
+
+	s := a.stream
+	for s.pos < m.index {
+		if _, err := s.tr.Next(); err != nil {
+			s.close()
+			a.stream = nil
+			if errors.Is(err, io.EOF) {
+				err = fs.ErrNotExist
+			}
+			return nil, true, err
+		}
+		s.pos++
+	}
+	s.busy = true
+
+	release := closerFunc(func() error {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		s.busy = false
+		if a.stream != s {
+			s.close()
+		}
+		return nil
+	})
+
+	return &archiveFile{r: s.tr, info: m.info, closers: []io.Closer{release}}, true, nil
+
+}
+
+// closerFunc is a function called as an io.Closer.
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+// openMember returns a reader of the content of member in the open archive f,
+// and a Closer to call before f is closed.
+func (a *archiveFS) openMember(f fs.File, member string, m *archiveMember) (io.Reader, io.Closer, error) {
+
+	switch a.format {
+	case archiveZip:
+		zr, err := openZip(f, a.limits.MaxNestedZip)
+		if err != nil {
+			return nil, nil, err
+		}
+		rc, err := zr.Open(member)
+		if err != nil {
+			return nil, nil, err
+		}
+		return rc, rc, nil
+	case archiveTar:
+		if s, ok := f.(io.Seeker); ok && m.offset >= 0 {
+			if _, err := s.Seek(m.offset, io.SeekStart); err != nil {
+				return nil, nil, err
+			}
+			return io.LimitReader(f, m.info.Size()), io.NopCloser(nil), nil
+		}
+		return findTarMember(tar.NewReader(f), member, io.NopCloser(nil))
+	case archiveTarGzip:
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		return findTarMember(tar.NewReader(zr), member, zr)
+	}
+
+	return nil, nil, fs.ErrNotExist
+
+}
+
+// findTarMember reads tr up to the header of member, and returns tr and closer.
+func findTarMember(tr *tar.Reader, member string, closer io.Closer) (io.Reader, io.Closer, error) {
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, nil, fs.ErrNotExist
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if name, ok := memberName(hdr.Name); ok && name == member {
+			return tr, closer, nil
+		}
+	}
+
+}
+
+// openZip reads the central directory of the zip archive f. Unless f
+// implements io.ReaderAt, as an *os.File does, its content is read into memory
+// first, which is the case for a zip archive nested in another archive. An
+// error wrapping ErrArchiveLimit is returned if it is larger than maxSize.
+func openZip(f fs.File, maxSize int64) (*zip.Reader, error) {
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	ra, ok := f.(io.ReaderAt)
+	if !ok {
+		if info.Size() > maxSize {
+			return nil, fmt.Errorf("%w: nested zip archive larger than %s", ErrArchiveLimit, FormatSize(maxSize, SizeBinary, 2))
+		}
+		data, err := io.ReadAll(io.LimitReader(f, info.Size()))
+		if err != nil {
+			return nil, err
+		}
+		ra = bytes.NewReader(data)
+	}
+
+	return zip.NewReader(ra, info.Size())
+
+}
+
+// memberName cleans the name of an archive member into a slash-separated
+// relative path. It returns false for names which escape the archive, such as
+// "../etc/passwd".
+func memberName(name string) (string, bool) {
+
+	name = strings.TrimLeft(path.Clean("/"+name), "/")
+	if name == EMPTY || !fs.ValidPath(name) {
+		return EMPTY, false
+	}
+
+	return name, true
+
+}
+
+// archiveFile is the fs.File returned by archiveFS.Open.
+type archiveFile struct {
+	r       io.Reader
+	info    fs.FileInfo
+	closers []io.Closer
+}
+
+func (f *archiveFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *archiveFile) Read(p []byte) (int, error) {
+	return f.r.Read(p)
+}
+
+func (f *archiveFile) Close() error {
+
+	var errs []error
+	for _, c := range f.closers {
+		errs = append(errs, c.Close())
+	}
+
+	return errors.Join(errs...)
+
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// expandArchives returns files with the members of each zip, tar, and tar.gz
+// archive among them inserted after the archive, as virtual FileObjs read
+// through an archiveFS. See archiveMembers.
+func (w *worker) expandArchives(files Files) Files {
+
+	expanded := make(Files, 0, len(files))
+	for _, fo := range files {
+		expanded = append(expanded, fo)
+		expanded = append(expanded, w.archiveMembers(fo, 1)...)
+	}
+
+	return expanded
+
+}
+
+// archiveMembers returns the member FileObjs of fo if it is an archive, each
+// followed by its own members if it is an archive and depth is less than the
+// MaxDepth of the archive limits. A member FileObj's Container holds the
+// archive's FullPath. Members are objectified with the worker's Sets and
+// options, and filtered by its SkipFuncs and entry kinds. An error indexing an
+// archive, or an archive which exceeds the limits, is stored in the archive's
+// Err field; the members indexed before a limit was reached are returned.
+func (w *worker) archiveMembers(fo *FileObj, depth int) Files {
+
+	if fo == nil || !fo.IsExists || !fo.IsReadable || fo.info == nil || !fo.info.Mode().IsRegular() {
+		return nil
+	}
+
+	limits := w.opts.archiveLimits
+	if depth > limits.MaxDepth {
+		return nil
+	}
+
+	a, err := openArchive(fo.options(), fo.ioPath(), limits)
+	fo.keepErr(err)
+	if a == nil {
+		return nil
+	}
+
+	mo := *fo.options()
+	mo.fsys = a
+	if a.streamed() {
+		// The members are read from a single pass over the archive, one at a
+		// time in its order.
+		mo.concurrency, mo.hashWorkers, mo.schedule = 1, 0, ScheduleWalk
+		a.startStreaming()
+	}
+	mw := &worker{RootPath: a.path, setter: w.setter, opts: &mo}
+
+	members, _ := mw.collect(func(emit func(string) error) error {
+		for _, name := range a.names() {
+			p := a.memberPath(name)
+			if mw.skips(p, fs.FileInfoToDirEntry(a.members[name].info)) || !mo.wantsKind(EntKindRegular) {
+				continue
+			}
+			if err := emit(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	a.stopStreaming()
+	sort.SliceStable(members, func(i, j int) bool { return members[i].FullPath() < members[j].FullPath() })
+
+	var expanded Files
+	for _, m := range members {
+		m.Container = fo.FullPath()
+		expanded = append(expanded, m)
+		expanded = append(expanded, w.archiveMembers(m, depth+1)...)
+	}
+
+	return expanded
+
+}
+
+// InContainer returns true if the FileObj is a member of an archive, expanded
+// by WithArchiveMembers.
+func (fo *FileObj) InContainer() bool {
+	return fo.Container != EMPTY
+}
+
+// MemberPath returns the slash-separated path of an archive member within its
+// archive, e.g. "bin/app" for /srv/build/app.zip!/bin/app, or an empty string
+// if the FileObj is not a member of an archive.
+func (fo *FileObj) MemberPath() string {
+
+	if !fo.InContainer() {
+		return EMPTY
+	}
+
+	return strings.TrimPrefix(fo.FullPath(), fo.Container+ContainerSep+"/")
+
+}
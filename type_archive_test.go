@@ -0,0 +1,230 @@
+package objectify
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// readCountingFS is the OS filesystem, counting the bytes read from files.
+type readCountingFS struct {
+	OSFileSystem
+	read atomic.Int64
+}
+
+func (c *readCountingFS) Open(name string) (fs.File, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &readCountingFile{File: f, fs: c}, nil
+
+}
+
+type readCountingFile struct {
+	*os.File
+	fs *readCountingFS
+}
+
+func (f *readCountingFile) Read(p []byte) (int, error) {
+
+	n, err := f.File.Read(p)
+	f.fs.read.Add(int64(n))
+
+	return n, err
+
+}
+
+// archiveContent returns the content of the n members written by the archive
+// helpers, keyed by member name.
+func archiveContent(n int) map[string][]byte {
+
+	members := make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		members[fmt.Sprintf("dir/member%03d", i)] = []byte(fmt.Sprintf("content of member %d %s", i, strings.Repeat("x", i)))
+	}
+
+	return members
+
+}
+
+// writeTarGz writes a tar.gz archive of members to p.
+func writeTarGz(t *testing.T, p string, members map[string][]byte) {
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for _, name := range sortedKeys(members) {
+		data := members[name]
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(p, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+}
+
+// zipBytes returns a zip archive of members, deflated.
+func zipBytes(t *testing.T, members map[string][]byte) []byte {
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range sortedKeys(members) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(members[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+
+}
+
+func sortedKeys(m map[string][]byte) []string {
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+
+}
+
+// scanArchive scans the single archive at p with WithArchiveMembers, and
+// returns the archive's FileObj and its members.
+func scanArchive(t *testing.T, p string, opts ...Option) (*FileObj, Files) {
+
+	files, err := Path(filepath.Dir(p), Sets{Size: true, ChecksumSHA256: true},
+		append([]Option{WithArchiveMembers()}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 || files[0].FullPath() != p {
+		t.Fatalf("first entry is not the archive %s", p)
+	}
+
+	return files[0], files[1:]
+
+}
+
+func TestArchiveTarGzipSinglePass(t *testing.T) {
+
+	members := archiveContent(200)
+	p := filepath.Join(t.TempDir(), "members.tar.gz")
+	writeTarGz(t, p, members)
+	info, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sys := &readCountingFS{}
+	archive, got := scanArchive(t, p, WithFileSystem(sys), WithConcurrency(8))
+	if archive.Err != nil {
+		t.Fatal(archive.Err)
+	}
+
+	if len(got) != len(members) {
+		t.Fatalf("got %d members, want %d", len(got), len(members))
+	}
+	for i, name := range sortedKeys(members) {
+		fo := got[i]
+		if fo.MemberPath() != name {
+			t.Fatalf("members[%d] = %s, want %s", i, fo.MemberPath(), name)
+		}
+		if want := sha256.Sum256(members[name]); !bytes.Equal(fo.SHA256, want[:]) {
+			t.Errorf("%s: SHA256 = %x, want %x", name, fo.SHA256, want)
+		}
+	}
+
+	// The archive is read to detect it, to index it, and once more to hash
+	// its members, rather than once per member.
+	if n := sys.read.Load(); n > 4*info.Size() {
+		t.Errorf("read %d bytes of a %d byte archive, want at most %d", n, info.Size(), 4*info.Size())
+	}
+
+}
+
+func TestArchiveLimits(t *testing.T) {
+
+	dir := t.TempDir()
+	small := archiveContent(10)
+	bomb := map[string][]byte{"zeros": make([]byte, 1<<20)}
+	nested := map[string][]byte{"inner.zip": zipBytes(t, small), "top": []byte("top")}
+
+	write := func(name string, data []byte) string {
+		p := filepath.Join(dir, name, "archive.zip")
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	tests := map[string]struct {
+		path    string
+		limits  ArchiveLimits
+		limited bool
+		members int
+	}{
+		"within limits": {write("small", zipBytes(t, small)), ArchiveLimits{}, false, 10},
+		"max members":   {write("members", zipBytes(t, small)), ArchiveLimits{MaxMembers: 4}, true, 4},
+		"max size":      {write("size", zipBytes(t, small)), ArchiveLimits{MaxSize: 100}, true, 4},
+		"max ratio":     {write("ratio", zipBytes(t, bomb)), ArchiveLimits{}, true, 0},
+		"depth 1":       {write("depth1", zipBytes(t, nested)), ArchiveLimits{}, false, 2},
+		"depth 2":       {write("depth2", zipBytes(t, nested)), ArchiveLimits{MaxDepth: 2}, false, 12},
+		"nested zip":    {write("nested", zipBytes(t, nested)), ArchiveLimits{MaxDepth: 2, MaxNestedZip: 100}, false, 2},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			archive, members := scanArchive(t, tt.path, WithArchiveLimits(tt.limits))
+			if limited := errors.Is(archive.Err, ErrArchiveLimit); limited != tt.limited {
+				t.Errorf("archive Err = %v, want ErrArchiveLimit: %t", archive.Err, tt.limited)
+			}
+			if len(members) != tt.members {
+				t.Errorf("got %d members, want %d", len(members), tt.members)
+			}
+			for _, m := range members {
+				if m.MemberPath() == "inner.zip" && tt.limits.MaxNestedZip > 0 && !errors.Is(m.Err, ErrArchiveLimit) {
+					t.Errorf("inner.zip Err = %v, want %v", m.Err, ErrArchiveLimit)
+				}
+			}
+		})
+	}
+
+}
@@ -0,0 +1,168 @@
+package objectify
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditRecord is one entry of the audit log written by an AuditLogger, encoded
+// as a line of JSON.
+type AuditRecord struct {
+
+	// Time is when the change was detected.
+	Time time.Time `json:"time"`
+
+	// Path is the FullPath of the entry, and OldPath its previous FullPath
+	// if it was renamed.
+	Path    string `json:"path"`
+	OldPath string `json:"old_path,omitempty"`
+
+	// Change is the kind of change, encoded by name (e.g. "modified").
+	Change EventKind `json:"change"`
+
+	// OldChecksum and NewChecksum are the checksums of the entry before and
+	// after the change, as hexadecimal strings: the SHA256 if it was
+	// computed, and otherwise the MD5. They are empty when there is no such
+	// state (e.g. OldChecksum of a created entry) or it was not hashed.
+	OldChecksum string `json:"old_checksum,omitempty"`
+	NewChecksum string `json:"new_checksum,omitempty"`
+}
+
+// AuditLogger appends an AuditRecord for each detected change to an
+// io.Writer, as a line of JSON, giving an append-only trail of changes. Use
+// it with WithAuditLogger, which records the changes found by
+// FileObj.Update and by a Monitor's rescans. An AuditLogger is safe for
+// concurrent use.
+type AuditLogger struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewAuditLogger returns an AuditLogger which writes records to w. Each record
+// is written with a single call to w.Write.
+func NewAuditLogger(w io.Writer) *AuditLogger {
+
+	return &AuditLogger{
+		mu: &sync.Mutex{},
+		w:  w,
+	}
+
+}
+
+// OpenAuditLog opens the file at path for appending, creating it with
+// permissions 0600 if it does not exist, and returns an AuditLogger which
+// writes to it. Existing records are never rewritten. Call Close when done.
+func OpenAuditLog(path string) (*AuditLogger, error) {
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	l := NewAuditLogger(f)
+	l.closer = f
+
+	return l, nil
+
+}
+
+// Log appends the record r. A zero Time is set to the current time.
+func (l *AuditLogger) Log(r AuditRecord) error {
+
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err = l.w.Write(line)
+
+	return err
+
+}
+
+// LogDiff appends a record for every Event of d (see Diff.Events), and returns
+// the first error encountered.
+func (l *AuditLogger) LogDiff(d Diff) error {
+
+	now := time.Now()
+
+	var first error
+	for _, e := range d.Events() {
+
+		r := AuditRecord{Time: now, Path: e.Path(), Change: e.Kind}
+		if e.Old != nil {
+			r.OldChecksum = auditChecksum(e.Old)
+		}
+		if e.New != nil {
+			r.NewChecksum = auditChecksum(e.New)
+		}
+		if e.Kind == EventRenamed {
+			r.OldPath = e.Old.FullPath()
+		}
+
+		if err := l.Log(r); err != nil && first == nil {
+			first = err
+		}
+
+	}
+
+	return first
+
+}
+
+// Close closes the file opened by OpenAuditLog. It does nothing for an
+// AuditLogger made with NewAuditLogger.
+func (l *AuditLogger) Close() error {
+
+	if l.closer == nil {
+		return nil
+	}
+
+	return l.closer.Close()
+
+}
+
+// auditChecksum returns the checksum recorded for fo in an AuditRecord: its
+// SHA256, or else its MD5.
+func auditChecksum(fo *FileObj) string {
+
+	if fo.ChecksumSHA256 != EMPTY {
+		return fo.ChecksumSHA256
+	}
+
+	return fo.ChecksumMD5
+
+}
+
+// WithAuditLogger records the changes found by FileObj.Update, and the Events
+// found by each Monitor rescan, with l. Since a Monitor keeps reporting a
+// change until it is accepted, its records repeat on every rescan until then.
+// Errors writing the log are not reported by Update, so l's writer should be
+// reliable.
+func WithAuditLogger(l *AuditLogger) Option {
+	return func(o *options) {
+		o.audit = l
+	}
+}
+
+// audit records the change to fo found by Update, from a previous state with
+// the checksum old, if an AuditLogger is set.
+func (fo *FileObj) audit(old string) {
+
+	if l := fo.options().audit; l != nil {
+		_ = l.Log(AuditRecord{Time: fo.options().now(), Path: fo.FullPath(), Change: EventModified, OldChecksum: old, NewChecksum: auditChecksum(fo)})
+	}
+
+}
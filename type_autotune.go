@@ -0,0 +1,163 @@
+package objectify
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// StorageKind is the kind of storage hosting a path, as detected by
+// DetectStorage.
+type StorageKind int
+
+const (
+	// StorageUnknown is storage which could not be classified, e.g. on
+	// overlay and other virtual filesystems, or on platforms where detection
+	// is not supported.
+	StorageUnknown StorageKind = iota
+
+	// StorageSSD is non-rotational local storage, such as SATA SSDs, NVMe
+	// drives, and memory-backed filesystems like tmpfs.
+	StorageSSD
+
+	// StorageHDD is rotational local storage, which is slow to seek.
+	StorageHDD
+
+	// StorageNetwork is a network mount, such as NFS, SMB, or a FUSE
+	// filesystem, where each call pays a round trip.
+	StorageNetwork
+)
+
+// String returns the name of the StorageKind, e.g. "ssd".
+func (k StorageKind) String() string {
+
+	switch k {
+	case StorageSSD:
+		return "ssd"
+	case StorageHDD:
+		return "hdd"
+	case StorageNetwork:
+		return "network"
+	}
+
+	return "unknown"
+
+}
+
+// DetectStorage returns the kind of storage hosting path. On Linux, network
+// mounts are recognized by their filesystem type, and local devices by the
+// rotational flag the kernel reports in /sys/dev/block. On macOS and the BSDs,
+// only network mounts are recognized, and on Windows, only mapped network
+// drives and shares. StorageUnknown is returned with an error wrapping
+// errors.ErrUnsupported on other platforms.
+func DetectStorage(path string) (StorageKind, error) {
+
+	k, err := detectStorage(path)
+	if err != nil {
+		return StorageUnknown, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return k, nil
+
+}
+
+// Tuning is a set of scan settings suited to a StorageKind, returned by
+// TuningFor and applied by WithAutoTune.
+type Tuning struct {
+
+	// StatWorkers is the number of entries objectified at once (see
+	// WithStatWorkers).
+	StatWorkers int
+
+	// HashWorkers is the number of checksums computed at once (see
+	// WithHashWorkers), or 0 for no limit beyond StatWorkers.
+	HashWorkers int
+
+	// QueueDepth is the number of discovered entries which may wait for a
+	// worker (see WithQueueDepth).
+	QueueDepth int
+
+	// ReadBuffer is the size of the reads made while hashing (see
+	// WithReadBuffer).
+	ReadBuffer int
+}
+
+// TuningFor returns the Tuning for storage of kind k:
+//
+//   - StorageSSD stats and hashes in parallel, one file per CPU.
+//   - StorageHDD stats in parallel, since metadata is often cached, but hashes
+//     one file at a time with large reads, so the disk is not made to seek
+//     between files.
+//   - StorageNetwork keeps many calls in flight to hide the round trips, and
+//     makes large reads.
+//   - StorageUnknown returns the defaults of a scan without options.
+func TuningFor(k StorageKind) Tuning {
+
+	cpus := runtime.GOMAXPROCS(0)
+
+	switch k {
+	case StorageSSD:
+		return Tuning{StatWorkers: 4 * cpus, HashWorkers: cpus, QueueDepth: 8 * cpus, ReadBuffer: 256 * 1024}
+	case StorageHDD:
+		return Tuning{StatWorkers: 4, HashWorkers: 1, QueueDepth: 8, ReadBuffer: 1024 * 1024}
+	case StorageNetwork:
+		return Tuning{StatWorkers: 32, HashWorkers: 4, QueueDepth: 128, ReadBuffer: 1024 * 1024}
+	}
+
+	return Tuning{StatWorkers: DefaultConcurrency, QueueDepth: 2 * DefaultConcurrency}
+
+}
+
+// WithAutoTune detects the kind of storage hosting the root of a scan with
+// DetectStorage, and applies the Tuning returned by TuningFor to the worker
+// counts, queue depth, and read buffer which are left at their defaults.
+// Values set with WithConcurrency (or WithStatWorkers) above 1,
+// WithHashWorkers, WithQueueDepth, or WithReadBuffer are kept. It has no
+// effect on scans of an fs.FS or through a FileSystem set by WithFileSystem,
+// or when the storage is StorageUnknown.
+func WithAutoTune() Option {
+	return func(o *options) {
+		o.autoTune = true
+	}
+}
+
+// WithReadBuffer reads the content of files in chunks of n bytes while
+// hashing them, instead of 32 KiB. Larger reads help storage which is slow to
+// seek or has a high latency per call. Values less than 1 are ignored.
+func WithReadBuffer(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.readBuffer = n
+		}
+	}
+}
+
+// tune applies the Tuning of the storage hosting root, if the autoTune option
+// is set and the settings were not tuned yet.
+func (o *options) tune(root string) {
+
+	if !o.autoTune || o.tuned || !o.onOS() {
+		return
+	}
+	o.tuned = true
+
+	k, err := detectStorage(root)
+	if err != nil || k == StorageUnknown {
+		return
+	}
+	t := TuningFor(k)
+
+	if o.concurrency == DefaultConcurrency {
+		o.concurrency = t.StatWorkers
+		if o.defaultQueue {
+			o.queueDepth = t.QueueDepth
+		}
+	}
+	if o.hashWorkers == 0 && t.HashWorkers > 0 {
+		o.hashWorkers = t.HashWorkers
+		o.hashSem = make(chan struct{}, o.hashWorkers)
+	}
+	if o.readBuffer == 0 {
+		o.readBuffer = t.ReadBuffer
+	}
+
+}
@@ -0,0 +1,22 @@
+package objectify
+
+// ETagInfo may be implemented by the fs.FileInfo values returned from an fs.FS
+// passed to PathFS or FileFS (e.g. a remote backend). The returned entity tag
+// is stored in the FileObj's ETag field.
+type ETagInfo interface {
+	ETag() string
+}
+
+// MD5Info may be implemented by the fs.FileInfo values returned from an fs.FS
+// passed to PathFS or FileFS. If MD5 returns a non-nil hash, it is used as the
+// MD5 checksum instead of reading the file's content.
+type MD5Info interface {
+	MD5() []byte
+}
+
+// ContentTypeInfo may be implemented by the fs.FileInfo values returned from an
+// fs.FS passed to PathFS or FileFS. The returned media type is stored in the
+// FileObj's ContentType field.
+type ContentTypeInfo interface {
+	ContentType() string
+}
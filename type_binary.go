@@ -0,0 +1,745 @@
+package objectify
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// binaryVersion is the version of the FileObj record encoding and of the
+	// snapshot header.
+	binaryVersion = 1
+
+	// snapshotMagic starts every Files snapshot written by WriteSnapshot.
+	snapshotMagic = "OBJFSNAP"
+
+	// maxRecordSize is the largest FileObj record ReadSnapshot accepts. Even
+	// the Chunks of a multi-terabyte file fit well within it.
+	maxRecordSize = 64 << 20
+)
+
+const (
+	flagIsLink = 1 << iota
+	flagIsReadable
+	flagIsExists
+	flagHasSets
+)
+
+const (
+	setSize = 1 << iota
+	setModes
+	setChecksumMD5
+	setChecksumSHA256
+	setLinkTarget
+	setLinkTargetFinal
+	setXAttrs
+	setDimensions
+	setDocProps
+	setCompression
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler (and therefore gob encoding).
+// All exported fields, the Sets, the Tags, the Meta, the XAttrs, the HMAC, the ImageHash, and the modification time
+// are encoded. The Err
+// field is stored as its message. The fs.FileInfo and scan options are not stored,
+// so an unmarshaled FileObj uses default options when updated.
+func (fo *FileObj) MarshalBinary() ([]byte, error) {
+
+	mu := fo.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	w := &binWriter{}
+	w.uvarint(binaryVersion)
+	w.fileObj(fo)
+
+	return w.buf, nil
+
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, decoding data written
+// by MarshalBinary. The FileObj keeps its lock and options; it must not be in
+// use by other goroutines (see FileObj).
+func (fo *FileObj) UnmarshalBinary(data []byte) error {
+
+	r := &binReader{data: data}
+	if version := r.uvarint(); r.err == nil && version != binaryVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidEncoding, version)
+	}
+
+	decoded := r.fileObj()
+	if r.err != nil {
+		return r.err
+	}
+
+	decoded.mu, decoded.opts = fo.mutex(), fo.options()
+	*fo = *decoded
+
+	return nil
+
+}
+
+// WriteSnapshot writes the Files to w in a compact binary format: a header,
+// followed by one length-prefixed record per FileObj. Root directories are
+// written once and referenced by index afterwards, which keeps snapshots of
+// large trees small. The ScanRoot of Files made with WithRelativePaths is
+// written once, in the header. nil entries are skipped. Use ReadSnapshot to
+// read it back.
+func (files Files) WriteSnapshot(w io.Writer) error {
+
+	bw := bufio.NewWriter(w)
+	enc := &binWriter{roots: make(map[string]uint64)}
+
+	if _, err := bw.WriteString(snapshotMagic); err != nil {
+		return err
+	}
+
+	root := files.ScanRoot()
+
+	var hdr [3 * binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(hdr[:], binaryVersion)
+	n += binary.PutUvarint(hdr[n:], uint64(files.count()))
+	n += binary.PutUvarint(hdr[n:], uint64(len(root)))
+	if _, err := bw.Write(hdr[:n]); err != nil {
+		return err
+	}
+	if _, err := bw.WriteString(root); err != nil {
+		return err
+	}
+
+	for _, fo := range files {
+
+		if fo == nil {
+			continue
+		}
+
+		enc.buf = enc.buf[:0]
+		enc.fileObj(fo)
+
+		n = binary.PutUvarint(hdr[:], uint64(len(enc.buf)))
+		if _, err := bw.Write(hdr[:n]); err != nil {
+			return err
+		}
+		if _, err := bw.Write(enc.buf); err != nil {
+			return err
+		}
+
+	}
+
+	return bw.Flush()
+
+}
+
+// ReadSnapshot reads Files written by Files.WriteSnapshot. The Files of a
+// snapshot made with WithRelativePaths have the recorded ScanRoot; use
+// Files.SetScanRoot to resolve them against another one.
+func ReadSnapshot(r io.Reader) (Files, error) {
+
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != snapshotMagic {
+		return nil, fmt.Errorf("%w: missing snapshot header", ErrInvalidEncoding)
+	}
+
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	if version != binaryVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidEncoding, version)
+	}
+
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	buf := make([]byte, min(size, 1<<16))
+	if uint64(len(buf)) != size {
+		return nil, fmt.Errorf("%w: scan root too long", ErrInvalidEncoding)
+	}
+	if _, err := io.ReadFull(br, buf); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	root := string(buf)
+
+	files := make(Files, 0, min(count, 1<<20))
+	dec := &binReader{}
+
+	for i := uint64(0); i < count; i++ {
+
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: record %d: %v", ErrInvalidEncoding, i, err)
+		}
+		if size > maxRecordSize {
+			return nil, fmt.Errorf("%w: record %d: size %d exceeds %d", ErrInvalidEncoding, i, size, maxRecordSize)
+		}
+
+		dec.data, err = readRecord(br, dec.data, int(size))
+		dec.pos = 0
+		if err != nil {
+			return nil, fmt.Errorf("%w: record %d: %v", ErrInvalidEncoding, i, err)
+		}
+
+		fo := dec.fileObj()
+		if dec.err != nil {
+			return nil, dec.err
+		}
+		files = append(files, fo)
+
+	}
+
+	if root != EMPTY {
+		files.SetScanRoot(root)
+	}
+
+	return files, nil
+
+}
+
+// readRecord reads a record of size bytes from r into buf, which is grown as
+// the data arrives, so a corrupt size in a truncated snapshot does not
+// allocate more than the snapshot holds.
+func readRecord(r io.Reader, buf []byte, size int) ([]byte, error) {
+
+	buf = buf[:0]
+	for len(buf) < size {
+		if len(buf) == cap(buf) {
+			buf = append(buf, 0)[:len(buf)]
+		}
+		n, err := io.ReadFull(r, buf[len(buf):min(cap(buf), size)])
+		buf = buf[:len(buf)+n]
+		if err != nil {
+			return buf, err
+		}
+	}
+
+	return buf, nil
+
+}
+
+// count returns the number of non-nil entries.
+func (files Files) count() int {
+
+	n := 0
+	for _, fo := range files {
+		if fo != nil {
+			n++
+		}
+	}
+
+	return n
+
+}
+
+// binWriter appends encoded values to buf. When roots is not nil, Root
+// directories are interned: the first occurrence is written inline and later
+// occurrences are written as an index.
+type binWriter struct {
+	buf   []byte
+	roots map[string]uint64
+}
+
+func (w *binWriter) uvarint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *binWriter) varint(v int64) {
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+func (w *binWriter) bytes(p []byte) {
+	w.uvarint(uint64(len(p)))
+	w.buf = append(w.buf, p...)
+}
+
+func (w *binWriter) str(s string) {
+	w.uvarint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+// time writes 0 for the zero time, otherwise 1 followed by the Unix seconds
+// and nanoseconds.
+func (w *binWriter) time(t time.Time) {
+
+	if t.IsZero() {
+		w.uvarint(0)
+		return
+	}
+
+	w.uvarint(1)
+	w.varint(t.Unix())
+	w.uvarint(uint64(t.Nanosecond()))
+
+}
+
+// root writes 0 followed by the string for a new (or non-interned) root,
+// otherwise the root's index plus one.
+func (w *binWriter) root(s string) {
+
+	if w.roots != nil {
+		if idx, ok := w.roots[s]; ok {
+			w.uvarint(idx + 1)
+			return
+		}
+		w.roots[s] = uint64(len(w.roots))
+	}
+
+	w.uvarint(0)
+	w.str(s)
+
+}
+
+// mode writes the EntKind of the EntMode, or 0 followed by the string for
+// an unknown EntMode.
+func (w *binWriter) mode(m EntMode) {
+
+	k := m.Kind()
+	w.uvarint(uint64(k))
+	if k == 0 {
+		w.str(m.String())
+	}
+
+}
+
+// fileObj writes a single FileObj record.
+func (w *binWriter) fileObj(fo *FileObj) {
+
+	var flags, sets uint64
+	if fo.IsLink {
+		flags |= flagIsLink
+	}
+	if fo.IsReadable {
+		flags |= flagIsReadable
+	}
+	if fo.IsExists {
+		flags |= flagIsExists
+	}
+	if fo.Set != nil {
+		flags |= flagHasSets
+		sets = encodeSets(*fo.Set)
+	}
+
+	w.uvarint(flags)
+	w.uvarint(sets)
+	w.root(fo.Root)
+	w.str(fo.Filename)
+	w.varint(fo.SizeBytes)
+	w.mode(fo.Mode)
+	w.time(fo.modTime)
+	w.time(fo.UpdatedAt)
+	w.bytes(fo.MD5)
+	w.bytes(fo.SHA256)
+	w.str(fo.ETag)
+	w.str(fo.ContentType)
+	w.str(fo.Target)
+	w.str(fo.TargetFinal)
+	w.str(fo.LinkPath)
+
+	if fo.Err != nil {
+		w.str(fo.Err.Error())
+	} else {
+		w.str(EMPTY)
+	}
+
+	w.uvarint(uint64(fo.Perm))
+	w.varint(int64(fo.UID))
+	w.varint(int64(fo.GID))
+
+	keys := make([]string, 0, len(fo.Tags))
+	for k := range fo.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w.uvarint(uint64(len(keys)))
+	for _, k := range keys {
+		w.str(k)
+		w.str(fo.Tags[k])
+	}
+
+	keys = keys[:0]
+	for k := range fo.XAttrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w.uvarint(uint64(len(keys)))
+	for _, k := range keys {
+		w.str(k)
+		w.bytes(fo.XAttrs[k])
+	}
+
+	w.bytes(fo.HMAC)
+	w.bytes(fo.ImageHash)
+	w.uvarint(uint64(fo.Width))
+	w.uvarint(uint64(fo.Height))
+
+	keys = keys[:0]
+	for k := range fo.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w.uvarint(uint64(len(keys)))
+	for _, k := range keys {
+		w.str(k)
+		w.str(fo.Meta[k])
+	}
+
+	w.str(string(fo.Compression))
+	w.bytes(fo.DecompressedSHA256)
+	w.str(fo.Container)
+
+	w.uvarint(uint64(len(fo.Chunks)))
+	for _, c := range fo.Chunks {
+		w.uvarint(uint64(c.Offset))
+		w.uvarint(uint64(c.Size))
+		w.bytes(c.SHA256)
+	}
+
+	w.str(fo.ReparseTarget)
+
+	w.uvarint(uint64(len(fo.Streams)))
+	for _, st := range fo.Streams {
+		w.str(st.Name)
+		w.uvarint(uint64(st.Size))
+		w.bytes(st.SHA256)
+	}
+
+	w.varint(fo.AllocatedBytes)
+
+}
+
+// binReader decodes values from data. The first decoding error is kept in
+// err, after which all reads return zero values.
+type binReader struct {
+	data  []byte
+	pos   int
+	roots []string
+	err   error
+}
+
+// fail records a decoding error.
+func (r *binReader) fail(what string) {
+
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: truncated or corrupt %s", ErrInvalidEncoding, what)
+	}
+
+}
+
+func (r *binReader) uvarint() uint64 {
+
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail("uvarint")
+		return 0
+	}
+	r.pos += n
+
+	return v
+
+}
+
+func (r *binReader) varint() int64 {
+
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail("varint")
+		return 0
+	}
+	r.pos += n
+
+	return v
+
+}
+
+// raw returns the next length-prefixed byte slice, without copying.
+func (r *binReader) raw() []byte {
+
+	n := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail("length")
+		return nil
+	}
+
+	p := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+
+	return p
+
+}
+
+// bytes returns a copy of the next byte slice, or nil if it is empty.
+func (r *binReader) bytes() []byte {
+
+	p := r.raw()
+	if len(p) == 0 {
+		return nil
+	}
+
+	return append([]byte(nil), p...)
+
+}
+
+func (r *binReader) str() string {
+	return string(r.raw())
+}
+
+func (r *binReader) time() time.Time {
+
+	if r.uvarint() == 0 {
+		return time.Time{}
+	}
+
+	sec := r.varint()
+	nsec := r.uvarint()
+
+	return time.Unix(sec, int64(nsec))
+
+}
+
+func (r *binReader) root() string {
+
+	idx := r.uvarint()
+	if idx > 0 {
+		if idx > uint64(len(r.roots)) {
+			r.fail("root index")
+			return EMPTY
+		}
+		return r.roots[idx-1]
+	}
+
+	s := r.str()
+	r.roots = append(r.roots, s)
+
+	return s
+
+}
+
+func (r *binReader) mode() EntMode {
+
+	k := EntKind(r.uvarint())
+	if k == 0 {
+		return EntMode(r.str())
+	}
+
+	for m, mk := range entKinds {
+		if mk == k {
+			return m
+		}
+	}
+
+	r.fail("mode")
+	return EntModeErrored
+
+}
+
+// fileObj reads a single FileObj record.
+func (r *binReader) fileObj() *FileObj {
+
+	flags := r.uvarint()
+	sets := r.uvarint()
+
+	fo := &FileObj{
+		Root:        r.root(),
+		Filename:    r.str(),
+		SizeBytes:   r.varint(),
+		Mode:        r.mode(),
+		modTime:     r.time(),
+		UpdatedAt:   r.time(),
+		MD5:         r.bytes(),
+		SHA256:      r.bytes(),
+		ETag:        r.str(),
+		ContentType: r.str(),
+		Target:      r.str(),
+		TargetFinal: r.str(),
+		LinkPath:    r.str(),
+		IsLink:      flags&flagIsLink != 0,
+		IsReadable:  flags&flagIsReadable != 0,
+		IsExists:    flags&flagIsExists != 0,
+		opts:        newOptions(),
+		mu:          &sync.RWMutex{},
+	}
+
+	if msg := r.str(); msg != EMPTY {
+		fo.Err = errors.New(msg)
+	}
+
+	fo.Perm = fs.FileMode(r.uvarint())
+	fo.UID = int(r.varint())
+	fo.GID = int(r.varint())
+
+	n := r.uvarint()
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail("tags")
+		return fo
+	}
+	if n > 0 {
+		fo.Tags = make(map[string]string, n)
+	}
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		k := r.str()
+		fo.Tags[k] = r.str()
+	}
+
+	n = r.uvarint()
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail("xattrs")
+		return fo
+	}
+	if n > 0 {
+		fo.XAttrs = make(map[string][]byte, n)
+	}
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		k := r.str()
+		fo.XAttrs[k] = append([]byte{}, r.raw()...)
+	}
+
+	fo.HMAC = r.bytes()
+	fo.ImageHash = r.bytes()
+	fo.Width = int(r.uvarint())
+	fo.Height = int(r.uvarint())
+
+	n = r.uvarint()
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail("meta")
+		return fo
+	}
+	if n > 0 {
+		fo.Meta = make(map[string]string, n)
+	}
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		k := r.str()
+		fo.Meta[k] = r.str()
+	}
+
+	fo.Compression = Compression(r.str())
+	fo.DecompressedSHA256 = r.bytes()
+	fo.Container = r.str()
+
+	n = r.uvarint()
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail("chunks")
+		return fo
+	}
+	if n > 0 {
+		fo.Chunks = make([]Chunk, n)
+	}
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		fo.Chunks[i] = Chunk{Offset: int64(r.uvarint()), Size: int64(r.uvarint()), SHA256: r.bytes()}
+	}
+
+	fo.ReparseTarget = r.str()
+
+	n = r.uvarint()
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail("streams")
+		return fo
+	}
+	if n > 0 {
+		fo.Streams = make([]Stream, n)
+	}
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		fo.Streams[i] = Stream{Name: r.str(), Size: int64(r.uvarint()), SHA256: r.bytes()}
+	}
+
+	fo.AllocatedBytes = r.varint()
+
+	if fo.MD5 != nil {
+		fo.ChecksumMD5 = fmt.Sprintf("%x", fo.MD5)
+	}
+	if fo.SHA256 != nil {
+		fo.ChecksumSHA256 = fmt.Sprintf("%x", fo.SHA256)
+	}
+	if fo.HMAC != nil {
+		fo.ChecksumHMAC = fmt.Sprintf("%x", fo.HMAC)
+	}
+	if fo.DecompressedSHA256 != nil {
+		fo.ChecksumDecompressed = fmt.Sprintf("%x", fo.DecompressedSHA256)
+	}
+	if flags&flagHasSets != 0 {
+		s := decodeSets(sets)
+		fo.Set = &s
+	}
+
+	return fo
+
+}
+
+// encodeSets packs a Sets into a bit field.
+func encodeSets(s Sets) uint64 {
+
+	var v uint64
+	if s.Size {
+		v |= setSize
+	}
+	if s.Modes {
+		v |= setModes
+	}
+	if s.ChecksumMD5 {
+		v |= setChecksumMD5
+	}
+	if s.ChecksumSHA256 {
+		v |= setChecksumSHA256
+	}
+	if s.LinkTarget {
+		v |= setLinkTarget
+	}
+	if s.LinkTargetFinal {
+		v |= setLinkTargetFinal
+	}
+	if s.XAttrs {
+		v |= setXAttrs
+	}
+	if s.Dimensions {
+		v |= setDimensions
+	}
+	if s.DocProps {
+		v |= setDocProps
+	}
+	if s.Compression {
+		v |= setCompression
+	}
+
+	return v
+
+}
+
+// decodeSets unpacks a bit field written by encodeSets.
+func decodeSets(v uint64) Sets {
+	return Sets{
+		Size:            v&setSize != 0,
+		Modes:           v&setModes != 0,
+		ChecksumMD5:     v&setChecksumMD5 != 0,
+		ChecksumSHA256:  v&setChecksumSHA256 != 0,
+		LinkTarget:      v&setLinkTarget != 0,
+		LinkTargetFinal: v&setLinkTargetFinal != 0,
+		XAttrs:          v&setXAttrs != 0,
+		Dimensions:      v&setDimensions != 0,
+		DocProps:        v&setDocProps != 0,
+		Compression:     v&setCompression != 0,
+	}
+}
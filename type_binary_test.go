@@ -0,0 +1,141 @@
+package objectify
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// snapshotFiles scans a small tree with checksums and tags, for encoding.
+func snapshotFiles(t testing.TB) Files {
+
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := Path(dir, SetsAll(), WithSortedPaths())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files[0].Tags = map[string]string{"owner": "ops"}
+
+	return files
+
+}
+
+// snapshotHeader returns the header of a snapshot of count records.
+func snapshotHeader(count uint64) []byte {
+
+	data := []byte(snapshotMagic)
+	data = binary.AppendUvarint(data, binaryVersion)
+	data = binary.AppendUvarint(data, count)
+	data = binary.AppendUvarint(data, 0)
+
+	return data
+
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+
+	files := snapshotFiles(t)
+
+	var buf bytes.Buffer
+	if err := files.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(files) {
+		t.Fatalf("got %d files, want %d", len(decoded), len(files))
+	}
+	for i, fo := range decoded {
+		want := files[i]
+		if fo.FullPath() != want.FullPath() || fo.SizeBytes != want.SizeBytes ||
+			!bytes.Equal(fo.SHA256, want.SHA256) || !bytes.Equal(fo.MD5, want.MD5) {
+			t.Errorf("files[%d] = %s (%d bytes, %x), want %s (%d bytes, %x)",
+				i, fo.FullPath(), fo.SizeBytes, fo.SHA256, want.FullPath(), want.SizeBytes, want.SHA256)
+		}
+	}
+	if decoded[0].Tags["owner"] != "ops" {
+		t.Errorf("Tags = %v, want owner=ops", decoded[0].Tags)
+	}
+
+}
+
+func TestReadSnapshotTruncated(t *testing.T) {
+
+	var buf bytes.Buffer
+	if err := snapshotFiles(t).WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	for n := 0; n < len(data); n++ {
+		if _, err := ReadSnapshot(bytes.NewReader(data[:n])); !errors.Is(err, ErrInvalidEncoding) {
+			t.Fatalf("ReadSnapshot(%d of %d bytes) error = %v, want %v", n, len(data), err, ErrInvalidEncoding)
+		}
+	}
+
+}
+
+func TestReadSnapshotCorrupt(t *testing.T) {
+
+	tests := map[string][]byte{
+		"bad magic":       []byte("NOTASNAP"),
+		"bad version":     binary.AppendUvarint([]byte(snapshotMagic), binaryVersion+1),
+		"huge record":     binary.AppendUvarint(snapshotHeader(1), 1<<62),
+		"oversize record": binary.AppendUvarint(snapshotHeader(1), maxRecordSize+1),
+		"short record":    append(binary.AppendUvarint(snapshotHeader(1), 1<<20), 1, 2, 3),
+		"garbage record":  append(binary.AppendUvarint(snapshotHeader(1), 4), 0xff, 0xff, 0xff, 0xff),
+		"missing records": snapshotHeader(1 << 40),
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ReadSnapshot(bytes.NewReader(data)); !errors.Is(err, ErrInvalidEncoding) {
+				t.Fatalf("ReadSnapshot() error = %v, want %v", err, ErrInvalidEncoding)
+			}
+		})
+	}
+
+}
+
+func TestUnmarshalBinaryTruncated(t *testing.T) {
+
+	data, err := snapshotFiles(t)[0].MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n := 0; n < len(data); n++ {
+		var fo FileObj
+		if err := fo.UnmarshalBinary(data[:n]); !errors.Is(err, ErrInvalidEncoding) {
+			t.Fatalf("UnmarshalBinary(%d of %d bytes) error = %v, want %v", n, len(data), err, ErrInvalidEncoding)
+		}
+	}
+
+}
+
+func FuzzReadSnapshot(f *testing.F) {
+
+	var buf bytes.Buffer
+	if err := snapshotFiles(f).WriteSnapshot(&buf); err != nil {
+		f.Fatal(err)
+	}
+	f.Add(buf.Bytes())
+	f.Add(binary.AppendUvarint(snapshotHeader(1), 1<<62))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = ReadSnapshot(bytes.NewReader(data))
+	})
+
+}
@@ -0,0 +1,194 @@
+package objectify
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+const (
+	// bloomMagic starts every BloomFilter encoded by MarshalBinary.
+	bloomMagic = "OBJFBLOM"
+
+	// bloomVersion is the version of the BloomFilter encoding.
+	bloomVersion = 1
+)
+
+// BloomFilter is a compact, probabilistic set of SHA256 checksums. Test never
+// reports false for a checksum which was added, and reports true for one which
+// was not with about the false positive rate the filter was sized for. It can
+// be encoded with MarshalBinary and sent to a peer, which can then cheaply
+// test "do you already have this content?" before transferring a file.
+type BloomFilter struct {
+	bits []uint64
+	m    uint64
+	k    uint64
+	n    uint64
+}
+
+// NewBloomFilter returns an empty BloomFilter sized for n checksums with a false
+// positive rate of about fpRate. An fpRate outside (0, 1) is treated as 0.01,
+// and an n less than 1 as 1.
+func NewBloomFilter(n int, fpRate float64) *BloomFilter {
+
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	n = max(n, 1)
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	k = max(k, 1)
+
+	return &BloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+
+}
+
+// ChecksumBloom returns a BloomFilter holding the SHA256 checksum of each entry
+// which has one, sized for a false positive rate of about fpRate (see
+// NewBloomFilter).
+func (files Files) ChecksumBloom(fpRate float64) *BloomFilter {
+
+	n := 0
+	for _, fo := range files {
+		if fo != nil && fo.SHA256 != nil {
+			n++
+		}
+	}
+
+	b := NewBloomFilter(n, fpRate)
+	for _, fo := range files {
+		if fo != nil && fo.SHA256 != nil {
+			b.Add(fo.SHA256)
+		}
+	}
+
+	return b
+
+}
+
+// Add adds the checksum sum to the filter. It has no effect on a zero
+// BloomFilter; use NewBloomFilter.
+func (b *BloomFilter) Add(sum []byte) {
+
+	if b.m == 0 {
+		return
+	}
+
+	h1, h2 := bloomHashes(sum)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+	b.n++
+
+}
+
+// Test returns true if the checksum sum may have been added to the filter, and
+// false if it was certainly not.
+func (b *BloomFilter) Test(sum []byte) bool {
+
+	if b.m == 0 {
+		return false
+	}
+
+	h1, h2 := bloomHashes(sum)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+// TestFile returns true if the SHA256 checksum of fo may be in the filter. It
+// returns false if fo has no SHA256 checksum.
+func (b *BloomFilter) TestFile(fo *FileObj) bool {
+	return fo != nil && fo.SHA256 != nil && b.Test(fo.SHA256)
+}
+
+// Len returns the number of checksums added to the filter.
+func (b *BloomFilter) Len() int {
+	return int(b.n)
+}
+
+// bloomHashes derives the two hashes used for double hashing from a checksum.
+// A SHA256 checksum is already uniformly distributed, so its first 16 bytes
+// are used directly; shorter input is padded with zeroes. h2 is odd, so every
+// bit of the filter can be reached.
+func bloomHashes(sum []byte) (h1, h2 uint64) {
+
+	var p [16]byte
+	copy(p[:], sum)
+
+	h1 = binary.LittleEndian.Uint64(p[:8])
+	h2 = binary.LittleEndian.Uint64(p[8:]) | 1
+
+	return h1, h2
+
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The encoding is a magic
+// string, the version, the number of bits, hashes, and checksums added, and
+// the bits.
+func (b *BloomFilter) MarshalBinary() ([]byte, error) {
+
+	w := &binWriter{buf: []byte(bloomMagic)}
+	w.uvarint(bloomVersion)
+	w.uvarint(b.m)
+	w.uvarint(b.k)
+	w.uvarint(b.n)
+	for _, word := range b.bits {
+		w.buf = binary.LittleEndian.AppendUint64(w.buf, word)
+	}
+
+	return w.buf, nil
+
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, decoding data written
+// by MarshalBinary.
+func (b *BloomFilter) UnmarshalBinary(data []byte) error {
+
+	if len(data) < len(bloomMagic) || string(data[:len(bloomMagic)]) != bloomMagic {
+		return fmt.Errorf("%w: not a bloom filter", ErrInvalidEncoding)
+	}
+
+	r := &binReader{data: data, pos: len(bloomMagic)}
+	if v := r.uvarint(); r.err == nil && v != bloomVersion {
+		return fmt.Errorf("%w: unsupported bloom filter version %d", ErrInvalidEncoding, v)
+	}
+	m, k, n := r.uvarint(), r.uvarint(), r.uvarint()
+	if r.err != nil {
+		return r.err
+	}
+
+	// Check m against the bytes left before rounding it up to words, which
+	// would overflow for m close to 2^64.
+	left := uint64(len(data) - r.pos)
+	if m == 0 || m > left*8 {
+		return fmt.Errorf("%w: truncated or corrupt bloom filter", ErrInvalidEncoding)
+	}
+	words := (m + 63) / 64
+	if k == 0 || k > 64 || left != words*8 {
+		return fmt.Errorf("%w: truncated or corrupt bloom filter", ErrInvalidEncoding)
+	}
+
+	bits := make([]uint64, words)
+	for i := range bits {
+		bits[i] = binary.LittleEndian.Uint64(data[r.pos+8*i:])
+	}
+
+	*b = BloomFilter{bits: bits, m: m, k: k, n: n}
+
+	return nil
+
+}
@@ -0,0 +1,101 @@
+package objectify
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// bloomHeader returns the encoding of a bloom filter header with the given
+// bit count, hash count, and number of added checksums.
+func bloomHeader(m, k, n uint64) []byte {
+
+	data := []byte(bloomMagic)
+	data = binary.AppendUvarint(data, bloomVersion)
+	data = binary.AppendUvarint(data, m)
+	data = binary.AppendUvarint(data, k)
+	data = binary.AppendUvarint(data, n)
+
+	return data
+
+}
+
+func TestBloomFilterUnmarshalCorrupt(t *testing.T) {
+
+	tests := map[string][]byte{
+		"empty":            nil,
+		"magic only":       []byte(bloomMagic),
+		"no bits":          bloomHeader(1<<64-1, 7, 0),
+		"m overflows":      append(bloomHeader(1<<64-1, 7, 0), make([]byte, 8)...),
+		"m exceeds bits":   append(bloomHeader(65, 7, 0), make([]byte, 8)...),
+		"too many bits":    append(bloomHeader(64, 7, 0), make([]byte, 16)...),
+		"zero m":           append(bloomHeader(0, 7, 0), make([]byte, 8)...),
+		"zero k":           append(bloomHeader(64, 0, 0), make([]byte, 8)...),
+		"k too large":      append(bloomHeader(64, 65, 0), make([]byte, 8)...),
+		"truncated header": bloomHeader(64, 7, 0)[:len(bloomMagic)+2],
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b BloomFilter
+			if err := b.UnmarshalBinary(data); !errors.Is(err, ErrInvalidEncoding) {
+				t.Fatalf("UnmarshalBinary() error = %v, want %v", err, ErrInvalidEncoding)
+			}
+		})
+	}
+
+}
+
+func TestBloomFilterRoundTrip(t *testing.T) {
+
+	b := NewBloomFilter(100, 0.01)
+	sum := sha256.Sum256([]byte("objectify"))
+	b.Add(sum[:])
+
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded BloomFilter
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Test(sum[:]) {
+		t.Fatal("decoded filter does not contain the added checksum")
+	}
+
+}
+
+func FuzzBloomFilterUnmarshal(f *testing.F) {
+
+	b := NewBloomFilter(10, 0.01)
+	sum := sha256.Sum256([]byte("objectify"))
+	b.Add(sum[:])
+	data, err := b.MarshalBinary()
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add(data)
+	f.Add(bloomHeader(1<<64-1, 7, 0))
+	f.Add(append(bloomHeader(65, 1, 0), make([]byte, 8)...))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+
+		var b BloomFilter
+		if err := b.UnmarshalBinary(data); err != nil {
+			return
+		}
+
+		// A filter which decodes must be usable.
+		b.Test(sum[:])
+		b.Add(sum[:])
+		if !b.Test(sum[:]) {
+			t.Fatal("decoded filter does not contain an added checksum")
+		}
+
+	})
+
+}
@@ -0,0 +1,153 @@
+package objectify
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// CaseSensitivity is whether a filesystem tells apart paths which differ only
+// by letter case, as probed by ProbeCase.
+type CaseSensitivity int
+
+const (
+	// CaseUnknown is reported when there was no name to probe.
+	CaseUnknown CaseSensitivity = iota
+
+	// CaseSensitive filesystems, the default on Linux, hold "Report.txt" and
+	// "report.txt" as two entries.
+	CaseSensitive
+
+	// CaseInsensitive filesystems, the defaults on macOS and Windows, find
+	// "Report.txt" when "report.txt" is looked up.
+	CaseInsensitive
+)
+
+// String returns the name of the CaseSensitivity.
+func (c CaseSensitivity) String() string {
+
+	switch c {
+	case CaseSensitive:
+		return "sensitive"
+	case CaseInsensitive:
+		return "insensitive"
+	}
+
+	return "unknown"
+
+}
+
+// Key returns the form of path to compare paths with, e.g. as a map key when
+// diffing or merging scans: folded as by CaseCollisions on a CaseInsensitive
+// filesystem, and unchanged otherwise.
+func (c CaseSensitivity) Key(path string) string {
+
+	if c == CaseInsensitive {
+		return foldPath(path)
+	}
+
+	return path
+
+}
+
+// EqualPaths returns true if the paths a and b name the same entry on a
+// filesystem with the CaseSensitivity c, i.e. if their Keys are equal.
+func (c CaseSensitivity) EqualPaths(a, b string) bool {
+	return c.Key(a) == c.Key(b)
+}
+
+// ProbeCase probes whether the filesystem holding dir is case-sensitive,
+// without writing to it. The first entry of dir whose name has a letter (or
+// else dir itself, in its parent) is looked up by its name with the case of
+// its letters swapped: if the same file is found, the filesystem is
+// CaseInsensitive, and otherwise CaseSensitive. CaseUnknown is returned if
+// there is no such name. Since case sensitivity can be set per directory
+// (e.g. with ext4 casefolding or on NTFS), the directory of interest should be
+// probed. An error is returned if dir cannot be read.
+func ProbeCase(dir string) (CaseSensitivity, error) {
+
+	return probeCase(&options{}, dir)
+
+}
+
+// ProbeCaseFS works like ProbeCase, for the directory dir of fsys. Unless fsys
+// returns os.FileInfos which os.SameFile can compare, a name found with its
+// case swapped is taken to be the same file if its size, mode, and
+// modification time are equal.
+func ProbeCaseFS(fsys fs.FS, dir string) (CaseSensitivity, error) {
+
+	return probeCase(&options{fsys: fsys}, dir)
+
+}
+
+// probeCase probes the directory dir, in the fs.FS of o or on the OS
+// filesystem through its FileSystem.
+func probeCase(o *options, dir string) (CaseSensitivity, error) {
+
+	w := newPathWorker(dir, Sets{}, o)
+
+	c := CaseUnknown
+	err := w.readDirBatches(dir, func(dirents []fs.DirEntry) bool {
+		for _, ent := range dirents {
+			if c = probeName(o, w.join(dir, ent.Name()), w.join(dir, swapCase(ent.Name()))); c != CaseUnknown {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil || c != CaseUnknown || o.fsys != nil {
+		return c, err
+	}
+
+	abs := pathAbsSafe(dir)
+	parent, base := filepath.Split(abs)
+
+	return probeName(o, abs, filepath.Join(parent, swapCase(base))), nil
+
+}
+
+// probeName looks up p and swapped, the path p with the case of its base name
+// swapped, and returns whether they name the same file, or CaseUnknown if the
+// base name has no letter or p cannot be stat'ed.
+func probeName(o *options, p, swapped string) CaseSensitivity {
+
+	if p == swapped {
+		return CaseUnknown
+	}
+
+	info, ok := o.statPath(p)
+	if !ok {
+		return CaseUnknown
+	}
+
+	other, ok := o.statPath(swapped)
+	switch {
+	case !ok:
+		return CaseSensitive
+	case os.SameFile(info, other):
+		return CaseInsensitive
+	case !o.onOS() && info.Size() == other.Size() && info.Mode() == other.Mode() && info.ModTime().Equal(other.ModTime()):
+		return CaseInsensitive
+	}
+
+	return CaseSensitive
+
+}
+
+// swapCase returns s with its upper case letters made lower case and its
+// lower case letters made upper case.
+func swapCase(s string) string {
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsUpper(r):
+			return unicode.ToLower(r)
+		case unicode.IsLower(r):
+			return unicode.ToUpper(r)
+		}
+		return r
+	}, s)
+
+}
@@ -0,0 +1,400 @@
+package objectify
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCheckpointInterval is the time between checkpoints used by
+// WithCheckpoint for intervals less than 1.
+const DefaultCheckpointInterval = time.Minute
+
+const (
+	// journalMagic starts every journal written by a checkpoint.
+	journalMagic = "OBJFJRNL"
+
+	// journalVersion is the version of the journal header.
+	journalVersion = 1
+)
+
+// checkpoint appends the progress of a scan to its journal, and holds the
+// progress read back from a journal by Resume.
+type checkpoint struct {
+	journal string
+	every   time.Duration
+	last    time.Time
+
+	// root and sets are those of the scan, written to the journal so that
+	// Resume can restart it.
+	root string
+	sets Sets
+
+	// resumed are the FileObjs read from the journal, and done their paths,
+	// which are not objectified again.
+	resumed Files
+	done    map[string]bool
+
+	// pending are the FileObjs objectified since the last checkpoint, which
+	// are appended to the journal at the next one.
+	pending Files
+
+	// f is the journal, open for appending once the first checkpoint is
+	// written, and size the length of its header and complete records.
+	f    *os.File
+	size int64
+
+	mu  sync.Mutex
+	err error
+}
+
+// newCheckpoint returns the checkpoint of a scan by w, or nil if the worker
+// has no journal.
+func newCheckpoint(w *worker) *checkpoint {
+
+	if w.opts.journal == EMPTY || w.singleFileMode {
+		return nil
+	}
+
+	// The root is made absolute, so a resumed scan walks the same paths
+	// whatever its working directory.
+	root := w.RootPath
+	if w.opts.fsys == nil {
+		root = pathAbsSafe(root)
+	}
+
+	return &checkpoint{
+		journal: w.opts.journal,
+		every:   w.opts.checkpointEvery,
+		last:    time.Now(),
+		root:    root,
+		sets:    w.setter,
+	}
+
+}
+
+// skip returns true if the FileObj of the path p was read from the journal.
+func (c *checkpoint) skip(p string) bool {
+	return c != nil && c.done[p]
+}
+
+// progress records fo, the last FileObj objectified (or nil), and appends
+// the FileObjs recorded since the last checkpoint to the journal if the
+// checkpoint interval has passed.
+func (c *checkpoint) progress(fo *FileObj) error {
+
+	if c == nil {
+		return nil
+	}
+	if fo != nil {
+		c.pending = append(c.pending, fo)
+	}
+	if time.Since(c.last) < c.every {
+		return nil
+	}
+	c.last = time.Now()
+
+	err := c.write()
+	if err != nil {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+	}
+
+	return err
+
+}
+
+// failed returns the error of the last failed journal write, if any.
+func (c *checkpoint) failed() error {
+
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+
+}
+
+// write appends a record for each pending FileObj to the journal, opening it
+// first if needed, and syncs it. A checkpoint therefore costs the entries
+// objectified since the last one, rather than the whole scan so far.
+func (c *checkpoint) write() error {
+
+	if c.f == nil {
+		if err := c.open(); err != nil {
+			return fmt.Errorf("writing checkpoint %s: %w", c.journal, err)
+		}
+	}
+	if len(c.pending) == 0 {
+		return nil
+	}
+
+	var buf []byte
+	rec := &binWriter{}
+	for _, fo := range c.pending {
+		rec.buf = rec.buf[:0]
+		rec.fileObj(fo)
+		buf = binary.AppendUvarint(buf, uint64(len(rec.buf)))
+		buf = append(buf, rec.buf...)
+	}
+	if err := c.append(buf); err != nil {
+		return fmt.Errorf("writing checkpoint %s: %w", c.journal, err)
+	}
+	c.pending = c.pending[:0]
+
+	return nil
+
+}
+
+// open opens the journal for appending. The journal of a new scan is created
+// with its header: the scan's root and Sets. That of a resumed scan is cut
+// back to its last complete record, dropping one torn by a crash.
+func (c *checkpoint) open() error {
+
+	if c.size > 0 {
+		f, err := os.OpenFile(c.journal, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return err
+		}
+		if err := f.Truncate(c.size); err != nil {
+			_ = f.Close()
+			return err
+		}
+		c.f = f
+		return nil
+	}
+
+	f, err := os.OpenFile(c.journal, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0o666)
+	if err != nil {
+		return err
+	}
+	c.f = f
+
+	w := &binWriter{buf: []byte(journalMagic)}
+	w.uvarint(journalVersion)
+	w.uvarint(binaryVersion)
+	w.str(c.root)
+	w.uvarint(encodeSets(c.sets))
+
+	return c.append(w.buf)
+
+}
+
+// append writes b to the end of the journal and syncs it. If either fails, the
+// journal is cut back to its previous size, so that the next checkpoint does
+// not append after a partial record.
+func (c *checkpoint) append(b []byte) error {
+
+	_, err := c.f.Write(b)
+	if err == nil {
+		err = c.f.Sync()
+	}
+	if err != nil {
+		_ = c.f.Truncate(c.size)
+		return err
+	}
+	c.size += int64(len(b))
+
+	return nil
+
+}
+
+// close closes the journal, if it is open, leaving it for Resume.
+func (c *checkpoint) close() {
+
+	if c == nil || c.f == nil {
+		return
+	}
+
+	_ = c.f.Close()
+	c.f = nil
+
+}
+
+// finish removes the journal once the scan is complete.
+func (c *checkpoint) finish() error {
+
+	if c == nil {
+		return nil
+	}
+
+	c.close()
+	if err := os.Remove(c.journal); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+
+}
+
+// Resume continues a scan made with WithCheckpoint from its journal, e.g.
+// after the process was restarted. The root and Sets of the scan are read from
+// the journal, and the FileObjs it holds are returned as they were recorded,
+// without being objectified again; the remaining entries are objectified as
+// by Path. opts must be the options of the original scan, as options are not
+// recorded (except that the journal keeps being checkpointed, every
+// DefaultCheckpointInterval unless WithCheckpoint is given). The journal is
+// removed once the scan is complete. An error wrapping ErrInvalidEncoding is
+// returned if the journal cannot be decoded.
+func Resume(journal string, opts ...Option) (Files, error) {
+
+	return resume(nil, journal, opts...)
+
+}
+
+// ResumeFS works like Resume, for a scan of fsys made with PathFS.
+func ResumeFS(fsys fs.FS, journal string, opts ...Option) (Files, error) {
+
+	return resume(fsys, journal, opts...)
+
+}
+
+// resume reads the journal and runs a path worker which skips the entries it
+// holds.
+func resume(fsys fs.FS, journal string, opts ...Option) (Files, error) {
+
+	f, err := os.Open(journal)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c, err := readJournal(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", journal, err)
+	}
+
+	o := newOptions(append([]Option{WithCheckpoint(journal, 0)}, opts...)...)
+	o.fsys = fsys
+
+	c.journal, c.every, c.last = o.journal, o.checkpointEvery, time.Now()
+	c.done = make(map[string]bool, len(c.resumed))
+	for _, fo := range c.resumed {
+		fo.opts = o
+		fo.info, _ = o.statPath(fo.ioPath())
+		c.done[fo.FullPath()] = true
+	}
+
+	w := newPathWorker(c.root, c.sets, o)
+	w.cp = c
+
+	return run(w)
+
+}
+
+// readJournal decodes a journal written by checkpoint.write. A final record
+// cut short, as by a crash while it was appended, is ignored; the size of the
+// checkpoint returned is the length of the journal up to it.
+func readJournal(r io.Reader) (*checkpoint, error) {
+
+	jr := &journalReader{r: bufio.NewReader(r)}
+
+	magic := make([]byte, len(journalMagic))
+	if _, err := io.ReadFull(jr, magic); err != nil || string(magic) != journalMagic {
+		return nil, fmt.Errorf("%w: missing journal header", ErrInvalidEncoding)
+	}
+
+	version, err := binary.ReadUvarint(jr)
+	if err == nil && version != journalVersion {
+		return nil, fmt.Errorf("%w: unsupported journal version %d", ErrInvalidEncoding, version)
+	}
+
+	c := &checkpoint{}
+	var recVersion, sets uint64
+	if err == nil {
+		recVersion, err = binary.ReadUvarint(jr)
+	}
+	if err == nil && recVersion != binaryVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidEncoding, recVersion)
+	}
+	if err == nil {
+		var n uint64
+		if n, err = binary.ReadUvarint(jr); err == nil && n > 1<<16 {
+			err = errors.New("root too long")
+		}
+		if err == nil {
+			b := make([]byte, n)
+			_, err = io.ReadFull(jr, b)
+			c.root = string(b)
+		}
+	}
+	if err == nil {
+		sets, err = binary.ReadUvarint(jr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	c.sets = decodeSets(sets)
+	c.size = jr.n
+
+	dec := &binReader{}
+	for i := 0; ; i++ {
+
+		size, err := binary.ReadUvarint(jr)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: record %d: %v", ErrInvalidEncoding, i, err)
+		}
+		if size > maxRecordSize {
+			return nil, fmt.Errorf("%w: record %d: size %d exceeds %d", ErrInvalidEncoding, i, size, maxRecordSize)
+		}
+
+		dec.data, err = readRecord(jr, dec.data, int(size))
+		dec.pos = 0
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: record %d: %v", ErrInvalidEncoding, i, err)
+		}
+
+		fo := dec.fileObj()
+		if dec.err != nil {
+			return nil, dec.err
+		}
+		c.resumed = append(c.resumed, fo)
+		c.size = jr.n
+
+	}
+
+	return c, nil
+
+}
+
+// journalReader counts the bytes read from a journal.
+type journalReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (j *journalReader) Read(p []byte) (int, error) {
+
+	n, err := j.r.Read(p)
+	j.n += int64(n)
+
+	return n, err
+
+}
+
+func (j *journalReader) ReadByte() (byte, error) {
+
+	b, err := j.r.ReadByte()
+	if err == nil {
+		j.n++
+	}
+
+	return b, err
+
+}
@@ -0,0 +1,91 @@
+package objectify
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpointAppends(t *testing.T) {
+
+	dir := dedupeTree(t, map[string]string{"a": "first", "b": "second", "c": "third"})
+	journal := filepath.Join(t.TempDir(), "journal")
+	s := Sets{Size: true, ChecksumSHA256: true}
+
+	c := &checkpoint{journal: journal, root: dir, sets: s}
+	var sizes []int64
+	var prefix []byte
+	for _, name := range []string{"a", "b"} {
+
+		fo, err := File(filepath.Join(dir, name), s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.progress(fo); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := os.ReadFile(journal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(b, prefix) {
+			t.Fatalf("checkpoint of %s rewrote the journal instead of appending to it", name)
+		}
+		prefix = b
+		sizes = append(sizes, int64(len(b)))
+
+	}
+	c.close()
+	if sizes[1] <= sizes[0] {
+		t.Fatalf("journal sizes %v, want the second checkpoint to append a record", sizes)
+	}
+
+	// A record torn by a crash is dropped, and the rest are resumed.
+	f, err := os.OpenFile(journal, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte{0x40, 1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	jf, err := os.Open(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := readJournal(jf)
+	_ = jf.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rc.resumed) != 2 || rc.size != sizes[1] || rc.root != dir || rc.sets != s {
+		t.Fatalf("read %d records, size %d (want 2 and %d), root %q, Sets %+v", len(rc.resumed), rc.size, sizes[1], rc.root, rc.sets)
+	}
+
+	// The resumed entries are not read again, so a change to one since the
+	// checkpoint is not seen.
+	if err := os.WriteFile(filepath.Join(dir, "a"), []byte("changed"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	files, err := Resume(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("Resume returned %d entries, want 3", len(files))
+	}
+	for _, fo := range files {
+		if fo.Filename == "a" && fo.SizeBytes != int64(len("first")) {
+			t.Errorf("resumed entry a was objectified again: size %d", fo.SizeBytes)
+		}
+	}
+	if _, err := os.Stat(journal); !os.IsNotExist(err) {
+		t.Errorf("journal left after the scan completed: %v", err)
+	}
+
+}
@@ -0,0 +1,236 @@
+package objectify
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// Chunk is a content-defined chunk of a file, as cut by FastCDC: the Offset
+// and Size of the chunk within the file and the SHA256 of its content.
+type Chunk struct {
+	Offset int64
+	Size   int64
+	SHA256 []byte
+}
+
+// ChunkParams are the minimum, average, and maximum chunk sizes used by
+// WithChunks. AvgSize is rounded to the nearest power of two.
+type ChunkParams struct {
+	MinSize int
+	AvgSize int
+	MaxSize int
+}
+
+// DefaultChunkParams returns the ChunkParams used for fields which are not set:
+// chunks of 16 KiB to 256 KiB, averaging 64 KiB.
+func DefaultChunkParams() ChunkParams {
+	return ChunkParams{
+		MinSize: 16 << 10,
+		AvgSize: 64 << 10,
+		MaxSize: 256 << 10,
+	}
+}
+
+// gearTable maps each byte to a random 64-bit value for the gear hash. It is
+// generated from a fixed seed, so chunk boundaries are the same on every
+// platform and in every version.
+var gearTable = func() (t [256]uint64) {
+
+	// splitmix64
+	x := uint64(0x6f626a6563746679)
+	for i := range t {
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		t[i] = z ^ (z >> 31)
+	}
+
+	return t
+
+}()
+
+// chunker cuts content into chunks with FastCDC, using normalized chunking: a
+// boundary is harder to find before AvgSize (maskS has one more bit than the
+// average) and easier after it (maskL has one less). The masks select the top
+// bits of the gear hash, which depend on the last 64 bytes.
+type chunker struct {
+	min, avg, max int
+	maskS, maskL  uint64
+}
+
+// newChunker returns a chunker for p, with the fields of p which are not set
+// taken from DefaultChunkParams.
+func newChunker(p ChunkParams) *chunker {
+
+	d := DefaultChunkParams()
+	if p.MinSize <= 0 {
+		p.MinSize = d.MinSize
+	}
+	if p.AvgSize <= 0 {
+		p.AvgSize = d.AvgSize
+	}
+	if p.MaxSize <= 0 {
+		p.MaxSize = d.MaxSize
+	}
+
+	n := bits.Len(uint(p.AvgSize)) - 1
+	if p.AvgSize-1<<n > 1<<(n+1)-p.AvgSize {
+		n++
+	}
+	n = min(max(n, 2), 62)
+
+	c := &chunker{
+		min:   min(p.MinSize, 1<<n),
+		avg:   1 << n,
+		max:   max(p.MaxSize, 1<<n),
+		maskS: topBits(n + 1),
+		maskL: topBits(n - 1),
+	}
+
+	return c
+
+}
+
+// topBits returns a mask of the n most significant bits.
+func topBits(n int) uint64 {
+	return ^uint64(0) << (64 - n)
+}
+
+// cut returns the length of the first chunk of data, which holds the rest of
+// the content if it is shorter than the maximum chunk size.
+func (c *chunker) cut(data []byte) int {
+
+	n := len(data)
+	if n <= c.min {
+		return n
+	}
+	n = min(n, c.max)
+	normal := min(n, c.avg)
+
+	var hash uint64
+	i := c.min
+	for ; i < normal; i++ {
+		hash = hash<<1 + gearTable[data[i]]
+		if hash&c.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		hash = hash<<1 + gearTable[data[i]]
+		if hash&c.maskL == 0 {
+			return i + 1
+		}
+	}
+
+	return n
+
+}
+
+// chunks reads r to the end and returns its chunks.
+func (c *chunker) chunks(r io.Reader) ([]Chunk, error) {
+
+	var (
+		chunks []Chunk
+		offset int64
+		eof    bool
+	)
+
+	buf := make([]byte, 0, 2*c.max)
+	for {
+
+		if !eof && len(buf) < c.max {
+			n, err := io.ReadFull(r, buf[len(buf):cap(buf)])
+			buf = buf[:len(buf)+n]
+			switch {
+			case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+				eof = true
+			case err != nil:
+				return nil, err
+			}
+		}
+		if len(buf) == 0 {
+			return chunks, nil
+		}
+
+		n := c.cut(buf)
+		sum := sha256.Sum256(buf[:n])
+		chunks = append(chunks, Chunk{Offset: offset, Size: int64(n), SHA256: sum[:]})
+		offset += int64(n)
+		buf = buf[:copy(buf, buf[n:])]
+
+	}
+
+}
+
+// addChunks adds the Chunks field to the pass when WithChunks is used and
+// the entry is a readable regular file. Otherwise, the field is cleared.
+func (fo *FileObj) addChunks(p *contentPass) {
+
+	fo.Chunks = nil
+
+	c := fo.options().chunker
+	if c == nil || !fo.IsExists || !fo.IsReadable || fo.info == nil || !fo.info.Mode().IsRegular() {
+		return
+	}
+
+	p.hash = true
+	p.read(func(r io.Reader) error {
+		chunks, err := c.chunks(r)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fo.FullPath(), err)
+		}
+		fo.Chunks = chunks
+		fo.options().stats.addHashed(fo.info.Size())
+		return nil
+	})
+
+}
+
+// ChunkSums returns the Chunks of the FileObj (see WithChunks), computing them on
+// the first call with WithLazy (see MD5Sum).
+func (fo *FileObj) ChunkSums() []Chunk {
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	fo.resolveChecksums()
+
+	return fo.Chunks
+
+}
+
+// UniqueChunks returns the chunks of the Files with distinct content, in the
+// order they are first found, and the total size of those chunks. Comparing
+// it to the total size of the Files shows how much a chunk-level dedup store
+// would save.
+func (files Files) UniqueChunks() ([]Chunk, int64) {
+
+	var (
+		unique []Chunk
+		size   int64
+	)
+
+	seen := make(map[string]bool)
+	for _, fo := range files {
+		if fo == nil {
+			continue
+		}
+		for _, c := range fo.Chunks {
+			key := string(c.SHA256)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			unique = append(unique, c)
+			size += c.Size
+		}
+	}
+
+	return unique, size
+
+}
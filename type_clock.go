@@ -0,0 +1,40 @@
+package objectify
+
+import (
+	"time"
+)
+
+// Clock tells the current time to a FileObj: when it is updated (UpdatedAt),
+// whether its WithTTL has expired, how old it is (Age, OlderThan, and
+// SecondsSinceUpdatedAt), and when its History and audit records were made.
+// WithClock replaces the system clock with one a test controls.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock of time.Now, used by default.
+type SystemClock struct{}
+
+// Now returns time.Now().
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// WithClock makes the FileObjs tell time with c instead of the system clock,
+// so time-dependent behavior, such as WithTTL refreshes and staleness, can be
+// tested deterministically. Durations measured by Scan and Verify, and the
+// intervals of checkpoints and Monitors, keep using the system clock.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// now returns the current time of the options' Clock.
+func (o *options) now() time.Time {
+
+	if o.clock == nil {
+		return time.Now()
+	}
+
+	return o.clock.Now()
+
+}
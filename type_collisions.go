@@ -0,0 +1,54 @@
+package objectify
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// CaseCollisions groups the entries whose full paths differ only by letter case
+// or Unicode normalization (e.g. "Report.txt" and "report.txt", or "é" written as
+// one code point and as "e" plus a combining accent). Such entries cannot
+// coexist on a case-insensitive or normalization-insensitive filesystem, such as
+// the defaults on macOS and Windows, so syncing them there loses data.
+// Repeated paths are ignored, and only groups with two or more distinct paths
+// are returned. Each group is sorted by FullPath, and the groups are sorted by
+// the path of their first entry. Directories are only compared through the paths
+// of the entries in them.
+func (files Files) CaseCollisions() []Files {
+
+	byKey := make(map[string]Files)
+	seen := make(map[string]bool)
+	for _, fo := range files {
+
+		if fo == nil || seen[fo.FullPath()] {
+			continue
+		}
+		seen[fo.FullPath()] = true
+
+		key := foldPath(fo.FullPath())
+		byKey[key] = append(byKey[key], fo)
+
+	}
+
+	var groups []Files
+	for _, g := range byKey {
+		if len(g) > 1 {
+			g.sortByPath()
+			groups = append(groups, g)
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0].FullPath() < groups[j][0].FullPath()
+	})
+
+	return groups
+
+}
+
+// foldPath returns the NFC normalized, lower case form of path.
+func foldPath(path string) string {
+	return strings.ToLower(norm.NFC.String(path))
+}
@@ -0,0 +1,172 @@
+package objectify
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+)
+
+// Compression identifies the compression format of a file's content, as
+// detected by its magic bytes.
+type Compression string
+
+const (
+	CompressionNone  Compression = ""
+	CompressionGzip  Compression = "gzip"
+	CompressionZstd  Compression = "zstd"
+	CompressionXz    Compression = "xz"
+	CompressionBzip2 Compression = "bzip2"
+	CompressionLz4   Compression = "lz4"
+)
+
+// compressionMagic maps the leading bytes of each compression format. lz4 has
+// both the frame format and the legacy format used by older lz4 tools.
+var compressionMagic = []struct {
+	magic  []byte
+	format Compression
+}{
+	{[]byte{0x1f, 0x8b}, CompressionGzip},
+	{[]byte{0x28, 0xb5, 0x2f, 0xfd}, CompressionZstd},
+	{[]byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, CompressionXz},
+	{[]byte{0x04, 0x22, 0x4d, 0x18}, CompressionLz4},
+	{[]byte{0x02, 0x21, 0x4c, 0x18}, CompressionLz4},
+}
+
+// detectCompression returns the compression format whose magic bytes start
+// header, or CompressionNone.
+func detectCompression(header []byte) Compression {
+
+	for _, m := range compressionMagic {
+		if bytes.HasPrefix(header, m.magic) {
+			return m.format
+		}
+	}
+
+	// bzip2 streams start with "BZh" and the block size, from 1 to 9.
+	if len(header) >= 4 && string(header[:3]) == "BZh" && header[3] >= '1' && header[3] <= '9' {
+		return CompressionBzip2
+	}
+
+	return CompressionNone
+
+}
+
+// IsCompressed returns true if the Compression is a known compression format.
+// Compressing such a file again rarely makes it smaller.
+func (c Compression) IsCompressed() bool {
+	return c != CompressionNone
+}
+
+// setCompression sets the Compression field (see addCompression). Returns an
+// error if the file cannot be read.
+func (fo *FileObj) setCompression() error {
+	return fo.pass(fo.addCompression)
+}
+
+// addCompression adds the Compression field to the pass when Sets.Compression
+// is true and the entry is a readable regular file, detected from its first
+// bytes. Otherwise, the field is cleared.
+func (fo *FileObj) addCompression(p *contentPass) {
+
+	fo.Compression = CompressionNone
+
+	if !fo.Set.Compression || !fo.IsExists || !fo.IsReadable || fo.info == nil || !fo.info.Mode().IsRegular() {
+		return
+	}
+
+	p.read(func(r io.Reader) error {
+		header := make([]byte, 6)
+		n, err := io.ReadFull(r, header)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return err
+		}
+		fo.Compression = detectCompression(header[:n])
+		return nil
+	})
+
+}
+
+// Decompressor returns a reader of the decompressed content of r, which holds
+// a stream of a known Compression format. If the returned reader is also an
+// io.Closer, it is closed once the content has been read.
+type Decompressor func(r io.Reader) (io.Reader, error)
+
+// builtinDecompressors are the Decompressors available without
+// WithDecompressor. The standard library has no zstd, xz, or lz4 decoder.
+var builtinDecompressors = map[Compression]Decompressor{
+	CompressionGzip: func(r io.Reader) (io.Reader, error) {
+		return gzip.NewReader(r)
+	},
+	CompressionBzip2: func(r io.Reader) (io.Reader, error) {
+		return bzip2.NewReader(r), nil
+	},
+}
+
+// decompressor returns the Decompressor for c, preferring one added with
+// WithDecompressor, or nil if there is none.
+func (o *options) decompressor(c Compression) Decompressor {
+
+	if fn, ok := o.decompressors[c]; ok {
+		return fn
+	}
+
+	return builtinDecompressors[c]
+
+}
+
+// addDecompressedChecksum adds the DecompressedSHA256 and
+// ChecksumDecompressed fields to the pass when WithDecompressedChecksum is
+// used and the entry is a readable regular file in a compression format with a
+// Decompressor. The format is detected by the file's magic bytes whether or
+// not Sets.Compression is true. The fields are cleared first, and left empty
+// if the decompressed content is larger than the limit. The pass fails if the
+// content is not a valid stream of the detected format.
+func (fo *FileObj) addDecompressedChecksum(p *contentPass) {
+
+	fo.DecompressedSHA256, fo.ChecksumDecompressed = nil, EMPTY
+
+	limit := fo.options().decompressLimit
+	if limit <= 0 || !fo.IsExists || !fo.IsReadable || fo.info == nil || !fo.info.Mode().IsRegular() {
+		return
+	}
+
+	p.hash = true
+	p.read(func(r io.Reader) error {
+
+		br := bufio.NewReader(r)
+		header, _ := br.Peek(6)
+		fn := fo.options().decompressor(detectCompression(header))
+		if fn == nil {
+			return nil
+		}
+
+		dr, err := fn(br)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fo.FullPath(), err)
+		}
+		if c, ok := dr.(io.Closer); ok {
+			defer c.Close()
+		}
+
+		hash := sha256.New()
+		n, err := io.Copy(hash, io.LimitReader(dr, limit+1))
+		if err != nil {
+			return fmt.Errorf("%s: %w", fo.FullPath(), err)
+		}
+		fo.options().stats.addHashed(fo.info.Size())
+		if n > limit {
+			return nil
+		}
+
+		fo.DecompressedSHA256 = hash.Sum(nil)
+		fo.ChecksumDecompressed = fmt.Sprintf("%x", fo.DecompressedSHA256)
+
+		return nil
+
+	})
+
+}
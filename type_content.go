@@ -0,0 +1,204 @@
+package objectify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// errPassDone stops a contentPass once no writer is left to read the content.
+var errPassDone = errors.New("content pass done")
+
+// contentPass reads the content of a file once for all of the fields computed
+// from it, so that each field does not open and read the file again, which is
+// a full GET for the remote FileSystems. Fields are added to a pass by the
+// add methods of the FileObj, such as addChecksums, and the pass is run by
+// FileObj.pass.
+type contentPass struct {
+
+	// writers see every byte of the content, e.g. hashes.
+	writers []io.Writer
+
+	// readers each read the content from the start in their own goroutine,
+	// and may stop early. They must only set the fields they compute.
+	readers []func(r io.Reader) error
+
+	// done are called once the content has been read, in order.
+	done []func()
+
+	// extractors are run after the content has been read, on the open file
+	// rewound to its start (see FileObj.extract).
+	extractors []Extractor
+
+	// hash holds a slot of the hashWorkers option while the content is read.
+	hash bool
+}
+
+// write adds a writer which sees every byte of the content.
+func (p *contentPass) write(w io.Writer) {
+	p.writers = append(p.writers, w)
+}
+
+// read adds a reader which reads the content in its own goroutine.
+func (p *contentPass) read(fn func(r io.Reader) error) {
+	p.readers = append(p.readers, fn)
+}
+
+// then adds a function called once the content has been read.
+func (p *contentPass) then(fn func()) {
+	p.done = append(p.done, fn)
+}
+
+// streamed returns true if the pass reads the content before the extractors
+// run.
+func (p *contentPass) streamed() bool {
+	return len(p.writers) > 0 || len(p.readers) > 0
+}
+
+// pass opens the content of the FileObj once and reads it for each of the
+// fields added by fields. The writers and readers share a single read of the
+// content, which stops early once every reader has returned and there are no
+// writers. The extractors then run on the same open file, rewound to its
+// start; if it cannot seek, the content is copied to a temporary file while it
+// is read, rather than read again. It returns the first error.
+func (fo *FileObj) pass(fields ...func(p *contentPass)) (err error) {
+
+	p := &contentPass{}
+	for _, add := range fields {
+		add(p)
+	}
+	if !p.streamed() && len(p.extractors) == 0 {
+		return nil
+	}
+
+	if p.hash {
+		release := fo.options().acquireHash()
+		defer release()
+	}
+
+	f, err := fo.open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cErr := f.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}()
+	fo.options().stats.addSyscalls(2)
+
+	src := fs.File(f)
+	if _, ok := f.(io.Seeker); !ok && len(p.extractors) > 0 && (p.streamed() || len(p.extractors) > 1) {
+		spool, err := os.CreateTemp(EMPTY, "objectify-*")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = spool.Close()
+			_ = os.Remove(spool.Name())
+		}()
+		p.write(spool)
+		src = spool
+	}
+
+	var first error
+	if p.streamed() {
+		if first, err = p.run(fo.hashFile(f), fo.FullPath()); err != nil {
+			return err
+		}
+		for _, fn := range p.done {
+			fn()
+		}
+	}
+
+	if err := fo.extract(p.extractors, src, p.streamed()); err != nil && first == nil {
+		first = err
+	}
+
+	return first
+
+}
+
+// run reads r once, writing the content to the writers and to a pipe for each
+// reader. It returns the first error of a reader, or the error reading r, in
+// which case the results of the pass must not be used. A reader which panics
+// returns an error wrapping ErrPanic.
+func (p *contentPass) run(r io.Reader, path string) (first error, err error) {
+
+	var wg sync.WaitGroup
+
+	errs := make([]error, len(p.readers))
+	pipes := make([]*io.PipeWriter, len(p.readers))
+	out := &fanout{writers: append([]io.Writer(nil), p.writers...)}
+	for i, fn := range p.readers {
+
+		pr, pw := io.Pipe()
+		pipes[i] = pw
+		out.writers = append(out.writers, pw)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = fmt.Errorf("%w: %s: %v", ErrPanic, path, r)
+				}
+				_ = pr.Close()
+			}()
+			errs[i] = fn(pr)
+		}()
+
+	}
+
+	_, err = io.Copy(out, r)
+	if errors.Is(err, errPassDone) {
+		err = nil
+	}
+	for _, pw := range pipes {
+		_ = pw.CloseWithError(err)
+	}
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range errs {
+		if e != nil {
+			return e, nil
+		}
+	}
+
+	return nil, nil
+
+}
+
+// fanout writes to each of its writers, dropping the pipes of readers which
+// have returned, and fails with errPassDone once no writer is left.
+type fanout struct {
+	writers []io.Writer
+}
+
+func (f *fanout) Write(b []byte) (int, error) {
+
+	live := f.writers[:0]
+	for _, w := range f.writers {
+		if _, err := w.Write(b); err != nil {
+			if errors.Is(err, io.ErrClosedPipe) {
+				continue
+			}
+			return 0, err
+		}
+		live = append(live, w)
+	}
+	f.writers = live
+
+	if len(live) == 0 {
+		return 0, errPassDone
+	}
+
+	return len(b), nil
+
+}
@@ -0,0 +1,224 @@
+package objectify
+
+import (
+	"bytes"
+	"compress/gzip"
+	"image"
+	"image/png"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// passFS is the OS filesystem, counting the opens and the bytes read. With
+// stream set, the files it opens cannot seek, like those of the remote
+// FileSystems.
+type passFS struct {
+	OSFileSystem
+	stream bool
+	opens  atomic.Int64
+	read   atomic.Int64
+}
+
+func (p *passFS) Open(name string) (fs.File, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	p.opens.Add(1)
+	if p.stream {
+		return streamFile{File: f, read: &p.read}, nil
+	}
+
+	return &seekFile{File: f, read: &p.read}, nil
+
+}
+
+// streamFile is an open file which counts the bytes read and cannot seek.
+type streamFile struct {
+	fs.File
+	read *atomic.Int64
+}
+
+func (f streamFile) Read(p []byte) (int, error) {
+
+	n, err := f.File.Read(p)
+	f.read.Add(int64(n))
+
+	return n, err
+
+}
+
+// seekFile is an open OS file which counts the bytes read.
+type seekFile struct {
+	*os.File
+	read *atomic.Int64
+}
+
+func (f *seekFile) Read(p []byte) (int, error) {
+
+	n, err := f.File.Read(p)
+	f.read.Add(int64(n))
+
+	return n, err
+
+}
+
+// lengthExtractor records the length of the content it reads under its key.
+type lengthExtractor string
+
+func (lengthExtractor) Match(*FileObj) bool {
+	return true
+}
+
+func (e lengthExtractor) Extract(fo *FileObj, r io.Reader) error {
+
+	n, err := io.Copy(io.Discard, r)
+	fo.SetMeta(string(e), strconv.FormatInt(n, 10))
+
+	return err
+
+}
+
+// contentFiles writes a PNG image and a gzip file to a temporary directory,
+// and returns their paths.
+func contentFiles(t *testing.T) []string {
+
+	dir := t.TempDir()
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 40, 30))); err != nil {
+		t.Fatal(err)
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err := zw.Write(bytes.Repeat([]byte("objectify "), 10000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for name, content := range map[string][]byte{"image.png": img.Bytes(), "data.gz": gz.Bytes()} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+
+	return paths
+
+}
+
+func TestContentReadOnce(t *testing.T) {
+
+	s := Sets{Size: true, ChecksumMD5: true, ChecksumSHA256: true, Dimensions: true, Compression: true, DocProps: true}
+	for _, stream := range []bool{false, true} {
+		for _, path := range contentFiles(t) {
+
+			sys := &passFS{stream: stream}
+			fo, err := File(path, s,
+				WithFileSystem(sys),
+				WithHMACKey([]byte("key")),
+				WithDecompressedChecksum(1<<20),
+				WithChunks(ChunkParams{}),
+				WithImageHash(),
+				WithExtractor(lengthExtractor("first")),
+				WithExtractor(lengthExtractor("second")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			name := filepath.Base(path) + " stream=" + strconv.FormatBool(stream)
+			if fo.Err != nil {
+				t.Fatalf("%s: %v", name, fo.Err)
+			}
+
+			if n := sys.opens.Load(); n != 1 {
+				t.Errorf("%s: opened %d times, want 1", name, n)
+			}
+			// Files which cannot seek are read once, and the extractors
+			// read a temporary copy.
+			if n := sys.read.Load(); stream && n != fo.SizeBytes {
+				t.Errorf("%s: read %d bytes of %d", name, n, fo.SizeBytes)
+			}
+
+			want := strconv.FormatInt(fo.SizeBytes, 10)
+			if fo.Meta["first"] != want || fo.Meta["second"] != want {
+				t.Errorf("%s: Meta = %v, want both lengths %s", name, fo.Meta, want)
+			}
+			if fo.SHA256 == nil || fo.MD5 == nil || fo.HMAC == nil || len(fo.Chunks) == 0 {
+				t.Errorf("%s: missing checksums or chunks", name)
+			}
+			switch filepath.Ext(path) {
+			case ".png":
+				if fo.Width != 40 || fo.Height != 30 || fo.ImageHash == nil {
+					t.Errorf("%s: %dx%d, ImageHash %x", name, fo.Width, fo.Height, fo.ImageHash)
+				}
+			case ".gz":
+				if fo.Compression != CompressionGzip || fo.DecompressedSHA256 == nil {
+					t.Errorf("%s: Compression %v, DecompressedSHA256 %x", name, fo.Compression, fo.DecompressedSHA256)
+				}
+			}
+
+		}
+	}
+
+}
+
+func TestContentHeaderOnly(t *testing.T) {
+
+	// Compression only needs the first bytes, so the rest of a large file
+	// should not be read.
+	path := filepath.Join(t.TempDir(), "big")
+	if err := os.WriteFile(path, make([]byte, 4<<20), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sys := &passFS{stream: true}
+	if _, err := File(path, Sets{Compression: true}, WithFileSystem(sys)); err != nil {
+		t.Fatal(err)
+	}
+	if n := sys.read.Load(); n >= 4<<20 {
+		t.Errorf("read %d bytes to detect compression, want less than the file", n)
+	}
+
+}
+
+func TestSetMetaConcurrentUpdate(t *testing.T) {
+
+	path := contentFiles(t)[0]
+	fo, err := File(path, Sets{Size: true}, WithExtractor(lengthExtractor("length")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			_ = fo.Update()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			fo.SetMeta("note", "x")
+		}
+	}()
+	wg.Wait()
+
+	if fo.Meta["length"] != strconv.FormatInt(fo.SizeBytes, 10) {
+		t.Errorf("Meta = %v", fo.Meta)
+	}
+
+}
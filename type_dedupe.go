@@ -0,0 +1,402 @@
+package objectify
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DedupeAction is the action DedupeReport.Apply takes for each extra copy.
+type DedupeAction int
+
+const (
+	// DedupeDelete removes each extra copy.
+	DedupeDelete DedupeAction = iota
+
+	// DedupeHardlink replaces each extra copy with a hard link to the kept file.
+	DedupeHardlink
+
+	// DedupeSymlink replaces each extra copy with a symlink to the kept file's
+	// absolute path.
+	DedupeSymlink
+)
+
+// String returns the name of the DedupeAction.
+func (a DedupeAction) String() string {
+
+	switch a {
+	case DedupeDelete:
+		return "delete"
+	case DedupeHardlink:
+		return "hardlink"
+	case DedupeSymlink:
+		return "symlink"
+	}
+
+	return fmt.Sprintf("DedupeAction(%d)", int(a))
+
+}
+
+// KeepFunc chooses which file of a duplicate group is kept, returning its index.
+// The group is sorted by FullPath and has at least two entries.
+type KeepFunc func(group Files) int
+
+// KeepFirst keeps the file whose FullPath sorts first.
+func KeepFirst(Files) int {
+	return 0
+}
+
+// KeepOldest keeps the file with the earliest modification time.
+func KeepOldest(group Files) int {
+	return pick(group, func(a, b *FileObj) bool { return a.modTime.Before(b.modTime) })
+}
+
+// KeepNewest keeps the file with the latest modification time.
+func KeepNewest(group Files) int {
+	return pick(group, func(a, b *FileObj) bool { return a.modTime.After(b.modTime) })
+}
+
+// KeepShortestPath keeps the file with the shortest FullPath.
+func KeepShortestPath(group Files) int {
+	return pick(group, func(a, b *FileObj) bool { return len(a.FullPath()) < len(b.FullPath()) })
+}
+
+// pick returns the index of the first entry for which no other entry is better.
+func pick(group Files, better func(a, b *FileObj) bool) int {
+
+	best := 0
+	for i := 1; i < len(group); i++ {
+		if better(group[i], group[best]) {
+			best = i
+		}
+	}
+
+	return best
+
+}
+
+// Duplicates groups the entries which have identical content, as shown by
+// their SHA256 checksum or, when SHA256 is not populated, their MD5 checksum.
+// Entries without a checksum, empty files, symlinks, and repeated paths are
+// ignored. Only groups with two or more entries are returned. Each group is
+// sorted by FullPath, and the groups are sorted by size (largest first), then
+// by the path of their first entry.
+func (files Files) Duplicates() []Files {
+
+	return files.groupDuplicates(contentKey, false)
+
+}
+
+// DuplicatesWithEmpty works like Duplicates, but also groups the empty files,
+// which all have the same content.
+func (files Files) DuplicatesWithEmpty() []Files {
+
+	return files.groupDuplicates(contentKey, true)
+
+}
+
+// DuplicatesDecompressed works like Duplicates, but groups compressed files by
+// their DecompressedSHA256 (see WithDecompressedChecksum) and other files by
+// their SHA256, so a log matches its compressed copies whatever compression
+// run or level produced them. Files without either checksum are ignored. The
+// groups are sorted by the size of their first entry.
+func (files Files) DuplicatesDecompressed() []Files {
+
+	return files.groupDuplicates(func(fo *FileObj) string {
+		switch {
+		case fo.DecompressedSHA256 != nil:
+			return fmt.Sprintf("sha256:%x", fo.DecompressedSHA256)
+		case fo.SHA256 != nil:
+			return fmt.Sprintf("sha256:%x", fo.SHA256)
+		}
+		return EMPTY
+	}, false)
+
+}
+
+// groupDuplicates groups the entries by the key returned by fn, ignoring
+// entries with an empty key, symlinks, repeated paths, and, unless empty is
+// set, empty files. It returns the groups with two or more entries, sorted as
+// described for Duplicates.
+func (files Files) groupDuplicates(fn func(fo *FileObj) string, empty bool) []Files {
+
+	byKey := make(map[string]Files)
+	seen := make(map[string]bool)
+	for _, fo := range files {
+
+		if fo == nil || fo.isSymlink() || seen[fo.FullPath()] {
+			continue
+		}
+		seen[fo.FullPath()] = true
+		if fo.SizeBytes == 0 && !empty {
+			continue
+		}
+
+		key := fn(fo)
+		if key == EMPTY {
+			continue
+		}
+		byKey[key] = append(byKey[key], fo)
+
+	}
+
+	var groups []Files
+	for _, g := range byKey {
+		if len(g) > 1 {
+			g.sortByPath()
+			groups = append(groups, g)
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i][0].SizeBytes != groups[j][0].SizeBytes {
+			return groups[i][0].SizeBytes > groups[j][0].SizeBytes
+		}
+		return groups[i][0].FullPath() < groups[j][0].FullPath()
+	})
+
+	return groups
+
+}
+
+// isSymlink returns true if the FileObj is known to be a symlink.
+func (fo *FileObj) isSymlink() bool {
+	return fo.IsLink || (fo.info != nil && fo.info.Mode()&fs.ModeSymlink != 0)
+}
+
+// DedupeGroup is a set of identical files: the file which is kept and its
+// extra copies.
+type DedupeGroup struct {
+	Keep   *FileObj
+	Extras Files
+}
+
+// DedupeResult records the outcome of applying a DedupeAction to one extra copy.
+// Err is nil if the action succeeded, or would have succeeded in a dry run as
+// far as could be checked.
+type DedupeResult struct {
+	Path   string
+	Keep   string
+	Action DedupeAction
+	DryRun bool
+	Err    error
+}
+
+// DedupeReport holds the duplicate groups of a scan, with the file to keep in
+// each group chosen by a KeepFunc. Review the groups, then call Apply.
+type DedupeReport struct {
+	Groups []DedupeGroup
+
+	// Reclaimable is the number of bytes held by the extra copies.
+	Reclaimable int64
+}
+
+// NewDedupeReport builds a DedupeReport from files.Duplicates(), using keep to
+// choose the file kept in each group. KeepFirst is used if keep is nil.
+func NewDedupeReport(files Files, keep KeepFunc) *DedupeReport {
+
+	if keep == nil {
+		keep = KeepFirst
+	}
+
+	r := &DedupeReport{}
+	for _, g := range files.Duplicates() {
+
+		k := keep(g)
+		if k < 0 || k >= len(g) {
+			k = 0
+		}
+
+		dg := DedupeGroup{Keep: g[k]}
+		for i, fo := range g {
+			if i != k {
+				dg.Extras = append(dg.Extras, fo)
+				r.Reclaimable += fo.SizeBytes
+			}
+		}
+		r.Groups = append(r.Groups, dg)
+
+	}
+
+	return r
+
+}
+
+// Apply takes the action for every extra copy in the report and returns a
+// result for each. With dryRun set, nothing is changed, but each extra copy is
+// still checked. Before the extra copies of a group are touched, the kept file
+// is re-read once, and the group is skipped with ErrDedupeChanged if it no
+// longer matches the checksum recorded in the scan. Each extra copy is then
+// re-read, and skipped with ErrDedupeChanged if it no longer matches its
+// recorded checksum, or with ErrDedupeMismatch if its SHA256 checksum differs
+// from the kept file's, so groups matched by MD5 alone are only acted on when
+// their content is confirmed by SHA256. Extra copies which are already hard
+// links to the kept file are skipped with ErrDedupeLinked for DedupeHardlink,
+// and extra copies which are the kept file itself, reached through another
+// path, are skipped with ErrDedupeSameEntry for every action.
+// Links replace the extra copy atomically by renaming over it. Only files
+// scanned from the OS filesystem can be deduplicated.
+func (r *DedupeReport) Apply(action DedupeAction, dryRun bool) []DedupeResult {
+
+	var results []DedupeResult
+	for _, g := range r.Groups {
+
+		keepSum, keepErr := verifyKeep(g.Keep)
+		for _, extra := range g.Extras {
+
+			res := DedupeResult{
+				Path:   extra.FullPath(),
+				Keep:   g.Keep.FullPath(),
+				Action: action,
+				DryRun: dryRun,
+				Err:    keepErr,
+			}
+			if keepErr == nil {
+				res.Err = applyDedupe(g.Keep, keepSum, extra, action, dryRun)
+			}
+			results = append(results, res)
+
+		}
+
+	}
+
+	return results
+
+}
+
+// verifyKeep checks that the kept file of a group can be deduplicated and
+// still matches its recorded checksum, and returns its current SHA256
+// checksum.
+func verifyKeep(keep *FileObj) ([]byte, error) {
+
+	if !keep.options().onOS() {
+		return nil, ErrDedupeNotLocal
+	}
+
+	sum, ok := verify(keep)
+	if !ok {
+		return nil, ErrDedupeChanged
+	}
+
+	return sum, nil
+
+}
+
+// applyDedupe checks and, unless dryRun is set, applies the action to extra.
+// keepSum is the current SHA256 checksum of keep, returned by verifyKeep.
+func applyDedupe(keep *FileObj, keepSum []byte, extra *FileObj, action DedupeAction, dryRun bool) error {
+
+	if !extra.options().onOS() {
+		return ErrDedupeNotLocal
+	}
+	sum, ok := verify(extra)
+	if !ok {
+		return ErrDedupeChanged
+	}
+	if !bytes.Equal(sum, keepSum) {
+		return ErrDedupeMismatch
+	}
+
+	keepInfo, err := os.Stat(keep.ioPath())
+	if err != nil {
+		return err
+	}
+	extraInfo, err := os.Lstat(extra.ioPath())
+	if err != nil {
+		return err
+	}
+	if os.SameFile(keepInfo, extraInfo) {
+		if sameEntry(keep.ioPath(), extra.ioPath()) {
+			return ErrDedupeSameEntry
+		}
+		if action == DedupeHardlink {
+			return ErrDedupeLinked
+		}
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	switch action {
+	case DedupeDelete:
+		return os.Remove(extra.ioPath())
+	case DedupeHardlink:
+		return replaceWith(extra.ioPath(), func(tmp string) error {
+			return os.Link(keep.ioPath(), tmp)
+		})
+	case DedupeSymlink:
+		return replaceWith(extra.ioPath(), func(tmp string) error {
+			return os.Symlink(pathAbsSafe(keep.ioPath()), tmp)
+		})
+	}
+
+	return fmt.Errorf("unknown dedupe action: %v", action)
+
+}
+
+// sameEntry reports whether the paths of two files which are the same file
+// name the same directory entry, e.g. through a different root to the same
+// tree, or with different case on a case-insensitive volume. Distinct hard
+// links differ in their directory or name. Names in the same directory
+// differing only in case are treated as the same entry, since both may only
+// be told apart on a case-sensitive volume.
+func sameEntry(a, b string) bool {
+
+	if !strings.EqualFold(filepath.Base(a), filepath.Base(b)) {
+		return false
+	}
+
+	dirA, err := os.Stat(filepath.Dir(a))
+	if err != nil {
+		return false
+	}
+	dirB, err := os.Stat(filepath.Dir(b))
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(dirA, dirB)
+
+}
+
+// verify re-reads the file and returns its current SHA256 checksum, and true
+// if it still matches the checksum recorded in fo: its SHA256 checksum or,
+// when SHA256 is not populated, its MD5 checksum.
+func verify(fo *FileObj) ([]byte, bool) {
+
+	sets := Sets{ChecksumSHA256: true, ChecksumMD5: fo.SHA256 == nil}
+	cur := newFileObj(fo.ioPath(), sets, nil)
+	if cur == nil || !cur.IsExists || cur.Err != nil || cur.SHA256 == nil {
+		return nil, false
+	}
+
+	if fo.SHA256 != nil {
+		return cur.SHA256, bytes.Equal(fo.SHA256, cur.SHA256)
+	}
+
+	return cur.SHA256, bytes.Equal(fo.MD5, cur.MD5)
+
+}
+
+// replaceWith creates a new entry with create at a temporary name in the same
+// directory as path, then renames it over path.
+func replaceWith(path string, create func(tmp string) error) error {
+
+	tmp := filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.objectify-dedupe-%d", filepath.Base(path), os.Getpid()))
+	if err := create(tmp); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return nil
+
+}
@@ -0,0 +1,188 @@
+package objectify
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// dedupeTree writes files with the given content under a new temporary
+// directory, and returns the directory.
+func dedupeTree(t *testing.T, files map[string]string) string {
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+
+}
+
+// dedupeReport scans keep and extra and returns a report with a single group
+// keeping keep.
+func dedupeReport(t *testing.T, keep, extra string) *DedupeReport {
+
+	s := Sets{ChecksumSHA256: true}
+	k, err := File(keep, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := File(extra, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &DedupeReport{Groups: []DedupeGroup{{Keep: k, Extras: Files{e}}}}
+
+}
+
+// applyOne applies action to a report with a single extra copy and returns
+// the error of its result.
+func applyOne(t *testing.T, r *DedupeReport, action DedupeAction, dryRun bool) error {
+
+	results := r.Apply(action, dryRun)
+	if len(results) != 1 {
+		t.Fatalf("Apply returned %d results, want 1", len(results))
+	}
+
+	return results[0].Err
+
+}
+
+func TestDedupeApply(t *testing.T) {
+
+	for _, tc := range []struct {
+		action DedupeAction
+		check  func(t *testing.T, keep, extra string)
+	}{
+		{DedupeDelete, func(t *testing.T, keep, extra string) {
+			if _, err := os.Lstat(extra); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("extra copy still exists: %v", err)
+			}
+		}},
+		{DedupeHardlink, func(t *testing.T, keep, extra string) {
+			ki, err := os.Stat(keep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ei, err := os.Lstat(extra)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !os.SameFile(ki, ei) {
+				t.Error("extra copy is not a hard link to the kept file")
+			}
+		}},
+		{DedupeSymlink, func(t *testing.T, keep, extra string) {
+			target, err := os.Readlink(extra)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if target != keep {
+				t.Errorf("symlink target = %q, want %q", target, keep)
+			}
+		}},
+	} {
+		t.Run(tc.action.String(), func(t *testing.T) {
+
+			dir := dedupeTree(t, map[string]string{"keep": "same", "extra": "same"})
+			keep, extra := filepath.Join(dir, "keep"), filepath.Join(dir, "extra")
+
+			if err := applyOne(t, dedupeReport(t, keep, extra), tc.action, true); err != nil {
+				t.Fatalf("dry run: %v", err)
+			}
+			if info, err := os.Lstat(extra); err != nil || !info.Mode().IsRegular() {
+				t.Fatalf("dry run changed the extra copy: %v", err)
+			}
+
+			if err := applyOne(t, dedupeReport(t, keep, extra), tc.action, false); err != nil {
+				t.Fatal(err)
+			}
+			tc.check(t, keep, extra)
+			if b, err := os.ReadFile(keep); err != nil || string(b) != "same" {
+				t.Errorf("kept file = %q, %v", b, err)
+			}
+
+		})
+	}
+
+}
+
+func TestDedupeApplySameEntry(t *testing.T) {
+
+	dir := dedupeTree(t, map[string]string{"a": "same"})
+	root := filepath.Join(t.TempDir(), "root")
+	if err := os.Symlink(dir, root); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	keep, extra := filepath.Join(dir, "a"), filepath.Join(root, "a")
+
+	for _, action := range []DedupeAction{DedupeDelete, DedupeHardlink, DedupeSymlink} {
+		for _, dryRun := range []bool{true, false} {
+			err := applyOne(t, dedupeReport(t, keep, extra), action, dryRun)
+			if !errors.Is(err, ErrDedupeSameEntry) {
+				t.Errorf("%s (dry run %v): err = %v, want ErrDedupeSameEntry", action, dryRun, err)
+			}
+		}
+	}
+
+	if b, err := os.ReadFile(keep); err != nil || string(b) != "same" {
+		t.Errorf("kept file = %q, %v", b, err)
+	}
+
+}
+
+func TestDedupeApplyHardlinked(t *testing.T) {
+
+	dir := dedupeTree(t, map[string]string{"keep": "same"})
+	keep, extra := filepath.Join(dir, "keep"), filepath.Join(dir, "extra")
+	if err := os.Link(keep, extra); err != nil {
+		t.Skip("hard links not supported:", err)
+	}
+
+	if err := applyOne(t, dedupeReport(t, keep, extra), DedupeHardlink, false); !errors.Is(err, ErrDedupeLinked) {
+		t.Errorf("hardlink: err = %v, want ErrDedupeLinked", err)
+	}
+	if err := applyOne(t, dedupeReport(t, keep, extra), DedupeDelete, false); err != nil {
+		t.Errorf("delete: %v", err)
+	}
+	if b, err := os.ReadFile(keep); err != nil || string(b) != "same" {
+		t.Errorf("kept file = %q, %v", b, err)
+	}
+
+}
+
+func TestDedupeApplyChanged(t *testing.T) {
+
+	dir := dedupeTree(t, map[string]string{"keep": "same", "extra": "same", "other": "other"})
+	keep, extra := filepath.Join(dir, "keep"), filepath.Join(dir, "extra")
+
+	r := dedupeReport(t, keep, extra)
+	if err := os.WriteFile(extra, []byte("changed"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyOne(t, r, DedupeDelete, true); !errors.Is(err, ErrDedupeChanged) {
+		t.Errorf("changed extra: err = %v, want ErrDedupeChanged", err)
+	}
+
+	r = dedupeReport(t, keep, filepath.Join(dir, "other"))
+	if err := applyOne(t, r, DedupeDelete, false); !errors.Is(err, ErrDedupeMismatch) {
+		t.Errorf("different content: err = %v, want ErrDedupeMismatch", err)
+	}
+
+	r = dedupeReport(t, keep, extra)
+	if err := os.WriteFile(keep, []byte("changed"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyOne(t, r, DedupeDelete, false); !errors.Is(err, ErrDedupeChanged) {
+		t.Errorf("changed keep: err = %v, want ErrDedupeChanged", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other")); err != nil {
+		t.Errorf("mismatched file removed: %v", err)
+	}
+
+}
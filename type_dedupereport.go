@@ -0,0 +1,128 @@
+package objectify
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Reclaimable returns the number of bytes held by the group's extra copies.
+func (g DedupeGroup) Reclaimable() int64 {
+
+	var n int64
+	for _, fo := range g.Extras {
+		n += fo.SizeBytes
+	}
+
+	return n
+
+}
+
+// Copies returns the number of extra copies in the report.
+func (r *DedupeReport) Copies() int {
+
+	n := 0
+	for _, g := range r.Groups {
+		n += len(g.Extras)
+	}
+
+	return n
+
+}
+
+// WriteText writes the report as aligned text: for each group, a header with
+// its checksum, file size, and reclaimable bytes, then the kept file and its
+// extra copies, followed by a summary line with the totals.
+func (r *DedupeReport) WriteText(w io.Writer) error {
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i, g := range r.Groups {
+		fmt.Fprintf(tw, "group %d\t%s\t%s each, %s reclaimable\n",
+			i+1, auditChecksum(g.Keep), FormatSize(g.Keep.SizeBytes, SizeBinary, 2), FormatSize(g.Reclaimable(), SizeBinary, 2))
+		fmt.Fprintf(tw, "  keep\t%s\n", g.Keep.FullPath())
+		for _, fo := range g.Extras {
+			fmt.Fprintf(tw, "  extra\t%s\n", fo.FullPath())
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%d groups, %d extra copies, %s (%d bytes) reclaimable\n",
+		len(r.Groups), r.Copies(), FormatSize(r.Reclaimable, SizeBinary, 2), r.Reclaimable)
+
+	return err
+
+}
+
+// WriteJSON writes the report as an indented JSON object with the totals and
+// the groups, each with its checksum, file size, kept file, extra copies, and
+// reclaimable bytes.
+func (r *DedupeReport) WriteJSON(w io.Writer) error {
+
+	type group struct {
+		Checksum    string   `json:"checksum"`
+		SizeBytes   int64    `json:"size_bytes"`
+		Keep        string   `json:"keep"`
+		Extras      []string `json:"extras"`
+		Reclaimable int64    `json:"reclaimable_bytes"`
+	}
+
+	groups := make([]group, 0, len(r.Groups))
+	for _, g := range r.Groups {
+		extras := make([]string, len(g.Extras))
+		for i, fo := range g.Extras {
+			extras[i] = fo.FullPath()
+		}
+		groups = append(groups, group{auditChecksum(g.Keep), g.Keep.SizeBytes, g.Keep.FullPath(), extras, g.Reclaimable()})
+	}
+
+	type totals struct {
+		Groups      int   `json:"groups"`
+		Copies      int   `json:"extra_copies"`
+		Reclaimable int64 `json:"reclaimable_bytes"`
+	}
+
+	out := struct {
+		Totals totals  `json:"totals"`
+		Groups []group `json:"groups"`
+	}{totals{len(r.Groups), r.Copies(), r.Reclaimable}, groups}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(out)
+
+}
+
+// WriteCSV writes the report as CSV with a header row and one row per file:
+// the group number, its role ("keep" or "extra"), path, size, checksum, and
+// the bytes reclaimed by removing it, which is 0 for kept files. Summing the
+// last column gives the report's Reclaimable.
+func (r *DedupeReport) WriteCSV(w io.Writer) error {
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"group", "role", "path", "size_bytes", "checksum", "reclaimable_bytes"})
+
+	row := func(group int, role string, fo *FileObj, reclaimable int64) {
+		_ = cw.Write([]string{
+			strconv.Itoa(group), role, fo.FullPath(), strconv.FormatInt(fo.SizeBytes, 10),
+			auditChecksum(fo), strconv.FormatInt(reclaimable, 10),
+		})
+	}
+
+	for i, g := range r.Groups {
+		row(i+1, "keep", g.Keep, 0)
+		for _, fo := range g.Extras {
+			row(i+1, "extra", fo, fo.SizeBytes)
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+
+}
@@ -0,0 +1,195 @@
+package objectify
+
+import (
+	"bytes"
+	"maps"
+	"sort"
+)
+
+// Change pairs the old and new FileObj of an entry which exists in both
+// scans being compared, but whose recorded fields differ.
+type Change struct {
+	Old *FileObj
+	New *FileObj
+}
+
+// Diff is the result of comparing two scans with Files.Diff. Entries are
+// matched by FullPath, and each slice is sorted by FullPath.
+type Diff struct {
+	Added   Files
+	Removed Files
+	Changed []Change
+}
+
+// Empty returns true if the Diff contains no added, removed, or changed entries.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares files (the older scan) against newer. Entries are matched by
+// FullPath. Entries only in newer are Added, entries only in files are
+// Removed, and entries in both whose size, mode, permissions, ownership,
+// modification time, link target, extended attributes, or checksums differ are
+// Changed, as are entries which became unreadable, failed to read, or lost a
+// checksum the newer scan was asked for. Fields are only compared when both
+// entries have them populated, so a scan can be diffed against a
+// ParseManifest result by checksum alone.
+func (files Files) Diff(newer Files) Diff {
+
+	var d Diff
+
+	olds := files.byPath()
+	news := newer.byPath()
+
+	for p, n := range news {
+		o, ok := olds[p]
+		if !ok {
+			d.Added = append(d.Added, n)
+			continue
+		}
+		if differs(o, n) {
+			d.Changed = append(d.Changed, Change{Old: o, New: n})
+		}
+	}
+
+	for p, o := range olds {
+		if _, ok := news[p]; !ok {
+			d.Removed = append(d.Removed, o)
+		}
+	}
+
+	d.Added.sortByPath()
+	d.Removed.sortByPath()
+	sort.Slice(d.Changed, func(i, j int) bool {
+		return d.Changed[i].New.FullPath() < d.Changed[j].New.FullPath()
+	})
+
+	return d
+
+}
+
+// since returns the part of d which is not already in prev, an earlier Diff
+// from the same baseline: the entries added, removed, or changed in d which
+// were not added, removed, or changed to the same state in prev.
+func (d Diff) since(prev Diff) Diff {
+
+	var out Diff
+
+	added := prev.Added.byPath()
+	for _, fo := range d.Added {
+		if p, ok := added[fo.FullPath()]; !ok || differs(p, fo) {
+			out.Added = append(out.Added, fo)
+		}
+	}
+
+	removed := prev.Removed.byPath()
+	for _, fo := range d.Removed {
+		if _, ok := removed[fo.FullPath()]; !ok {
+			out.Removed = append(out.Removed, fo)
+		}
+	}
+
+	changed := make(map[string]*FileObj, len(prev.Changed))
+	for _, c := range prev.Changed {
+		changed[c.New.FullPath()] = c.New
+	}
+	for _, c := range d.Changed {
+		if p, ok := changed[c.New.FullPath()]; !ok || differs(p, c.New) {
+			out.Changed = append(out.Changed, c)
+		}
+	}
+
+	return out
+
+}
+
+// byPath returns a map of FullPath to FileObj. nil entries are ignored.
+func (files Files) byPath() map[string]*FileObj {
+
+	m := make(map[string]*FileObj, len(files))
+	for _, fo := range files {
+		if fo != nil {
+			m[fo.FullPath()] = fo
+		}
+	}
+
+	return m
+
+}
+
+// sortByPath sorts the Files in place by FullPath.
+func (files Files) sortByPath() {
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].FullPath() < files[j].FullPath()
+	})
+
+}
+
+// differs returns true if the recorded fields of a and b differ. An entry
+// which stopped or started existing, being readable, or failing to read always
+// differs. Size, mode (with permissions and ownership), link target, and
+// extended attributes are only compared when both FileObjs were populated with
+// the matching Sets; modification times and checksums are only compared when
+// both FileObjs have them recorded. An MD5 or SHA256 checksum recorded in a
+// but missing from b also differs when the Sets of b include it, since the
+// file could not be read again.
+func differs(a, b *FileObj) bool {
+
+	if a.IsExists != b.IsExists || a.IsReadable != b.IsReadable || (a.Err == nil) != (b.Err == nil) {
+		return true
+	}
+	if lost(a.SHA256, b.SHA256, b, func(s *Sets) bool { return s.ChecksumSHA256 }) ||
+		lost(a.MD5, b.MD5, b, func(s *Sets) bool { return s.ChecksumMD5 }) {
+		return true
+	}
+	if both(a, b, func(s *Sets) bool { return s.Size }) && a.SizeBytes != b.SizeBytes {
+		return true
+	}
+	if both(a, b, func(s *Sets) bool { return s.Modes }) &&
+		(a.Mode != b.Mode || a.Perm != b.Perm || a.UID != b.UID || a.GID != b.GID) {
+		return true
+	}
+	if !a.modTime.IsZero() && !b.modTime.IsZero() && !a.modTime.Equal(b.modTime) {
+		return true
+	}
+	if both(a, b, func(s *Sets) bool { return s.LinkTarget }) && a.Target != b.Target {
+		return true
+	}
+	if both(a, b, func(s *Sets) bool { return s.XAttrs }) && !maps.EqualFunc(a.XAttrs, b.XAttrs, bytes.Equal) {
+		return true
+	}
+	if both(a, b, func(s *Sets) bool { return s.Dimensions }) && (a.Width != b.Width || a.Height != b.Height) {
+		return true
+	}
+	if both(a, b, func(s *Sets) bool { return s.Compression }) && a.Compression != b.Compression {
+		return true
+	}
+	if a.SHA256 != nil && b.SHA256 != nil && !bytes.Equal(a.SHA256, b.SHA256) {
+		return true
+	}
+	if a.MD5 != nil && b.MD5 != nil && !bytes.Equal(a.MD5, b.MD5) {
+		return true
+	}
+	if a.HMAC != nil && b.HMAC != nil && !bytes.Equal(a.HMAC, b.HMAC) {
+		return true
+	}
+	if a.DecompressedSHA256 != nil && b.DecompressedSHA256 != nil && !bytes.Equal(a.DecompressedSHA256, b.DecompressedSHA256) {
+		return true
+	}
+
+	return false
+
+}
+
+// lost returns true if the checksum old was recorded, but cur is missing
+// although the Sets of b include it.
+func lost(old, cur []byte, b *FileObj, set func(*Sets) bool) bool {
+	return old != nil && cur == nil && (b.Set == nil || set(b.Set))
+}
+
+// both returns true if set reports true for the Sets of a and b. A FileObj
+// without Sets is treated as having every field populated.
+func both(a, b *FileObj, set func(*Sets) bool) bool {
+	return (a.Set == nil || set(a.Set)) && (b.Set == nil || set(b.Set))
+}
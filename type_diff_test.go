@@ -0,0 +1,137 @@
+package objectify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffChanged(t *testing.T) {
+
+	sum := []byte{1, 2, 3}
+	entry := func(edit func(fo *FileObj)) *FileObj {
+		fo := &FileObj{
+			Root:       "/r",
+			Filename:   "a",
+			Set:        &Sets{ChecksumSHA256: true},
+			SHA256:     sum,
+			IsExists:   true,
+			IsReadable: true,
+		}
+		if edit != nil {
+			edit(fo)
+		}
+		return fo
+	}
+
+	for _, tc := range []struct {
+		name    string
+		edit    func(fo *FileObj)
+		changed bool
+	}{
+		{"same", nil, false},
+		{"checksum changed", func(fo *FileObj) { fo.SHA256 = []byte{4} }, true},
+		{"checksum lost", func(fo *FileObj) { fo.SHA256 = nil }, true},
+		{"checksum not requested", func(fo *FileObj) { fo.SHA256, fo.Set = nil, &Sets{} }, false},
+		{"unreadable", func(fo *FileObj) { fo.IsReadable = false }, true},
+		{"missing", func(fo *FileObj) { fo.IsExists = false }, true},
+		{"read error", func(fo *FileObj) { fo.Err = errors.New("read failed") }, true},
+	} {
+		d := Files{entry(nil)}.Diff(Files{entry(tc.edit)})
+		if got := len(d.Changed) == 1; got != tc.changed {
+			t.Errorf("%s: changed = %v, want %v", tc.name, got, tc.changed)
+		}
+	}
+
+}
+
+func TestDiffUnsetFields(t *testing.T) {
+
+	now := time.Now()
+	scanned := func(edit func(fo *FileObj)) *FileObj {
+		fo := &FileObj{
+			Root:       "/r",
+			Filename:   "a",
+			Set:        &Sets{Size: true, Modes: true, ChecksumSHA256: true},
+			SizeBytes:  10,
+			Mode:       EntModeRegular,
+			Perm:       0o644,
+			modTime:    now,
+			SHA256:     []byte{1, 2, 3},
+			IsExists:   true,
+			IsReadable: true,
+		}
+		if edit != nil {
+			edit(fo)
+		}
+		return fo
+	}
+
+	for _, tc := range []struct {
+		name    string
+		old     *FileObj
+		cur     *FileObj
+		changed bool
+	}{
+		{"modtime changed", scanned(nil), scanned(func(fo *FileObj) { fo.modTime = now.Add(time.Hour) }), true},
+		{"modtime not recorded", scanned(nil), scanned(func(fo *FileObj) { fo.modTime = time.Time{} }), false},
+		{"size changed", scanned(nil), scanned(func(fo *FileObj) { fo.SizeBytes = 11 }), true},
+		{"size not requested", scanned(nil), scanned(func(fo *FileObj) {
+			fo.SizeBytes, fo.Set = 0, &Sets{Modes: true, ChecksumSHA256: true}
+		}), false},
+		{"mode not requested", scanned(nil), scanned(func(fo *FileObj) {
+			fo.Perm, fo.Set = 0, &Sets{Size: true, ChecksumSHA256: true}
+		}), false},
+		{"no Sets", scanned(nil), scanned(func(fo *FileObj) { fo.Set = nil }), false},
+		{"no Sets, size changed", scanned(nil), scanned(func(fo *FileObj) { fo.Set, fo.SizeBytes = nil, 11 }), true},
+		{"MD5 only in one", scanned(nil), scanned(func(fo *FileObj) { fo.MD5 = []byte{4} }), false},
+	} {
+		d := Files{tc.old}.Diff(Files{tc.cur})
+		if got := len(d.Changed) == 1; got != tc.changed {
+			t.Errorf("%s: changed = %v, want %v", tc.name, got, tc.changed)
+		}
+	}
+
+}
+
+func TestDiffManifest(t *testing.T) {
+
+	// A manifest only records checksums, so a scan is compared against it by
+	// checksum alone.
+	sha := func(b byte) []byte {
+		sum := make([]byte, 32)
+		sum[0] = b
+		return sum
+	}
+	entry := func(name string, sum []byte) *FileObj {
+		return &FileObj{
+			Root:       "dir",
+			Filename:   name,
+			Set:        &Sets{Size: true, Modes: true, ChecksumSHA256: true},
+			SizeBytes:  int64(len(name)),
+			Mode:       EntModeRegular,
+			modTime:    time.Now(),
+			SHA256:     sum,
+			IsExists:   true,
+			IsReadable: true,
+		}
+	}
+
+	manifest, err := ParseManifest(strings.NewReader(string((&Manifest{Files: Files{
+		entry("same", sha(1)),
+		entry("changed", sha(2)),
+		entry("removed", sha(3)),
+	}}).Bytes())))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := manifest.Diff(Files{entry("same", sha(1)), entry("changed", sha(9)), entry("added", sha(4))})
+	if len(d.Added) != 1 || d.Added[0].Filename != "added" ||
+		len(d.Removed) != 1 || d.Removed[0].Filename != "removed" ||
+		len(d.Changed) != 1 || d.Changed[0].New.Filename != "changed" {
+		t.Errorf("Diff = %d added, %d removed, %d changed, want 1 of each", len(d.Added), len(d.Removed), len(d.Changed))
+	}
+
+}
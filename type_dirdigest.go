@@ -0,0 +1,147 @@
+package objectify
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirDigests returns a digest for each directory holding entries of the Files,
+// and for each directory between those and their deepest common ancestor, by
+// path. Like a git tree hash, a directory's digest is derived from its
+// children: the name and content checksum of each file (SHA256 or MD5, as
+// chosen by algo), the name and target of each symlink, and the name and
+// digest of each subdirectory, sorted by name. A change anywhere under a
+// directory changes its digest, so comparing the digests of two scans, e.g.
+// of /etc, answers "did anything under it change?" with a single comparison.
+// Entries without the checksum contribute only their name.
+func (files Files) DirDigests(algo MerkleAlgo) map[string][]byte {
+
+	type child struct {
+		kind byte
+		name string
+		sum  []byte
+	}
+
+	children := make(map[string][]child)
+	seen := make(map[string]bool)
+	slash := false
+	for _, fo := range files {
+
+		if fo == nil || seen[fo.FullPath()] {
+			continue
+		}
+		seen[fo.FullPath()] = true
+		slash = fo.options().fsys != nil
+
+		c := child{kind: 'f', name: fo.Filename, sum: algo.contentSum(fo)}
+		if fo.isSymlink() {
+			c.kind, c.sum = 'l', []byte(fo.LinkPath)
+		}
+		children[fo.Root] = append(children[fo.Root], c)
+
+	}
+	if len(children) == 0 {
+		return map[string][]byte{}
+	}
+
+	dir := filepath.Dir
+	if slash {
+		dir = path.Dir
+	}
+
+	// Add the directories between each Root and the deepest common ancestor.
+	roots := make([]string, 0, len(children))
+	for root := range children {
+		roots = append(roots, root)
+	}
+	top := roots[0]
+	for _, root := range roots[1:] {
+		for !isWithin(root, top) {
+			parent := dir(top)
+			if parent == top {
+				break
+			}
+			top = parent
+		}
+	}
+	dirs := make(map[string]bool)
+	for _, root := range roots {
+		for d := root; !dirs[d]; d = dir(d) {
+			dirs[d] = true
+			if d == top || dir(d) == d {
+				break
+			}
+		}
+	}
+
+	// Hash the deepest directories first, so each subdirectory's digest is
+	// known before its parent's.
+	order := make([]string, 0, len(dirs))
+	for d := range dirs {
+		order = append(order, d)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if di, dj := depth(order[i]), depth(order[j]); di != dj {
+			return di > dj
+		}
+		return order[i] < order[j]
+	})
+
+	digests := make(map[string][]byte, len(order))
+	for _, d := range order {
+
+		entries := children[d]
+		sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+		h := algo.newHash()
+		for _, c := range entries {
+			fmt.Fprintf(h, "%c %d:%s %x\n", c.kind, len(c.name), c.name, c.sum)
+		}
+		digests[d] = h.Sum(nil)
+
+		if parent := dir(d); d != top && parent != d {
+			children[parent] = append(children[parent], child{kind: 'd', name: baseOf(d, slash), sum: digests[d]})
+		}
+
+	}
+
+	return digests
+
+}
+
+// isWithin returns true if p is dir or is inside it.
+func isWithin(p, dir string) bool {
+
+	if p == dir {
+		return true
+	}
+
+	prefix := strings.TrimRight(dir, `/\`)
+	return strings.HasPrefix(p, prefix+"/") || strings.HasPrefix(p, prefix+string(filepath.Separator))
+
+}
+
+// depth returns the number of elements in p, counting "." as none.
+func depth(p string) int {
+
+	if p == "." {
+		return 0
+	}
+
+	return len(strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }))
+
+}
+
+// baseOf returns the last element of p.
+func baseOf(p string, slash bool) string {
+
+	if slash {
+		return path.Base(p)
+	}
+
+	return filepath.Base(p)
+
+}
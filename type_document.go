@@ -0,0 +1,469 @@
+package objectify
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Keys of the FileObj.Meta entries set when Sets.DocProps is true.
+const (
+	// MetaTitle and MetaAuthor are the document's title and author, from the
+	// Info dictionary of a PDF or the core properties of a DOCX or XLSX file.
+	MetaTitle  = "doc.title"
+	MetaAuthor = "doc.author"
+
+	// MetaPages is the number of pages of a PDF, or the page count recorded by
+	// the application which last saved a DOCX file.
+	MetaPages = "doc.pages"
+
+	// MetaSheets is the number of worksheets of an XLSX file.
+	MetaSheets = "doc.sheets"
+)
+
+// maxDocumentSize is the size of the largest PDF read, and of the largest
+// DOCX or XLSX file read into memory when the open file does not implement
+// io.ReaderAt.
+const maxDocumentSize = 64 << 20
+
+// documentExtractor is the Extractor run when Sets.DocProps is true.
+type documentExtractor struct{}
+
+// Match returns true: documents are detected by their magic bytes in Extract.
+func (documentExtractor) Match(*FileObj) bool {
+	return true
+}
+
+// Extract reads the properties of a PDF, DOCX, or XLSX file, detected by its
+// magic bytes and, for the zip-based formats, by the parts it contains, and sets
+// the MetaTitle, MetaAuthor, MetaPages, and MetaSheets entries which are found.
+// Files of other types and files which cannot be parsed are left unchanged, as
+// are PDFs larger than maxDocumentSize.
+func (documentExtractor) Extract(fo *FileObj, r io.Reader) error {
+
+	size := fo.SizeBytes
+	if fo.info != nil {
+		size = fo.info.Size()
+	}
+	if size <= 0 {
+		return nil
+	}
+
+	var header [5]byte
+	n, err := io.ReadFull(r, header[:])
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil
+	}
+	src := r
+	r = io.MultiReader(bytes.NewReader(header[:n]), r)
+
+	var props map[string]string
+	switch {
+	case string(header[:n]) == "%PDF-":
+		if size <= maxDocumentSize {
+			data, err := io.ReadAll(io.LimitReader(r, maxDocumentSize))
+			if err != nil {
+				return err
+			}
+			props = pdfProps(data)
+		}
+	case bytes.HasPrefix(header[:n], []byte("PK\x03\x04")):
+		props = officeProps(src, r, size)
+	}
+
+	for k, v := range props {
+		fo.SetMeta(k, v)
+	}
+
+	return nil
+
+}
+
+// officeProps returns the properties of the DOCX or XLSX file, of size bytes,
+// held by the open file src. The zip archive is read through src if it
+// implements io.ReaderAt, and otherwise read from r into memory.
+func officeProps(src, r io.Reader, size int64) map[string]string {
+
+	var ra io.ReaderAt
+	if f, ok := src.(io.ReaderAt); ok {
+		ra = f
+	} else {
+		if size > maxDocumentSize {
+			return nil
+		}
+		data, err := io.ReadAll(io.LimitReader(r, maxDocumentSize))
+		if err != nil {
+			return nil
+		}
+		ra, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil
+	}
+
+	parts := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+
+	var kind string
+	switch {
+	case parts["word/document.xml"] != nil:
+		kind = "docx"
+	case parts["xl/workbook.xml"] != nil:
+		kind = "xlsx"
+	default:
+		return nil
+	}
+
+	props := make(map[string]string)
+
+	var core struct {
+		Title   string `xml:"title"`
+		Creator string `xml:"creator"`
+	}
+	if readXMLPart(parts["docProps/core.xml"], &core) {
+		if v := strings.TrimSpace(core.Title); v != EMPTY {
+			props[MetaTitle] = v
+		}
+		if v := strings.TrimSpace(core.Creator); v != EMPTY {
+			props[MetaAuthor] = v
+		}
+	}
+
+	switch kind {
+	case "docx":
+		var app struct {
+			Pages string `xml:"Pages"`
+		}
+		if readXMLPart(parts["docProps/app.xml"], &app) {
+			if n, err := strconv.Atoi(strings.TrimSpace(app.Pages)); err == nil {
+				props[MetaPages] = strconv.Itoa(n)
+			}
+		}
+	case "xlsx":
+		var workbook struct {
+			Sheets []struct{} `xml:"sheets>sheet"`
+		}
+		if readXMLPart(parts["xl/workbook.xml"], &workbook) {
+			props[MetaSheets] = strconv.Itoa(len(workbook.Sheets))
+		}
+	}
+
+	return props
+
+}
+
+// readXMLPart decodes the XML zip part f into v, and returns true if it exists
+// and is valid. Parts larger than maxDocumentSize are not read.
+func readXMLPart(f *zip.File, v any) bool {
+
+	if f == nil || f.UncompressedSize64 > maxDocumentSize {
+		return false
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return false
+	}
+	defer rc.Close()
+
+	return xml.NewDecoder(io.LimitReader(rc, maxDocumentSize)).Decode(v) == nil
+
+}
+
+var (
+	pdfRootRef   = regexp.MustCompile(`/Root\s+(\d+)\s+\d+\s+R`)
+	pdfInfoRef   = regexp.MustCompile(`/Info\s+(\d+)\s+\d+\s+R`)
+	pdfPagesRef  = regexp.MustCompile(`/Pages\s+(\d+)\s+\d+\s+R`)
+	pdfCount     = regexp.MustCompile(`/Count\s+(\d+)`)
+	pdfObjStm    = regexp.MustCompile(`/Type\s*/ObjStm`)
+	pdfObjStmN   = regexp.MustCompile(`/N\s+(\d+)`)
+	pdfObjStmOff = regexp.MustCompile(`/First\s+(\d+)`)
+)
+
+// pdfFile looks up the objects of a PDF held in memory. Objects are found by
+// searching for their "N 0 obj" headers, or in compressed object streams,
+// rather than through the cross-reference table, so files with a damaged table
+// can still be read.
+type pdfFile struct {
+	data []byte
+
+	// streamed holds the objects found in object streams, by number. It is
+	// populated on first use.
+	streamed map[int][]byte
+}
+
+// pdfProps returns the title, author, and page count of the PDF in data. The
+// title and author are omitted for encrypted files.
+func pdfProps(data []byte) map[string]string {
+
+	p := &pdfFile{data: data}
+	props := make(map[string]string)
+
+	if m := lastMatch(pdfRootRef, data); m != nil {
+		if pages := pdfPagesRef.FindSubmatch(p.object(atoi(m[1]))); pages != nil {
+			if count := pdfCount.FindSubmatch(p.object(atoi(pages[1]))); count != nil {
+				props[MetaPages] = string(count[1])
+			}
+		}
+	}
+
+	if m := lastMatch(pdfInfoRef, data); m != nil && !bytes.Contains(data, []byte("/Encrypt")) {
+		info := p.object(atoi(m[1]))
+		if v := p.stringValue(info, "/Title"); v != EMPTY {
+			props[MetaTitle] = v
+		}
+		if v := p.stringValue(info, "/Author"); v != EMPTY {
+			props[MetaAuthor] = v
+		}
+	}
+
+	return props
+
+}
+
+// object returns the body of object num, between its "obj" and "endobj"
+// keywords, or nil if it is not found. The last definition in the file wins, as
+// it does for incremental updates.
+func (p *pdfFile) object(num int) []byte {
+
+	header := regexp.MustCompile(`(?:^|[^0-9])` + strconv.Itoa(num) + `\s+\d+\s+obj\b`)
+	if locs := header.FindAllIndex(p.data, -1); locs != nil {
+		body := p.data[locs[len(locs)-1][1]:]
+		if end := bytes.Index(body, []byte("endobj")); end >= 0 {
+			return body[:end]
+		}
+		return body
+	}
+
+	if p.streamed == nil {
+		p.streamed = p.objectStreams()
+	}
+
+	return p.streamed[num]
+
+}
+
+// objectStreams decompresses the FlateDecode object streams of the file and
+// returns the objects they hold, by number.
+func (p *pdfFile) objectStreams() map[int][]byte {
+
+	objects := make(map[int][]byte)
+
+	for _, loc := range pdfObjStm.FindAllIndex(p.data, -1) {
+
+		// The stream dictionary runs from the "obj" keyword before the match
+		// to the "stream" keyword after it.
+		objStart := bytes.LastIndex(p.data[:loc[0]], []byte("obj"))
+		rest := p.data[loc[0]:]
+		start := bytes.Index(rest, []byte("stream"))
+		if objStart < 0 || start < 0 {
+			continue
+		}
+		dict := p.data[objStart : loc[0]+start]
+		body := rest[start+len("stream"):]
+		body = bytes.TrimLeft(body, "\r\n")
+
+		n, first := pdfObjStmN.FindSubmatch(dict), pdfObjStmOff.FindSubmatch(dict)
+		if n == nil || first == nil || !bytes.Contains(dict, []byte("/FlateDecode")) {
+			continue
+		}
+
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			continue
+		}
+		content, _ := io.ReadAll(io.LimitReader(zr, maxDocumentSize))
+		_ = zr.Close()
+
+		offset := atoi(first[1])
+		if offset > len(content) {
+			continue
+		}
+		fields := strings.Fields(string(content[:offset]))
+		count := atoi(n[1])
+		for i := 0; i < count && 2*i+1 < len(fields); i++ {
+			start := offset + atoi(fields[2*i+1])
+			end := len(content)
+			if 2*i+3 < len(fields) {
+				end = offset + atoi(fields[2*i+3])
+			}
+			if start <= end && end <= len(content) {
+				objects[atoi(fields[2*i])] = content[start:end]
+			}
+		}
+
+	}
+
+	return objects
+
+}
+
+// stringValue returns the text string stored under key in the dictionary dict,
+// following an indirect reference if needed, or an empty string.
+func (p *pdfFile) stringValue(dict []byte, key string) string {
+
+	i := bytes.Index(dict, []byte(key))
+	if i < 0 {
+		return EMPTY
+	}
+	value := bytes.TrimLeft(dict[i+len(key):], " \t\r\n")
+
+	if ref := regexp.MustCompile(`^(\d+)\s+\d+\s+R`).FindSubmatch(value); ref != nil {
+		value = bytes.TrimLeft(p.object(atoi(ref[1])), " \t\r\n")
+	}
+
+	var raw []byte
+	switch {
+	case bytes.HasPrefix(value, []byte("(")):
+		raw = pdfLiteral(value[1:])
+	case bytes.HasPrefix(value, []byte("<")) && !bytes.HasPrefix(value, []byte("<<")):
+		raw = pdfHex(value[1:])
+	default:
+		return EMPTY
+	}
+
+	return strings.TrimSpace(pdfText(raw))
+
+}
+
+// pdfLiteral decodes a literal string, starting after its opening parenthesis.
+func pdfLiteral(b []byte) []byte {
+
+	var out []byte
+	depth := 1
+	for i := 0; i < len(b); i++ {
+
+		c := b[i]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			i++
+			if i >= len(b) {
+				return out
+			}
+			switch e := b[i]; e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// A line continuation.
+				if e == '\r' && i+1 < len(b) && b[i+1] == '\n' {
+					i++
+				}
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					for j := 0; j < 3 && i < len(b) && b[i] >= '0' && b[i] <= '7'; j++ {
+						v = v*8 + int(b[i]-'0')
+						i++
+					}
+					i--
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+
+	}
+
+	return out
+
+}
+
+// pdfHex decodes a hexadecimal string, starting after its opening angle bracket.
+func pdfHex(b []byte) []byte {
+
+	var digits []byte
+	for _, c := range b {
+		if c == '>' {
+			break
+		}
+		if strings.IndexByte("0123456789abcdefABCDEF", c) >= 0 {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(v)
+	}
+
+	return out
+
+}
+
+// pdfText converts a PDF text string to UTF-8: UTF-16BE and UTF-8 strings are
+// recognized by their byte order marks, and other strings are treated as
+// PDFDocEncoding, which matches Latin-1 for printable characters.
+func pdfText(b []byte) string {
+
+	switch {
+	case bytes.HasPrefix(b, []byte{0xfe, 0xff}):
+		u := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(u))
+	case bytes.HasPrefix(b, []byte{0xef, 0xbb, 0xbf}):
+		return string(b[3:])
+	}
+
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+
+	return string(runes)
+
+}
+
+// lastMatch returns the submatches of the last match of re in b, or nil.
+func lastMatch(re *regexp.Regexp, b []byte) [][]byte {
+
+	all := re.FindAllSubmatch(b, -1)
+	if all == nil {
+		return nil
+	}
+
+	return all[len(all)-1]
+
+}
+
+// atoi returns the integer value of a string or byte slice of digits, or 0.
+func atoi[T string | []byte](s T) int {
+
+	n, _ := strconv.Atoi(string(s))
+
+	return n
+
+}
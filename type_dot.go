@@ -0,0 +1,201 @@
+package objectify
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dotEscaper escapes a string for a double-quoted Graphviz DOT ID.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+
+// dotQuote returns s as a double-quoted Graphviz DOT ID.
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+// dotEdge is a symlink drawn by WriteDOT, from the link to the entry its
+// contents name.
+type dotEdge struct {
+	from, to, label string
+}
+
+// WriteDOT writes the symlinks and hard links among the Files to w as a
+// Graphviz DOT digraph, e.g. for "dot -Tsvg", to untangle symlink farms. Each
+// symlink is drawn with an edge, labeled with its literal contents, to the
+// entry it names (resolved against the link's directory); a chain of links
+// shows as a path of edges and a cycle as a loop. Entries which are hard
+// links to one inode are drawn together in a box. Only entries taking part
+// in such a relationship are drawn:
+//
+//   - symlinks are ellipses, red if their target could not be resolved (e.g.
+//     a dangling link or ErrSymlinkCycle);
+//   - targets outside the Files are dotted, and red if they do not exist as
+//     far as the scan knows, i.e. when the link's Target is empty;
+//   - other entries are boxes, or folders for directories.
+//
+// Symlinks need Sets.LinkTarget (or Sets.LinkTargetFinal, whose final target
+// is used when the literal contents were not read). Hard links are found from
+// the device and inode of entries on the OS filesystem, on platforms which
+// report them. nil entries are skipped.
+func (files Files) WriteDOT(w io.Writer) error {
+
+	entries := make(map[string]*FileObj)
+	for _, fo := range files {
+		if fo != nil {
+			entries[fo.FullPath()] = fo
+		}
+	}
+
+	var edges []dotEdge
+	drawn := make(map[string]bool)
+	dangling := make(map[string]bool)
+
+	for p, fo := range entries {
+
+		if !fo.IsLink {
+			continue
+		}
+
+		to, label := fo.linkEdge()
+		if to == EMPTY {
+			continue
+		}
+
+		edges = append(edges, dotEdge{from: p, to: to, label: label})
+		drawn[p], drawn[to] = true, true
+		if fo.Target == EMPTY && fo.TargetFinal == EMPTY {
+			dangling[to] = true
+		}
+
+	}
+
+	groups := files.hardLinkGroups()
+	for _, g := range groups {
+		for _, fo := range g {
+			drawn[fo.FullPath()] = true
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph objectify {\n")
+	bw.WriteString("  rankdir=LR;\n")
+	bw.WriteString("  node [shape=box, fontname=\"Helvetica\"];\n")
+	bw.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+
+	grouped := make(map[string]bool)
+	for i, g := range groups {
+		fmt.Fprintf(bw, "  subgraph cluster_%d {\n", i)
+		fmt.Fprintf(bw, "    label=%s;\n    style=rounded;\n", dotQuote(fmt.Sprintf("hard links (%d)", len(g))))
+		for _, fo := range g {
+			p := fo.FullPath()
+			grouped[p] = true
+			fmt.Fprintf(bw, "    %s%s;\n", dotQuote(p), dotNodeAttrs(fo, false))
+		}
+		bw.WriteString("  }\n")
+	}
+
+	nodes := make([]string, 0, len(drawn))
+	for p := range drawn {
+		if !grouped[p] {
+			nodes = append(nodes, p)
+		}
+	}
+	sort.Strings(nodes)
+	for _, p := range nodes {
+		fmt.Fprintf(bw, "  %s%s;\n", dotQuote(p), dotNodeAttrs(entries[p], dangling[p]))
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].from < edges[j].from
+	})
+	for _, e := range edges {
+		fmt.Fprintf(bw, "  %s -> %s [label=%s];\n", dotQuote(e.from), dotQuote(e.to), dotQuote(e.label))
+	}
+
+	bw.WriteString("}\n")
+
+	return bw.Flush()
+
+}
+
+// linkEdge returns the path the symlink fo names and the label of its edge:
+// its LinkPath resolved against its directory, or else its final target.
+func (fo *FileObj) linkEdge() (to, label string) {
+
+	switch {
+	case fo.LinkPath != EMPTY && fo.options().fsys != nil:
+		return path.Join(fo.Root, fo.LinkPath), fo.LinkPath
+	case fo.LinkPath != EMPTY && filepath.IsAbs(fo.LinkPath):
+		return filepath.Clean(fo.LinkPath), fo.LinkPath
+	case fo.LinkPath != EMPTY:
+		return filepath.Join(fo.Root, fo.LinkPath), fo.LinkPath
+	case fo.TargetFinal != EMPTY:
+		return fo.TargetFinal, "final"
+	case fo.Target != EMPTY:
+		return fo.Target, "final"
+	}
+
+	return EMPTY, EMPTY
+
+}
+
+// dotNodeAttrs returns the DOT attribute list of a node drawn by WriteDOT for
+// the entry fo, which is nil for a target outside the Files.
+func dotNodeAttrs(fo *FileObj, dangling bool) string {
+
+	switch {
+	case fo == nil && dangling:
+		return " [style=dotted, color=red, fontcolor=red]"
+	case fo == nil:
+		return " [style=dotted]"
+	case fo.IsLink && (fo.Err != nil || (fo.Target == EMPTY && fo.TargetFinal == EMPTY)):
+		return " [shape=ellipse, color=red, fontcolor=red]"
+	case fo.IsLink:
+		return " [shape=ellipse]"
+	case fo.Mode.IsDir():
+		return " [shape=folder]"
+	}
+
+	return EMPTY
+
+}
+
+// hardLinkGroups returns the groups of two or more entries of the Files on the
+// OS filesystem which share a device and inode, sorted by FullPath, in order
+// of their first entry. Directories and symlinks are ignored.
+func (files Files) hardLinkGroups() []Files {
+
+	byID := make(map[[2]uint64]Files)
+	seen := make(map[string]bool)
+	for _, fo := range files {
+
+		if fo == nil || fo.info == nil || fo.options().fsys != nil || fo.IsLink || fo.info.IsDir() || seen[fo.FullPath()] {
+			continue
+		}
+		seen[fo.FullPath()] = true
+
+		if id, ok := fileID(fo.info); ok {
+			byID[id] = append(byID[id], fo)
+		}
+
+	}
+
+	var groups []Files
+	for _, g := range byID {
+		if len(g) > 1 {
+			g.sortByPath()
+			groups = append(groups, g)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0].FullPath() < groups[j][0].FullPath()
+	})
+
+	return groups
+
+}
@@ -0,0 +1,51 @@
+package objectify
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// WithDotfilesOnly scans only the dotfiles and dot-directories directly under
+// the root, such as ~/.bashrc and ~/.config, skipping every other entry of
+// the root, for tooling which manages or audits user configuration. With
+// WithRecursive, the contents of the dot-directories are scanned too, whatever
+// their names. It has no effect on File.
+func WithDotfilesOnly() Option {
+	return func(o *options) {
+		o.dotfilesOnly = true
+	}
+}
+
+// WithConfigScan sets up a scan of the user configuration under a home
+// directory, e.g. for dotfile managers and drift detectors: it combines
+// WithDotfilesOnly, WithRecursive, and WithSkipPresets(SkipJunk), and skips
+// caches and trash, which are not configuration: directories named .cache,
+// .Trash, or .Trash-* (see SkipOSJunk), and .local/share/Trash.
+func WithConfigScan() Option {
+	return func(o *options) {
+		for _, opt := range []Option{WithDotfilesOnly(), WithRecursive(), WithSkipPresets(SkipJunk), WithSkipFunc(skipConfigCache)} {
+			opt(o)
+		}
+	}
+}
+
+// skipConfigCache is the SkipFunc of WithConfigScan which skips cache and
+// trash directories.
+func skipConfigCache(p string, d fs.DirEntry) bool {
+
+	if !d.IsDir() {
+		return false
+	}
+
+	switch d.Name() {
+	case ".cache", ".Trash":
+		return true
+	case "Trash":
+		p = filepath.ToSlash(p)
+		return strings.HasSuffix(p, "/.local/share/Trash") || p == ".local/share/Trash"
+	}
+
+	return false
+
+}
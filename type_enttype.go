@@ -1,6 +1,7 @@
 package objectify
 
 import (
+	"fmt"
 	"io/fs"
 	"os"
 )
@@ -21,11 +22,116 @@ var (
 	EntModeErrored   EntMode = "unknown"
 )
 
+// EntKind is a bitmask representation of EntMode, useful for combined checks
+// such as mode.Kind()&(EntKindPipe|EntKindSocket) != 0.
+type EntKind uint16
+
+const (
+	EntKindDir EntKind = 1 << iota
+	EntKindLink
+	EntKindRegular
+	EntKindTemp
+	EntKindPipe
+	EntKindSocket
+	EntKindDevice
+	EntKindIrregular
+	EntKindOther
+	EntKindErrored
+
+	// EntKindSpecial matches pipes, sockets, devices, and irregular files.
+	EntKindSpecial = EntKindPipe | EntKindSocket | EntKindDevice | EntKindIrregular
+)
+
+// entKinds maps each EntMode to its EntKind bit.
+var entKinds = map[EntMode]EntKind{
+	EntModeDir:       EntKindDir,
+	EntModeLink:      EntKindLink,
+	EntModeRegular:   EntKindRegular,
+	EntModeTemp:      EntKindTemp,
+	EntModePipe:      EntKindPipe,
+	EntModeSocket:    EntKindSocket,
+	EntModeDevice:    EntKindDevice,
+	EntModeIrregular: EntKindIrregular,
+	EntModeOther:     EntKindOther,
+	EntModeErrored:   EntKindErrored,
+}
+
+// Has returns true if any of the bits in m are set in k.
+func (k EntKind) Has(m EntKind) bool {
+	return k&m != 0
+}
+
+// ParseEntMode returns the EntMode matching the given string representation,
+// as produced by EntMode.String. It returns an error if the string is not a
+// known EntMode.
+func ParseEntMode(s string) (EntMode, error) {
+
+	e := EntMode(s)
+	if _, ok := entKinds[e]; !ok {
+		return EntModeErrored, fmt.Errorf("unknown EntMode: %q", s)
+	}
+
+	return e, nil
+
+}
+
 // String returns the string representation of the EntMode.
 func (e EntMode) String() string {
 	return string(e)
 }
 
+// Kind returns the EntKind bit for the EntMode. An empty or unknown EntMode
+// returns 0.
+func (e EntMode) Kind() EntKind {
+	return entKinds[e]
+}
+
+// Is returns true if the EntMode matches any of the bits in k.
+func (e EntMode) Is(k EntKind) bool {
+	return e.Kind().Has(k)
+}
+
+// IsDir returns true if the EntMode is EntModeDir.
+func (e EntMode) IsDir() bool {
+	return e.Is(EntKindDir)
+}
+
+// IsLink returns true if the EntMode is EntModeLink.
+func (e EntMode) IsLink() bool {
+	return e.Is(EntKindLink)
+}
+
+// IsRegular returns true if the EntMode is EntModeRegular.
+func (e EntMode) IsRegular() bool {
+	return e.Is(EntKindRegular)
+}
+
+// IsSpecial returns true if the EntMode is a pipe, socket, device, or irregular file.
+func (e EntMode) IsSpecial() bool {
+	return e.Is(EntKindSpecial)
+}
+
+// entFileModes maps each EntMode to the fs.FileMode type bits it stands for.
+var entFileModes = map[EntMode]fs.FileMode{
+	EntModeDir:       fs.ModeDir,
+	EntModeLink:      fs.ModeSymlink,
+	EntModeTemp:      fs.ModeTemporary,
+	EntModePipe:      fs.ModeNamedPipe,
+	EntModeSocket:    fs.ModeSocket,
+	EntModeDevice:    fs.ModeDevice,
+	EntModeIrregular: fs.ModeIrregular,
+	EntModeOther:     fs.ModeIrregular,
+	EntModeErrored:   fs.ModeIrregular,
+}
+
+// FileMode returns the fs.FileMode type bits of the EntMode, e.g.
+// fs.ModeDir for EntModeDir, the reverse of the mapping made when the
+// FileObj is populated. EntModeRegular and an empty EntMode return 0, and
+// EntModeOther and EntModeErrored return fs.ModeIrregular.
+func (e EntMode) FileMode() fs.FileMode {
+	return entFileModes[e]
+}
+
 // getEntMode returns the EntMode and fs.FileInfo for the given path.
 // If there is an error in retrieving fs.FileInfo, the function returns
 // EntModeErrored and nil.
@@ -0,0 +1,167 @@
+package objectify
+
+import (
+	"fmt"
+	"sort"
+)
+
+// EventKind is the kind of change an Event describes.
+type EventKind int
+
+const (
+	// EventCreated is an entry which only exists in the newer scan.
+	EventCreated EventKind = iota + 1
+
+	// EventModified is an entry whose recorded fields differ between scans.
+	EventModified
+
+	// EventDeleted is an entry which only exists in the older scan.
+	EventDeleted
+
+	// EventRenamed is an entry which was deleted from one path and created at
+	// another with the same size and checksum.
+	EventRenamed
+)
+
+// String returns the name of the EventKind, e.g. "created".
+func (k EventKind) String() string {
+
+	switch k {
+	case EventCreated:
+		return "created"
+	case EventModified:
+		return "modified"
+	case EventDeleted:
+		return "deleted"
+	case EventRenamed:
+		return "renamed"
+	}
+
+	return fmt.Sprintf("EventKind(%d)", int(k))
+
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the EventKind as its
+// name.
+func (k EventKind) MarshalText() ([]byte, error) {
+
+	return []byte(k.String()), nil
+
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a name written
+// by MarshalText.
+func (k *EventKind) UnmarshalText(text []byte) error {
+
+	for _, kind := range []EventKind{EventCreated, EventModified, EventDeleted, EventRenamed} {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown event kind: %q", text)
+
+}
+
+// Event is a change to one entry, as reported by Diff.Events and by a
+// Monitor. Old is the entry in the older scan and New the entry in the newer
+// scan; Old is nil for EventCreated and New is nil for EventDeleted.
+type Event struct {
+	Kind EventKind
+	Old  *FileObj
+	New  *FileObj
+}
+
+// Path returns the FullPath of the entry the Event is about: that of New, or
+// of Old for EventDeleted.
+func (e Event) Path() string {
+
+	if e.New != nil {
+		return e.New.FullPath()
+	}
+
+	return e.Old.FullPath()
+
+}
+
+// Events returns the changes of the Diff as Events, sorted by Path. An Added
+// and a Removed entry with the same non-zero size and checksum (see
+// Duplicates) are paired as one EventRenamed, so a scan without checksums or
+// sizes reports no renames, and empty files, which all have the same content,
+// are never paired. Otherwise Added entries are EventCreated, Removed entries
+// are EventDeleted, and Changed entries are EventModified.
+func (d Diff) Events() []Event {
+
+	removed := make(map[string]Files)
+	for _, o := range d.Removed {
+		if key := renameKey(o); key != EMPTY {
+			removed[key] = append(removed[key], o)
+		}
+	}
+
+	events := make([]Event, 0, len(d.Added)+len(d.Removed)+len(d.Changed))
+	renamed := make(map[*FileObj]bool)
+
+	for _, n := range d.Added {
+		key := renameKey(n)
+		if olds := removed[key]; key != EMPTY && len(olds) > 0 {
+			events = append(events, Event{Kind: EventRenamed, Old: olds[0], New: n})
+			renamed[olds[0]] = true
+			removed[key] = olds[1:]
+			continue
+		}
+		events = append(events, Event{Kind: EventCreated, New: n})
+	}
+	for _, o := range d.Removed {
+		if !renamed[o] {
+			events = append(events, Event{Kind: EventDeleted, Old: o})
+		}
+	}
+	for _, c := range d.Changed {
+		events = append(events, Event{Kind: EventModified, Old: c.Old, New: c.New})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Path() < events[j].Path()
+	})
+
+	return events
+
+}
+
+// Events compares files (the older scan) against newer, as Diff does, and
+// returns the changes as Events.
+func (files Files) Events(newer Files) []Event {
+
+	return files.Diff(newer).Events()
+
+}
+
+// renameKey returns the contentKey of an entry which can be paired as an
+// EventRenamed, or an empty key for symlinks and empty files.
+func renameKey(fo *FileObj) string {
+
+	if fo.SizeBytes == 0 || fo.isSymlink() {
+		return EMPTY
+	}
+
+	return contentKey(fo)
+
+}
+
+// contentKey returns a key which is equal for entries with the same size and
+// content, from their SHA256 checksum or, when SHA256 is not populated, their
+// MD5 checksum. It is empty if neither is populated.
+func contentKey(fo *FileObj) string {
+
+	switch {
+	case fo.SHA256 != nil:
+		return fmt.Sprintf("sha256:%d:%x", fo.SizeBytes, fo.SHA256)
+	case fo.MD5 != nil:
+		return fmt.Sprintf("md5:%d:%x", fo.SizeBytes, fo.MD5)
+	}
+
+	return EMPTY
+
+}
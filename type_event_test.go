@@ -0,0 +1,38 @@
+package objectify
+
+import (
+	"testing"
+)
+
+func TestEventsRenames(t *testing.T) {
+
+	entry := func(name string, size int64, sum byte) *FileObj {
+		return &FileObj{Root: "/r", Filename: name, SizeBytes: size, SHA256: []byte{sum}}
+	}
+
+	d := Diff{
+		Added:   Files{entry("moved", 5, 1), entry("new-empty", 0, 0), entry("other", 5, 2)},
+		Removed: Files{entry("empty", 0, 0), entry("orig", 5, 1)},
+	}
+
+	kinds := make(map[string]EventKind)
+	for _, e := range d.Events() {
+		kinds[e.Path()] = e.Kind
+	}
+
+	want := map[string]EventKind{
+		"/r/moved":     EventRenamed,
+		"/r/new-empty": EventCreated,
+		"/r/empty":     EventDeleted,
+		"/r/other":     EventCreated,
+	}
+	if len(kinds) != len(want) {
+		t.Fatalf("events = %v, want %v", kinds, want)
+	}
+	for p, k := range want {
+		if kinds[p] != k {
+			t.Errorf("%s: %v, want %v", p, kinds[p], k)
+		}
+	}
+
+}
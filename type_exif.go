@@ -0,0 +1,650 @@
+package objectify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Keys of the FileObj.Meta entries set by WithEXIF.
+const (
+	// MetaCaptureTime is the time the photo was taken (EXIF DateTimeOriginal),
+	// formatted as RFC 3339 when the EXIF data records a UTC offset, or as
+	// "2006-01-02T15:04:05" (camera local time) when it does not.
+	MetaCaptureTime = "exif.capture_time"
+
+	// MetaCameraMake and MetaCameraModel are the camera's manufacturer and
+	// model, e.g. "Apple" and "iPhone 15 Pro".
+	MetaCameraMake  = "exif.make"
+	MetaCameraModel = "exif.model"
+
+	// MetaGPS is "true" if the EXIF data holds a GPS position, and "false"
+	// otherwise. The position itself is not recorded.
+	MetaGPS = "exif.gps"
+)
+
+const (
+	// exifTimeLayout is the layout of EXIF date and time values.
+	exifTimeLayout = "2006:01:02 15:04:05"
+
+	// maxHEIFBox is the largest HEIF meta box or Exif item read, to bound
+	// memory use on corrupt files.
+	maxHEIFBox = 4 << 20
+)
+
+// EXIF tag IDs read by parseEXIF.
+const (
+	tagMake               = 0x010f
+	tagModel              = 0x0110
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+)
+
+// heifBrands are the ftyp brands of HEIC/HEIF image files.
+var heifBrands = []string{"heic", "heix", "heim", "heis", "hevc", "hevx", "mif1", "msf1"}
+
+// exifExtractor is the Extractor added by WithEXIF.
+type exifExtractor struct{}
+
+// Match returns true: JPEG and HEIC files are detected by their magic bytes in
+// Extract.
+func (exifExtractor) Match(*FileObj) bool {
+	return true
+}
+
+// Extract reads the EXIF data of a JPEG or HEIC file, detected by its magic
+// bytes, and sets the MetaCaptureTime, MetaCameraMake, MetaCameraModel, and
+// MetaGPS entries of the Meta field. Files of other types, and files without
+// readable EXIF data, are left unchanged. HEIC files are only read if r
+// implements io.ReadSeeker.
+func (exifExtractor) Extract(fo *FileObj, r io.Reader) error {
+
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(12)
+
+	var tiff []byte
+	switch {
+	case bytes.HasPrefix(header, []byte{0xff, 0xd8}):
+		tiff = jpegEXIF(br)
+	case len(header) == 12 && string(header[4:8]) == "ftyp" && isHEIFBrand(string(header[8:12])):
+		if rs, ok := r.(io.ReadSeeker); ok {
+			tiff = heifEXIF(rs)
+		}
+	}
+	if tiff == nil {
+		return nil
+	}
+
+	for k, v := range parseEXIF(tiff) {
+		fo.SetMeta(k, v)
+	}
+
+	return nil
+
+}
+
+// isHEIFBrand returns true if brand is the ftyp brand of a HEIC/HEIF image.
+func isHEIFBrand(brand string) bool {
+
+	for _, b := range heifBrands {
+		if brand == b {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// jpegEXIF walks the marker segments of the JPEG image read from r and returns
+// the TIFF data of its Exif APP1 segment, or nil if it has none.
+func jpegEXIF(r *bufio.Reader) []byte {
+
+	if _, err := r.Discard(2); err != nil {
+		return nil
+	}
+
+	for {
+
+		b, err := r.ReadByte()
+		if err != nil || b != 0xff {
+			return nil
+		}
+		marker, err := r.ReadByte()
+		for err == nil && marker == 0xff {
+			marker, err = r.ReadByte()
+		}
+		if err != nil {
+			return nil
+		}
+
+		switch {
+		case marker == 0xd9 || marker == 0xda:
+			// End of image, or start of scan: no EXIF data follows.
+			return nil
+		case marker >= 0xd0 && marker <= 0xd8 || marker == 0x01:
+			// Markers without a length.
+			continue
+		}
+
+		var size [2]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil
+		}
+		n := int(binary.BigEndian.Uint16(size[:])) - 2
+		if n < 0 {
+			return nil
+		}
+
+		if marker != 0xe1 {
+			if _, err := r.Discard(n); err != nil {
+				return nil
+			}
+			continue
+		}
+
+		seg := make([]byte, n)
+		if _, err := io.ReadFull(r, seg); err != nil {
+			return nil
+		}
+		if bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return seg[6:]
+		}
+
+	}
+
+}
+
+// heifEXIF finds the Exif item of the HEIF file read from r, through the item
+// information (iinf) and item location (iloc) boxes of its meta box, and
+// returns its TIFF data, or nil if it has none.
+func heifEXIF(r io.ReadSeeker) []byte {
+
+	meta := isoBox(r, "meta", maxHEIFBox)
+	if len(meta) < 4 {
+		return nil
+	}
+
+	// meta is a full box: skip its version and flags.
+	var exifID uint32
+	var found bool
+	var offset, length uint64
+	var located bool
+	forEachBox(meta[4:], func(typ string, body []byte) {
+		if typ == "iinf" {
+			exifID, found = heifExifItem(body)
+		}
+	})
+	if !found {
+		return nil
+	}
+	forEachBox(meta[4:], func(typ string, body []byte) {
+		if typ == "iloc" {
+			offset, length, located = heifItemLocation(body, exifID)
+		}
+	})
+	if !located || length < 4 || length > maxHEIFBox {
+		return nil
+	}
+
+	if _, err := r.Seek(int64(offset), io.SeekStart); err != nil {
+		return nil
+	}
+	item := make([]byte, length)
+	if _, err := io.ReadFull(r, item); err != nil {
+		return nil
+	}
+
+	// The item starts with the offset of the TIFF header, which usually
+	// skips an "Exif\0\0" prefix.
+	skip := uint64(binary.BigEndian.Uint32(item))
+	if skip > length-4 {
+		return nil
+	}
+
+	return item[4+skip:]
+
+}
+
+// isoBox returns the body of the first top-level box of type typ in the ISO base
+// media file (HEIF or MP4) read from r, or nil if it is not found or its body is
+// larger than max bytes.
+func isoBox(r io.ReadSeeker, typ string, max uint64) []byte {
+
+	var pos int64
+	for {
+
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return nil
+		}
+
+		var hdr [16]byte
+		if _, err := io.ReadFull(r, hdr[:8]); err != nil {
+			return nil
+		}
+		size := uint64(binary.BigEndian.Uint32(hdr[:4]))
+		hdrLen := uint64(8)
+		if size == 1 {
+			if _, err := io.ReadFull(r, hdr[8:]); err != nil {
+				return nil
+			}
+			size, hdrLen = binary.BigEndian.Uint64(hdr[8:]), 16
+		}
+
+		if string(hdr[4:8]) == typ {
+			if size == 0 || size < hdrLen || size-hdrLen > max {
+				return nil
+			}
+			body := make([]byte, size-hdrLen)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil
+			}
+			return body
+		}
+
+		if size < hdrLen {
+			// A size of 0 extends the box to the end of the file.
+			return nil
+		}
+		pos += int64(size)
+
+	}
+
+}
+
+// forEachBox calls fn with the type and body of each box in b, stopping at the
+// first truncated box.
+func forEachBox(b []byte, fn func(typ string, body []byte)) {
+
+	for len(b) >= 8 {
+
+		size := uint64(binary.BigEndian.Uint32(b))
+		hdrLen := uint64(8)
+		if size == 1 && len(b) >= 16 {
+			size, hdrLen = binary.BigEndian.Uint64(b[8:]), 16
+		}
+		if size == 0 {
+			size = uint64(len(b))
+		}
+		if size < hdrLen || size > uint64(len(b)) {
+			return
+		}
+
+		fn(string(b[4:8]), b[hdrLen:size])
+		b = b[size:]
+
+	}
+
+}
+
+// heifExifItem returns the ID of the item of type "Exif" listed in the body of
+// an iinf box.
+func heifExifItem(iinf []byte) (id uint32, ok bool) {
+
+	c := &boxCursor{b: iinf}
+	version := c.uint(1)
+	c.skip(3)
+	if version == 0 {
+		c.skip(2)
+	} else {
+		c.skip(4)
+	}
+	if c.bad {
+		return 0, false
+	}
+
+	forEachBox(c.b, func(typ string, body []byte) {
+		if typ != "infe" || ok {
+			return
+		}
+		e := &boxCursor{b: body}
+		v := e.uint(1)
+		e.skip(3)
+		if v < 2 {
+			return
+		}
+		var itemID uint64
+		if v == 2 {
+			itemID = e.uint(2)
+		} else {
+			itemID = e.uint(4)
+		}
+		e.skip(2)
+		if itemType := e.bytes(4); !e.bad && string(itemType) == "Exif" {
+			id, ok = uint32(itemID), true
+		}
+	})
+
+	return id, ok
+
+}
+
+// heifItemLocation returns the file offset and length of the first extent of
+// item id, from the body of an iloc box. Items stored in an idat box or
+// constructed from other items are not supported.
+func heifItemLocation(iloc []byte, id uint32) (offset, length uint64, ok bool) {
+
+	c := &boxCursor{b: iloc}
+	version := c.uint(1)
+	c.skip(3)
+	sizes := c.uint(1)
+	offsetSize, lengthSize := int(sizes>>4), int(sizes&0xf)
+	sizes = c.uint(1)
+	baseOffsetSize, indexSize := int(sizes>>4), int(sizes&0xf)
+	if version == 0 {
+		indexSize = 0
+	}
+
+	var count uint64
+	if version < 2 {
+		count = c.uint(2)
+	} else {
+		count = c.uint(4)
+	}
+
+	for i := uint64(0); i < count && !c.bad; i++ {
+
+		var itemID uint64
+		if version < 2 {
+			itemID = c.uint(2)
+		} else {
+			itemID = c.uint(4)
+		}
+		method := uint64(0)
+		if version == 1 || version == 2 {
+			method = c.uint(2) & 0xf
+		}
+		c.skip(2)
+		base := c.uint(baseOffsetSize)
+		extents := c.uint(2)
+
+		for j := uint64(0); j < extents && !c.bad; j++ {
+			c.skip(indexSize)
+			extOffset := c.uint(offsetSize)
+			extLength := c.uint(lengthSize)
+			if j == 0 && uint32(itemID) == id && method == 0 && !c.bad {
+				return base + extOffset, extLength, true
+			}
+		}
+
+	}
+
+	return 0, 0, false
+
+}
+
+// boxCursor reads big-endian values from b. Reading past the end sets bad,
+// after which all reads return zero values.
+type boxCursor struct {
+	b   []byte
+	bad bool
+}
+
+// bytes returns the next n bytes.
+func (c *boxCursor) bytes(n int) []byte {
+
+	if c.bad || n < 0 || n > len(c.b) {
+		c.bad = true
+		return nil
+	}
+
+	v := c.b[:n]
+	c.b = c.b[n:]
+
+	return v
+
+}
+
+// skip discards the next n bytes.
+func (c *boxCursor) skip(n int) {
+	c.bytes(n)
+}
+
+// uint returns the next n bytes (0 to 8) as a big-endian unsigned integer.
+func (c *boxCursor) uint(n int) uint64 {
+
+	var v uint64
+	for _, b := range c.bytes(n) {
+		v = v<<8 | uint64(b)
+	}
+
+	return v
+
+}
+
+// tiffReader reads the IFDs of TIFF data in its byte order.
+type tiffReader struct {
+	b     []byte
+	order binary.ByteOrder
+}
+
+// tiffEntry is an entry of a TIFF IFD.
+type tiffEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// parseEXIF returns the Meta entries found in the TIFF data of an EXIF block:
+// MetaCaptureTime, MetaCameraMake, MetaCameraModel, and MetaGPS. Entries whose
+// tags are missing are omitted, except MetaGPS.
+func parseEXIF(tiff []byte) map[string]string {
+
+	if len(tiff) < 8 {
+		return nil
+	}
+
+	t := &tiffReader{b: tiff}
+	switch string(tiff[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil
+	}
+	if t.order.Uint16(tiff[2:]) != 42 {
+		return nil
+	}
+
+	ifd0 := t.ifd(t.order.Uint32(tiff[4:]))
+	meta := make(map[string]string)
+
+	if v := t.ascii(ifd0[tagMake]); v != EMPTY {
+		meta[MetaCameraMake] = v
+	}
+	if v := t.ascii(ifd0[tagModel]); v != EMPTY {
+		meta[MetaCameraModel] = v
+	}
+
+	taken, offset := t.ascii(ifd0[tagDateTime]), EMPTY
+	if e, ok := ifd0[tagExifIFD]; ok {
+		exif := t.ifd(t.long(e))
+		if v := t.ascii(exif[tagDateTimeOriginal]); v != EMPTY {
+			taken = v
+		}
+		offset = t.ascii(exif[tagOffsetTimeOriginal])
+	}
+	if ts, ok := exifTime(taken, offset); ok {
+		meta[MetaCaptureTime] = ts
+	}
+
+	gps := false
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		gps = len(t.ifd(t.long(e))) > 0
+	}
+	meta[MetaGPS] = boolString(gps)
+
+	return meta
+
+}
+
+// ifd returns the entries of the IFD at offset, by tag. It returns nil if the
+// IFD is out of bounds.
+func (t *tiffReader) ifd(offset uint32) map[uint16]tiffEntry {
+
+	if offset < 8 || uint64(offset)+2 > uint64(len(t.b)) {
+		return nil
+	}
+
+	n := int(t.order.Uint16(t.b[offset:]))
+	start := int(offset) + 2
+	if start+12*n > len(t.b) {
+		return nil
+	}
+
+	entries := make(map[uint16]tiffEntry, n)
+	for i := 0; i < n; i++ {
+
+		e := t.b[start+12*i : start+12*i+12]
+		entry := tiffEntry{
+			typ:   t.order.Uint16(e[2:]),
+			count: t.order.Uint32(e[4:]),
+		}
+
+		size := uint64(entry.count) * uint64(tiffTypeSize(entry.typ))
+		if size <= 4 {
+			entry.value = e[8 : 8+size]
+		} else {
+			off := uint64(t.order.Uint32(e[8:]))
+			if off+size > uint64(len(t.b)) {
+				continue
+			}
+			entry.value = t.b[off : off+size]
+		}
+		entries[t.order.Uint16(e)] = entry
+
+	}
+
+	return entries
+
+}
+
+// ascii returns the value of an ASCII entry, without trailing NULs and spaces.
+func (t *tiffReader) ascii(e tiffEntry) string {
+
+	if e.typ != 2 {
+		return EMPTY
+	}
+
+	return strings.TrimRight(string(e.value), "\x00 ")
+
+}
+
+// long returns the value of a LONG (or IFD) entry holding an offset.
+func (t *tiffReader) long(e tiffEntry) uint32 {
+
+	if (e.typ != 4 && e.typ != 13) || len(e.value) < 4 {
+		return 0
+	}
+
+	return t.order.Uint32(e.value)
+
+}
+
+// tiffTypeSize returns the size in bytes of a value of the TIFF field type,
+// or 0 for an unknown type.
+func tiffTypeSize(typ uint16) int {
+
+	switch typ {
+	case 1, 2, 6, 7:
+		return 1
+	case 3, 8:
+		return 2
+	case 4, 9, 11, 13:
+		return 4
+	case 5, 10, 12:
+		return 8
+	}
+
+	return 0
+
+}
+
+// exifTime converts an EXIF date and time, and an optional OffsetTime value
+// such as "+02:00", to the MetaCaptureTime format.
+func exifTime(value, offset string) (string, bool) {
+
+	t, err := time.Parse(exifTimeLayout, value)
+	if err != nil {
+		return EMPTY, false
+	}
+
+	if zoned, err := time.Parse(exifTimeLayout+"-07:00", value+offset); err == nil && offset != EMPTY {
+		return zoned.Format(time.RFC3339), true
+	}
+
+	return t.Format("2006-01-02T15:04:05"), true
+
+}
+
+// boolString returns "true" or "false".
+func boolString(b bool) string {
+
+	if b {
+		return "true"
+	}
+
+	return "false"
+
+}
+
+// CaptureTime returns the time the photo was taken, from the MetaCaptureTime
+// entry set by WithEXIF, and whether it is known. A capture time recorded
+// without a UTC offset is interpreted in the local time zone.
+func (fo *FileObj) CaptureTime() (time.Time, bool) {
+
+	v, ok := fo.Meta[MetaCaptureTime]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", v, time.Local); err == nil {
+		return t, true
+	}
+
+	return time.Time{}, false
+
+}
+
+// ByCaptureTime returns a copy of the Files sorted by the time each photo was
+// taken (see CaptureTime), falling back to the modification time for entries
+// without one, so photos can be organized by shot date even when they were
+// copied or edited later. Entries with equal times are sorted by FullPath.
+func (files Files) ByCaptureTime() Files {
+
+	sorted := make(Files, 0, len(files))
+	for _, fo := range files {
+		if fo != nil {
+			sorted = append(sorted, fo)
+		}
+	}
+
+	when := func(fo *FileObj) time.Time {
+		if t, ok := fo.CaptureTime(); ok {
+			return t
+		}
+		return fo.modTime
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ti, tj := when(sorted[i]), when(sorted[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return sorted[i].FullPath() < sorted[j].FullPath()
+	})
+
+	return sorted
+
+}
@@ -0,0 +1,126 @@
+package objectify
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// Extractor enriches a FileObj with format-specific metadata, such as the
+// page count of a document or the tags of an audio file, stored with SetMeta
+// in the Meta field. Extractors are added with WithExtractor and run during
+// every update, after the other fields have been populated from the same read
+// of the file. Each extractor receives a copy of the FileObj with its own
+// lock, so it may read its fields and call its methods; the entries it sets
+// with SetMeta are then added to the Meta field of the FileObj being updated.
+type Extractor interface {
+	// Match returns true if the extractor applies to the FileObj, e.g. by
+	// its Filename extension. It should not read the file.
+	Match(fo *FileObj) bool
+
+	// Extract reads the content of the file from r, which is positioned at
+	// the start of the file, and records metadata with fo.SetMeta. r is the
+	// open fs.File, or a temporary copy of the content for files which
+	// cannot seek, so it implements io.ReadSeeker for the OS filesystem
+	// and for most fs.FS implementations. An error is stored in the
+	// FileObj's Err field unless an earlier error is already stored.
+	Extract(fo *FileObj, r io.Reader) error
+}
+
+// extractMeta replaces the Meta field with the metadata recorded by the
+// extractors which apply to the FileObj (see addMeta). Returns the first
+// error.
+func (fo *FileObj) extractMeta() error {
+	return fo.pass(fo.addMeta)
+}
+
+// addMeta clears the Meta field and adds the extractors which apply to the
+// FileObj to the pass: the documentExtractor when Sets.DocProps is true,
+// followed by the extractors in the options.
+func (fo *FileObj) addMeta(p *contentPass) {
+
+	fo.Meta = nil
+
+	var extractors []Extractor
+	if fo.Set.DocProps {
+		extractors = append(extractors, documentExtractor{})
+	}
+	extractors = append(extractors, fo.options().extractors...)
+
+	fo.addExtractors(p, extractors)
+
+}
+
+// addExtractors adds each of the extractors which match the FileObj to the
+// pass, adding to the Meta field. Extractors are only run for readable
+// regular files.
+func (fo *FileObj) addExtractors(p *contentPass, extractors []Extractor) {
+
+	if !fo.IsExists || !fo.IsReadable || fo.info == nil || !fo.info.Mode().IsRegular() {
+		return
+	}
+
+	for _, e := range extractors {
+		if e.Match(fo) {
+			p.extractors = append(p.extractors, e)
+		}
+	}
+
+}
+
+// extract runs the extractors on the content of f, seeking it back to the
+// start before each one, unless f is at its start and the extractor is the
+// first. Every extractor runs, and the first error is returned.
+func (fo *FileObj) extract(extractors []Extractor, f fs.File, read bool) error {
+
+	var first error
+	for _, e := range extractors {
+
+		if read {
+			s, ok := f.(io.Seeker)
+			if !ok {
+				return fmt.Errorf("%s: cannot rewind the file for extractors", fo.FullPath())
+			}
+			if _, err := s.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		read = true
+
+		view := *fo
+		view.mu, view.Meta = &sync.RWMutex{}, nil
+		if err := e.Extract(&view, f); err != nil && first == nil {
+			first = err
+		}
+		for k, v := range view.Meta {
+			fo.setMeta(k, v)
+		}
+
+	}
+
+	return first
+
+}
+
+// SetMeta sets the Meta key to value, creating the Meta map if needed. It is
+// intended for Extractors; values set by hand are replaced on the next update.
+func (fo *FileObj) SetMeta(key, value string) {
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	fo.setMeta(key, value)
+
+}
+
+// setMeta sets the Meta key to value, with the lock held.
+func (fo *FileObj) setMeta(key, value string) {
+
+	if fo.Meta == nil {
+		fo.Meta = make(map[string]string)
+	}
+	fo.Meta[key] = value
+
+}
@@ -0,0 +1,105 @@
+package objectify
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ExtStat is the number and total size of the files with one extension.
+type ExtStat struct {
+
+	// Ext is the lower-case extension, including its dot (e.g. ".mp4"), or
+	// an empty string for files without an extension.
+	Ext string `json:"ext"`
+
+	Count int   `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+// ExtStats is the distribution of the entries of a scan by extension and by
+// EntMode, returned by Files.ExtStats.
+type ExtStats struct {
+
+	// Exts holds the statistics of each extension, those whose files hold the
+	// most bytes first.
+	Exts []ExtStat `json:"exts"`
+
+	// Modes is the number of entries of each EntMode, directories and
+	// symlinks included.
+	Modes map[EntMode]int `json:"modes"`
+
+	// Files is the number of files counted in Exts, and Bytes the sum of
+	// their SizeBytes.
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Ext returns the statistics of the extension ext, matched without regard to
+// case and with or without its leading dot, e.g. Ext("log"). It returns a
+// zero ExtStat for ext if no file has that extension.
+func (s *ExtStats) Ext(ext string) ExtStat {
+
+	ext = strings.ToLower(ext)
+	if ext != EMPTY && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	for _, st := range s.Exts {
+		if st.Ext == ext {
+			return st
+		}
+	}
+
+	return ExtStat{Ext: ext}
+
+}
+
+// ExtStats returns the number and total size of the files with each extension,
+// e.g. to show how much of a share is video and how much is logs, and the
+// number of entries of each EntMode. Extensions are compared without regard
+// to case. Directories and symlinks are counted in Modes only, and repeated
+// paths once. The sizes are those recorded with Sets.Size.
+func (files Files) ExtStats() *ExtStats {
+
+	s := &ExtStats{Modes: make(map[EntMode]int)}
+
+	byExt := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, fo := range files {
+
+		if fo == nil || seen[fo.FullPath()] {
+			continue
+		}
+		seen[fo.FullPath()] = true
+
+		s.Modes[fo.Mode]++
+		if fo.Mode.IsDir() || fo.isSymlink() {
+			continue
+		}
+
+		ext := strings.ToLower(filepath.Ext(fo.Filename))
+		i, ok := byExt[ext]
+		if !ok {
+			i = len(s.Exts)
+			byExt[ext] = i
+			s.Exts = append(s.Exts, ExtStat{Ext: ext})
+		}
+		s.Exts[i].Count++
+		s.Exts[i].Bytes += fo.SizeBytes
+
+		s.Files++
+		s.Bytes += fo.SizeBytes
+
+	}
+
+	sort.Slice(s.Exts, func(i, j int) bool {
+		if s.Exts[i].Bytes != s.Exts[j].Bytes {
+			return s.Exts[i].Bytes > s.Exts[j].Bytes
+		}
+		return s.Exts[i].Ext < s.Exts[j].Ext
+	})
+
+	return s
+
+}
@@ -0,0 +1,184 @@
+package objectify
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// FaultPoint is an operation at which WithFaults injects an error.
+type FaultPoint int
+
+const (
+	// FaultStat fails the Lstat of an entry, as if it did not exist or could
+	// not be stat'ed.
+	FaultStat FaultPoint = iota + 1
+
+	// FaultOpen fails the opening of an entry's content, e.g. for hashing.
+	FaultOpen
+
+	// FaultRead opens the entry's content, but fails the first read from it,
+	// as when a disk errors out in the middle of hashing a file.
+	FaultRead
+
+	// FaultReadlink fails reading the contents of a symlink, and so resolving
+	// its Target and TargetFinal.
+	FaultReadlink
+)
+
+// String returns the name of the FaultPoint, e.g. "open".
+func (p FaultPoint) String() string {
+
+	switch p {
+	case FaultStat:
+		return "stat"
+	case FaultOpen:
+		return "open"
+	case FaultRead:
+		return "read"
+	case FaultReadlink:
+		return "readlink"
+	}
+
+	return "unknown"
+
+}
+
+// Fault is an error injected by WithFaults at an operation on the paths
+// matching a pattern.
+type Fault struct {
+
+	// Point is the operation which fails.
+	Point FaultPoint
+
+	// Pattern selects the paths the operation fails for, with the syntax of
+	// filepath.Match. A pattern without a path separator is matched against
+	// the base name of the path (e.g. "*.iso"), and other patterns against
+	// the whole path (e.g. "/data/*/locked"). An empty pattern matches every
+	// path.
+	Pattern string
+
+	// Err is the error the operation fails with, wrapped in an *fs.PathError
+	// (e.g. fs.ErrPermission). It is ErrInjectedFault if nil.
+	Err error
+}
+
+// matches returns true if the Fault applies to the operation p on path.
+func (f Fault) matches(p FaultPoint, path string) bool {
+
+	if f.Point != p {
+		return false
+	}
+	if f.Pattern == EMPTY {
+		return true
+	}
+
+	name := path
+	if !strings.ContainsRune(f.Pattern, filepath.Separator) && !strings.ContainsRune(f.Pattern, '/') {
+		name = filepath.Base(path)
+	}
+	ok, _ := filepath.Match(f.Pattern, name)
+
+	return ok
+
+}
+
+// WithFaults makes the operations on OS paths fail as described by faults, so
+// applications built on objectify can test their handling of unreadable,
+// vanishing, or corrupt files without preparing such files. It is meant for
+// tests. For each operation, the first matching Fault applies; operations
+// which match none proceed as usual, through the FileSystem set by
+// WithFileSystem if any, or through the hardened opening and stat'ing of
+// WithHardenedOpen. Faults have no effect on scans of an fs.FS (see PathFS).
+func WithFaults(faults ...Fault) Option {
+	return func(o *options) {
+		o.faults = append(o.faults, faults...)
+	}
+}
+
+// faultFS is the FileSystem which injects the faults of WithFaults into the
+// operations of sys.
+type faultFS struct {
+	sys    FileSystem
+	faults []Fault
+}
+
+// fault returns the error of the first Fault for the operation p on name, as
+// an *fs.PathError, or nil.
+func (f faultFS) fault(p FaultPoint, name string) error {
+
+	for _, ft := range f.faults {
+		if ft.matches(p, name) {
+			err := ft.Err
+			if err == nil {
+				err = ErrInjectedFault
+			}
+			return &fs.PathError{Op: p.String(), Path: name, Err: err}
+		}
+	}
+
+	return nil
+
+}
+
+func (f faultFS) Lstat(name string) (fs.FileInfo, error) {
+
+	if err := f.fault(FaultStat, name); err != nil {
+		return nil, err
+	}
+
+	return f.sys.Lstat(name)
+
+}
+
+func (f faultFS) Open(name string) (fs.File, error) {
+
+	return f.openWith(name, func() (fs.File, error) {
+		return f.sys.Open(name)
+	})
+
+}
+
+// openWith opens name with open, injecting the FaultOpen and FaultRead faults.
+func (f faultFS) openWith(name string, open func() (fs.File, error)) (fs.File, error) {
+
+	if err := f.fault(FaultOpen, name); err != nil {
+		return nil, err
+	}
+
+	file, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.fault(FaultRead, name); err != nil {
+		return faultFile{File: file, err: err}, nil
+	}
+
+	return file, nil
+
+}
+
+func (f faultFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return f.sys.ReadDir(name)
+}
+
+func (f faultFS) Readlink(name string) (string, error) {
+
+	if err := f.fault(FaultReadlink, name); err != nil {
+		return EMPTY, err
+	}
+
+	return f.sys.Readlink(name)
+
+}
+
+// faultFile is a file opened by a faultFS whose reads fail with err.
+type faultFile struct {
+	fs.File
+	err error
+}
+
+func (f faultFile) Read([]byte) (int, error) {
+	return 0, f.err
+}
@@ -0,0 +1,38 @@
+package objectify
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFaultsWithHardenedOpen(t *testing.T) {
+
+	dir := t.TempDir()
+	for _, name := range []string{"ok", "locked", "bad"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := Path(dir, Sets{ChecksumSHA256: true}, WithHardenedOpen(), WithSortedPaths(),
+		WithFaults(Fault{Point: FaultOpen, Pattern: "locked"}, Fault{Point: FaultRead, Pattern: "bad"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fo := range files {
+		switch fo.Filename {
+		case "ok":
+			if fo.Err != nil || fo.SHA256 == nil {
+				t.Errorf("%s: Err = %v, SHA256 = %x, want the checksum", fo.Filename, fo.Err, fo.SHA256)
+			}
+		default:
+			if !errors.Is(fo.Err, ErrInjectedFault) {
+				t.Errorf("%s: Err = %v, want %v", fo.Filename, fo.Err, ErrInjectedFault)
+			}
+		}
+	}
+
+}
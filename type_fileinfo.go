@@ -0,0 +1,83 @@
+package objectify
+
+import (
+	"io/fs"
+	"time"
+)
+
+// fileObjInfo is the fs.FileInfo returned by FileObj.FileInfo.
+type fileObjInfo struct {
+	fo      *FileObj
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i fileObjInfo) Name() string       { return i.name }
+func (i fileObjInfo) Size() int64        { return i.size }
+func (i fileObjInfo) Mode() fs.FileMode  { return i.mode }
+func (i fileObjInfo) ModTime() time.Time { return i.modTime }
+func (i fileObjInfo) IsDir() bool        { return i.mode.IsDir() }
+
+// Sys returns the *FileObj the fileObjInfo was made from, or nil for a
+// directory synthesized by Files.FS.
+func (i fileObjInfo) Sys() any {
+
+	if i.fo == nil {
+		return nil
+	}
+
+	return i.fo
+
+}
+
+// FileInfo returns an fs.FileInfo of the FileObj as last populated, so scan
+// results can be passed to APIs which take standard library file metadata.
+// Its Name is the Filename, its Size the SizeBytes, and its Mode the mode the
+// entry was stat'ed with, or, for a FileObj without one (e.g. one read from a
+// snapshot), its Mode's FileMode with its Perm. Its Sys method returns the
+// *FileObj. The values are copied, so later updates are not reflected.
+func (fo *FileObj) FileInfo() fs.FileInfo {
+
+	mu := fo.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	mode := fo.Mode.FileMode() | fo.Perm
+	if fo.info != nil {
+		mode = fo.info.Mode()
+	}
+
+	return fileObjInfo{
+		fo:      fo,
+		name:    fo.Filename,
+		size:    fo.SizeBytes,
+		mode:    mode,
+		modTime: fo.modTime,
+	}
+
+}
+
+// DirEntry returns an fs.DirEntry of the FileObj, whose Info method returns
+// its FileInfo, e.g. to feed scan results to code written for fs.ReadDir.
+func (fo *FileObj) DirEntry() fs.DirEntry {
+
+	return fs.FileInfoToDirEntry(fo.FileInfo())
+
+}
+
+// DirEntries returns the DirEntry of every FileObj, in order. nil entries are
+// skipped.
+func (files Files) DirEntries() []fs.DirEntry {
+
+	entries := make([]fs.DirEntry, 0, len(files))
+	for _, fo := range files {
+		if fo != nil {
+			entries = append(entries, fo.DirEntry())
+		}
+	}
+
+	return entries
+
+}
@@ -1,15 +1,43 @@
 package objectify
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"hash"
 	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"sync"
 	"time"
 )
 
 type Files []*FileObj
 
 // FileObj represents a directory entry object.
+//
+// The methods of a FileObj are safe for concurrent use: Update, Force,
+// ChangeSets, the Compute methods, and the other methods which modify it hold
+// its write lock, and accessors such as ModTime, Tag, and Clone hold its read
+// lock. Reading or writing the exported fields directly is not synchronized,
+// so a FileObj shared between goroutines should be read through its methods
+// or through a Clone, which is a consistent snapshot.
+//
+// FileObjs returned by this package have their lock from the start. A FileObj
+// built as a literal elsewhere (e.g. by a store or a backend) gets its lock on
+// the first call of a method, so it is only safe for concurrent use once it
+// was handed to other goroutines after that call. UnmarshalBinary and
+// UnmarshalJSON replace the whole FileObj, and must not be called while it is
+// in use by other goroutines.
 type FileObj struct {
 
 	// UpdatedAt represents the last time this struct was updated.
@@ -26,6 +54,12 @@ type FileObj struct {
 	// SizeBytes is the size of the file in Bytes.
 	SizeBytes int64
 
+	// AllocatedBytes is the space allocated to the file on disk, from its
+	// count of blocks, when Sets.Size is true. It is less than SizeBytes for
+	// sparse files, and more for files padded to whole blocks. It is -1 where
+	// the block count is not available (e.g. on Windows or remote backends).
+	AllocatedBytes int64
+
 	// ChecksumMD5 and ChecksumSHA256 are hash byte array string-representations.
 	// MD5 and SHA256 are the hash byte arrays.
 	ChecksumMD5    string
@@ -33,20 +67,134 @@ type FileObj struct {
 	ChecksumSHA256 string
 	SHA256         []byte
 
+	// ChecksumHMAC and HMAC are the HMAC-SHA256 of the file's content, keyed
+	// with the key set by WithHMACKey. Unlike a plain checksum, an HMAC cannot
+	// be regenerated for altered content without the key.
+	ChecksumHMAC string
+	HMAC         []byte
+
+	// ChecksumDecompressed and DecompressedSHA256 are the SHA256 of the
+	// decompressed content of a compressed file, set when scanning with
+	// WithDecompressedChecksum. They are empty for other files.
+	ChecksumDecompressed string
+	DecompressedSHA256   []byte
+
+	// Chunks are the content-defined chunks of a regular file, set when
+	// scanning with WithChunks.
+	Chunks []Chunk
+
+	// Streams are the NTFS alternate data streams of a regular file on
+	// Windows, set when scanning with WithAlternateStreams. It is nil if the
+	// file has none.
+	Streams []Stream
+
+	// ImageHash is the 64-bit perceptual difference hash (dHash) of a PNG,
+	// JPEG, or GIF image, set when scanning with WithImageHash. Compare it
+	// with ImageDistance. It is nil for other files.
+	ImageHash []byte
+
+	// Width and Height are the size in pixels of a PNG, JPEG, GIF, or WebP
+	// image, read from its header when Sets.Dimensions is true. They are 0
+	// for other files. The JPEG EXIF orientation is not applied.
+	Width  int
+	Height int
+
+	// Compression is the compression format of the file's content, detected
+	// by its magic bytes when Sets.Compression is true.
+	Compression Compression
+
+	// ETag is the entity tag reported by a backend whose fs.FileInfo
+	// implements ETagInfo (e.g. an S3 object's ETag).
+	// ContentType is the media type reported by a backend whose fs.FileInfo
+	// implements ContentTypeInfo (e.g. an HTTP Content-Type header).
+	ETag        string
+	ContentType string
+
+	// Container is the FullPath of the archive holding the entry, for the
+	// virtual FileObjs of archive members expanded by WithArchiveMembers. It
+	// is empty for other entries.
+	Container string
+
 	// Mode is the EntMode of the directory entry.
 	// modeFS is returned from os.Lstat
 	Mode EntMode
 	info fs.FileInfo
 
+	// Perm holds the permission bits, including the setuid, setgid, and sticky
+	// bits. UID and GID are the numeric owner and group, or -1 where ownership
+	// is not available (e.g. on Windows or for remote backends). They are populated
+	// when Sets.Modes is true.
+	Perm fs.FileMode
+	UID  int
+	GID  int
+
 	// Target will be populated with a symlinks target path.
+	// LinkPath is the literal link contents as returned by os.Readlink,
+	// which may be relative or point to a missing (dangling) target.
 	Target      string
 	TargetFinal string
+	LinkPath    string
+
+	// ReparseTarget is the target of an NTFS junction, volume mount point, or
+	// symlink on Windows, as returned by os.Readlink, when Sets.LinkTarget is
+	// true. Depending on the Go version (see the winsymlink GODEBUG setting),
+	// junctions and mount points are reported as irregular files rather than
+	// as links, so it is the only target recorded for them.
+	ReparseTarget string
+
+	// XAttrs holds the extended attributes of the entry, by name, when
+	// Sets.XAttrs is true. It is nil if the entry has none.
+	XAttrs map[string][]byte
 
 	IsLink     bool
 	IsReadable bool
 	IsExists   bool
 
+	// Err holds the first error encountered during the last update, such as
+	// ErrSymlinkCycle when the final link target cannot be resolved.
+	Err error
+
 	Set *Sets
+
+	// Tags holds user-defined annotations, such as "uploaded=true". Tags are
+	// never set by objectify itself; they are kept by Update and copied by
+	// Clone, and are included in the JSON, binary, and protocol buffer encodings.
+	Tags map[string]string
+
+	// Meta holds metadata extracted from the file's content by Extractors, by
+	// key, such as the MetaCaptureTime set by WithEXIF. Unlike Tags, it is
+	// replaced on each update. It is nil if nothing was extracted.
+	Meta map[string]string
+
+	// opts are the scan options the FileObj was created with.
+	opts *options
+
+	// dir is the parent directory, held open while a hardened scan populates
+	// the FileObj, which the entry is stat'ed and opened relative to (see
+	// worker.objectify).
+	dir *os.File
+
+	// ctx, if set, cancels the reads of the content of the file while it is
+	// populated (see updateWithTimeout and openHash).
+	ctx context.Context
+
+	// mu guards the FileObj against concurrent calls of its methods. It is
+	// allocated when the FileObj is created, or on first use (see mutex) for
+	// FileObjs built as literals.
+	mu *sync.RWMutex
+
+	// history holds the previous states recorded by Update, oldest first
+	// (see WithHistory).
+	history []Version
+
+	// pendingChecksums and pendingTargetFinal record the fields which the
+	// lazy option deferred until they are first accessed.
+	pendingChecksums   bool
+	pendingTargetFinal bool
+
+	// statOnly makes update stop before reading the content of the file,
+	// which is then read by a hash worker (see worker.dispatch).
+	statOnly bool
 }
 
 type Action int
@@ -57,35 +205,162 @@ const (
 	F_MODES
 	F_SIZE
 	F_LINKTARGET
+	F_XATTRS
+
+	// F_ALL applies every other action.
+	F_ALL
+
+	F_DIMENSIONS
+	F_DOCPROPS
+	F_COMPRESSION
 )
 
 // newFileObj creates a new instance of FileObj based on the provided
-// path and Sets. If the path is empty, it returns nil. Otherwise,
+// path, Sets, and options. If the path is empty, it returns nil. Otherwise,
 // it splits the path into directory and file, and initializes the FileObj
 // with the extracted values. The Sets field of the FileObj is set to the
-// provided Sets. If the file exists and is readable, it calls the Update
-// method to populate additional information. Finally, it sets the timestamp
-// of the FileObj.
-func newFileObj(path string, s Sets) *FileObj {
+// provided Sets. If o is nil, default options are used. If the file exists,
+// it calls the update method to populate additional information. Finally,
+// it sets the timestamp of the FileObj.
+func newFileObj(path string, s Sets, o *options) *FileObj {
+
+	fo := newFileObjPaths(path, s, o)
+	if fo == nil {
+		return nil
+	}
+
+	return fo.populate()
+
+}
+
+// populate updates the FileObj, within the perFileTimeout option if it is set,
+// and returns it (or the updated copy, see updateWithTimeout). The reads of
+// its content are cancelled once the ctx option is done.
+func (fo *FileObj) populate() *FileObj {
+
+	o := fo.options()
+	fo.ctx = o.ctx
+	if o.perFileTimeout > 0 {
+		fo = fo.updateWithTimeout(o.perFileTimeout)
+	} else {
+		_ = fo.update()
+	}
+	fo.ctx = nil
+
+	return fo
+
+}
+
+// newFileObjPaths returns a FileObj with only its paths, Sets, and options
+// set, or nil if the path is empty.
+func newFileObjPaths(path string, s Sets, o *options) *FileObj {
 
 	if path == EMPTY {
 		return nil
 	}
 
-	dir, file := pathBaseSplit(path)
+	if o == nil {
+		o = newOptions()
+	}
 
-	fo := &FileObj{
+	var dir, file string
+	if o.fsys != nil {
+		dir, file = pathBaseSplitFS(path)
+	} else {
+		dir, file = pathBaseSplit(path)
+	}
+
+	return &FileObj{
 		Filename: file,
 		Root:     dir,
 		Set:      &s,
+		opts:     o,
+		mu:       &sync.RWMutex{},
+	}
+
+}
+
+// mutex returns the lock which guards the FileObj. FileObjs created by this
+// package get their lock when they are created; for those built as literals
+// elsewhere (e.g. by a store or backend), it is allocated on first use, which
+// is not synchronized (see FileObj).
+func (fo *FileObj) mutex() *sync.RWMutex {
+
+	if fo.mu == nil {
+		fo.mu = &sync.RWMutex{}
+	}
+
+	return fo.mu
+
+}
+
+// updateWithTimeout updates a copy of the FileObj in a separate goroutine and
+// returns it if the update finishes within d. Otherwise the FileObj is
+// returned with an ErrFileTimeout error and its UpdatedAt set: if the entry was
+// stat'ed in time, with the fields read by the stat (IsExists, IsReadable, the
+// mode, size, and so on) but without those read from its content, such as its
+// checksums. The reads of the content are cancelled, so the goroutine stops
+// at its next read and frees its hash slot, but a blocked system call cannot
+// be interrupted.
+func (fo *FileObj) updateWithTimeout(d time.Duration) *FileObj {
+
+	parent := fo.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, d)
+	defer cancel()
+
+	c := fo.Clone()
+	c.ctx = ctx
+	statted := make(chan *FileObj, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.statOnly = true
+		_ = c.update()
+		c.statOnly = false
+		statted <- c.Clone()
+		_ = c.updateContent()
+	}()
+
+	select {
+	case <-done:
+		c.ctx = nil
+		return c
+	case <-ctx.Done():
 	}
 
-	_ = fo.update()
+	select {
+	case s := <-statted:
+		s.ctx = nil
+		fo = s
+	default:
+	}
+	fo.Err = fmt.Errorf("%w: %s after %s", ErrFileTimeout, fo.FullPath(), d)
+	if err := parent.Err(); err != nil {
+		fo.Err = err
+	}
+	fo.timestamp()
 
 	return fo
 
 }
 
+// options returns the options the FileObj was created with. FileObjs created
+// by this package get their options when they are created; for those built
+// as literals elsewhere, default options are stored on first use, like the
+// lock of mutex.
+func (fo *FileObj) options() *options {
+
+	if fo.opts == nil {
+		fo.opts = newOptions()
+	}
+
+	return fo.opts
+
+}
+
 // hasPaths checks if the FileObj has valid values for Filename
 // and Root fields. Returns true if both fields are not empty,
 // otherwise returns false.
@@ -98,50 +373,128 @@ func (fo *FileObj) hasPaths() bool {
 
 }
 
-// setChecksums calculates and sets the checksums (SHA256 and MD5) of the file specified by
-// the FileObj's FullPath.
-// If Sets.ChecksumSHA256 is true, it calculates and sets the SHA256 checksum.
-// If Sets.ChecksumMD5 is true, it calculates and sets the MD5 checksum.
-// Returns an error if there is any failure in calculating the checksums.
+// setChecksums calculates and sets the checksums of the file, reading it
+// once for both (see addChecksums). Returns an error if the file cannot be
+// read.
 func (fo *FileObj) setChecksums() error {
+	return fo.pass(fo.addChecksums)
+}
 
-	var err error
+// addChecksums adds the checksums (SHA256 and MD5) requested by the Sets to
+// the pass, if the content is hashable.
+// If Sets.ChecksumSHA256 is true, the SHA256 checksum is calculated and set.
+// If Sets.ChecksumMD5 is true, the MD5 checksum is calculated and set. If the stored
+// fs.FileInfo implements MD5Info and provides a hash, that hash is used instead.
+// When a HashCache is set (see WithHashCache), digests recorded for the same size and
+// modification time are reused, and newly computed digests are recorded.
+func (fo *FileObj) addChecksums(p *contentPass) {
+
+	if !fo.hashable() {
+		return
+	}
 
-	if fo.IsExists && fo.IsReadable {
+	cache, path, size, modTime := fo.cacheKey()
 
-		if fo.Set.ChecksumSHA256 {
-			fo.SHA256, fo.ChecksumSHA256, err = getSHA256(fo.FullPath())
-			if err != nil {
-				return err
-			}
+	var cachedMD5, cachedSHA256 []byte
+	if cache != nil {
+		cachedMD5, cachedSHA256, _ = cache.GetChecksums(path, size, modTime)
+	}
+
+	var sha, md hash.Hash
+	if fo.Set.ChecksumSHA256 {
+		if cachedSHA256 != nil {
+			fo.SHA256 = cachedSHA256
+			fo.ChecksumSHA256 = fmt.Sprintf("%x", fo.SHA256)
+		} else {
+			fo.SHA256, fo.ChecksumSHA256 = nil, EMPTY
+			sha = sha256.New()
+			p.write(sha)
 		}
-		if fo.Set.ChecksumMD5 {
-			fo.MD5, fo.ChecksumMD5, err = getMD5(fo.FullPath())
-			if err != nil {
-				return err
-			}
+	}
+	if fo.Set.ChecksumMD5 {
+		if mi, ok := fo.info.(MD5Info); ok && mi.MD5() != nil {
+			fo.MD5 = mi.MD5()
+			fo.ChecksumMD5 = fmt.Sprintf("%x", fo.MD5)
+		} else if cachedMD5 != nil {
+			fo.MD5 = cachedMD5
+			fo.ChecksumMD5 = fmt.Sprintf("%x", fo.MD5)
+		} else {
+			fo.MD5, fo.ChecksumMD5 = nil, EMPTY
+			md = md5.New()
+			p.write(md)
+		}
+	}
+	if sha == nil && md == nil {
+		return
+	}
 
+	p.hash = true
+	p.then(func() {
+		if sha != nil {
+			fo.SHA256 = sha.Sum(nil)
+			fo.ChecksumSHA256 = fmt.Sprintf("%x", fo.SHA256)
+			fo.options().stats.addHashed(fo.info.Size())
+			cachedSHA256 = fo.SHA256
+		}
+		if md != nil {
+			fo.MD5 = md.Sum(nil)
+			fo.ChecksumMD5 = fmt.Sprintf("%x", fo.MD5)
+			fo.options().stats.addHashed(fo.info.Size())
+			cachedMD5 = fo.MD5
 		}
+		if cache != nil {
+			cache.PutChecksums(path, size, modTime, cachedMD5, cachedSHA256)
+		}
+	})
+
+}
+
+// hashable returns true if the content of the FileObj can be hashed: it
+// exists, is readable, and is not a symlink in hardened mode.
+func (fo *FileObj) hashable() bool {
+	return fo.IsExists && fo.IsReadable && !(fo.options().hardened && fo.isSymlink())
+}
+
+// addHMAC adds the HMAC-SHA256 of the file's content to the pass when a key
+// was set with WithHMACKey and the file is readable. Otherwise, the HMAC
+// fields are cleared.
+func (fo *FileObj) addHMAC(p *contentPass) {
+
+	fo.HMAC, fo.ChecksumHMAC = nil, EMPTY
+
+	key := fo.options().hmacKey
+	if key == nil || !fo.hashable() {
+		return
 	}
 
-	return nil
+	mac := hmac.New(sha256.New, key)
+	p.hash = true
+	p.write(mac)
+	p.then(func() {
+		fo.HMAC = mac.Sum(nil)
+		fo.ChecksumHMAC = fmt.Sprintf("%x", fo.HMAC)
+		fo.options().stats.addHashed(fo.info.Size())
+	})
 
 }
 
-// setEntMode updates the Mode, info, modTime, and IsLink fields of the FileObj
-// based on the values of IsExists, IsReadable, and Sets.Modes.
-// If IsExists is true and IsReadable is true, it sets the Mode field by calling getEntMode
-// and assigns the returned value to both the Mode and info fields.
+// setEntMode updates the Mode, modTime, Perm, UID, GID, and IsLink fields of the FileObj
+// based on the values of IsExists and Sets.Modes.
+// If IsExists is true, it sets the Mode field by calling getEntModeWithInfo
+// with the stored fs.FileInfo, so entries which are not readable (e.g. dangling
+// symlinks) still report their mode.
 // It also updates the modTime field by retrieving the modification time from info.
 // If Sets.Modes is true and Mode is EntModeLink, it sets the IsLink field to true.
 // Returns the value of the Mode field.
 func (fo *FileObj) setEntMode() EntMode {
 
-	if fo.IsExists && fo.IsReadable {
+	if fo.IsExists && fo.info != nil {
 
 		if fo.Set.Modes {
 			fo.Mode = getEntModeWithInfo(fo.info.Mode())
 			fo.modTime = fo.info.ModTime()
+			fo.Perm = fo.info.Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+			fo.UID, fo.GID = ownerIDs(fo.info)
 		}
 
 		if fo.Set.Modes && (fo.Mode == EntModeLink) {
@@ -155,33 +508,42 @@ func (fo *FileObj) setEntMode() EntMode {
 }
 
 // setPrelims updates preliminary information about the FileObj instance.
-// It sets the info field with the return value of attemptStat method, and
-// updates the IsExists and IsReadable fields based on the presence and
-// readability of the file.
+// It issues a single os.Lstat (or fs.Stat for an fs.FS) through statPath, stores the
+// fs.FileInfo in the info field so the other setters can reuse it, and records the
+// modification time. IsExists is set when the stat succeeds and IsReadable is derived
+// from the permission bits and ownership in the same fs.FileInfo (see isReadable), so
+// the file is not opened. Entries in an fs.FS are always considered readable.
 // Returns true if the FileObj has valid paths, the file exists and is readable,
 // otherwise returns false.
 func (fo *FileObj) setPrelims() bool {
 
 	var ok bool
 
-	if fo.hasPaths() {
+	fo.IsExists = false
+	fo.IsReadable = false
 
-		fo.info, ok = attemptStat(fo.FullPath())
-		if !ok {
-			fo.IsExists = false
-			fo.IsReadable = false
-		}
-
-		if isReadable(fo.FullPath()) {
-			fo.IsExists = true
-			fo.IsReadable = true
-		}
+	if !fo.hasPaths() {
+		return false
+	}
 
-	} else {
+	fo.info, ok = fo.stat()
+	fo.options().stats.addSyscalls(1)
+	if !ok {
+		return false
+	}
 
-		fo.IsExists = false
-		fo.IsReadable = false
+	fo.IsExists = true
+	fo.IsReadable = fo.options().fsys != nil || isReadable(fo.options().sys(), fo.ioPath(), fo.info)
+	if fo.options().fsys == nil && fo.info.Mode()&os.ModeSymlink != 0 {
+		fo.options().stats.addSyscalls(1)
+	}
+	fo.modTime = fo.info.ModTime()
 
+	if ei, ok := fo.info.(ETagInfo); ok {
+		fo.ETag = ei.ETag()
+	}
+	if ci, ok := fo.info.(ContentTypeInfo); ok {
+		fo.ContentType = ci.ContentType()
 	}
 
 	return fo.IsExists && fo.IsReadable
@@ -189,170 +551,614 @@ func (fo *FileObj) setPrelims() bool {
 }
 
 // setReadable sets the IsReadable field of the FileObj by calling the
-// isReadable function with the FileObj's FullPath. The result is assigned
-// to the IsReadable field and returned.
+// isReadable function with the FileObj's FullPath and stored fs.FileInfo.
+// The result is assigned to the IsReadable field and returned.
 func (fo *FileObj) setReadable() bool {
 
-	fo.IsReadable = isReadable(fo.FullPath())
+	fo.IsReadable = isReadable(fo.options().sys(), fo.ioPath(), fo.info)
 
 	return fo.IsReadable
 
 }
 
 // setSize sets the size of the FileObj if Sets.Size is true.
-// If fo.modeFS is nil, attemptStat is called to retrieve fs.FileInfo.
+// The fs.FileInfo collected by setPrelims is reused. If fo.info is nil,
+// attemptStat is called to retrieve fs.FileInfo.
 // If info is still nil, fo.SizeBytes is set to 0 and the function returns.
-// Otherwise, fo.SizeBytes is set to the size provided by info.
+// Otherwise, fo.SizeBytes is set to the size provided by info, and
+// fo.AllocatedBytes to its allocated size (-1 if it is not available).
 func (fo *FileObj) setSize() {
 
 	if fo.Set.Size {
 
 		if fo.info == nil {
-			fo.info, _ = attemptStat(fo.FullPath())
+			fo.info, _ = fo.stat()
 		}
 
 		if fo.info == nil {
-			fo.SizeBytes = 0
+			fo.SizeBytes, fo.AllocatedBytes = 0, 0
 			return
 		}
 
 		fo.SizeBytes = fo.info.Size()
+		fo.AllocatedBytes = -1
+		if n, ok := allocatedSize(fo.info); ok {
+			fo.AllocatedBytes = n
+		}
 
 	}
 
 }
 
-func (fo *FileObj) setTargets() {
+// setLinkPath sets the LinkPath field to the literal target of the symlink,
+// as returned by os.Readlink, if Sets.LinkTarget is true and the stored
+// fs.FileInfo describes a symlink. Unlike setTargets, it does not require the
+// target to exist, so dangling links are reported as well. On Windows, the
+// ReparseTarget of symlinks, junctions, and mount points is set as well.
+func (fo *FileObj) setLinkPath() {
 
-	if fo.IsExists && fo.IsReadable && fo.IsLink {
+	if !fo.Set.LinkTarget || fo.info == nil {
+		return
+	}
+
+	if fo.info.Mode()&os.ModeSymlink != 0 {
+		fo.LinkPath, _ = getsLinkPath(fo.options().sys(), fo.ioPath())
+		fo.options().stats.addSyscalls(1)
+	}
+	if fo.options().onOS() && fo.info.Mode()&(os.ModeSymlink|os.ModeIrregular) != 0 {
+		fo.ReparseTarget, _ = reparseTarget(fo.ioPath())
+	}
+
+}
+
+// setTargets sets the Target and TargetFinal fields of a symlink when
+// Sets.LinkTarget or Sets.LinkTargetFinal is true. The link does not need to be
+// readable. TargetFinal is resolved one hop at a time, up to the configured
+// maximum number of hops. Returns ErrSymlinkCycle (wrapped) if the link chain
+// loops or is too long, or any other error encountered while resolving it.
+func (fo *FileObj) setTargets() error {
+
+	var err error
+
+	if fo.IsExists && fo.IsLink {
 
 		if fo.Set.LinkTarget || fo.Set.LinkTargetFinal {
 
-			if !fo.Set.Modes {
-				fo.Mode, fo.info = getEntMode(fo.FullPath())
+			if !fo.Set.Modes && fo.info != nil {
+				fo.Mode = getEntModeWithInfo(fo.info.Mode())
 			}
 
 		}
 
 		if fo.Set.LinkTarget {
-			fo.Target, _ = getsTarget(fo.FullPath())
+			fo.Target, _ = getsTarget(fo.options().sys(), fo.ioPath())
+			fo.options().stats.addSyscalls(1)
 		}
 
 		if fo.Set.LinkTargetFinal {
-			fo.TargetFinal, _ = getsFinalTarget(fo.FullPath(), fo.info)
+			if fo.options().lazy {
+				fo.TargetFinal, fo.pendingTargetFinal = EMPTY, true
+			} else {
+				fo.TargetFinal, err = getsFinalTarget(fo.options().sys(), fo.ioPath(), fo.info, fo.options().maxLinkHops)
+				fo.options().stats.addSyscalls(2)
+			}
 		}
 
 	}
 
+	return err
+
+}
+
+// setXAttrs sets the XAttrs field when Sets.XAttrs is true and the entry is on
+// the OS filesystem. The attributes of a symlink itself are read, not those of
+// its target. Returns an error if the attributes cannot be read.
+func (fo *FileObj) setXAttrs() error {
+
+	fo.XAttrs = nil
+
+	if !fo.Set.XAttrs || !fo.IsExists || !fo.options().onOS() {
+		return nil
+	}
+
+	var err error
+	fo.XAttrs, err = readXAttrs(fo.ioPath())
+	fo.options().stats.addSyscalls(int64(1 + len(fo.XAttrs)))
+
+	return err
+
 }
 
 // timestamp sets the UpdatedAt field of the FileObj to the current
-// time and returns it.
+// time of its Clock (see WithClock) and returns it.
 func (fo *FileObj) timestamp() time.Time {
 
-	fo.UpdatedAt = time.Now()
+	fo.UpdatedAt = fo.options().now()
 	return fo.UpdatedAt
 
 }
 
 // update updates the FileObj by performing the following actions:
-//   - If setPrelims (which sets info, checks exists and readability) passes, then:
+//   - Calls setPrelims (which sets info, checks exists and readability), then
+//     if the entry exists:
 //   - Calls setEntMode to update the Mode, modTime, and IsLink fields
 //   - Calls setSize to update the SizeBytes field based on the file size
 //   - Calls setTargets to update the Target and/or TargetFinal fields if
 //     Sets.LinkTarget/Sets.LinkTargetFinal is true
+//   - Calls setLinkPath to update the LinkPath field if Sets.LinkTarget is true
 //   - Calls setChecksums to update the checksums (SHA256 and MD5) if file exists and
 //     is readable
 //   - Calls timestamp to update the UpdatedAt field to the current time
 //
-// Returns nil (for now).
-func (fo *FileObj) update() error {
+// The first error returned by setTargets or setChecksums is stored in the Err
+// field and returned. A panic while populating the FileObj (for example, from a
+// HashCache) is recovered and stored as an ErrPanic error instead.
+func (fo *FileObj) update() (err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			fo.Err = fmt.Errorf("%w: %s: %v", ErrPanic, fo.FullPath(), r)
+			err = fo.Err
+		}
+	}()
+
+	fo.Err = nil
+	fo.pendingChecksums, fo.pendingTargetFinal = false, false
 
-	if fo.setPrelims() {
+	_ = fo.setPrelims()
+
+	if fo.IsExists {
 
 		_ = fo.setEntMode()
 		fo.setSize()
-		fo.setTargets()
-		_ = fo.setChecksums()
-		fo.timestamp()
+		fo.keepErr(fo.setTargets())
+		fo.setLinkPath()
+		fo.keepErr(fo.setXAttrs())
+		fo.keepErr(fo.setStreams())
+		if !fo.statOnly {
+			fo.readContent()
+		}
 
 	}
 
-	return nil
+	return fo.Err
+
+}
+
+// readContent sets the fields read from the content of the file (the
+// checksums, unless the lazy option defers them, the dimensions, compression,
+// image hash, and extracted metadata) from a single read of the file (see
+// pass), then the timestamp.
+func (fo *FileObj) readContent() {
+
+	fields := []func(p *contentPass){fo.addDimensions, fo.addCompression, fo.addImageHash, fo.addMeta}
+	if fo.options().lazy {
+		fo.deferChecksums()
+	} else {
+		fields = append(fields, fo.addChecksums, fo.addHMAC, fo.addDecompressedChecksum, fo.addChunks)
+	}
+	fo.keepErr(fo.pass(fields...))
+	fo.timestamp()
+
+}
+
+// updateContent calls readContent for a FileObj updated with statOnly set,
+// if it exists, and returns the Err field.
+func (fo *FileObj) updateContent() (err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			fo.Err = fmt.Errorf("%w: %s: %v", ErrPanic, fo.FullPath(), r)
+			err = fo.Err
+		}
+	}()
+
+	if fo.IsExists {
+		fo.readContent()
+	}
+
+	return fo.Err
+
+}
+
+// keepErr stores err in the Err field unless an earlier error is already stored.
+func (fo *FileObj) keepErr(err error) {
+
+	if err != nil && fo.Err == nil {
+		fo.Err = err
+	}
 
 }
 
 // ChangeSets overwrites the Set field with a new Sets object.
 func (fo *FileObj) ChangeSets(s Sets) {
 
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
 	fo.Set = &s
 
 }
 
-// Force applies the specified action to the FileObj by changing its sets and
+// Force applies the specified actions to the FileObj by changing its sets and
 // calling the corresponding helper methods. The original sets of the FileObj are
-// stored temporarily and restored after applying the action.
+// stored temporarily and restored after applying each action.
 // The available actions are:
-//   - F_CHECKSUM_MD5: Changes the sets to enable checksum MD5 calculation and
-//     calls the setChecksums() method.
-//   - F_CHECKSUM_SHA256: Changes the sets to enable checksum SHA256 calculation
-//     and calls the setChecksums() method.
+//   - F_CHECKSUM_MD5: Calculates the MD5 checksum, as ComputeMD5 does.
+//   - F_CHECKSUM_SHA256: Calculates the SHA256 checksum, as ComputeSHA256 does.
 //   - F_MODES: Changes the sets to enable mode and file info retrieval and calls
 //     the setEntMode() method.
 //   - F_SIZE: Changes the sets to enable size calculation and calls the setSize()
 //     method.
 //   - F_LINKTARGET: Changes the sets to enable link target retrieval and calls
-//     the setTargets() method.
-func (fo *FileObj) Force(a Action) {
+//     the setTargets() and setLinkPath() methods.
+//   - F_XATTRS: Changes the sets to enable extended attribute retrieval and calls
+//     the setXAttrs() method.
+//   - F_DIMENSIONS: Changes the sets to enable image dimension retrieval and
+//     calls the setDimensions() method.
+//   - F_DOCPROPS: Reads the document properties into the Meta field, keeping
+//     its other entries.
+//   - F_COMPRESSION: Changes the sets to enable compression detection and calls
+//     the setCompression() method.
+//   - F_ALL: Applies all of the above.
+//
+// Every action is applied even if an earlier one fails. The errors are returned
+// joined with errors.Join, or nil if all actions succeeded. Calls written for
+// the earlier single-action form, such as fo.Force(F_SIZE), still compile.
+func (fo *FileObj) Force(actions ...Action) error {
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	return fo.forceActions(actions)
+
+}
+
+// forceActions applies the actions for Force, with the lock held.
+func (fo *FileObj) forceActions(actions []Action) error {
+
+	var errs []error
+	for _, a := range actions {
+
+		if a == F_ALL {
+			errs = append(errs, fo.forceActions([]Action{F_CHECKSUM_MD5, F_CHECKSUM_SHA256, F_MODES, F_SIZE, F_LINKTARGET, F_XATTRS, F_DIMENSIONS, F_DOCPROPS, F_COMPRESSION}))
+			continue
+		}
+
+		errs = append(errs, fo.force(a))
+
+	}
+
+	return errors.Join(errs...)
+
+}
+
+// force applies a single action for Force.
+func (fo *FileObj) force(a Action) error {
+
+	var err error
 
 	originalSets := fo.Set
 
 	switch a {
 	case F_CHECKSUM_MD5:
 
-		fo.ChangeSets(Sets{ChecksumMD5: true})
-		_ = fo.setChecksums()
+		err = fo.compute(Sets{ChecksumMD5: true})
 
 	case F_CHECKSUM_SHA256:
 
-		fo.ChangeSets(Sets{ChecksumSHA256: true})
-		_ = fo.setChecksums()
+		err = fo.compute(Sets{ChecksumSHA256: true})
 
 	case F_MODES:
 
-		fo.ChangeSets(Sets{Modes: true})
+		fo.Set = &Sets{Modes: true}
 		_ = fo.setEntMode()
 
 	case F_SIZE:
 
-		fo.ChangeSets(Sets{Size: true})
+		fo.Set = &Sets{Size: true}
 		fo.setSize()
 
 	case F_LINKTARGET:
 
-		fo.ChangeSets(Sets{LinkTarget: true})
-		fo.setTargets()
+		fo.Set = &Sets{LinkTarget: true}
+		err = fo.setTargets()
+		fo.setLinkPath()
+
+	case F_XATTRS:
+
+		fo.Set = &Sets{XAttrs: true}
+		err = fo.setXAttrs()
+
+	case F_DIMENSIONS:
+
+		fo.Set = &Sets{Dimensions: true}
+		err = fo.setDimensions()
+
+	case F_DOCPROPS:
+
+		fo.Set = &Sets{DocProps: true}
+		err = fo.pass(func(p *contentPass) {
+			fo.addExtractors(p, []Extractor{documentExtractor{}})
+		})
+
+	case F_COMPRESSION:
+
+		fo.Set = &Sets{Compression: true}
+		err = fo.setCompression()
+
+	default:
+
+		err = fmt.Errorf("unknown action: %d", a)
+
+	}
+
+	fo.Set = originalSets
+
+	return err
+
+}
+
+// ComputeSHA256 calculates the SHA256 checksum of the file regardless of the
+// Sets, stores it in the SHA256 and ChecksumSHA256 fields, and returns it as a
+// hexadecimal string. Unlike Force, it returns an error if the file does not
+// exist, is not readable, or cannot be read.
+func (fo *FileObj) ComputeSHA256() (string, error) {
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := fo.compute(Sets{ChecksumSHA256: true}); err != nil {
+		return EMPTY, err
+	}
+
+	return fo.ChecksumSHA256, nil
+
+}
+
+// ComputeMD5 calculates the MD5 checksum of the file regardless of the Sets,
+// stores it in the MD5 and ChecksumMD5 fields, and returns it as a hexadecimal
+// string. See ComputeSHA256.
+func (fo *FileObj) ComputeMD5() (string, error) {
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
 
+	if err := fo.compute(Sets{ChecksumMD5: true}); err != nil {
+		return EMPTY, err
 	}
 
+	return fo.ChecksumMD5, nil
+
+}
+
+// compute runs setChecksums with the specified Sets, restoring the original Sets
+// afterwards. It returns fs.ErrNotExist or fs.ErrPermission (wrapped with the
+// path) if the file does not exist or is not readable.
+func (fo *FileObj) compute(s Sets) error {
+
+	switch {
+	case !fo.IsExists:
+		return fmt.Errorf("%w: %s", fs.ErrNotExist, fo.FullPath())
+	case !fo.IsReadable:
+		return fmt.Errorf("%w: %s", fs.ErrPermission, fo.FullPath())
+	}
+
+	originalSets := fo.Set
+	fo.Set = &s
+	err := fo.setChecksums()
 	fo.Set = originalSets
 
+	return err
+
 }
 
 // FullPath returns the full path of the FileObj by joining the Root and Filename.
-// Utilizes filepath.Join to combine the two components.
+// Utilizes filepath.Join to combine the two components, or path.Join if the
+// FileObj was read from an fs.FS.
 func (fo *FileObj) FullPath() string {
+
+	if fo.options().fsys != nil {
+		return path.Join(fo.Root, fo.Filename)
+	}
+
 	return filepath.Join(fo.Root, fo.Filename)
+
+}
+
+// stat returns the fs.FileInfo of the entry without following a symlink,
+// relative to the held parent directory if there is one, or through statPath.
+// The faults of WithFaults apply either way.
+func (fo *FileObj) stat() (fs.FileInfo, bool) {
+
+	if fo.dir != nil {
+		if (faultFS{faults: fo.options().faults}).fault(FaultStat, fo.ioPath()) != nil {
+			return nil, false
+		}
+		info, err := lstatAt(fo.dir, fo.Filename)
+		return info, err == nil
+	}
+
+	return fo.options().statPath(fo.ioPath())
+
+}
+
+// open opens the content of the entry. Relative to the held parent directory,
+// it is opened with openAt and must be the file stat'ed by setPrelims;
+// otherwise it is opened by its ioPath with the options' open. The faults of
+// WithFaults apply either way.
+func (fo *FileObj) open() (fs.File, error) {
+
+	if fo.dir != nil {
+		return faultFS{faults: fo.options().faults}.openWith(fo.ioPath(), func() (fs.File, error) {
+			return openAt(fo.dir, fo.Filename, fo.info)
+		})
+	}
+
+	return fo.options().open(fo.ioPath())
+
+}
+
+// openHash opens the content of the entry with open to read it whole, e.g.
+// for hashing (see hashFile).
+func (fo *FileObj) openHash() (fs.File, error) {
+
+	f, err := fo.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return fo.hashFile(f), nil
+
+}
+
+// hashFile wraps the open file f to read it whole, reading it in chunks of
+// the readBuffer option if it is set, and through the shared io_uring if the
+// ioUring option is set and it is available. If the FileObj's ctx is set,
+// reads fail once it is done, including those waiting on the ring. Closing the returned file closes f.
+func (fo *FileObj) hashFile(f fs.File) fs.File {
+
+	o := fo.options()
+	size := o.readBuffer
+	if o.ioUring {
+		ctx := fo.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if rf, ok := withRing(f, ctx); ok {
+			f = rf
+			if size == 0 {
+				size = ringReadSize
+			}
+		}
+	}
+	if fo.ctx != nil {
+		f = ctxFile{File: f, ctx: fo.ctx}
+	}
+	if size == 0 {
+		return f
+	}
+
+	return bufferedFile{File: f, r: bufio.NewReaderSize(f, size)}
+
+}
+
+// bufferedFile is a file whose reads go through r.
+type bufferedFile struct {
+	fs.File
+	r *bufio.Reader
+}
+
+func (f bufferedFile) Read(p []byte) (int, error) {
+	return f.r.Read(p)
+}
+
+// ctxFile is a file whose reads fail with the error of ctx once it is done.
+type ctxFile struct {
+	fs.File
+	ctx context.Context
+}
+
+func (f ctxFile) Read(p []byte) (int, error) {
+
+	if err := f.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return f.File.Read(p)
+
+}
+
+// ioPath returns the path the FileObj is read from: its FullPath, resolved
+// against the scan root if the Root is relative to it (see WithRelativePaths).
+func (fo *FileObj) ioPath() string {
+
+	o := fo.options()
+	switch {
+	case o.scanRoot == EMPTY:
+		return fo.FullPath()
+	case o.fsys != nil:
+		return path.Join(o.scanRoot, fo.FullPath())
+	case filepath.IsAbs(fo.Root):
+		return fo.FullPath()
+	}
+
+	return filepath.Join(o.scanRoot, fo.FullPath())
+
+}
+
+// SameFileAs returns true if the FileObj and other are the same file, e.g. two
+// hard links to one inode, as reported by os.SameFile for the fs.FileInfo
+// recorded when each was last populated. Symlinks are compared themselves, not
+// their targets. It returns false if either has no recorded fs.FileInfo (e.g.
+// FileObjs read from a snapshot), or if they cannot be compared (e.g. entries
+// of an fs.FS whose fs.FileInfo does not come from the os package).
+func (fo *FileObj) SameFileAs(other *FileObj) bool {
+
+	if fo == nil || other == nil {
+		return false
+	}
+
+	info, otherInfo := fo.statInfo(), other.statInfo()
+	if info == nil || otherInfo == nil {
+		return false
+	}
+
+	if os.SameFile(info, otherInfo) {
+		return true
+	}
+
+	// The fs.FileInfo of an entry stat'ed by a hardened scan is not an os
+	// type, so its device and inode are compared instead.
+	a, okA := fileID(info)
+	b, okB := fileID(otherInfo)
+
+	return okA && okB && a == b
+
+}
+
+// statInfo returns the fs.FileInfo recorded when the FileObj was last
+// populated, under its read lock.
+func (fo *FileObj) statInfo() fs.FileInfo {
+
+	mu := fo.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return fo.info
+
 }
 
 // HasChanged checks if the file specified by FileObj has been modified since
 // its last update. It returns true if the file exists, is readable, and its
-// modification time is after the last update time. Otherwise, it returns false.
+// modification time is after the last update time, or if the last update timed
+// out (see WithPerFileTimeout), so Update fills in the missing fields.
+// Otherwise, it returns false.
 func (fo *FileObj) HasChanged() bool {
 
+	mu := fo.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return fo.hasChanged()
+
+}
+
+// hasChanged reports whether the file has changed for HasChanged and Update,
+// with the lock held.
+func (fo *FileObj) hasChanged() bool {
+
+	if errors.Is(fo.Err, ErrFileTimeout) {
+		return true
+	}
+
 	if fo.IsExists && fo.IsReadable {
 
-		info, ok := attemptStat(fo.FullPath())
+		info, ok := fo.options().statPath(fo.ioPath())
 		if !ok {
 			return false
 		}
@@ -365,27 +1171,239 @@ func (fo *FileObj) HasChanged() bool {
 
 }
 
+// ModTime returns the modification time of the directory entry, as recorded
+// during the last update. If a TTL was set with WithTTL and has expired, the
+// FileObj is updated first.
+func (fo *FileObj) ModTime() time.Time {
+
+	fo.refresh()
+
+	mu := fo.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return fo.modTime
+
+}
+
+// Fresh updates all fields if a TTL was set with WithTTL and more than the TTL
+// has passed since UpdatedAt, then returns the FileObj. Use it before reading
+// fields directly, e.g. fo.Fresh().SizeBytes; accessor methods such as ModTime
+// and SizeString call it themselves. Fields read directly are not guarded
+// against concurrent updates (see FileObj).
+func (fo *FileObj) Fresh() *FileObj {
+
+	fo.refresh()
+
+	return fo
+
+}
+
+// refresh re-runs update if the ttl option is set and more than the TTL has
+// passed since UpdatedAt.
+func (fo *FileObj) refresh() {
+
+	o := fo.options()
+	if o.ttl <= 0 {
+		return
+	}
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	if o.now().Sub(fo.UpdatedAt) > o.ttl {
+		_ = fo.update()
+	}
+
+}
+
+// SetModTime sets the modification time recorded for the directory entry. It is
+// intended for backends and converters which restore a FileObj from stored metadata.
+func (fo *FileObj) SetModTime(t time.Time) {
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	fo.modTime = t
+
+}
+
 // SecondsSinceUpdatedAt returns the number of seconds since the UpdatedAt time of
 // the FileObj.
 func (fo *FileObj) SecondsSinceUpdatedAt() int64 {
-	return int64(time.Now().Sub(fo.UpdatedAt).Seconds())
+	return int64(fo.options().now().Sub(fo.UpdatedAt).Seconds())
 }
 
-// SizeString returns the formatted string representation of the size in bytes.
-// Converts the given size in bytes to a human-readable format (e.g., KB, MB, GB, etc.).
+// SizeString returns the formatted string representation of the size in bytes,
+// in binary units with two decimal places (e.g. "1.50 MiB"). See FormatSize.
 func (fo *FileObj) SizeString() string {
-	return sizeString(fo.SizeBytes)
+	return FormatSize(fo.freshSize(), SizeBinary, 2)
+}
+
+// SizeStringSI returns the formatted string representation of the size in bytes,
+// in decimal (SI) units with two decimal places (e.g. "1.57 MB"). See FormatSize.
+func (fo *FileObj) SizeStringSI() string {
+	return FormatSize(fo.freshSize(), SizeSI, 2)
+}
+
+// freshSize refreshes the FileObj and returns its SizeBytes.
+func (fo *FileObj) freshSize() int64 {
+
+	fo.refresh()
+
+	mu := fo.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return fo.SizeBytes
+
+}
+
+// TargetObj objectifies the final target of the symlink represented by the FileObj,
+// using the same Sets and options, so the link and its destination can be compared.
+// TargetFinal is used if it has been populated; otherwise the target is resolved.
+// Returns ErrNotLink if the FileObj is not a symlink, or the error encountered while
+// resolving the target (e.g. ErrSymlinkCycle or a dangling link).
+func (fo *FileObj) TargetObj() (*FileObj, error) {
+
+	mu := fo.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if !fo.IsExists || fo.info == nil || fo.info.Mode()&os.ModeSymlink == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotLink, fo.FullPath())
+	}
+
+	target := fo.TargetFinal
+	if target == EMPTY {
+
+		var err error
+		target, err = getsFinalTarget(fo.options().sys(), fo.ioPath(), fo.info, fo.options().maxLinkHops)
+		if err != nil {
+			return nil, err
+		}
+
+	}
+
+	return newFileObj(target, *fo.Set, fo.options()), nil
+
+}
+
+// Clone returns a deep copy of the FileObj, including its Sets, checksums, Chunks,
+// XAttrs, and Tags. The clone keeps the scan options of the original, and has
+// a lock of its own.
+func (fo *FileObj) Clone() *FileObj {
+
+	mu := fo.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	c := *fo
+	c.mu = &sync.RWMutex{}
+
+	c.MD5 = bytes.Clone(fo.MD5)
+	c.SHA256 = bytes.Clone(fo.SHA256)
+	c.HMAC = bytes.Clone(fo.HMAC)
+	c.DecompressedSHA256 = bytes.Clone(fo.DecompressedSHA256)
+	c.ImageHash = bytes.Clone(fo.ImageHash)
+	if fo.Chunks != nil {
+		c.Chunks = make([]Chunk, len(fo.Chunks))
+		for i, ch := range fo.Chunks {
+			ch.SHA256 = bytes.Clone(ch.SHA256)
+			c.Chunks[i] = ch
+		}
+	}
+	if fo.Set != nil {
+		s := *fo.Set
+		c.Set = &s
+	}
+	if fo.Streams != nil {
+		c.Streams = make([]Stream, len(fo.Streams))
+		for i, st := range fo.Streams {
+			st.SHA256 = bytes.Clone(st.SHA256)
+			c.Streams[i] = st
+		}
+	}
+	c.history = slices.Clone(fo.history)
+	if fo.Tags != nil {
+		c.Tags = maps.Clone(fo.Tags)
+	}
+	if fo.Meta != nil {
+		c.Meta = maps.Clone(fo.Meta)
+	}
+	if fo.XAttrs != nil {
+		c.XAttrs = make(map[string][]byte, len(fo.XAttrs))
+		for k, v := range fo.XAttrs {
+			c.XAttrs[k] = bytes.Clone(v)
+		}
+	}
+
+	return &c
+
+}
+
+// SetTag sets the Tag key to value, creating the Tags map if needed.
+func (fo *FileObj) SetTag(key, value string) {
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	if fo.Tags == nil {
+		fo.Tags = make(map[string]string)
+	}
+	fo.Tags[key] = value
+
+}
+
+// Tag returns the value of the Tag key, and whether it is set.
+func (fo *FileObj) Tag(key string) (string, bool) {
+
+	mu := fo.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	v, ok := fo.Tags[key]
+
+	return v, ok
+
+}
+
+// URI returns the full path of the FileObj as a percent-encoded file:// URL,
+// e.g. file:///srv/data/my%20file.txt. For a FileObj read from an fs.FS, which
+// has no absolute path, the percent-encoded slash-separated path is returned as
+// a relative URI reference instead.
+func (fo *FileObj) URI() string {
+
+	if fo.options().fsys != nil {
+		u := url.URL{Path: fo.ioPath()}
+		return u.String()
+	}
+
+	return fileURI(fo.ioPath())
+
 }
 
 // Update checks if the file specified by FileObj has been
 // modified since its last update. If it has changed, and
 // the file exists, is readable, and its modification time
 // is after the last update time, Update calls update.
+// Concurrent calls are serialized, so the file is read once.
+// With WithHistory, the state being replaced is recorded first.
 func (fo *FileObj) Update() *FileObj {
 
-	if fo.HasChanged() {
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	if fo.hasChanged() {
 
+		old := auditChecksum(fo)
+		fo.recordVersion()
 		_ = fo.update()
+		fo.audit(old)
 
 	}
 
@@ -400,9 +1418,22 @@ func (fo *FileObj) DebugOut() {
 	fmt.Printf("Filename: %s\nRoot: %s\n", fo.Filename, fo.Root)
 	fmt.Printf("Size: %s\n", fo.SizeString())
 	fmt.Printf("ChecksumMD5: %s\nChecksumSHA256: %s\n", fo.ChecksumMD5, fo.ChecksumSHA256)
+	fmt.Printf("ChecksumHMAC: %s\n", fo.ChecksumHMAC)
+	fmt.Printf("ChecksumDecompressed: %s\n", fo.ChecksumDecompressed)
+	fmt.Printf("ImageHash: %x\n", fo.ImageHash)
+	fmt.Printf("Width: %d\nHeight: %d\n", fo.Width, fo.Height)
+	fmt.Printf("Compression: %s\n", fo.Compression)
+	fmt.Printf("Container: %s\n", fo.Container)
 	fmt.Printf("EntMode: %s\n", fo.Mode.String())
+	fmt.Printf("Perm: %s\nUID: %d\nGID: %d\n", fo.Perm, fo.UID, fo.GID)
 	fmt.Printf("Target: %s\n", fo.Target)
+	fmt.Printf("LinkPath: %s\n", fo.LinkPath)
+	fmt.Printf("ReparseTarget: %s\n", fo.ReparseTarget)
+	fmt.Printf("TargetFinal: %s\n", fo.TargetFinal)
+	fmt.Printf("Err: %v\n", fo.Err)
 	fmt.Printf("IsExists: %t\nIsReadable: %t\nIsLink: %t\n", fo.IsExists, fo.IsReadable, fo.IsLink)
 	fmt.Printf("Sets: %v\n", fo.Set)
+	fmt.Printf("XAttrs: %q\n", fo.XAttrs)
+	fmt.Printf("Tags: %v\n", fo.Tags)
 	fmt.Printf("modTime: %s\n", fo.modTime.Format("Mon Jan 2 15:04:05 MST 2006"))
 }
@@ -0,0 +1,267 @@
+package objectify
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowFS is the OS filesystem, except that each read of a file's content
+// takes delay, until slow is cleared. It counts the reads.
+type slowFS struct {
+	OSFileSystem
+	delay time.Duration
+	slow  atomic.Bool
+	reads atomic.Int64
+}
+
+func (s *slowFS) Open(name string) (fs.File, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &slowFile{File: f, sys: s}, nil
+
+}
+
+type slowFile struct {
+	*os.File
+	sys *slowFS
+}
+
+func (f *slowFile) Read(p []byte) (int, error) {
+
+	f.sys.reads.Add(1)
+	if f.sys.slow.Load() {
+		time.Sleep(f.sys.delay)
+	}
+
+	return f.File.Read(p[:min(len(p), 16)])
+
+}
+
+func TestPerFileTimeout(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "slow")
+	if err := os.WriteFile(path, make([]byte, 4096), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sys := &slowFS{delay: 20 * time.Millisecond}
+	sys.slow.Store(true)
+
+	fo, err := File(path, Sets{Size: true, ChecksumSHA256: true},
+		WithFileSystem(sys), WithPerFileTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !errors.Is(fo.Err, ErrFileTimeout) {
+		t.Fatalf("Err = %v, want %v", fo.Err, ErrFileTimeout)
+	}
+	if !fo.IsExists || !fo.IsReadable || fo.SizeBytes != 4096 {
+		t.Errorf("IsExists = %t, IsReadable = %t, SizeBytes = %d, want the stat fields", fo.IsExists, fo.IsReadable, fo.SizeBytes)
+	}
+	if fo.SHA256 != nil {
+		t.Errorf("SHA256 = %x, want none", fo.SHA256)
+	}
+
+	// The abandoned read stops at its next read, well before the 256 reads of
+	// the whole file.
+	time.Sleep(100 * time.Millisecond)
+	reads := sys.reads.Load()
+	time.Sleep(100 * time.Millisecond)
+	if n := sys.reads.Load(); n != reads || n >= 256 {
+		t.Errorf("abandoned update made %d reads, then %d, want it cancelled", reads, n)
+	}
+
+	sys.slow.Store(false)
+	fo.Update()
+	if fo.Err != nil || fo.SHA256 == nil {
+		t.Errorf("after Update, Err = %v, SHA256 = %x, want the checksum", fo.Err, fo.SHA256)
+	}
+
+}
+
+// countingFS is the OS filesystem, counting the calls to Lstat and Open.
+type countingFS struct {
+	OSFileSystem
+	lstats atomic.Int64
+	opens  atomic.Int64
+}
+
+func (c *countingFS) Lstat(name string) (fs.FileInfo, error) {
+
+	c.lstats.Add(1)
+
+	return os.Lstat(name)
+
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+
+	c.opens.Add(1)
+
+	return os.Open(name)
+
+}
+
+// prelimsTree creates n small files in a temporary directory, and returns
+// their paths.
+func prelimsTree(tb testing.TB, n int) []string {
+
+	dir := tb.TempDir()
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("file%d", i))
+		if err := os.WriteFile(paths[i], []byte(paths[i]), 0o600); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	return paths
+
+}
+
+// statOpenPrelims makes the calls setPrelims and setSize made before
+// readability was derived from the Lstat: an Lstat, an open and close to check
+// the file is readable, and another stat for the size. It returns the number
+// of calls, counted as ScanStats.Syscalls counts them.
+func statOpenPrelims(sys FileSystem, path string) int64 {
+
+	calls := int64(1)
+	if _, err := sys.Lstat(path); err != nil {
+		return calls
+	}
+
+	calls++
+	if f, err := sys.Open(path); err == nil {
+		calls++
+		_ = f.Close()
+	}
+
+	calls++
+	_, _ = sys.Lstat(path)
+
+	return calls
+
+}
+
+func TestPrelimsSingleLstat(t *testing.T) {
+
+	type counts struct{ lstats, opens, syscalls int64 }
+	scan := func(n int) counts {
+		paths := prelimsTree(t, n)
+		sys := &countingFS{}
+		r, err := Scan(filepath.Dir(paths[0]), SetsFast(), WithFileSystem(sys))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Stats.Entries != n {
+			t.Fatalf("Entries = %d, want %d", r.Stats.Entries, n)
+		}
+		return counts{sys.lstats.Load(), sys.opens.Load(), r.Stats.Syscalls}
+	}
+
+	// The difference between scans of 10 and 30 files leaves out the calls
+	// made once for the root.
+	small, large := scan(10), scan(30)
+	if n := large.opens - small.opens; n != 0 {
+		t.Errorf("opened 20 more files %d times, want 0", n)
+	}
+	if n := large.lstats - small.lstats; n != 20 {
+		t.Errorf("Lstat called %d times for 20 more files, want 20", n)
+	}
+	if n := large.syscalls - small.syscalls; n != 20 {
+		t.Errorf("Syscalls grew by %d for 20 more files, want 20", n)
+	}
+
+}
+
+// BenchmarkPrelims compares the calls made per file to collect the
+// preliminary fields: "stat-open" makes the Lstat, open, close, and second
+// stat of the former setPrelims and setSize, and "lstat" scans the same files,
+// reading ScanStats.Syscalls. Both report syscalls/file.
+func BenchmarkPrelims(b *testing.B) {
+
+	paths := prelimsTree(b, 100)
+
+	b.Run("stat-open", func(b *testing.B) {
+		var calls int64
+		for i := 0; i < b.N; i++ {
+			for _, p := range paths {
+				calls += statOpenPrelims(OSFileSystem{}, p)
+			}
+		}
+		b.ReportMetric(float64(calls)/float64(b.N*len(paths)), "syscalls/file")
+	})
+
+	b.Run("lstat", func(b *testing.B) {
+		var calls int64
+		for i := 0; i < b.N; i++ {
+			r, err := Scan(filepath.Dir(paths[0]), SetsFast(), WithConcurrency(1))
+			if err != nil {
+				b.Fatal(err)
+			}
+			calls += r.Stats.Syscalls
+		}
+		b.ReportMetric(float64(calls)/float64(b.N*len(paths)), "syscalls/file")
+	})
+
+}
+
+func TestFileObjConcurrentUse(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	scanned, err := File(path, Sets{Size: true, ChecksumSHA256: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := scanned.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &FileObj{}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	// A literal gets its lock on the first call, before it is shared.
+	literal := &FileObj{Root: filepath.Dir(path), Filename: "file", Set: &Sets{Size: true}}
+	_ = literal.ModTime()
+
+	for name, fo := range map[string]*FileObj{"scanned": scanned, "decoded": decoded, "literal": literal} {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					fo.Update()
+					_ = fo.Clone()
+					_ = fo.ModTime()
+					if _, err := fo.MarshalBinary(); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+			if name != "literal" && fo.SizeBytes != 7 {
+				t.Errorf("SizeBytes = %d, want 7", fo.SizeBytes)
+			}
+		})
+	}
+
+}
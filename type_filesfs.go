@@ -0,0 +1,305 @@
+package objectify
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// filesFS is the fs.FS returned by Files.FS.
+type filesFS struct {
+	nodes map[string]*fsNode
+}
+
+// fsNode is a file or directory of a filesFS. fo is nil for a directory which
+// holds scanned entries but was not scanned itself.
+type fsNode struct {
+	name     string
+	fo       *FileObj
+	dir      bool
+	children []*fsNode
+}
+
+// info returns the fs.FileInfo of the node: the FileInfo of its FileObj, or a
+// read-only directory for a directory which was not scanned.
+func (n *fsNode) info() fs.FileInfo {
+
+	if n.fo == nil {
+		return fileObjInfo{name: n.name, mode: fs.ModeDir | 0o555}
+	}
+
+	info := n.fo.FileInfo().(fileObjInfo)
+	info.name = n.name
+	if n.dir {
+		info.mode |= fs.ModeDir
+	}
+
+	return info
+
+}
+
+// FS returns a read-only fs.FS of the Files, e.g. for http.FileServer(http.FS(...)),
+// fs.WalkDir, or testing/fstest. Entries are named by their path below the
+// deepest directory holding all of them, with slashes, so a recursive scan of
+// /srv/data has the entry /srv/data/a/b.txt at "a/b.txt". Directories
+// holding entries are listed even if they were not scanned themselves.
+//
+// Metadata (Stat, ReadDir, and the fs.FileInfo of open files) comes from the
+// FileObjs as last populated, so it describes the scan, while reading a file
+// opens it where it was scanned (on disk, or in the fs.FS of PathFS), so
+// content read reflects its current state. The returned fs.FS implements
+// fs.StatFS and fs.ReadDirFS. nil entries are skipped, and of entries with the
+// same path, the last is used.
+func (files Files) FS() fs.FS {
+
+	paths := make(map[*FileObj]string, len(files))
+	root := EMPTY
+	for _, fo := range files {
+
+		if fo == nil {
+			continue
+		}
+
+		p := filepath.ToSlash(fo.FullPath())
+		paths[fo] = p
+		if root == EMPTY {
+			root = path.Dir(p)
+		} else {
+			root = commonDir(root, path.Dir(p))
+		}
+
+	}
+
+	fsys := &filesFS{nodes: map[string]*fsNode{".": {name: ".", dir: true}}}
+	for _, fo := range files {
+		if fo == nil {
+			continue
+		}
+		n := fsys.node(relSlash(root, paths[fo]))
+		n.fo = fo
+		n.dir = n.dir || fo.Mode.IsDir()
+	}
+
+	for _, n := range fsys.nodes {
+		sort.Slice(n.children, func(i, j int) bool {
+			return n.children[i].name < n.children[j].name
+		})
+	}
+
+	return fsys
+
+}
+
+// node returns the node named name, creating it and its parent directories if
+// needed.
+func (f *filesFS) node(name string) *fsNode {
+
+	if n, ok := f.nodes[name]; ok {
+		return n
+	}
+
+	parent := f.node(path.Dir(name))
+	parent.dir = true
+
+	n := &fsNode{name: path.Base(name)}
+	parent.children = append(parent.children, n)
+	f.nodes[name] = n
+
+	return n
+
+}
+
+// lookup returns the node named name, or an *fs.PathError for op.
+func (f *filesFS) lookup(op, name string) (*fsNode, error) {
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	n, ok := f.nodes[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return n, nil
+
+}
+
+// Open implements fs.FS. Directories are listed from the Files; files are
+// opened where they were scanned.
+func (f *filesFS) Open(name string) (fs.File, error) {
+
+	n, err := f.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.dir {
+		return &fsDir{node: n, path: name}, nil
+	}
+
+	file, err := n.fo.options().open(n.fo.ioPath())
+	if err != nil {
+		var pe *fs.PathError
+		if errors.As(err, &pe) {
+			err = pe.Err
+		}
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &fsFile{File: file, info: n.info()}, nil
+
+}
+
+// Stat implements fs.StatFS, from the Files without touching the disk.
+func (f *filesFS) Stat(name string) (fs.FileInfo, error) {
+
+	n, err := f.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.info(), nil
+
+}
+
+// ReadDir implements fs.ReadDirFS, from the Files without touching the disk.
+func (f *filesFS) ReadDir(name string) ([]fs.DirEntry, error) {
+
+	n, err := f.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !n.dir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+
+	return dirEntries(n.children), nil
+
+}
+
+// errNotDir and errIsDir are the errors of reading a directory of a filesFS
+// as a file and listing a file as a directory.
+var (
+	errNotDir = errors.New("not a directory")
+	errIsDir  = errors.New("is a directory")
+)
+
+// dirEntries returns the fs.DirEntry of each node.
+func dirEntries(nodes []*fsNode) []fs.DirEntry {
+
+	entries := make([]fs.DirEntry, len(nodes))
+	for i, n := range nodes {
+		entries[i] = fs.FileInfoToDirEntry(n.info())
+	}
+
+	return entries
+
+}
+
+// fsFile is a file opened by a filesFS: the file opened where it was scanned,
+// whose Stat returns the FileObj's metadata.
+type fsFile struct {
+	fs.File
+	info fs.FileInfo
+}
+
+// Stat returns the fs.FileInfo of the FileObj.
+func (f *fsFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// Seek implements io.Seeker if the underlying file does, as http.FileServer
+// requires.
+func (f *fsFile) Seek(offset int64, whence int) (int64, error) {
+
+	s, ok := f.File.(io.Seeker)
+	if !ok {
+		return 0, &fs.PathError{Op: "seek", Path: f.info.Name(), Err: errors.ErrUnsupported}
+	}
+
+	return s.Seek(offset, whence)
+
+}
+
+// ReadAt implements io.ReaderAt if the underlying file does.
+func (f *fsFile) ReadAt(p []byte, off int64) (int, error) {
+
+	r, ok := f.File.(io.ReaderAt)
+	if !ok {
+		return 0, &fs.PathError{Op: "read", Path: f.info.Name(), Err: errors.ErrUnsupported}
+	}
+
+	return r.ReadAt(p, off)
+
+}
+
+// fsDir is a directory opened by a filesFS, listed from the Files.
+type fsDir struct {
+	node   *fsNode
+	path   string
+	offset int
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) { return d.node.info(), nil }
+func (d *fsDir) Close() error               { return nil }
+
+func (d *fsDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: errIsDir}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *fsDir) ReadDir(count int) ([]fs.DirEntry, error) {
+
+	rest := d.node.children[d.offset:]
+	if count > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && count < len(rest) {
+		rest = rest[:count]
+	}
+	d.offset += len(rest)
+
+	return dirEntries(rest), nil
+
+}
+
+// commonDir returns the deepest directory holding both of the slash-separated
+// directories a and b, or "/" if they have none in common.
+func commonDir(a, b string) string {
+
+	for a != b && !strings.HasPrefix(b, strings.TrimSuffix(a, "/")+"/") {
+		if a == "." && !path.IsAbs(b) {
+			return a
+		}
+		next := path.Dir(a)
+		if next == a {
+			return "/"
+		}
+		a = next
+	}
+
+	return a
+
+}
+
+// relSlash returns the slash-separated path p relative to the directory root,
+// as a valid fs.FS name.
+func relSlash(root, p string) string {
+
+	switch {
+	case p == root:
+		return "."
+	case root == ".":
+		return strings.TrimPrefix(path.Clean(p), "/")
+	case root == "/":
+		return strings.TrimPrefix(p, "/")
+	}
+
+	return strings.TrimPrefix(p, root+"/")
+
+}
@@ -0,0 +1,147 @@
+package objectify
+
+import (
+	"io/fs"
+	"time"
+)
+
+// FileSnapshot is a frozen view of a FileObj, made with FileObj.Snapshot, for
+// consumers which only read the results of a scan. It is a plain value with no
+// pointers, slices, maps, or fs.FileInfo into mutable state, so it can be
+// shared between goroutines without locking, stored in maps by value, and
+// compared with ==.
+// The Chunks, Streams, ImageHash, XAttrs, Tags, and Meta of the FileObj are
+// not kept; use Clone for a full copy.
+type FileSnapshot struct {
+
+	// Path is the FullPath of the FileObj. Root and Filename are its parent
+	// directory and base name.
+	Path     string
+	Root     string
+	Filename string
+
+	// Container is the FullPath of the archive holding an archive member.
+	Container string
+
+	SizeBytes      int64
+	AllocatedBytes int64
+
+	Mode EntMode
+	Perm fs.FileMode
+	UID  int
+	GID  int
+
+	// ModTime and UpdatedAt are in UTC, without a monotonic clock reading,
+	// so that equal times compare equal with ==.
+	ModTime   time.Time
+	UpdatedAt time.Time
+
+	// The checksums are hexadecimal strings, as in the FileObj's Checksum
+	// fields, and are empty if they were not computed.
+	ChecksumMD5          string
+	ChecksumSHA256       string
+	ChecksumHMAC         string
+	ChecksumDecompressed string
+
+	Width       int
+	Height      int
+	Compression Compression
+	ETag        string
+	ContentType string
+
+	Target        string
+	TargetFinal   string
+	LinkPath      string
+	ReparseTarget string
+
+	IsLink     bool
+	IsReadable bool
+	IsExists   bool
+
+	// Err is the message of the FileObj's Err field, or empty.
+	Err string
+}
+
+// Snapshot returns a FileSnapshot of the FileObj as last populated. Fields
+// deferred by WithLazy are left as they are; call Resolve first to include
+// them. It returns the zero FileSnapshot for a nil FileObj.
+func (fo *FileObj) Snapshot() FileSnapshot {
+
+	if fo == nil {
+		return FileSnapshot{}
+	}
+
+	mu := fo.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	s := FileSnapshot{
+		Path:                 fo.FullPath(),
+		Root:                 fo.Root,
+		Filename:             fo.Filename,
+		Container:            fo.Container,
+		SizeBytes:            fo.SizeBytes,
+		AllocatedBytes:       fo.AllocatedBytes,
+		Mode:                 fo.Mode,
+		Perm:                 fo.Perm,
+		UID:                  fo.UID,
+		GID:                  fo.GID,
+		ModTime:              fo.modTime.UTC(),
+		UpdatedAt:            fo.UpdatedAt.UTC(),
+		ChecksumMD5:          fo.ChecksumMD5,
+		ChecksumSHA256:       fo.ChecksumSHA256,
+		ChecksumHMAC:         fo.ChecksumHMAC,
+		ChecksumDecompressed: fo.ChecksumDecompressed,
+		Width:                fo.Width,
+		Height:               fo.Height,
+		Compression:          fo.Compression,
+		ETag:                 fo.ETag,
+		ContentType:          fo.ContentType,
+		Target:               fo.Target,
+		TargetFinal:          fo.TargetFinal,
+		LinkPath:             fo.LinkPath,
+		ReparseTarget:        fo.ReparseTarget,
+		IsLink:               fo.IsLink,
+		IsReadable:           fo.IsReadable,
+		IsExists:             fo.IsExists,
+	}
+	if fo.Err != nil {
+		s.Err = fo.Err.Error()
+	}
+
+	return s
+
+}
+
+// Snapshots returns a FileSnapshot of every FileObj, in order. nil entries
+// are skipped.
+func (files Files) Snapshots() []FileSnapshot {
+
+	snaps := make([]FileSnapshot, 0, len(files))
+	for _, fo := range files {
+		if fo == nil {
+			continue
+		}
+		snaps = append(snaps, fo.Snapshot())
+	}
+
+	return snaps
+
+}
+
+// SnapshotMap returns the FileSnapshots of the Files keyed by their Path, for
+// lookups by path. If a path occurs more than once, the last entry is kept.
+func (files Files) SnapshotMap() map[string]FileSnapshot {
+
+	m := make(map[string]FileSnapshot, len(files))
+	for _, fo := range files {
+		if fo == nil {
+			continue
+		}
+		s := fo.Snapshot()
+		m[s.Path] = s
+	}
+
+	return m
+
+}
@@ -0,0 +1,121 @@
+package objectify
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileSystem is the operating system filesystem as read by scans of OS paths
+// (Path, File, Scan, Walk, NewMonitor, and the Update of their FileObjs).
+// WithFileSystem replaces it, e.g. with a fake which reports permission
+// errors, broken links, or unusual modes, so code built on objectify can be
+// tested deterministically. Names are OS paths, as passed to the os package.
+//
+// Symlinks are followed with Readlink and Lstat, one hop at a time; only the
+// final element of a path is resolved, so a fake need not model links to
+// directories in the middle of a path. Features which query the OS directly
+// (extended attributes, alternate data streams, reparse points, hardened
+// opening, and Dedupe actions) are disabled when a FileSystem is set.
+type FileSystem interface {
+	Lstat(name string) (fs.FileInfo, error)
+	Open(name string) (fs.File, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Readlink(name string) (string, error)
+}
+
+// OSFileSystem is the FileSystem of the os package, used by default. A fake
+// can embed it to override only some of its methods.
+type OSFileSystem struct{}
+
+func (OSFileSystem) Lstat(name string) (fs.FileInfo, error)     { return os.Lstat(name) }
+func (OSFileSystem) Open(name string) (fs.File, error)          { return os.Open(name) }
+func (OSFileSystem) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (OSFileSystem) Readlink(name string) (string, error)       { return os.Readlink(name) }
+
+// WithFileSystem reads OS paths through sys instead of the os package. It has
+// no effect on scans of an fs.FS (see PathFS). See FileSystem.
+func WithFileSystem(sys FileSystem) Option {
+	return func(o *options) {
+		if _, ok := sys.(OSFileSystem); ok {
+			sys = nil
+		}
+		o.filesystem = sys
+	}
+}
+
+// sys returns the FileSystem OS paths are read through, injecting the faults
+// of WithFaults if any.
+func (o *options) sys() FileSystem {
+
+	var sys FileSystem = OSFileSystem{}
+	if o.filesystem != nil {
+		sys = o.filesystem
+	}
+
+	if len(o.faults) > 0 {
+		return faultFS{sys: sys, faults: o.faults}
+	}
+
+	return sys
+
+}
+
+// onOS returns true if entries are read from the OS filesystem itself, rather
+// than from an fs.FS (see PathFS) or a FileSystem set by WithFileSystem.
+func (o *options) onOS() bool {
+	return o.fsys == nil && o.filesystem == nil
+}
+
+// statFollow returns the fs.FileInfo of name in sys, following symlinks.
+func statFollow(sys FileSystem, name string) (fs.FileInfo, error) {
+
+	if _, ok := sys.(OSFileSystem); ok {
+		return os.Stat(name)
+	}
+
+	target, err := followLinks(sys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return sys.Lstat(target)
+
+}
+
+// followLinks returns the path name leads to in sys, following symlinks. On
+// the OS filesystem, every element of name is resolved
+// with filepath.EvalSymlinks; in other FileSystems, only the final element
+// is, one link at a time up to DefaultMaxLinkHops.
+func followLinks(sys FileSystem, name string) (string, error) {
+
+	if _, ok := sys.(OSFileSystem); ok {
+		return filepath.EvalSymlinks(name)
+	}
+
+	info, err := sys.Lstat(name)
+	for hops := 0; err == nil && info.Mode()&fs.ModeSymlink != 0; hops++ {
+
+		if hops >= DefaultMaxLinkHops {
+			return EMPTY, fmt.Errorf("%w: %s exceeds %d hops", ErrSymlinkCycle, name, DefaultMaxLinkHops)
+		}
+
+		var link string
+		if link, err = sys.Readlink(name); err != nil {
+			break
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(name), link)
+		}
+		name = filepath.Clean(link)
+		info, err = sys.Lstat(name)
+
+	}
+	if err != nil {
+		return EMPTY, err
+	}
+
+	return name, nil
+
+}
@@ -0,0 +1,109 @@
+package objectify
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Force applies the actions to every FileObj (see FileObj.Force) using up to
+// concurrency goroutines, e.g. to back-fill SHA256 checksums for a scan made
+// with SetsAllNoChecksums:
+//
+//	err := files.Force(ctx, 8, F_CHECKSUM_SHA256)
+//
+// A concurrency less than 1 is treated as 1. When ctx is done, no further
+// entries are started and ctx.Err() is included in the result. The errors of
+// all entries are returned joined with errors.Join, or nil if every action
+// succeeded.
+func (files Files) Force(ctx context.Context, concurrency int, actions ...Action) error {
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan *FileObj)
+	errs := make([]error, concurrency)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for fo := range jobs {
+				errs[i] = errors.Join(errs[i], fo.Force(actions...))
+			}
+		}(i)
+	}
+
+	var ctxErr error
+dispatch:
+	for _, fo := range files {
+
+		if fo == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			ctxErr = ctx.Err()
+			break dispatch
+		case jobs <- fo:
+		}
+
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return errors.Join(append(errs, ctxErr)...)
+
+}
+
+// Each calls fn with every FileObj using up to concurrency goroutines, e.g. to
+// upload or convert the files of a scan:
+//
+//	err := files.Each(ctx, 8, func(fo *FileObj) error {
+//		return upload(ctx, fo.FullPath())
+//	})
+//
+// A concurrency less than 1 is treated as 1, and nil entries are skipped.
+// Unlike Force, Each stops at the first error: no further entries are
+// started, the calls in progress are waited for, and the error is returned.
+// When ctx is done, no further entries are started and ctx.Err() is returned.
+func (files Files) Each(ctx context.Context, concurrency int, fn func(*FileObj) error) error {
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+
+	for _, fo := range files {
+
+		if fo == nil {
+			continue
+		}
+
+		// Go blocks until a goroutine is free, so check for an error or a
+		// cancelled ctx before starting each entry.
+		if gctx.Err() != nil {
+			break
+		}
+
+		g.Go(func() error {
+			return fn(fo)
+		})
+
+	}
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+
+}
@@ -0,0 +1,44 @@
+package objectify
+
+import (
+	"io/fs"
+	"time"
+)
+
+// HashCache persists checksums between scans, so that files which have not
+// changed are not read again. It is set with WithHashCache. Entries are keyed
+// by full path and are only used when the recorded size and modification time
+// still match the file. A HashCache must be safe for concurrent use when
+// WithConcurrency is greater than 1.
+type HashCache interface {
+
+	// GetChecksums returns the raw MD5 and SHA256 digests recorded for path
+	// when it had the given size and modTime. Either digest may be nil if it
+	// was not recorded. ok is false if there is no matching entry.
+	GetChecksums(path string, size int64, modTime time.Time) (md5, sha256 []byte, ok bool)
+
+	// PutChecksums records the digests computed for path at the given size and
+	// modTime. A nil digest means it was not computed.
+	PutChecksums(path string, size int64, modTime time.Time, md5, sha256 []byte)
+}
+
+// WithHashCache sets a HashCache which is consulted before checksums are
+// computed for a regular file, and updated after they are.
+func WithHashCache(c HashCache) Option {
+	return func(o *options) {
+		o.hashCache = c
+	}
+}
+
+// cacheKey returns the HashCache in use and the key values for the FileObj.
+// The cache is nil if none is set or the entry is not a regular file.
+func (fo *FileObj) cacheKey() (HashCache, string, int64, time.Time) {
+
+	c := fo.options().hashCache
+	if c == nil || fo.info == nil || fo.info.Mode()&fs.ModeType != 0 {
+		return nil, EMPTY, 0, time.Time{}
+	}
+
+	return c, fo.ioPath(), fo.info.Size(), fo.info.ModTime()
+
+}
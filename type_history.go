@@ -0,0 +1,70 @@
+package objectify
+
+import (
+	"io/fs"
+	"slices"
+	"time"
+)
+
+// Version is a previous state of a FileObj, recorded by Update when it found
+// the file changed (see WithHistory).
+type Version struct {
+
+	// SizeBytes, ModTime, Mode, and Perm are the size, modification time,
+	// kind, and permission bits of the file before the change.
+	SizeBytes int64
+	ModTime   time.Time
+	Mode      EntMode
+	Perm      fs.FileMode
+
+	// ChecksumMD5 and ChecksumSHA256 are the checksums of the file before the
+	// change, as hexadecimal strings, if they had been computed.
+	ChecksumMD5    string
+	ChecksumSHA256 string
+
+	// UpdatedAt is when the state was read, and ReplacedAt when Update found
+	// it had changed.
+	UpdatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+// History returns the previous states of the FileObj recorded by Update,
+// oldest first, or nil unless WithHistory was used. At most as many states
+// as set with WithHistory are kept; older ones are dropped.
+func (fo *FileObj) History() []Version {
+
+	mu := fo.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return slices.Clone(fo.history)
+
+}
+
+// recordVersion adds the current state of the FileObj to its history before
+// Update re-reads it, dropping the oldest state once the history option's
+// limit is reached. A FileObj which was never populated is not recorded.
+func (fo *FileObj) recordVersion() {
+
+	limit := fo.options().history
+	if limit < 1 || fo.UpdatedAt.IsZero() {
+		return
+	}
+
+	v := Version{
+		SizeBytes:      fo.SizeBytes,
+		ModTime:        fo.modTime,
+		Mode:           fo.Mode,
+		Perm:           fo.Perm,
+		ChecksumMD5:    fo.ChecksumMD5,
+		ChecksumSHA256: fo.ChecksumSHA256,
+		UpdatedAt:      fo.UpdatedAt,
+		ReplacedAt:     fo.options().now(),
+	}
+
+	if len(fo.history) >= limit {
+		fo.history = slices.Delete(fo.history, 0, len(fo.history)-limit+1)
+	}
+	fo.history = append(fo.history, v)
+
+}
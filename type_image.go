@@ -0,0 +1,307 @@
+package objectify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math/bits"
+	"sort"
+)
+
+// maxImagePixels is the largest image, in pixels, which is decoded to compute
+// an ImageHash. Larger images are skipped, to bound memory use.
+const maxImagePixels = 64 << 20
+
+// imageMagic maps the leading bytes of the image formats which can be decoded
+// to compute an ImageHash.
+var imageMagic = [][]byte{
+	[]byte("\x89PNG\r\n\x1a\n"),
+	[]byte("\xff\xd8\xff"),
+	[]byte("GIF87a"),
+	[]byte("GIF89a"),
+}
+
+// isDecodableImage returns true if header starts with the magic bytes of a PNG,
+// JPEG, or GIF image.
+func isDecodableImage(header []byte) bool {
+
+	for _, m := range imageMagic {
+		if bytes.HasPrefix(header, m) {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// addImageHash adds the ImageHash field to the pass when the imageHash option
+// is set and the file is a PNG, JPEG, or GIF image (detected by its magic
+// bytes). Otherwise, the field is cleared. Images which are corrupt or too
+// large to decode have no ImageHash.
+func (fo *FileObj) addImageHash(p *contentPass) {
+
+	fo.ImageHash = nil
+
+	if !fo.options().imageHash || !fo.IsExists || !fo.IsReadable || fo.isSymlink() {
+		return
+	}
+
+	p.hash = true
+	p.read(func(r io.Reader) error {
+
+		header := make([]byte, 8)
+		n, err := io.ReadFull(r, header)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return err
+		}
+		if !isDecodableImage(header[:n]) {
+			return nil
+		}
+
+		fo.ImageHash, _ = dHash(io.MultiReader(bytes.NewReader(header[:n]), r))
+
+		return nil
+
+	})
+
+}
+
+// dHash decodes the image read from r and returns its 64-bit difference hash:
+// the image is reduced to a 9x8 grid of average luminance, and each bit records
+// whether a cell is brighter than its right-hand neighbour. Images which look
+// alike, whatever their encoding or resolution, have hashes a small Hamming
+// distance apart. It returns nil for images larger than maxImagePixels.
+func dHash(r io.Reader) ([]byte, error) {
+
+	var buf bytes.Buffer
+	cfg, _, err := image.DecodeConfig(io.TeeReader(r, &buf))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, nil
+	}
+
+	img, _, err := image.Decode(io.MultiReader(&buf, r))
+	if err != nil {
+		return nil, err
+	}
+
+	const cols, rows = 9, 8
+	var sum [rows][cols]float64
+	var count [rows][cols]int
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := (y - b.Min.Y) * rows / h
+		for x := b.Min.X; x < b.Max.X; x++ {
+			col := (x - b.Min.X) * cols / w
+			cr, cg, cb, _ := img.At(x, y).RGBA()
+			sum[row][col] += 0.299*float64(cr) + 0.587*float64(cg) + 0.114*float64(cb)
+			count[row][col]++
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols-1; x++ {
+			hash <<= 1
+			if cellAvg(sum[y][x], count[y][x]) > cellAvg(sum[y][x+1], count[y][x+1]) {
+				hash |= 1
+			}
+		}
+	}
+
+	out := make([]byte, 8)
+	for i := range out {
+		out[i] = byte(hash >> (56 - 8*i))
+	}
+
+	return out, nil
+
+}
+
+// cellAvg returns sum/n, or 0 for an empty cell of a very small image.
+func cellAvg(sum float64, n int) float64 {
+
+	if n == 0 {
+		return 0
+	}
+
+	return sum / float64(n)
+
+}
+
+// ImageDistance returns the Hamming distance between the ImageHash of a and b:
+// 0 for images which look identical, up to 64 for unrelated ones. Distances of
+// about 10 or less usually mean the same picture, re-encoded or resized. ok is
+// false if either FileObj has no ImageHash.
+func ImageDistance(a, b *FileObj) (distance int, ok bool) {
+
+	if a == nil || b == nil || len(a.ImageHash) != 8 || len(b.ImageHash) != 8 {
+		return 0, false
+	}
+
+	for i := range a.ImageHash {
+		distance += bits.OnesCount8(a.ImageHash[i] ^ b.ImageHash[i])
+	}
+
+	return distance, true
+
+}
+
+// SimilarImage returns true if both FileObjs have an ImageHash and the distance
+// between them (see ImageDistance) is at most maxDistance.
+func (fo *FileObj) SimilarImage(other *FileObj, maxDistance int) bool {
+
+	d, ok := ImageDistance(fo, other)
+
+	return ok && d <= maxDistance
+
+}
+
+// SimilarImages groups the entries whose images look alike: entries are joined
+// into a group when their ImageDistance is at most maxDistance, directly or
+// through other members of the group. Entries without an ImageHash and repeated
+// paths are ignored. Only groups with two or more entries are returned; each is
+// sorted by FullPath, and the groups by the path of their first entry.
+// Comparison is pairwise, so it suits photo libraries rather than millions of files.
+func (files Files) SimilarImages(maxDistance int) []Files {
+
+	var imgs Files
+	seen := make(map[string]bool)
+	for _, fo := range files {
+		if fo == nil || len(fo.ImageHash) != 8 || seen[fo.FullPath()] {
+			continue
+		}
+		seen[fo.FullPath()] = true
+		imgs = append(imgs, fo)
+	}
+
+	parent := make([]int, len(imgs))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range imgs {
+		for j := i + 1; j < len(imgs); j++ {
+			if imgs[i].SimilarImage(imgs[j], maxDistance) {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	byRoot := make(map[int]Files)
+	for i, fo := range imgs {
+		byRoot[find(i)] = append(byRoot[find(i)], fo)
+	}
+
+	var groups []Files
+	for _, g := range byRoot {
+		if len(g) > 1 {
+			g.sortByPath()
+			groups = append(groups, g)
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0].FullPath() < groups[j][0].FullPath()
+	})
+
+	return groups
+
+}
+
+// setDimensions sets the Width and Height fields (see addDimensions).
+// Returns an error if the file cannot be opened.
+func (fo *FileObj) setDimensions() error {
+	return fo.pass(fo.addDimensions)
+}
+
+// addDimensions adds the Width and Height fields to the pass when
+// Sets.Dimensions is true and the file is a PNG, JPEG, GIF, or WebP image.
+// Only the image header is read; the pixels are not decoded. Otherwise, or if
+// the header is corrupt, the fields are cleared.
+func (fo *FileObj) addDimensions(p *contentPass) {
+
+	fo.Width, fo.Height = 0, 0
+
+	if !fo.Set.Dimensions || !fo.IsExists || !fo.IsReadable || fo.isSymlink() {
+		return
+	}
+
+	p.read(func(r io.Reader) error {
+		fo.Width, fo.Height = imageDimensions(bufio.NewReader(r))
+		return nil
+	})
+
+}
+
+// imageDimensions returns the width and height recorded in the header of the
+// PNG, JPEG, GIF, or WebP image read from r, or zeros if r does not hold one.
+func imageDimensions(r *bufio.Reader) (width, height int) {
+
+	header, _ := r.Peek(30)
+
+	if bytes.HasPrefix(header, []byte("RIFF")) && len(header) >= 12 && string(header[8:12]) == "WEBP" {
+		return webpDimensions(header)
+	}
+	if !isDecodableImage(header) {
+		return 0, 0
+	}
+
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return 0, 0
+	}
+
+	return cfg.Width, cfg.Height
+
+}
+
+// webpDimensions parses the canvas size from the first chunk of a WebP file:
+// VP8X (extended), VP8L (lossless), or VP8 (lossy). header holds the first 30
+// bytes of the file.
+func webpDimensions(header []byte) (width, height int) {
+
+	if len(header) < 30 {
+		return 0, 0
+	}
+
+	data := header[20:]
+	switch string(header[12:16]) {
+	case "VP8X":
+		width = 1 + int(data[4]) | int(data[5])<<8 | int(data[6])<<16
+		height = 1 + int(data[7]) | int(data[8])<<8 | int(data[9])<<16
+	case "VP8L":
+		if data[0] != 0x2f {
+			return 0, 0
+		}
+		bits := binary.LittleEndian.Uint32(data[1:5])
+		width = 1 + int(bits&0x3fff)
+		height = 1 + int(bits>>14&0x3fff)
+	case "VP8 ":
+		if !bytes.Equal(data[3:6], []byte{0x9d, 0x01, 0x2a}) {
+			return 0, 0
+		}
+		width = int(binary.LittleEndian.Uint16(data[6:8]) & 0x3fff)
+		height = int(binary.LittleEndian.Uint16(data[8:10]) & 0x3fff)
+	}
+
+	return width, height
+
+}
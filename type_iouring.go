@@ -0,0 +1,26 @@
+package objectify
+
+// ringReadSize is the size of the reads submitted to the io_uring when
+// WithReadBuffer is not set.
+const ringReadSize = 256 * 1024
+
+// WithIOUring reads the content of files through a shared io_uring while
+// hashing them, so the reads of all the files being hashed at once are
+// submitted to the kernel in batches rather than made by one blocking call
+// each. It helps checksum-heavy scans of fast storage with WithConcurrency
+// (or WithHashWorkers) well above 1. Reads are 256 KiB unless WithReadBuffer
+// is set.
+//
+// The io_uring backend is only compiled into Linux binaries built with the
+// iouring build tag (go build -tags iouring). Elsewhere, and where the kernel
+// does not allow io_uring (it needs Linux 5.6, and is often disabled by
+// seccomp profiles in containers), files are read with blocking calls as if
+// the option was not set. The ring, with a goroutine serving it, is created
+// on first use and kept for the life of the process. Files opened through
+// WithFileSystem or an fs.FS, and those failed by WithFaults, are read as
+// usual.
+func WithIOUring() Option {
+	return func(o *options) {
+		o.ioUring = true
+	}
+}
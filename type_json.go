@@ -0,0 +1,275 @@
+package objectify
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// fileObjJSON is the JSON form of a FileObj. Checksums are written as hex
+// strings, XAttrs values as base64, and Err as its message. A field added here
+// must be added to JSONSchema too, and JSONSchemaVersion incremented.
+type fileObjJSON struct {
+	Root         string            `json:"root"`
+	Filename     string            `json:"filename"`
+	Container    string            `json:"container,omitempty"`
+	SizeBytes    int64             `json:"size_bytes"`
+	Mode         EntMode           `json:"mode"`
+	Perm         uint32            `json:"perm,omitempty"`
+	UID          int               `json:"uid"`
+	GID          int               `json:"gid"`
+	ModTime      *time.Time        `json:"mod_time,omitempty"`
+	UpdatedAt    *time.Time        `json:"updated_at,omitempty"`
+	MD5          string            `json:"md5,omitempty"`
+	SHA256       string            `json:"sha256,omitempty"`
+	HMAC         string            `json:"hmac_sha256,omitempty"`
+	Decompressed string            `json:"decompressed_sha256,omitempty"`
+	ImageHash    string            `json:"image_hash,omitempty"`
+	Chunks       []chunkJSON       `json:"chunks,omitempty"`
+	Streams      []streamJSON      `json:"streams,omitempty"`
+	Allocated    int64             `json:"allocated_bytes"`
+	Width        int               `json:"width,omitempty"`
+	Height       int               `json:"height,omitempty"`
+	Compression  Compression       `json:"compression,omitempty"`
+	ETag         string            `json:"etag,omitempty"`
+	ContentType  string            `json:"content_type,omitempty"`
+	Target       string            `json:"target,omitempty"`
+	TargetFinal  string            `json:"target_final,omitempty"`
+	LinkPath     string            `json:"link_path,omitempty"`
+	Reparse      string            `json:"reparse_target,omitempty"`
+	IsLink       bool              `json:"is_link"`
+	IsReadable   bool              `json:"is_readable"`
+	IsExists     bool              `json:"is_exists"`
+	XAttrs       map[string][]byte `json:"xattrs,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	Sets         *Sets             `json:"sets,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	Meta         map[string]string `json:"meta,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. All exported fields, the modification
+// time, the Tags, and the Meta are encoded, with checksums as hex strings and the Err
+// field as its message. The scan options are not stored.
+func (fo *FileObj) MarshalJSON() ([]byte, error) {
+
+	mu := fo.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	j := fileObjJSON{
+		Root:         fo.Root,
+		Filename:     fo.Filename,
+		Container:    fo.Container,
+		SizeBytes:    fo.SizeBytes,
+		Mode:         fo.Mode,
+		Perm:         uint32(fo.Perm),
+		UID:          fo.UID,
+		GID:          fo.GID,
+		ModTime:      timeOrNil(fo.modTime),
+		UpdatedAt:    timeOrNil(fo.UpdatedAt),
+		MD5:          hex.EncodeToString(fo.MD5),
+		SHA256:       hex.EncodeToString(fo.SHA256),
+		HMAC:         hex.EncodeToString(fo.HMAC),
+		Decompressed: hex.EncodeToString(fo.DecompressedSHA256),
+		ImageHash:    hex.EncodeToString(fo.ImageHash),
+		Chunks:       chunksJSON(fo.Chunks),
+		Streams:      streamsJSON(fo.Streams),
+		Allocated:    fo.AllocatedBytes,
+		Width:        fo.Width,
+		Height:       fo.Height,
+		Compression:  fo.Compression,
+		ETag:         fo.ETag,
+		ContentType:  fo.ContentType,
+		Target:       fo.Target,
+		TargetFinal:  fo.TargetFinal,
+		LinkPath:     fo.LinkPath,
+		Reparse:      fo.ReparseTarget,
+		IsLink:       fo.IsLink,
+		IsReadable:   fo.IsReadable,
+		IsExists:     fo.IsExists,
+		XAttrs:       fo.XAttrs,
+		Sets:         fo.Set,
+		Tags:         fo.Tags,
+		Meta:         fo.Meta,
+	}
+	if fo.Err != nil {
+		j.Error = fo.Err.Error()
+	}
+
+	return json.Marshal(j)
+
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding data written by MarshalJSON.
+// The FileObj keeps its lock and options; it must not be in use by other
+// goroutines (see FileObj).
+func (fo *FileObj) UnmarshalJSON(data []byte) error {
+
+	var j fileObjJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	md5, err := hex.DecodeString(j.MD5)
+	if err != nil {
+		return fmt.Errorf("md5: %w", err)
+	}
+	sha256, err := hex.DecodeString(j.SHA256)
+	if err != nil {
+		return fmt.Errorf("sha256: %w", err)
+	}
+	mac, err := hex.DecodeString(j.HMAC)
+	if err != nil {
+		return fmt.Errorf("hmac_sha256: %w", err)
+	}
+	decompressed, err := hex.DecodeString(j.Decompressed)
+	if err != nil {
+		return fmt.Errorf("decompressed_sha256: %w", err)
+	}
+	imageHash, err := hex.DecodeString(j.ImageHash)
+	if err != nil {
+		return fmt.Errorf("image_hash: %w", err)
+	}
+
+	mu, o := fo.mutex(), fo.options()
+	*fo = FileObj{
+		Root:        j.Root,
+		Filename:    j.Filename,
+		Container:   j.Container,
+		SizeBytes:   j.SizeBytes,
+		Mode:        j.Mode,
+		Perm:        fs.FileMode(j.Perm),
+		UID:         j.UID,
+		GID:         j.GID,
+		ETag:        j.ETag,
+		ContentType: j.ContentType,
+		Target:      j.Target,
+		TargetFinal: j.TargetFinal,
+		LinkPath:    j.LinkPath,
+		IsLink:      j.IsLink,
+		IsReadable:  j.IsReadable,
+		IsExists:    j.IsExists,
+		XAttrs:      j.XAttrs,
+		Width:       j.Width,
+		Height:      j.Height,
+		Compression: j.Compression,
+		Set:         j.Sets,
+		Tags:        j.Tags,
+		Meta:        j.Meta,
+		opts:        o,
+		mu:          mu,
+	}
+
+	fo.ReparseTarget = j.Reparse
+	fo.AllocatedBytes = j.Allocated
+	if j.ModTime != nil {
+		fo.modTime = *j.ModTime
+	}
+	if j.UpdatedAt != nil {
+		fo.UpdatedAt = *j.UpdatedAt
+	}
+	if len(md5) > 0 {
+		fo.MD5, fo.ChecksumMD5 = md5, hex.EncodeToString(md5)
+	}
+	if len(sha256) > 0 {
+		fo.SHA256, fo.ChecksumSHA256 = sha256, hex.EncodeToString(sha256)
+	}
+	if len(mac) > 0 {
+		fo.HMAC, fo.ChecksumHMAC = mac, hex.EncodeToString(mac)
+	}
+	if len(decompressed) > 0 {
+		fo.DecompressedSHA256, fo.ChecksumDecompressed = decompressed, hex.EncodeToString(decompressed)
+	}
+	if j.Chunks != nil {
+		fo.Chunks = make([]Chunk, len(j.Chunks))
+		for i, c := range j.Chunks {
+			sum, err := hex.DecodeString(c.SHA256)
+			if err != nil {
+				return fmt.Errorf("chunks: %w", err)
+			}
+			fo.Chunks[i] = Chunk{Offset: c.Offset, Size: c.Size, SHA256: sum}
+		}
+	}
+	if j.Streams != nil {
+		fo.Streams = make([]Stream, len(j.Streams))
+		for i, st := range j.Streams {
+			sum, err := hex.DecodeString(st.SHA256)
+			if err != nil {
+				return fmt.Errorf("streams: %w", err)
+			}
+			if len(sum) == 0 {
+				sum = nil
+			}
+			fo.Streams[i] = Stream{Name: st.Name, Size: st.Size, SHA256: sum}
+		}
+	}
+	if len(imageHash) > 0 {
+		fo.ImageHash = imageHash
+	}
+	if j.Error != EMPTY {
+		fo.Err = errors.New(j.Error)
+	}
+
+	return nil
+
+}
+
+// chunkJSON is the JSON form of a Chunk, with the checksum as a hex string.
+type chunkJSON struct {
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// chunksJSON returns the JSON form of chunks, or nil if there are none.
+func chunksJSON(chunks []Chunk) []chunkJSON {
+
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	j := make([]chunkJSON, len(chunks))
+	for i, c := range chunks {
+		j[i] = chunkJSON{Offset: c.Offset, Size: c.Size, SHA256: hex.EncodeToString(c.SHA256)}
+	}
+
+	return j
+
+}
+
+// streamJSON is the JSON form of a Stream, with the checksum as a hex string.
+type streamJSON struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// streamsJSON returns the JSON form of streams, or nil if there are none.
+func streamsJSON(streams []Stream) []streamJSON {
+
+	if len(streams) == 0 {
+		return nil
+	}
+
+	j := make([]streamJSON, len(streams))
+	for i, st := range streams {
+		j[i] = streamJSON{Name: st.Name, Size: st.Size, SHA256: hex.EncodeToString(st.SHA256)}
+	}
+
+	return j
+
+}
+
+// timeOrNil returns nil for the zero time, so it is omitted from JSON.
+func timeOrNil(t time.Time) *time.Time {
+
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+
+}
@@ -0,0 +1,153 @@
+package objectify
+
+// deferChecksums clears any previously computed checksums and marks them as
+// pending, if the Sets request a checksum (or an HMAC key, decompressed
+// checksum, or chunking is set) and the entry is readable.
+func (fo *FileObj) deferChecksums() {
+
+	fo.MD5, fo.ChecksumMD5 = nil, EMPTY
+	fo.SHA256, fo.ChecksumSHA256 = nil, EMPTY
+	fo.HMAC, fo.ChecksumHMAC = nil, EMPTY
+	fo.DecompressedSHA256, fo.ChecksumDecompressed = nil, EMPTY
+	fo.Chunks = nil
+
+	o := fo.options()
+	wanted := fo.Set.ChecksumMD5 || fo.Set.ChecksumSHA256 || o.hmacKey != nil || o.decompressLimit > 0 || o.chunker != nil
+	if fo.IsExists && fo.IsReadable && wanted {
+		fo.pendingChecksums = true
+	}
+
+}
+
+// MD5Sum returns the MD5 checksum of the FileObj. With WithLazy, the checksums
+// requested by the Sets are computed on the first call and kept; otherwise the
+// MD5 field is returned as-is. An error computing the checksums is stored in the
+// Err field.
+func (fo *FileObj) MD5Sum() []byte {
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	fo.resolveChecksums()
+
+	return fo.MD5
+
+}
+
+// SHA256Sum returns the SHA256 checksum of the FileObj, computing it on the
+// first call with WithLazy (see MD5Sum).
+func (fo *FileObj) SHA256Sum() []byte {
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	fo.resolveChecksums()
+
+	return fo.SHA256
+
+}
+
+// HMACSum returns the HMAC-SHA256 of the FileObj's content (see WithHMACKey),
+// computing it on the first call with WithLazy (see MD5Sum).
+func (fo *FileObj) HMACSum() []byte {
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	fo.resolveChecksums()
+
+	return fo.HMAC
+
+}
+
+// DecompressedSum returns the SHA256 of the FileObj's decompressed content (see
+// WithDecompressedChecksum), computing it on the first call with WithLazy (see
+// MD5Sum).
+func (fo *FileObj) DecompressedSum() []byte {
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	fo.resolveChecksums()
+
+	return fo.DecompressedSHA256
+
+}
+
+// FinalTarget returns the final target of a symlink. With WithLazy, the target
+// is resolved on the first call and kept; otherwise the TargetFinal field is
+// returned as-is. An error resolving the target (e.g. ErrSymlinkCycle) is stored
+// in the Err field.
+func (fo *FileObj) FinalTarget() string {
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	fo.resolveTargetFinal()
+
+	return fo.TargetFinal
+
+}
+
+// Resolve computes every field deferred by WithLazy, so that the FileObj's
+// fields can be read directly, compared, or encoded. It returns the Err field.
+func (fo *FileObj) Resolve() error {
+
+	mu := fo.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+
+	fo.resolveTargetFinal()
+	fo.resolveChecksums()
+
+	return fo.Err
+
+}
+
+// resolveChecksums computes the checksums if they are pending.
+func (fo *FileObj) resolveChecksums() {
+
+	if !fo.pendingChecksums {
+		return
+	}
+	fo.pendingChecksums = false
+
+	fo.keepErr(fo.pass(fo.addChecksums, fo.addHMAC, fo.addDecompressedChecksum, fo.addChunks))
+
+}
+
+// resolveTargetFinal resolves the final link target if it is pending.
+func (fo *FileObj) resolveTargetFinal() {
+
+	if !fo.pendingTargetFinal {
+		return
+	}
+	fo.pendingTargetFinal = false
+
+	var err error
+	fo.TargetFinal, err = getsFinalTarget(fo.options().sys(), fo.ioPath(), fo.info, fo.options().maxLinkHops)
+	fo.keepErr(err)
+
+}
+
+// Resolve calls Resolve on every FileObj, and returns the first non-nil Err.
+func (files Files) Resolve() error {
+
+	var first error
+	for _, fo := range files {
+		if fo == nil {
+			continue
+		}
+		if err := fo.Resolve(); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+
+}
@@ -0,0 +1,286 @@
+package objectify
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ParseManifest reads a checksum file produced by md5sum, sha256sum, or the BSD
+// md5/sha256 tools (and their --tag output), and returns a FileObj for each
+// listed file, holding its recorded digest. Supported line formats are:
+//
+//	d41d8cd98f00b204e9800998ecf8427e  path/to/file   (GNU text mode)
+//	d41d8cd98f00b204e9800998ecf8427e *path/to/file   (GNU binary mode)
+//	MD5 (path/to/file) = d41d8cd98f00b204e9800998ecf8427e
+//	SHA256(path/to/file)= e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+//
+// For GNU lines, the algorithm is chosen by the digest length. Lines starting
+// with a backslash have their path unescaped as coreutils does. Blank lines and
+// lines starting with # are ignored. A path listed more than once (e.g. in a
+// combined MD5 and SHA256 manifest) yields a single FileObj with both digests.
+//
+// Paths are kept as written: a relative path is not resolved, so its Root is
+// relative too. The returned FileObjs only have the checksum Sets enabled, so
+// Files.Diff compares them against a scan by checksum alone.
+// An error wrapping ErrInvalidManifest is returned for a malformed line.
+func ParseManifest(r io.Reader) (Files, error) {
+
+	var files Files
+	seen := make(map[string]*FileObj)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == EMPTY || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		algo, sum, path, ok := parseManifestLine(text)
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d", ErrInvalidManifest, line)
+		}
+
+		fo, ok := seen[path]
+		if !ok {
+			fo = &FileObj{
+				Root:       filepath.Dir(path),
+				Filename:   filepath.Base(path),
+				Set:        &Sets{},
+				IsExists:   true,
+				IsReadable: true,
+				opts:       newOptions(),
+				mu:         &sync.RWMutex{},
+			}
+			seen[path] = fo
+			files = append(files, fo)
+		}
+
+		switch algo {
+		case "MD5":
+			fo.MD5, fo.ChecksumMD5 = sum, hex.EncodeToString(sum)
+			fo.Set.ChecksumMD5 = true
+		case "SHA256":
+			fo.SHA256, fo.ChecksumSHA256 = sum, hex.EncodeToString(sum)
+			fo.Set.ChecksumSHA256 = true
+		}
+
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+
+}
+
+// parseManifestLine parses a single GNU or BSD style checksum line and returns
+// the algorithm name (MD5 or SHA256), the decoded digest, and the path.
+func parseManifestLine(line string) (algo string, sum []byte, path string, ok bool) {
+
+	escaped := strings.HasPrefix(line, "\\")
+	if escaped {
+		line = line[1:]
+	}
+
+	var hexSum string
+	if algo, hexSum, path, ok = parseBSDLine(line); !ok {
+		if hexSum, path, ok = parseGNULine(line); !ok {
+			return EMPTY, nil, EMPTY, false
+		}
+		switch len(hexSum) {
+		case 32:
+			algo = "MD5"
+		case 64:
+			algo = "SHA256"
+		default:
+			return EMPTY, nil, EMPTY, false
+		}
+	}
+
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil || (algo == "MD5" && len(sum) != 16) || (algo == "SHA256" && len(sum) != 32) {
+		return EMPTY, nil, EMPTY, false
+	}
+
+	if escaped {
+		path = unescapeManifestPath(path)
+	}
+
+	return algo, sum, path, path != EMPTY
+
+}
+
+// parseGNULine splits a "DIGEST  PATH" or "DIGEST *PATH" line.
+func parseGNULine(line string) (sum, path string, ok bool) {
+
+	sum, rest, ok := strings.Cut(line, " ")
+	if !ok || len(rest) < 2 || (rest[0] != ' ' && rest[0] != '*') {
+		return EMPTY, EMPTY, false
+	}
+
+	return sum, rest[1:], true
+
+}
+
+// parseBSDLine splits an "ALGO (PATH) = DIGEST" or "ALGO(PATH)= DIGEST" line.
+// Only the MD5 and SHA256 algorithms are recognized.
+func parseBSDLine(line string) (algo, sum, path string, ok bool) {
+
+	open := strings.IndexByte(line, '(')
+	if open < 0 {
+		return EMPTY, EMPTY, EMPTY, false
+	}
+
+	algo = strings.ToUpper(strings.TrimSpace(line[:open]))
+	if algo != "MD5" && algo != "SHA256" {
+		return EMPTY, EMPTY, EMPTY, false
+	}
+
+	// The path may itself contain ") = ", so split at the last one.
+	rest := line[open+1:]
+	end := strings.LastIndex(rest, ")")
+	if end < 0 {
+		return EMPTY, EMPTY, EMPTY, false
+	}
+	sum, ok = strings.CutPrefix(strings.TrimSpace(rest[end+1:]), "=")
+	if !ok {
+		return EMPTY, EMPTY, EMPTY, false
+	}
+
+	return algo, strings.TrimSpace(sum), rest[:end], true
+
+}
+
+// unescapeManifestPath reverses the escaping coreutils applies to paths
+// containing a backslash, newline, or carriage return.
+func unescapeManifestPath(p string) string {
+
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		if p[i] == '\\' && i+1 < len(p) {
+			switch p[i+1] {
+			case '\\':
+				b.WriteByte('\\')
+				i++
+				continue
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case 'r':
+				b.WriteByte('\r')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(p[i])
+	}
+
+	return b.String()
+
+}
+
+// Manifest writes the checksums recorded in a scan as a checksum file, which
+// can be signed with Sign and read back with ParseManifest, VerifyManifest, or
+// VerifySignedManifest. Lines use the tagged format of the BSD tools and
+// "sha256sum --tag", so "sha256sum -c" and "md5sum -c" can check them too
+// (each warns about the other's lines in a manifest listing both):
+//
+//	SHA256 (path/to/file) = e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+//	MD5 (path/to/file) = d41d8cd98f00b204e9800998ecf8427e
+type Manifest struct {
+
+	// Files are the entries to list. Entries without a recorded MD5 or SHA256
+	// checksum are skipped.
+	Files Files
+
+	// Base, if set, is removed from the start of each path, so the manifest
+	// lists paths relative to it (see VerifyManifest).
+	Base string
+}
+
+// NewManifest returns a Manifest listing files with their full paths.
+func NewManifest(files Files) *Manifest {
+	return &Manifest{Files: files}
+}
+
+// Bytes returns the text of the manifest. Entries are sorted by path, and each
+// has a SHA256 line and then an MD5 line, for the checksums it has recorded.
+// Paths containing a backslash, newline, or carriage return are escaped as
+// coreutils does. The output is the same for the same Files, so it can be signed.
+func (m *Manifest) Bytes() []byte {
+
+	type entry struct {
+		path string
+		fo   *FileObj
+	}
+
+	var entries []entry
+	for _, fo := range m.Files {
+		if fo == nil || (fo.SHA256 == nil && fo.MD5 == nil) {
+			continue
+		}
+		entries = append(entries, entry{path: m.path(fo), fo: fo})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].path < entries[j].path })
+
+	var b strings.Builder
+	for _, e := range entries {
+		if e.fo.SHA256 != nil {
+			writeManifestLine(&b, "SHA256", e.path, e.fo.SHA256)
+		}
+		if e.fo.MD5 != nil {
+			writeManifestLine(&b, "MD5", e.path, e.fo.MD5)
+		}
+	}
+
+	return []byte(b.String())
+
+}
+
+// WriteTo implements io.WriterTo, writing the text returned by Bytes.
+func (m *Manifest) WriteTo(w io.Writer) (int64, error) {
+
+	n, err := w.Write(m.Bytes())
+
+	return int64(n), err
+
+}
+
+// path returns the path listed for fo: its full path, relative to Base if set.
+func (m *Manifest) path(fo *FileObj) string {
+
+	p := fo.FullPath()
+	if m.Base == EMPTY {
+		return p
+	}
+
+	if rel, err := filepath.Rel(m.Base, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+
+	return p
+
+}
+
+// writeManifestLine writes a tagged checksum line, escaping the path as
+// coreutils does when needed.
+func writeManifestLine(b *strings.Builder, algo, path string, sum []byte) {
+
+	if strings.ContainsAny(path, "\\\n\r") {
+		b.WriteByte('\\')
+		path = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(path)
+	}
+
+	fmt.Fprintf(b, "%s (%s) = %s\n", algo, path, hex.EncodeToString(sum))
+
+}
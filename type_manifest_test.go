@@ -0,0 +1,112 @@
+package objectify
+
+import (
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	emptyMD5    = "d41d8cd98f00b204e9800998ecf8427e"
+	emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+func TestParseManifest(t *testing.T) {
+
+	for _, tc := range []struct {
+		name, line, path string
+		md5, sha256      string
+	}{
+		{"GNU text MD5", emptyMD5 + "  dir/file", "dir/file", emptyMD5, ""},
+		{"GNU binary SHA256", emptySHA256 + " *dir/file", "dir/file", "", emptySHA256},
+		{"GNU path with spaces", emptyMD5 + "  a file  name", "a file  name", emptyMD5, ""},
+		{"BSD tagged", "SHA256 (dir/file) = " + emptySHA256, "dir/file", "", emptySHA256},
+		{"BSD compact", "MD5(dir/file)= " + emptyMD5, "dir/file", emptyMD5, ""},
+		{"BSD lower case", "sha256 (dir/file) = " + emptySHA256, "dir/file", "", emptySHA256},
+		{"BSD path with parentheses", "MD5 (a (1)) = " + emptyMD5, "a (1)", emptyMD5, ""},
+		{"escaped GNU", "\\" + emptyMD5 + "  dir\\\\back\\nline", "dir\\back\nline", emptyMD5, ""},
+		{"escaped BSD", "\\SHA256 (cr\\rhere) = " + emptySHA256, "cr\rhere", "", emptySHA256},
+		{"CRLF", "MD5 (dir/file) = " + emptyMD5 + "\r", "dir/file", emptyMD5, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+
+			files, err := ParseManifest(strings.NewReader("# comment\n\n" + tc.line + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 1 {
+				t.Fatalf("parsed %d entries, want 1", len(files))
+			}
+
+			fo := files[0]
+			if p := fo.FullPath(); p != filepath.FromSlash(tc.path) {
+				t.Errorf("path = %q, want %q", p, tc.path)
+			}
+			if hex.EncodeToString(fo.MD5) != tc.md5 || hex.EncodeToString(fo.SHA256) != tc.sha256 {
+				t.Errorf("MD5 %x, SHA256 %x, want %q and %q", fo.MD5, fo.SHA256, tc.md5, tc.sha256)
+			}
+			if fo.Set.ChecksumMD5 != (tc.md5 != "") || fo.Set.ChecksumSHA256 != (tc.sha256 != "") || fo.Set.Size {
+				t.Errorf("Sets = %+v, want only the parsed checksum", *fo.Set)
+			}
+
+		})
+	}
+
+}
+
+func TestParseManifestCombined(t *testing.T) {
+
+	files, err := ParseManifest(strings.NewReader(
+		"SHA256 (a) = " + emptySHA256 + "\n" + emptyMD5 + "  a\n" + emptyMD5 + "  b\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].SHA256 == nil || files[0].MD5 == nil || files[1].SHA256 != nil {
+		t.Errorf("parsed %d entries, want a with both checksums and b with MD5 only", len(files))
+	}
+
+}
+
+func TestParseManifestInvalid(t *testing.T) {
+
+	for _, line := range []string{
+		"not a checksum line",
+		emptyMD5 + " file",
+		emptyMD5[:30] + "  file",
+		"SHA1 (file) = da39a3ee5e6b4b0d3255bfef95601890afd80709",
+		"MD5 (file) = " + emptySHA256,
+		"MD5 (file) " + emptyMD5,
+		"SHA256 () = " + emptySHA256,
+		"zz" + emptyMD5[2:] + "  file",
+	} {
+		if _, err := ParseManifest(strings.NewReader(line + "\n")); !errors.Is(err, ErrInvalidManifest) {
+			t.Errorf("%q: err = %v, want ErrInvalidManifest", line, err)
+		}
+	}
+
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+
+	sum, _ := hex.DecodeString(emptySHA256)
+	files := Files{
+		{Root: "/base/dir", Filename: "plain", SHA256: sum},
+		{Root: "/base", Filename: "back\\slash\nnewline", SHA256: sum},
+	}
+
+	parsed, err := ParseManifest(strings.NewReader(string((&Manifest{Files: files, Base: "/base"}).Bytes())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, fo := range parsed {
+		paths = append(paths, fo.FullPath())
+	}
+	want := []string{"back\\slash\nnewline", filepath.Join("dir", "plain")}
+	if strings.Join(paths, "|") != strings.Join(want, "|") {
+		t.Errorf("paths = %q, want %q", paths, want)
+	}
+
+}
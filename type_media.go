@@ -0,0 +1,543 @@
+package objectify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keys of the FileObj.Meta entries set by WithMediaInfo.
+const (
+	// MetaDuration is the playing time in seconds, with millisecond precision,
+	// e.g. "215.040".
+	MetaDuration = "media.duration"
+
+	// MetaVideoCodec and MetaAudioCodec identify the codec of the first video
+	// and audio track: the MP4 sample entry type (e.g. "avc1", "mp4a"), the
+	// Matroska CodecID (e.g. "V_MPEG4/ISO/AVC", "A_OPUS"), or "mp3".
+	MetaVideoCodec = "media.video_codec"
+	MetaAudioCodec = "media.audio_codec"
+
+	// MetaBitrate is the average bitrate of the whole file in bits per second,
+	// computed from its size and duration.
+	MetaBitrate = "media.bitrate"
+)
+
+const (
+	// maxMoovBox is the largest MP4 moov box read. The moov box holds the
+	// sample tables, so it grows with the length of the recording.
+	maxMoovBox = 64 << 20
+
+	// maxEBMLElement is the largest Matroska Info or Tracks element read.
+	maxEBMLElement = 16 << 20
+)
+
+// EBML element IDs read by mkvInfo.
+const (
+	ebmlHeader        = 0x1a45dfa3
+	ebmlSegment       = 0x18538067
+	ebmlInfo          = 0x1549a966
+	ebmlTimecodeScale = 0x2ad7b1
+	ebmlDuration      = 0x4489
+	ebmlTracks        = 0x1654ae6b
+	ebmlTrackEntry    = 0xae
+	ebmlTrackType     = 0x83
+	ebmlCodecID       = 0x86
+)
+
+// mediaInfo holds the fields recorded by extractMedia.
+type mediaInfo struct {
+	duration   time.Duration
+	videoCodec string
+	audioCodec string
+}
+
+// mediaExtractor is the Extractor added by WithMediaInfo.
+type mediaExtractor struct{}
+
+// Match returns true: media files are detected by their magic bytes in Extract.
+func (mediaExtractor) Match(*FileObj) bool {
+	return true
+}
+
+// Extract reads the headers of an MP4 (or QuickTime), Matroska (or WebM), or
+// MP3 file, detected by its magic bytes, and sets the MetaDuration,
+// MetaVideoCodec, MetaAudioCodec, and MetaBitrate entries of the Meta field.
+// The media streams are not decoded. Files of other types, and files whose
+// headers cannot be parsed, are left unchanged. MP4 and Matroska files are only
+// read if r implements io.ReadSeeker.
+func (mediaExtractor) Extract(fo *FileObj, r io.Reader) error {
+
+	size := fo.SizeBytes
+	if fo.info != nil {
+		size = fo.info.Size()
+	}
+
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(12)
+	rs, seekable := r.(io.ReadSeeker)
+
+	var info mediaInfo
+	var ok bool
+	switch {
+	case len(header) == 12 && string(header[4:8]) == "ftyp" && !isHEIFBrand(string(header[8:12])):
+		if seekable {
+			info, ok = mp4Info(rs)
+		}
+	case bytes.HasPrefix(header, []byte{0x1a, 0x45, 0xdf, 0xa3}):
+		if seekable {
+			info, ok = mkvInfo(rs)
+		}
+	case bytes.HasPrefix(header, []byte("ID3")) || isMP3Frame(header):
+		info, ok = mp3Info(br, size)
+	}
+	if !ok {
+		return nil
+	}
+
+	if info.duration > 0 {
+		fo.SetMeta(MetaDuration, strconv.FormatFloat(info.duration.Seconds(), 'f', 3, 64))
+		if size > 0 {
+			bps := float64(size) * 8 / info.duration.Seconds()
+			fo.SetMeta(MetaBitrate, strconv.FormatInt(int64(math.Round(bps)), 10))
+		}
+	}
+	if info.videoCodec != EMPTY {
+		fo.SetMeta(MetaVideoCodec, info.videoCodec)
+	}
+	if info.audioCodec != EMPTY {
+		fo.SetMeta(MetaAudioCodec, info.audioCodec)
+	}
+
+	return nil
+
+}
+
+// mp4Info reads the duration from the movie header (mvhd) of the MP4 file read
+// from r, and the codecs from the sample descriptions (stsd) of its tracks.
+func mp4Info(r io.ReadSeeker) (info mediaInfo, ok bool) {
+
+	moov := isoBox(r, "moov", maxMoovBox)
+	if moov == nil {
+		return info, false
+	}
+
+	forEachBox(moov, func(typ string, body []byte) {
+		switch typ {
+		case "mvhd":
+			info.duration, ok = mvhdDuration(body)
+		case "trak":
+			handler, codec := mp4Track(body)
+			switch {
+			case handler == "vide" && info.videoCodec == EMPTY:
+				info.videoCodec = codec
+			case handler == "soun" && info.audioCodec == EMPTY:
+				info.audioCodec = codec
+			}
+		}
+	})
+
+	return info, ok
+
+}
+
+// mvhdDuration returns the duration recorded in the body of an mvhd box.
+func mvhdDuration(mvhd []byte) (time.Duration, bool) {
+
+	c := &boxCursor{b: mvhd}
+	version := c.uint(1)
+	c.skip(3)
+
+	var timescale, duration uint64
+	if version == 1 {
+		c.skip(16)
+		timescale = c.uint(4)
+		duration = c.uint(8)
+	} else {
+		c.skip(8)
+		timescale = c.uint(4)
+		duration = c.uint(4)
+	}
+	if c.bad || timescale == 0 {
+		return 0, false
+	}
+
+	return scaleDuration(float64(duration) / float64(timescale)), true
+
+}
+
+// mp4Track returns the handler type ("vide", "soun", ...) of the trak box body,
+// from its mdia/hdlr box, and the type of its first sample entry, from its
+// mdia/minf/stbl/stsd box.
+func mp4Track(trak []byte) (handler, codec string) {
+
+	forEachBox(trak, func(typ string, mdia []byte) {
+		if typ != "mdia" {
+			return
+		}
+		forEachBox(mdia, func(typ string, body []byte) {
+			switch typ {
+			case "hdlr":
+				c := &boxCursor{b: body}
+				c.skip(8)
+				if v := c.bytes(4); !c.bad {
+					handler = string(v)
+				}
+			case "minf":
+				codec = mp4Codec(body)
+			}
+		})
+	})
+
+	return handler, codec
+
+}
+
+// mp4Codec returns the type of the first sample entry in the stbl/stsd box of
+// a minf box body.
+func mp4Codec(minf []byte) (codec string) {
+
+	forEachBox(minf, func(typ string, stbl []byte) {
+		if typ != "stbl" {
+			return
+		}
+		forEachBox(stbl, func(typ string, stsd []byte) {
+			if typ != "stsd" || len(stsd) < 8 {
+				return
+			}
+			// Skip the version, flags, and entry count.
+			forEachBox(stsd[8:], func(typ string, _ []byte) {
+				if codec == EMPTY {
+					codec = strings.TrimSpace(typ)
+				}
+			})
+		})
+	})
+
+	return codec
+
+}
+
+// mkvInfo reads the duration from the Info element, and the codecs from the
+// Tracks element, of the Matroska file read from r. Other top-level elements of
+// the segment, such as Clusters, are skipped with Seek until both are found.
+func mkvInfo(r io.ReadSeeker) (info mediaInfo, ok bool) {
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return info, false
+	}
+	br := bufio.NewReader(r)
+
+	id, size, n := readEBMLHeader(br)
+	if id != ebmlHeader || n == 0 || size == math.MaxUint64 {
+		return info, false
+	}
+	pos := int64(n) + int64(size)
+	if _, err := br.Discard(int(size)); err != nil {
+		return info, false
+	}
+
+	id, _, n = readEBMLHeader(br)
+	if id != ebmlSegment || n == 0 {
+		return info, false
+	}
+	pos += int64(n)
+
+	var haveInfo, haveTracks bool
+	for !(haveInfo && haveTracks) {
+
+		id, size, n := readEBMLHeader(br)
+		if n == 0 || size == math.MaxUint64 {
+			break
+		}
+		pos += int64(n)
+
+		switch id {
+		case ebmlInfo, ebmlTracks:
+			if size > maxEBMLElement {
+				return info, false
+			}
+			body := make([]byte, size)
+			if _, err := io.ReadFull(br, body); err != nil {
+				return info, haveInfo
+			}
+			if id == ebmlInfo {
+				info.duration, haveInfo = mkvDuration(body)
+			} else {
+				info.videoCodec, info.audioCodec = mkvCodecs(body)
+				haveTracks = true
+			}
+		default:
+			// Skip other elements, such as Clusters, without reading them.
+			if _, err := r.Seek(pos+int64(size), io.SeekStart); err != nil {
+				return info, haveInfo
+			}
+			br.Reset(r)
+		}
+		pos += int64(size)
+
+	}
+
+	return info, haveInfo || haveTracks
+
+}
+
+// mkvDuration returns the duration recorded in the body of an Info element.
+func mkvDuration(body []byte) (time.Duration, bool) {
+
+	scale := uint64(1000000)
+	var duration float64
+	var found bool
+	forEachEBML(body, func(id uint64, v []byte) {
+		switch id {
+		case ebmlTimecodeScale:
+			scale = ebmlUint(v)
+		case ebmlDuration:
+			switch len(v) {
+			case 4:
+				duration, found = float64(math.Float32frombits(binary.BigEndian.Uint32(v))), true
+			case 8:
+				duration, found = math.Float64frombits(binary.BigEndian.Uint64(v)), true
+			}
+		}
+	})
+	if !found {
+		return 0, false
+	}
+
+	return scaleDuration(duration * float64(scale) / 1e9), true
+
+}
+
+// mkvCodecs returns the CodecID of the first video and audio TrackEntry in the
+// body of a Tracks element.
+func mkvCodecs(body []byte) (video, audio string) {
+
+	forEachEBML(body, func(id uint64, entry []byte) {
+		if id != ebmlTrackEntry {
+			return
+		}
+		var kind uint64
+		var codec string
+		forEachEBML(entry, func(id uint64, v []byte) {
+			switch id {
+			case ebmlTrackType:
+				kind = ebmlUint(v)
+			case ebmlCodecID:
+				codec = strings.TrimRight(string(v), "\x00")
+			}
+		})
+		switch {
+		case kind == 1 && video == EMPTY:
+			video = codec
+		case kind == 2 && audio == EMPTY:
+			audio = codec
+		}
+	})
+
+	return video, audio
+
+}
+
+// readEBMLHeader reads an EBML element ID and data size from r, and returns
+// them with the number of bytes read, or n = 0 on error. An unknown size is
+// returned as math.MaxUint64.
+func readEBMLHeader(r *bufio.Reader) (id, size uint64, n int) {
+
+	id, idLen := readVint(r, false)
+	if idLen == 0 {
+		return 0, 0, 0
+	}
+	size, sizeLen := readVint(r, true)
+	if sizeLen == 0 {
+		return 0, 0, 0
+	}
+
+	return id, size, idLen + sizeLen
+
+}
+
+// readVint reads an EBML variable-length integer from r. With mask, the length
+// marker is removed, as for data sizes, and a value of all ones (an unknown
+// size) is returned as math.MaxUint64; without it, as for element IDs, the
+// marker is kept. It returns the length read, or 0 on error.
+func readVint(r *bufio.Reader, mask bool) (uint64, int) {
+
+	first, err := r.ReadByte()
+	if err != nil || first == 0 {
+		return 0, 0
+	}
+
+	length := bits.LeadingZeros8(first) + 1
+	v := uint64(first)
+	if mask {
+		v &= 0xff >> length
+	}
+	allOnes := v == 0xff>>length
+
+	for i := 1; i < length; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, 0
+		}
+		allOnes = allOnes && b == 0xff
+		v = v<<8 | uint64(b)
+	}
+	if mask && allOnes {
+		return math.MaxUint64, length
+	}
+
+	return v, length
+
+}
+
+// forEachEBML calls fn with the ID and data of each EBML element in b, stopping
+// at the first truncated element.
+func forEachEBML(b []byte, fn func(id uint64, data []byte)) {
+
+	r := bufio.NewReader(bytes.NewReader(b))
+	for {
+
+		id, size, n := readEBMLHeader(r)
+		if n == 0 || size > uint64(len(b)) {
+			return
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return
+		}
+		fn(id, data)
+
+	}
+
+}
+
+// ebmlUint returns the big-endian unsigned integer value of an EBML element.
+func ebmlUint(v []byte) uint64 {
+
+	var n uint64
+	for _, b := range v {
+		n = n<<8 | uint64(b)
+	}
+
+	return n
+
+}
+
+// mp3Bitrates are the MPEG audio Layer III bitrates in kbit/s, by bitrate
+// index, for MPEG-1 and for MPEG-2 and 2.5.
+var mp3Bitrates = [2][15]int{
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+}
+
+// mp3SampleRates are the MPEG-1 sample rates, by sample rate index. MPEG-2
+// halves them, and MPEG-2.5 quarters them.
+var mp3SampleRates = [3]int{44100, 48000, 32000}
+
+// isMP3Frame returns true if header starts with an MPEG audio Layer III frame
+// header.
+func isMP3Frame(header []byte) bool {
+
+	return len(header) >= 4 && header[0] == 0xff && header[1]&0xe0 == 0xe0 &&
+		header[1]>>1&0x3 == 1 && header[1]>>3&0x3 != 1 &&
+		header[2]>>4 != 0xf && header[2]>>2&0x3 != 3
+
+}
+
+// mp3Info reads the duration of the MP3 file read from r, of size bytes, from
+// its first frame: from the frame count of a Xing or Info header, if present
+// (as written for VBR files), or otherwise from the frame's bitrate.
+func mp3Info(r *bufio.Reader, size int64) (info mediaInfo, ok bool) {
+
+	audio := size
+	if header, _ := r.Peek(10); bytes.HasPrefix(header, []byte("ID3")) && len(header) == 10 {
+		tag := int64(header[6]&0x7f)<<21 | int64(header[7]&0x7f)<<14 | int64(header[8]&0x7f)<<7 | int64(header[9]&0x7f)
+		tag += 10
+		if header[5]&0x10 != 0 {
+			tag += 10
+		}
+		if _, err := r.Discard(int(tag)); err != nil {
+			return info, false
+		}
+		audio -= tag
+	}
+
+	frame, _ := r.Peek(4 + 32 + 12)
+	if !isMP3Frame(frame) {
+		return info, false
+	}
+
+	version := frame[1] >> 3 & 0x3 // 3 is MPEG-1, 2 is MPEG-2, 0 is MPEG-2.5
+	mpeg1 := version == 3
+	table, samples := 1, 576
+	if mpeg1 {
+		table, samples = 0, 1152
+	}
+	bitrate := mp3Bitrates[table][frame[2]>>4] * 1000
+	rate := mp3SampleRates[frame[2]>>2&0x3]
+	switch version {
+	case 2:
+		rate /= 2
+	case 0:
+		rate /= 4
+	}
+	mono := frame[3]>>6 == 3
+
+	// The Xing or Info header follows the side information of the first frame.
+	xing := 4 + 17
+	switch {
+	case mpeg1 && !mono:
+		xing = 4 + 32
+	case !mpeg1 && mono:
+		xing = 4 + 9
+	}
+
+	info.audioCodec = "mp3"
+	if len(frame) >= xing+12 {
+		tag := string(frame[xing : xing+4])
+		flags := binary.BigEndian.Uint32(frame[xing+4:])
+		if (tag == "Xing" || tag == "Info") && flags&1 != 0 {
+			frames := binary.BigEndian.Uint32(frame[xing+8:])
+			info.duration = scaleDuration(float64(frames) * float64(samples) / float64(rate))
+			return info, true
+		}
+	}
+	if bitrate > 0 && audio > 0 {
+		info.duration = scaleDuration(float64(audio) * 8 / float64(bitrate))
+	}
+
+	return info, true
+
+}
+
+// scaleDuration converts seconds to a time.Duration, rounded to the millisecond.
+func scaleDuration(seconds float64) time.Duration {
+
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)
+
+}
+
+// MediaDuration returns the playing time of an audio or video file, from the
+// MetaDuration entry set by WithMediaInfo, and whether it is known.
+func (fo *FileObj) MediaDuration() (time.Duration, bool) {
+
+	v, ok := fo.Meta[MetaDuration]
+	if !ok {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return scaleDuration(seconds), true
+
+}
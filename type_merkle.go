@@ -0,0 +1,274 @@
+package objectify
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MerkleAlgo selects the content checksum of each file and the hash function
+// of a MerkleTree.
+type MerkleAlgo int
+
+const (
+	// MerkleSHA256 builds the tree with SHA256 over the SHA256 of each file.
+	MerkleSHA256 MerkleAlgo = iota
+
+	// MerkleMD5 builds the tree with MD5 over the MD5 of each file. It is only
+	// suitable for detecting accidental changes.
+	MerkleMD5
+)
+
+// String returns the name of the MerkleAlgo.
+func (a MerkleAlgo) String() string {
+
+	switch a {
+	case MerkleSHA256:
+		return "SHA256"
+	case MerkleMD5:
+		return "MD5"
+	}
+
+	return fmt.Sprintf("MerkleAlgo(%d)", int(a))
+
+}
+
+// newHash returns a new hash.Hash for the algorithm.
+func (a MerkleAlgo) newHash() hash.Hash {
+
+	if a == MerkleMD5 {
+		return md5.New()
+	}
+
+	return sha256.New()
+
+}
+
+// contentSum returns the checksum of fo used for the algorithm.
+func (a MerkleAlgo) contentSum(fo *FileObj) []byte {
+
+	if a == MerkleMD5 {
+		return fo.MD5
+	}
+
+	return fo.SHA256
+
+}
+
+// Domain separation prefixes, as in RFC 6962, so a leaf can never be passed
+// off as an inner node.
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// MerkleTree is a deterministic Merkle tree over the (path, content checksum)
+// pairs of a scan. Its Root pins the paths and content of the whole tree in a
+// single hash, and Proof shows that one file is part of it without the rest.
+type MerkleTree struct {
+	Algo MerkleAlgo
+
+	// Paths are the slash-separated paths of the leaves, relative to the root
+	// given to MerkleRoot, in order.
+	Paths []string
+
+	// levels holds the hashes of each level, from the leaves to the root.
+	levels [][][]byte
+	sums   [][]byte
+	index  map[string]int
+}
+
+// MerkleProof shows that a file with the path Path and the content checksum Sum
+// is a leaf of a MerkleTree: hashing the leaf with each sibling in turn yields
+// the root.
+type MerkleProof struct {
+	Algo     MerkleAlgo
+	Path     string
+	Sum      []byte
+	Siblings []MerkleSibling
+}
+
+// MerkleSibling is a step of a MerkleProof: the hash of the sibling node, and
+// whether it is on the left.
+type MerkleSibling struct {
+	Hash []byte
+	Left bool
+}
+
+// MerkleRoot builds a MerkleTree over the entries of the Files under root which
+// have the content checksum of algo, i.e. SHA256 or MD5; other entries, such as
+// symlinks, unreadable files, and entries outside root, are left out. root is
+// usually the path which was scanned. Each leaf hashes the slash-separated path
+// of the entry relative to root, so the same tree yields the same root wherever
+// it is mounted or copied. The leaves are sorted by that path, so the root does
+// not depend on the scan order or concurrency either. Each inner node hashes
+// its two children, and an odd node is promoted to the next level unchanged. An
+// entry listed twice is included once.
+func (files Files) MerkleRoot(root string, algo MerkleAlgo) *MerkleTree {
+
+	type leaf struct {
+		path string
+		sum  []byte
+	}
+
+	seen := make(map[string]bool)
+	var leaves []leaf
+	for _, fo := range files {
+		if fo == nil || fo.isSymlink() {
+			continue
+		}
+		sum := algo.contentSum(fo)
+		p, ok := merklePath(root, fo)
+		if !ok || sum == nil || seen[p] {
+			continue
+		}
+		seen[p] = true
+		leaves = append(leaves, leaf{path: p, sum: sum})
+	}
+	sort.Slice(leaves, func(i, j int) bool { return leaves[i].path < leaves[j].path })
+
+	t := &MerkleTree{Algo: algo, index: make(map[string]int, len(leaves))}
+	level := make([][]byte, len(leaves))
+	for i, l := range leaves {
+		t.Paths = append(t.Paths, l.path)
+		t.sums = append(t.sums, l.sum)
+		t.index[l.path] = i
+		level[i] = algo.leafHash(l.path, l.sum)
+	}
+	t.levels = append(t.levels, level)
+
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, algo.nodeHash(level[i], level[i+1]))
+		}
+		t.levels = append(t.levels, next)
+		level = next
+	}
+
+	return t
+
+}
+
+// merklePath returns the slash-separated path of fo relative to root, and
+// false if fo is not under root. The path fo is read from is used, so entries
+// scanned with WithRelativePaths are placed under the scan root. Entries of an
+// fs.FS have slash-separated paths already.
+func merklePath(root string, fo *FileObj) (string, bool) {
+
+	p := fo.ioPath()
+	if fo.options().fsys != nil {
+		root = path.Clean(root)
+		switch {
+		case root == ".":
+			return p, p != "." && p != ".." && !strings.HasPrefix(p, "../")
+		case strings.HasPrefix(p, root+"/"):
+			return strings.TrimPrefix(p, root+"/"), true
+		}
+		return EMPTY, false
+	}
+
+	rel, err := filepath.Rel(pathAbsSafe(root), pathAbsSafe(p))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return EMPTY, false
+	}
+
+	return filepath.ToSlash(rel), true
+
+}
+
+// leafHash returns the hash of a leaf: the prefix, the length-prefixed path,
+// and the content checksum.
+func (a MerkleAlgo) leafHash(path string, sum []byte) []byte {
+
+	h := a.newHash()
+	h.Write([]byte{merkleLeafPrefix})
+	h.Write([]byte(fmt.Sprintf("%d:%s", len(path), path)))
+	h.Write(sum)
+
+	return h.Sum(nil)
+
+}
+
+// nodeHash returns the hash of an inner node with the children left and right.
+func (a MerkleAlgo) nodeHash(left, right []byte) []byte {
+
+	h := a.newHash()
+	h.Write([]byte{merkleNodePrefix})
+	h.Write(left)
+	h.Write(right)
+
+	return h.Sum(nil)
+
+}
+
+// Root returns the root hash of the tree. The root of an empty tree is the
+// hash of no input.
+func (t *MerkleTree) Root() []byte {
+
+	top := t.levels[len(t.levels)-1]
+	if len(top) == 0 {
+		return t.Algo.newHash().Sum(nil)
+	}
+
+	return top[0]
+
+}
+
+// RootString returns the root hash as a hexadecimal string.
+func (t *MerkleTree) RootString() string {
+	return fmt.Sprintf("%x", t.Root())
+}
+
+// Proof returns the MerkleProof of the leaf with the path p, relative to the
+// root given to MerkleRoot, which is converted to slashes. Returns fs.ErrNotExist (wrapped) if the tree has no
+// such leaf.
+func (t *MerkleTree) Proof(p string) (*MerkleProof, error) {
+
+	p = filepath.ToSlash(p)
+	i, ok := t.index[p]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not in the tree", fs.ErrNotExist, p)
+	}
+
+	proof := &MerkleProof{Algo: t.Algo, Path: p, Sum: t.sums[i]}
+	for _, level := range t.levels[:len(t.levels)-1] {
+		switch {
+		case i%2 == 1:
+			proof.Siblings = append(proof.Siblings, MerkleSibling{Hash: level[i-1], Left: true})
+		case i+1 < len(level):
+			proof.Siblings = append(proof.Siblings, MerkleSibling{Hash: level[i+1]})
+		}
+		i /= 2
+	}
+
+	return proof, nil
+
+}
+
+// Verify returns true if the proof leads from the leaf of Path and Sum to root.
+func (p *MerkleProof) Verify(root []byte) bool {
+
+	h := p.Algo.leafHash(p.Path, p.Sum)
+	for _, s := range p.Siblings {
+		if s.Left {
+			h = p.Algo.nodeHash(s.Hash, h)
+		} else {
+			h = p.Algo.nodeHash(h, s.Hash)
+		}
+	}
+
+	return bytes.Equal(h, root)
+
+}
@@ -0,0 +1,96 @@
+package objectify
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// merkleTree creates the same small tree under a new temporary directory,
+// and returns the directory.
+func merkleTree(t *testing.T) string {
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a": "a", "sub/b": "b", "sub/c": "c"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+
+}
+
+func TestMerkleRootRelative(t *testing.T) {
+
+	s := Sets{ChecksumSHA256: true}
+	scan := func(root string, opts ...Option) *MerkleTree {
+		files, err := Path(root, s, append(opts, WithRecursive())...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return files.MerkleRoot(root, MerkleSHA256)
+	}
+
+	one, two := merkleTree(t), merkleTree(t)
+	tree := scan(one)
+	want := []string{"a", "sub/b", "sub/c"}
+	if len(tree.Paths) != len(want) {
+		t.Fatalf("Paths = %q, want %q", tree.Paths, want)
+	}
+	for i := range want {
+		if tree.Paths[i] != want[i] {
+			t.Fatalf("Paths = %q, want %q", tree.Paths, want)
+		}
+	}
+
+	for name, other := range map[string]*MerkleTree{
+		"copy":           scan(two),
+		"relative paths": scan(one, WithRelativePaths()),
+	} {
+		if other.RootString() != tree.RootString() {
+			t.Errorf("%s: root = %s, want %s", name, other.RootString(), tree.RootString())
+		}
+	}
+
+	fsys := fstest.MapFS{
+		"top/a":     {Data: []byte("a")},
+		"top/sub/b": {Data: []byte("b")},
+		"top/sub/c": {Data: []byte("c")},
+	}
+	files, err := PathFS(fsys, "top", s, WithRecursive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := files.MerkleRoot("top", MerkleSHA256).RootString(); got != tree.RootString() {
+		t.Errorf("fs.FS: root = %s, want %s", got, tree.RootString())
+	}
+
+	proof, err := tree.Proof("sub/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proof.Verify(tree.Root()) {
+		t.Error("proof of sub/b does not verify")
+	}
+	if _, err := tree.Proof(filepath.Join(one, "a")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Proof(full path) error = %v, want %v", err, fs.ErrNotExist)
+	}
+
+	// Entries outside root are left out.
+	files, err = Path(one, s, WithRecursive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub := files.MerkleRoot(filepath.Join(one, "sub"), MerkleSHA256); len(sub.Paths) != 2 || sub.Paths[0] != "b" {
+		t.Errorf("Paths under sub = %q, want [b c]", sub.Paths)
+	}
+
+}
@@ -0,0 +1,165 @@
+package objectify
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultMonitorInterval is the time between rescans made by Monitor.Run when
+// Monitor.Interval is not set.
+const DefaultMonitorInterval = time.Minute
+
+// Monitor watches a directory for changes against a baseline scan, in the
+// manner of file integrity checkers such as Tripwire. The baseline is only
+// replaced when Accept or SetBaseline is called, so a change keeps being
+// reported by each rescan until it has been accepted.
+//
+// Changes to checksums are only detected when the Sets include them, and
+// changes to permissions and ownership when the Sets include Modes.
+type Monitor struct {
+
+	// Interval is the time between rescans made by Run.
+	// Defaults to DefaultMonitorInterval.
+	Interval time.Duration
+
+	// Alert is called by Run with the Diff from the baseline whenever a rescan
+	// finds added, removed, or changed entries.
+	Alert func(Diff)
+
+	// OnEvents is called by Run with the Events of the Diff whenever Alert
+	// would be, for callers which handle changes as Events.
+	OnEvents func([]Event)
+
+	// OnError is called by Run when a rescan fails. Run continues with the
+	// next rescan.
+	OnError func(error)
+
+	root string
+	sets Sets
+	opts []Option
+
+	mu       *sync.Mutex
+	baseline Files
+
+	// audit is the AuditLogger set with WithAuditLogger, if any.
+	audit *AuditLogger
+
+	// logged is the part of the Diff from the baseline which has already
+	// been written to audit. It is reset when the baseline is replaced.
+	logged Diff
+}
+
+// NewMonitor scans root with the given Sets and Options and returns a Monitor
+// using the result as its baseline.
+func NewMonitor(root string, s Sets, opts ...Option) (*Monitor, error) {
+
+	m := &Monitor{
+		root:  root,
+		sets:  s,
+		opts:  opts,
+		mu:    &sync.Mutex{},
+		audit: newOptions(opts...).audit,
+	}
+
+	if err := m.Accept(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+
+}
+
+// Baseline returns the current baseline scan.
+func (m *Monitor) Baseline() Files {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.baseline
+
+}
+
+// SetBaseline replaces the baseline, e.g. with a snapshot loaded by ReadSnapshot.
+func (m *Monitor) SetBaseline(files Files) {
+
+	m.mu.Lock()
+	m.baseline = files
+	m.logged = Diff{}
+	m.mu.Unlock()
+
+}
+
+// Accept rescans the root and makes the result the new baseline, acknowledging
+// any changes reported so far.
+func (m *Monitor) Accept() error {
+
+	files, err := Path(m.root, m.sets, m.opts...)
+	if err != nil {
+		return err
+	}
+	m.SetBaseline(files)
+
+	return nil
+
+}
+
+// Check rescans the root and returns the Diff from the baseline to the rescan.
+// The baseline is not changed. With WithAuditLogger, the changes not logged by
+// an earlier Check since the baseline was last replaced are logged, so a change
+// which has not been accepted is only logged again if the entry changes once
+// more. The Diff is returned along with the error if the log cannot be written.
+func (m *Monitor) Check() (Diff, error) {
+
+	files, err := Path(m.root, m.sets, m.opts...)
+	if err != nil {
+		return Diff{}, err
+	}
+
+	m.mu.Lock()
+	d := m.baseline.Diff(files)
+	if m.audit != nil {
+		err = m.audit.LogDiff(d.since(m.logged))
+		m.logged = d
+	}
+	m.mu.Unlock()
+
+	return d, err
+
+}
+
+// Run calls Check every Interval until ctx is done, calling Alert and OnEvents
+// for each non-empty Diff and OnError for each failed rescan or audit log
+// write. It returns ctx.Err().
+func (m *Monitor) Run(ctx context.Context) error {
+
+	interval := m.Interval
+	if interval <= 0 {
+		interval = DefaultMonitorInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		d, err := m.Check()
+		if err != nil && m.OnError != nil {
+			m.OnError(err)
+		}
+		if !d.Empty() && m.Alert != nil {
+			m.Alert(d)
+		}
+		if !d.Empty() && m.OnEvents != nil {
+			m.OnEvents(d.Events())
+		}
+
+	}
+
+}
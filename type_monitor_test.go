@@ -0,0 +1,59 @@
+package objectify
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMonitorAuditLogsNewChanges(t *testing.T) {
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("one")
+
+	var log bytes.Buffer
+	m, err := NewMonitor(dir, Sets{ChecksumSHA256: true}, WithAuditLogger(NewAuditLogger(&log)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := 0
+	check := func(name string, wantChanged, wantNew int) {
+		d, err := m.Check()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(d.Changed) != wantChanged {
+			t.Errorf("%s: %d changed, want %d", name, len(d.Changed), wantChanged)
+		}
+		n := strings.Count(log.String(), "\n")
+		if n-records != wantNew {
+			t.Errorf("%s: %d records logged, want %d", name, n-records, wantNew)
+		}
+		records = n
+	}
+
+	check("unchanged", 0, 0)
+	write("two")
+	check("first change", 1, 1)
+	check("rescan", 1, 0)
+	write("three")
+	check("changed again", 1, 1)
+	check("rescan again", 1, 0)
+
+	if err := m.Accept(); err != nil {
+		t.Fatal(err)
+	}
+	check("accepted", 0, 0)
+	write("four")
+	check("after accept", 1, 1)
+
+}
@@ -0,0 +1,651 @@
+package objectify
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"time"
+)
+
+const (
+	// DefaultMaxLinkHops is the number of symlinks followed when resolving a
+	// final link target before giving up with ErrSymlinkCycle.
+	DefaultMaxLinkHops = 40
+
+	// DefaultConcurrency is the number of entries objectified at once when
+	// WithConcurrency is not used, i.e. entries are objectified one by one.
+	DefaultConcurrency = 1
+)
+
+// Option configures scan-wide behavior for Path and File.
+type Option func(*options)
+
+// SkipFunc is consulted for each directory entry before it is objectified.
+// Returning true skips the entry; for a directory in a recursive scan, the
+// whole subtree is skipped.
+type SkipFunc func(path string, d fs.DirEntry) bool
+
+// options holds the settings applied by Option functions. A FileObj keeps a
+// reference to the options it was created with so that later updates behave
+// the same way as the initial scan.
+type options struct {
+	maxLinkHops   int
+	recursive     bool
+	oneFileSystem bool
+	skipFuncs     []SkipFunc
+	hashCache     HashCache
+	audit         *AuditLogger
+
+	// concurrency is the number of goroutines objectifying entries, and
+	// queueDepth the number of entries which may wait for them.
+	concurrency int
+	queueDepth  int
+
+	// hashSem limits the number of checksums computed at once. It is nil
+	// when the number is not limited.
+	hashWorkers int
+	hashSem     chan struct{}
+
+	// readBuffer is the size of the reads made while hashing, or 0 for the
+	// size io.Copy uses. autoTune sets them from the storage of the root
+	// when a scan starts, unless they are set, and tuned records that they
+	// were. defaultQueue is true if queueDepth was not set.
+	readBuffer   int
+	autoTune     bool
+	tuned        bool
+	defaultQueue bool
+
+	// ioUring reads file content through the shared io_uring while hashing,
+	// where it is available.
+	ioUring bool
+
+	// ctx stops the scan and cancels its reads of file content once it is
+	// done (see WithContext).
+	ctx context.Context
+
+	perFileTimeout time.Duration
+	schedule       Schedule
+	sortPaths      bool
+	ttl            time.Duration
+	history        int
+	clock          Clock
+	lazy           bool
+	allowEmpty     bool
+	dotfilesOnly   bool
+
+	// entTypes and noEntTypes filter the entries by kind. An entTypes of 0
+	// includes every kind.
+	entTypes   EntKind
+	noEntTypes EntKind
+
+	// hmacKey is the key used for the HMAC-SHA256 of each file, if set.
+	hmacKey []byte
+
+	// decompressLimit is the largest decompressed content hashed for
+	// DecompressedSHA256, or 0 if the digest is not computed. decompressors
+	// are the Decompressors added with WithDecompressor.
+	decompressLimit int64
+	decompressors   map[Compression]Decompressor
+
+	// chunker cuts the content of each file into Chunks, if set.
+	chunker *chunker
+
+	// streams lists the alternate data streams of each file, and hashStreams
+	// hashes them.
+	streams     bool
+	hashStreams bool
+
+	// archives expands the members of zip, tar, and tar.gz archives, within
+	// archiveLimits.
+	archives      bool
+	archiveLimits ArchiveLimits
+
+	// imageHash computes the ImageHash of image files.
+	imageHash bool
+
+	// extractors populate FileObj.Meta, in the order they were added.
+	extractors []Extractor
+
+	// relativePaths stores each Root relative to the scan root. scanRoot is
+	// the directory relative Roots are resolved against, or empty if Roots
+	// are used as they are.
+	relativePaths bool
+	scanRoot      string
+
+	// journal is the file the progress of a scan is checkpointed to, every
+	// checkpointEvery, or empty if it is not checkpointed.
+	journal         string
+	checkpointEvery time.Duration
+
+	// hardened opens files on disk with openNoFollow, or relative to their
+	// parent directory (see worker.holdRoot), and never reads the content of
+	// symlinks.
+	hardened bool
+
+	// stats counts the work done when scanning with Scan or ScanFS.
+	stats *scanCounters
+
+	// fsys is set by PathFS and FileFS. When nil, the OS filesystem is used,
+	// through filesystem if it is set (see WithFileSystem).
+	fsys       fs.FS
+	filesystem FileSystem
+
+	// faults are injected into the operations on OS paths (see WithFaults).
+	faults []Fault
+}
+
+// newOptions returns an options struct with default values and applies each
+// of the provided Option functions to it.
+func newOptions(opts ...Option) *options {
+
+	o := &options{
+		maxLinkHops:   DefaultMaxLinkHops,
+		concurrency:   DefaultConcurrency,
+		archiveLimits: DefaultArchiveLimits(),
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+
+	if o.queueDepth == 0 {
+		o.queueDepth = 2 * o.concurrency
+		o.defaultQueue = true
+	}
+	if o.hashWorkers > 0 {
+		o.hashSem = make(chan struct{}, o.hashWorkers)
+	}
+
+	return o
+
+}
+
+// WithMaxLinkHops sets the maximum number of symlinks followed when resolving
+// a final link target. Values less than 1 are ignored.
+func WithMaxLinkHops(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxLinkHops = n
+		}
+	}
+}
+
+// WithOneFileSystem restricts the scan to the device which hosts the root path,
+// like find -xdev. Entries (and, for recursive scans, directories) on other
+// devices, such as mounted network shares or bind mounts, are skipped.
+func WithOneFileSystem() Option {
+	return func(o *options) {
+		o.oneFileSystem = true
+	}
+}
+
+// WithRecursive makes Path descend into subdirectories. Symlinked directories,
+// and NTFS junctions and mount points, are not followed.
+func WithRecursive() Option {
+	return func(o *options) {
+		o.recursive = true
+	}
+}
+
+// WithSkipFunc adds a SkipFunc which is consulted by the worker before each
+// entry is objectified. It can be used more than once; an entry is skipped if
+// any SkipFunc returns true.
+func WithSkipFunc(fn SkipFunc) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.skipFuncs = append(o.skipFuncs, fn)
+		}
+	}
+}
+
+// WithSkipLargerThan skips regular files larger than n bytes before they are
+// objectified, e.g. to leave VM images out of an integrity scan without
+// hashing them first. It adds a SkipFunc, which stats each regular file.
+// Values less than 0 are ignored.
+func WithSkipLargerThan(n int64) Option {
+	if n < 0 {
+		return nil
+	}
+	return WithSkipFunc(func(_ string, d fs.DirEntry) bool {
+		return regularSize(d) > n
+	})
+}
+
+// WithSkipEmpty skips empty regular files, such as zero-byte placeholders,
+// before they are objectified. It adds a SkipFunc, which stats each regular
+// file.
+func WithSkipEmpty() Option {
+	return WithSkipFunc(func(_ string, d fs.DirEntry) bool {
+		return regularSize(d) == 0
+	})
+}
+
+// regularSize returns the size of the directory entry if it is a regular file,
+// or -1 for other entries and entries which cannot be stat'ed.
+func regularSize(d fs.DirEntry) int64 {
+
+	if !d.Type().IsRegular() {
+		return -1
+	}
+
+	info, err := d.Info()
+	if err != nil {
+		return -1
+	}
+
+	return info.Size()
+
+}
+
+// WithConcurrency sets the number of entries objectified at once. Values
+// greater than 1 speed up scans of fast storage (e.g. SSDs) and high-latency
+// backends, while 1 (the default) suits spinning disks. The order of the
+// returned Files does not depend on the concurrency. An fs.FS passed to
+// PathFS must be safe for concurrent use. Values less than 1 are ignored.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithHashWorkers reads the content of files (to compute their checksums,
+// HMAC, chunks, and the like) in a pool of n goroutines, separate from the
+// WithConcurrency goroutines which stat the entries and feed the pool, and
+// limits the number of checksums computed at once across the scan to n. This
+// lets many entries be stat'ed in parallel, without waiting for a hash worker,
+// while keeping reads of file content sequential on storage which is slow to
+// seek. Up to WithQueueDepth stat'ed entries wait for a hash worker. With
+// WithPerFileTimeout or WithHardenedOpen, each entry is still hashed by the
+// goroutine which stat'ed it, within the limit. By default the number is only
+// limited by the concurrency. Values less than 1 are ignored.
+func WithHashWorkers(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.hashWorkers = n
+		}
+	}
+}
+
+// WithStatWorkers sets the number of entries stat'ed at once. It is the same
+// as WithConcurrency, named to pair with WithHashWorkers: a scan of a spinning
+// disk can use WithStatWorkers(32) and WithHashWorkers(1) to read metadata
+// with high parallelism while a single hash worker reads the content of one
+// file at a time. Values less than 1 are ignored.
+func WithStatWorkers(n int) Option {
+	return WithConcurrency(n)
+}
+
+// WithQueueDepth sets the number of discovered entries which may wait to be
+// objectified while the directory walk continues. Defaults to twice the
+// concurrency. Values less than 1 are ignored.
+func WithQueueDepth(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.queueDepth = n
+		}
+	}
+}
+
+// WithContext stops the scan once ctx is done: the entries which were not
+// reached yet are skipped, the reads of the content of the files being hashed
+// fail with the error of ctx, which is recorded in their Err field, and the
+// scan returns the error of ctx. It does not apply to later Updates of the
+// scanned FileObjs.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithPerFileTimeout limits the time spent populating each FileObj, so a single
+// file on a hung network mount cannot stall the whole scan. A FileObj which
+// times out is returned with an ErrFileTimeout error in its Err field, and
+// with the fields read by its stat (such as IsExists, IsReadable, and its
+// size) if the stat finished in time, but none read from its content, such as
+// its checksums; its Update fills them in. The reads of the content are
+// cancelled, and stop at the next read once the system call in progress
+// returns. Durations less than or equal to 0 are ignored.
+func WithPerFileTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.perFileTimeout = d
+		}
+	}
+}
+
+// WithSortedPaths sorts the Files returned by Path and PathFS in lexical order
+// of their full paths, so that scans of the same tree can be compared line by
+// line. Without it, entries are returned in the order they are walked: each
+// directory's entries in the order the filesystem returns them (which is not
+// sorted on most OS filesystems), with subdirectories visited where they occur.
+// Either order is independent of the concurrency.
+func WithSortedPaths() Option {
+	return func(o *options) {
+		o.sortPaths = true
+	}
+}
+
+// WithTTL makes the returned FileObjs refresh themselves: once more than d has
+// passed since a FileObj's UpdatedAt, the next call to Fresh or to an accessor
+// such as ModTime, Age, or SizeString re-reads all of its fields. Fields read
+// directly are not refreshed, so use fo.Fresh().SizeBytes and the like.
+// Durations less than or equal to 0 are ignored.
+func WithTTL(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.ttl = d
+		}
+	}
+}
+
+// WithHistory makes each FileObj keep the states it had before its last n
+// changes, recorded whenever Update finds that the file has changed and reads
+// it again. The History method returns them. Values less than 1 are ignored.
+func WithHistory(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.history = n
+		}
+	}
+}
+
+// WithLazy defers the expensive fields, checksums and final link targets, until
+// they are first read through MD5Sum, SHA256Sum, or FinalTarget (or Resolve),
+// so a scan where only a few entries are inspected only reads those files. The
+// results are kept until the next update. Until they are resolved, the MD5,
+// SHA256, checksum string, and TargetFinal fields are empty, so call
+// Files.Resolve before comparing, deduplicating, verifying, or encoding a lazy
+// scan.
+func WithLazy() Option {
+	return func(o *options) {
+		o.lazy = true
+	}
+}
+
+// WithAllowEmpty makes a non-recursive Path or PathFS scan of a directory with no
+// non-directory entries return an empty Files slice and no error, instead of an
+// ErrNoEntries error.
+func WithAllowEmpty() Option {
+	return func(o *options) {
+		o.allowEmpty = true
+	}
+}
+
+// WithEntTypes limits a scan to the entries whose kind matches any of the bits
+// in k, e.g. WithEntTypes(EntKindRegular|EntKindLink). Entries are filtered by
+// their directory entry type before they are objectified, so filtered entries
+// cost nothing. Multiple calls add to the kinds included.
+func WithEntTypes(k EntKind) Option {
+	return func(o *options) {
+		o.entTypes |= k
+	}
+}
+
+// WithoutEntTypes excludes the entries whose kind matches any of the bits in k
+// from a scan, e.g. WithoutEntTypes(EntKindSpecial) to skip the pipes, sockets,
+// and devices found under /var or /tmp. Exclusions take precedence over
+// WithEntTypes. Multiple calls add to the kinds excluded.
+func WithoutEntTypes(k EntKind) Option {
+	return func(o *options) {
+		o.noEntTypes |= k
+	}
+}
+
+// wantsKind returns true if the entTypes and noEntTypes options allow an entry
+// of kind k.
+func (o *options) wantsKind(k EntKind) bool {
+
+	if o.noEntTypes.Has(k) {
+		return false
+	}
+
+	return o.entTypes == 0 || o.entTypes.Has(k)
+
+}
+
+// WithHMACKey computes the HMAC-SHA256 of each file's content with key, and
+// stores it in the HMAC and ChecksumHMAC fields. A manifest of HMACs cannot be
+// silently regenerated by someone who can rewrite the files but does not hold
+// the key. The key is copied. HMACs are never stored in a HashCache, and an
+// empty key is ignored.
+func WithHMACKey(key []byte) Option {
+	return func(o *options) {
+		if len(key) > 0 {
+			o.hmacKey = bytes.Clone(key)
+		}
+	}
+}
+
+// WithDecompressedChecksum computes the SHA256 of the decompressed content of
+// each gzip or bzip2 file, as detected by its magic bytes, and stores it in the
+// DecompressedSHA256 and ChecksumDecompressed fields. Logs compressed in
+// separate runs, or at different levels, have different checksums but the same
+// decompressed checksum; see Files.DuplicatesDecompressed. Other formats, such
+// as zstd, need a Decompressor added with WithDecompressor. Files which
+// decompress to more than maxBytes are left without a digest, so a small file
+// cannot make the scan read an unbounded stream. Values less than 1 are ignored.
+func WithDecompressedChecksum(maxBytes int64) Option {
+	return func(o *options) {
+		if maxBytes > 0 {
+			o.decompressLimit = maxBytes
+		}
+	}
+}
+
+// WithDecompressor sets the Decompressor used for content in format c by
+// WithDecompressedChecksum, replacing the built-in one, if any. It adds formats
+// the standard library cannot decode, e.g. zstd with a third-party package:
+//
+//	objf.WithDecompressor(objf.CompressionZstd, func(r io.Reader) (io.Reader, error) {
+//		return zstd.NewReader(r)
+//	})
+func WithDecompressor(c Compression, fn Decompressor) Option {
+	return func(o *options) {
+		if fn == nil {
+			return
+		}
+		if o.decompressors == nil {
+			o.decompressors = make(map[Compression]Decompressor)
+		}
+		o.decompressors[c] = fn
+	}
+}
+
+// WithChunks cuts the content of each regular file into content-defined chunks
+// with FastCDC and stores their offsets, sizes, and SHA256 checksums in the
+// Chunks field. An insertion or deletion only changes the chunks around it, so
+// dedup storage backends can store each distinct chunk once, and delta sync
+// tools can transfer only the chunks a peer is missing. The fields of p which
+// are not set are taken from DefaultChunkParams.
+func WithChunks(p ChunkParams) Option {
+	return func(o *options) {
+		o.chunker = newChunker(p)
+	}
+}
+
+// WithAlternateStreams lists the NTFS alternate data streams of each regular
+// file on Windows, such as the Zone.Identifier stream marking downloaded
+// files, and stores their names and sizes in the Streams field. Since streams
+// are also where malware hides, their content is hashed with SHA256 as well if
+// hash is true. Streams are not listed on other platforms or for an fs.FS.
+func WithAlternateStreams(hash bool) Option {
+	return func(o *options) {
+		o.streams = true
+		o.hashStreams = hash
+	}
+}
+
+// WithArchiveMembers makes Path and PathFS expand each zip, tar, or tar.gz
+// archive found by the scan, as detected by its magic bytes, into a virtual
+// FileObj for each regular file member, inserted after the archive's own
+// FileObj. A member's FullPath is the archive's path, ContainerSep, and the
+// member's path, e.g. /srv/build/app.zip!/bin/app, and its Container field
+// holds the archive's path; nothing is extracted to disk. Members are
+// objectified with the scan's Sets and options, and filtered by its SkipFuncs.
+// The members of a tar.gz archive are objectified one at a time, in the order
+// of the archive, so it is decompressed once.
+// Archives nested in archives are only expanded if WithArchiveLimits allows it.
+// An archive whose members exceed the limits records ErrArchiveLimit in its
+// Err field, and only the members before the limit was reached are expanded.
+func WithArchiveMembers() Option {
+	return func(o *options) {
+		o.archives = true
+	}
+}
+
+// WithArchiveLimits sets the ArchiveLimits of WithArchiveMembers, e.g. a
+// MaxDepth greater than 1 to expand archives nested in archives. Fields less
+// than or equal to 0 keep the values of DefaultArchiveLimits.
+func WithArchiveLimits(l ArchiveLimits) Option {
+	return func(o *options) {
+		d := DefaultArchiveLimits()
+		if l.MaxDepth > 0 {
+			d.MaxDepth = l.MaxDepth
+		}
+		if l.MaxMembers > 0 {
+			d.MaxMembers = l.MaxMembers
+		}
+		if l.MaxSize > 0 {
+			d.MaxSize = l.MaxSize
+		}
+		if l.MaxNestedZip > 0 {
+			d.MaxNestedZip = l.MaxNestedZip
+		}
+		if l.MaxRatio > 0 {
+			d.MaxRatio = l.MaxRatio
+		}
+		o.archiveLimits = d
+	}
+}
+
+// WithRelativePaths stores the Root of each entry relative to the root of the
+// scan, e.g. "sub/dir" instead of "/mnt/data/sub/dir", with "." for entries
+// directly in it, and records the root once on the result (see
+// Files.ScanRoot). Snapshots of the same tree mounted at different paths on
+// different hosts then hold the same paths. The root is made absolute for the
+// OS filesystem. Files are still read from the scan root when updated or
+// verified; use Files.SetScanRoot to read them from elsewhere.
+func WithRelativePaths() Option {
+	return func(o *options) {
+		o.relativePaths = true
+	}
+}
+
+// WithCheckpoint periodically writes the progress of a Path or PathFS scan to
+// the journal file: the root and the Sets, then the FileObjs objectified so
+// far. If the process is restarted, Resume (or ResumeFS) continues the scan
+// from the journal instead of starting over. A checkpoint is written at most
+// once per interval, or per DefaultCheckpointInterval if interval is less than
+// 1, and appends the FileObjs objectified since the previous one; a record cut
+// short by a crash is dropped on Resume. The journal is removed once the scan
+// is complete. A failure to write it ends the scan with the error.
+func WithCheckpoint(journal string, interval time.Duration) Option {
+	return func(o *options) {
+		if interval < 1 {
+			interval = DefaultCheckpointInterval
+		}
+		o.journal, o.checkpointEvery = journal, interval
+	}
+}
+
+// WithSchedule sets the order in which entries are objectified, e.g.
+// ScheduleLargeFirst so that with WithConcurrency one 100 GB file is hashed
+// while the other workers process the rest, instead of serializing the tail
+// of the scan. With a Schedule other than ScheduleWalk, the directories are
+// read (and each entry stat'ed) before the first entry is objectified. The
+// FileObjs are still returned in walk order. Walk is not affected.
+func WithSchedule(s Schedule) Option {
+	return func(o *options) {
+		o.schedule = s
+	}
+}
+
+// WithHardenedOpen protects privileged scanners from symlink-swap attacks.
+// Files on the OS filesystem are opened with O_NOFOLLOW and O_CLOEXEC (where
+// available) and must be regular files once opened, or ErrUnsafeFile is
+// recorded; a file swapped for a symlink between the directory read and the
+// open is therefore never read. The content of symlink entries is never read,
+// so they have no checksums. On unix, Path and Walk also hold the root
+// directory open, and open each directory and entry relative to its parent
+// with openat and fstatat, never following symlinks below the root, so that
+// renaming directories during the scan cannot redirect it to other files than
+// it stat'ed. Elsewhere, symlinks in the directories leading to a file are
+// still followed; use it with a root which unprivileged users cannot write.
+func WithHardenedOpen() Option {
+	return func(o *options) {
+		o.hardened = true
+	}
+}
+
+// WithImageHash computes a perceptual hash of each PNG, JPEG, or GIF image, as
+// detected by its magic bytes, and stores it in the ImageHash field. Images
+// which look alike have similar hashes even when they are encoded or sized
+// differently; use ImageDistance, SimilarImage, or Files.SimilarImages to find
+// them. Each image is decoded in full, so this is much slower than a checksum.
+func WithImageHash() Option {
+	return func(o *options) {
+		o.imageHash = true
+	}
+}
+
+// WithEXIF reads the EXIF metadata of JPEG and HEIC photos and records the
+// capture time, camera make and model, and whether a GPS position is present in
+// the Meta field (see MetaCaptureTime and the other Meta keys). Use CaptureTime
+// or Files.ByCaptureTime to sort photos by the date they were taken instead of
+// their modification time.
+func WithEXIF() Option {
+	return WithExtractor(exifExtractor{})
+}
+
+// WithMediaInfo reads the headers of MP4 (and QuickTime), Matroska (and WebM),
+// and MP3 files and records their duration, video and audio codecs, and average
+// bitrate in the Meta field (see MetaDuration and the other Meta keys), so a
+// media library does not need a second pass with a tool such as ffprobe. The
+// media streams are not decoded.
+func WithMediaInfo() Option {
+	return WithExtractor(mediaExtractor{})
+}
+
+// WithExtractor adds an Extractor which enriches each matching regular file
+// with format-specific metadata in its Meta field. It can be used more than
+// once; extractors run in the order they were added, each reading the file
+// from the start.
+func WithExtractor(e Extractor) Option {
+	return func(o *options) {
+		if e != nil {
+			o.extractors = append(o.extractors, e)
+		}
+	}
+}
+
+// ctxErr returns the error of the ctx option, or nil if it is not set or not
+// done.
+func (o *options) ctxErr() error {
+
+	if o.ctx == nil {
+		return nil
+	}
+
+	return o.ctx.Err()
+
+}
+
+// acquireHash waits until a checksum may be computed and returns a function
+// which releases the slot.
+func (o *options) acquireHash() func() {
+
+	if o.hashSem == nil {
+		return func() {}
+	}
+
+	o.hashSem <- struct{}{}
+
+	return func() {
+		<-o.hashSem
+	}
+
+}
@@ -0,0 +1,114 @@
+package objectify
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sync"
+)
+
+// headerWriter keeps the first bytes written to it, for detectCompression.
+type headerWriter struct {
+	header []byte
+}
+
+func (w *headerWriter) Write(p []byte) (int, error) {
+
+	if n := 8 - len(w.header); n > 0 {
+		w.header = append(w.header, p[:min(n, len(p))]...)
+	}
+
+	return len(p), nil
+
+}
+
+// NewFromReader builds a FileObj named name from the content read from r until
+// io.EOF, without a filesystem, so uploads, pipes, and in-memory blobs can be
+// compared with scans and manifests (e.g. with Files.Diff or Verify). r is
+// read once, and every requested field is computed in that single pass.
+//
+// Of the Sets, only Size, ChecksumMD5, ChecksumSHA256, and Compression apply;
+// the others are cleared. The HMAC is computed too if WithHMACKey is given,
+// and UpdatedAt is told by the Clock of WithClock; other options have no
+// effect. name is kept as written, like the paths of
+// ParseManifest, so a relative name has a relative Root. The FileObj is a
+// regular file which exists and is readable, with a zero modification time.
+// As it has no file behind it, Update and the Compute methods do not apply to
+// it. If reading r fails, nil and the error are returned; an empty name is
+// fs.ErrInvalid.
+func NewFromReader(name string, r io.Reader, s Sets, opts ...Option) (*FileObj, error) {
+
+	if name == EMPTY {
+		return nil, fmt.Errorf("%w: empty name", fs.ErrInvalid)
+	}
+
+	o := newOptions(opts...)
+	set := Sets{
+		Size:           s.Size,
+		ChecksumMD5:    s.ChecksumMD5,
+		ChecksumSHA256: s.ChecksumSHA256,
+		Compression:    s.Compression,
+	}
+
+	var md5Hash, sha256Hash, hmacHash hash.Hash
+	var header headerWriter
+	writers := []io.Writer{&header}
+	if set.ChecksumMD5 {
+		md5Hash = md5.New()
+		writers = append(writers, md5Hash)
+	}
+	if set.ChecksumSHA256 {
+		sha256Hash = sha256.New()
+		writers = append(writers, sha256Hash)
+	}
+	if o.hmacKey != nil {
+		hmacHash = hmac.New(sha256.New, o.hmacKey)
+		writers = append(writers, hmacHash)
+	}
+
+	n, err := io.Copy(io.MultiWriter(writers...), r)
+	if err != nil {
+		return nil, err
+	}
+
+	fo := &FileObj{
+		Filename:       filepath.Base(name),
+		Root:           filepath.Dir(name),
+		Mode:           EntModeRegular,
+		AllocatedBytes: -1,
+		IsExists:       true,
+		IsReadable:     true,
+		Set:            &set,
+		opts:           o,
+		mu:             &sync.RWMutex{},
+	}
+
+	if set.Size {
+		fo.SizeBytes = n
+	}
+	if md5Hash != nil {
+		fo.MD5 = md5Hash.Sum(nil)
+		fo.ChecksumMD5 = hex.EncodeToString(fo.MD5)
+	}
+	if sha256Hash != nil {
+		fo.SHA256 = sha256Hash.Sum(nil)
+		fo.ChecksumSHA256 = hex.EncodeToString(fo.SHA256)
+	}
+	if hmacHash != nil {
+		fo.HMAC = hmacHash.Sum(nil)
+		fo.ChecksumHMAC = hex.EncodeToString(fo.HMAC)
+	}
+	if set.Compression {
+		fo.Compression = detectCompression(header.header)
+	}
+	fo.UpdatedAt = o.now()
+
+	return fo, nil
+
+}
@@ -0,0 +1,198 @@
+package objectify
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// relativize makes the Root and Container of each FileObj relative to the
+// scan root, for the relativePaths option. The FileObjs are given a copy of
+// their options which records the root, so the options used to scan the
+// remaining entries are unchanged. In single file mode, the root is the
+// directory of the file.
+func (w *worker) relativize(files Files) {
+
+	slash := w.opts.fsys != nil
+
+	root := w.RootPath
+	switch {
+	case w.singleFileMode && slash:
+		root = path.Dir(root)
+	case w.singleFileMode:
+		root = filepath.Dir(pathAbsSafe(root))
+	case slash:
+		root = path.Clean(root)
+	default:
+		root = pathAbsSafe(root)
+	}
+
+	if w.relOpts == nil {
+		w.relOpts = make(map[*options]*options)
+	}
+
+	for _, fo := range files {
+
+		if fo == nil {
+			continue
+		}
+
+		fo.Root = relativeTo(root, fo.Root, slash)
+		if fo.Container != EMPTY {
+			fo.Container = relativeTo(root, fo.Container, slash)
+		}
+
+		// Archive members have their own options, reading the archive.
+		o, ok := w.relOpts[fo.options()]
+		if !ok {
+			c := *fo.options()
+			c.scanRoot = root
+			o = &c
+			w.relOpts[fo.options()] = o
+		}
+		fo.opts = o
+
+	}
+
+}
+
+// relativeTo returns p relative to root, or p if it is not inside root.
+func relativeTo(root, p string, slash bool) string {
+
+	if !slash {
+		if rel, err := filepath.Rel(root, p); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return rel
+		}
+		return p
+	}
+
+	switch {
+	case p == root:
+		return "."
+	case root == ".":
+		return p
+	case strings.HasPrefix(p, root+"/"):
+		return strings.TrimPrefix(p, root+"/")
+	}
+
+	return p
+
+}
+
+// ScanRoot returns the root of the scan the Files were made with
+// WithRelativePaths, or read back with it by ReadSnapshot, against which
+// their relative Roots are resolved. It returns an empty string if the Roots
+// are not relative.
+func (files Files) ScanRoot() string {
+
+	for _, fo := range files {
+		if fo != nil {
+			return fo.options().scanRoot
+		}
+	}
+
+	return EMPTY
+
+}
+
+// SetScanRoot sets the root against which the relative Roots of the Files are
+// resolved when they are read, e.g. updated or verified. Use it to check a
+// snapshot taken with WithRelativePaths against the same tree mounted at a
+// different path. An empty root resolves Roots against the working directory.
+func (files Files) SetScanRoot(root string) {
+
+	for _, fo := range files {
+		if fo != nil {
+			fo.options().scanRoot = root
+		}
+	}
+
+}
+
+// Rebase replaces the prefix oldPrefix of the Root (and Container) of each
+// entry with newPrefix, in place, and returns the number of entries rebased.
+// Prefixes match whole path elements, so "/mnt/backup" matches
+// "/mnt/backup/home" but not "/mnt/backups". It lets two scans of the same tree
+// at different locations be compared, e.g. rebasing a scan of
+// /mnt/backup/home/user onto /home/user before diffing it with a scan of
+// /home/user. Rebased entries are read from their new path when updated or
+// verified.
+func (files Files) Rebase(oldPrefix, newPrefix string) int {
+
+	oldPrefix, newPrefix = trimSeparators(oldPrefix), trimSeparators(newPrefix)
+
+	n := 0
+	for _, fo := range files {
+
+		if fo == nil {
+			continue
+		}
+
+		root, ok := rebase(fo.Root, oldPrefix, newPrefix)
+		if !ok {
+			continue
+		}
+		fo.Root = root
+		if fo.Container != EMPTY {
+			fo.Container, _ = rebase(fo.Container, oldPrefix, newPrefix)
+		}
+		n++
+
+	}
+
+	return n
+
+}
+
+// rebase returns p with the prefix oldPrefix replaced by newPrefix, and true,
+// or p and false if p is not oldPrefix or inside it.
+func rebase(p, oldPrefix, newPrefix string) (string, bool) {
+
+	if p == oldPrefix {
+		return newPrefix, true
+	}
+
+	rest, ok := strings.CutPrefix(p, oldPrefix)
+	if !ok || oldPrefix == EMPTY {
+		return p, false
+	}
+
+	// The separator at the boundary: the end of a root prefix such as "/",
+	// or the start of the rest.
+	sep := oldPrefix[len(oldPrefix)-1]
+	if !isSeparator(sep) {
+		if !isSeparator(rest[0]) {
+			return p, false
+		}
+		sep = rest[0]
+	}
+	rest = strings.TrimLeft(rest, `/\`)
+
+	switch {
+	case newPrefix == ".":
+		return rest, true
+	case newPrefix != EMPTY && isSeparator(newPrefix[len(newPrefix)-1]):
+		return newPrefix + rest, true
+	}
+
+	return newPrefix + string(sep) + rest, true
+
+}
+
+// trimSeparators removes the trailing separators of p, unless p is a root
+// such as "/".
+func trimSeparators(p string) string {
+
+	trimmed := strings.TrimRight(p, `/\`)
+	if trimmed == EMPTY && p != EMPTY {
+		return p[:1]
+	}
+
+	return trimmed
+
+}
+
+// isSeparator returns true if c is a slash or the OS path separator.
+func isSeparator(c byte) bool {
+	return c == '/' || c == filepath.Separator
+}
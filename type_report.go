@@ -0,0 +1,342 @@
+package objectify
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportCell is a cell of a reportTable. sort, if set, is the value the HTML
+// report sorts the column by instead of the text, e.g. a size in bytes.
+type reportCell struct {
+	Text string
+	Sort string
+}
+
+// reportTable is the table rendered by the WriteMarkdown and WriteHTML methods
+// of Files, Diff, and VerificationReport.
+type reportTable struct {
+	Title   string
+	Summary string
+	Headers []string
+	Rows    [][]reportCell
+}
+
+// cell returns a reportCell showing text.
+func cell(text string) reportCell {
+	return reportCell{Text: text}
+}
+
+// sizeCell returns a reportCell showing n bytes as SizeString does, sorted by
+// n. It is empty for a negative n.
+func sizeCell(n int64) reportCell {
+
+	if n < 0 {
+		return reportCell{}
+	}
+
+	return reportCell{Text: FormatSize(n, SizeBinary, 2), Sort: strconv.FormatInt(n, 10)}
+
+}
+
+// timeCell returns a reportCell showing t in RFC 3339, or an empty cell for the
+// zero time.
+func timeCell(t time.Time) reportCell {
+
+	if t.IsZero() {
+		return reportCell{}
+	}
+
+	return cell(t.Format(time.RFC3339))
+
+}
+
+// dropEmptyColumns removes the columns which are empty in every row, such as
+// the checksums of a scan made without them.
+func (t *reportTable) dropEmptyColumns() {
+
+	keep := make([]bool, len(t.Headers))
+	for _, row := range t.Rows {
+		for i, c := range row {
+			if c.Text != EMPTY {
+				keep[i] = true
+			}
+		}
+	}
+
+	headers := t.Headers[:0]
+	for i, h := range t.Headers {
+		if keep[i] {
+			headers = append(headers, h)
+		}
+	}
+	for r, row := range t.Rows {
+		cells := row[:0]
+		for i, c := range row {
+			if keep[i] {
+				cells = append(cells, c)
+			}
+		}
+		t.Rows[r] = cells
+	}
+	t.Headers = headers
+
+}
+
+// markdownEscaper escapes the characters which would break a Markdown table
+// cell.
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ", "\r", " ")
+
+// writeMarkdown writes the table as a Markdown document: the title as a
+// heading, the summary, and a GitHub-flavored table.
+func (t *reportTable) writeMarkdown(w io.Writer) error {
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", markdownEscaper.Replace(t.Title))
+	if t.Summary != EMPTY {
+		fmt.Fprintf(&b, "%s\n\n", markdownEscaper.Replace(t.Summary))
+	}
+
+	if len(t.Rows) == 0 {
+		b.WriteString("No entries.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("|")
+	for _, h := range t.Headers {
+		fmt.Fprintf(&b, " %s |", markdownEscaper.Replace(h))
+	}
+	b.WriteString("\n|")
+	for range t.Headers {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+
+	for _, row := range t.Rows {
+		b.WriteString("|")
+		for _, c := range row {
+			fmt.Fprintf(&b, " %s |", markdownEscaper.Replace(c.Text))
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+
+}
+
+// reportHTML is the template of a self-contained HTML report. Clicking a
+// column header sorts the rows by that column, by the cells' data-sort
+// values where they are set, and numerically where both values are numbers.
+var reportHTML = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1f2328; }
+table { border-collapse: collapse; font-size: 0.9em; }
+th, td { border: 1px solid #d0d7de; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; cursor: pointer; user-select: none; white-space: nowrap; }
+th[aria-sort=ascending]::after { content: " \25B2"; }
+th[aria-sort=descending]::after { content: " \25BC"; }
+tr:nth-child(even) td { background: #fafbfc; }
+td { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Summary}}<p>{{.Summary}}</p>
+{{end}}{{if .Rows}}<table>
+<thead><tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td{{if .Sort}} data-sort="{{.Sort}}"{{end}}>{{.Text}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+<script>
+document.querySelectorAll("th").forEach(function (th, col) {
+  th.addEventListener("click", function () {
+    var tbody = th.closest("table").tBodies[0];
+    var asc = th.getAttribute("aria-sort") !== "ascending";
+    th.parentNode.querySelectorAll("th").forEach(function (h) { h.removeAttribute("aria-sort"); });
+    th.setAttribute("aria-sort", asc ? "ascending" : "descending");
+    var key = function (tr) {
+      var td = tr.cells[col];
+      return td.hasAttribute("data-sort") ? td.getAttribute("data-sort") : td.textContent;
+    };
+    Array.from(tbody.rows).sort(function (a, b) {
+      var x = key(a), y = key(b);
+      var cmp = (x !== "" && y !== "" && !isNaN(x) && !isNaN(y)) ? x - y : x.localeCompare(y);
+      return asc ? cmp : -cmp;
+    }).forEach(function (tr) { tbody.appendChild(tr); });
+  });
+});
+</script>
+{{else}}<p>No entries.</p>
+{{end}}</body>
+</html>
+`))
+
+// writeHTML writes the table as a self-contained HTML page with sortable
+// columns.
+func (t *reportTable) writeHTML(w io.Writer) error {
+
+	return reportHTML.Execute(w, t)
+
+}
+
+// reportTitle returns title, or def if title is empty.
+func reportTitle(title, def string) string {
+
+	if title == EMPTY {
+		return def
+	}
+
+	return title
+
+}
+
+// table returns the reportTable of the Files: one row per entry with its
+// path, kind, size, modification time, checksums, and error. Columns which are
+// empty for every entry are left out.
+func (files Files) table(title string) *reportTable {
+
+	t := &reportTable{
+		Title:   reportTitle(title, "Scan report"),
+		Headers: []string{"Path", "Mode", "Size", "Modified", "SHA256", "MD5", "Error"},
+	}
+
+	var size int64
+	for _, fo := range files {
+
+		if fo == nil {
+			continue
+		}
+
+		snap := fo.Snapshot()
+		row := []reportCell{cell(snap.Path), cell(string(snap.Mode)), sizeCell(snap.SizeBytes), timeCell(snap.ModTime),
+			cell(snap.ChecksumSHA256), cell(snap.ChecksumMD5), cell(snap.Err)}
+		if snap.Mode.IsDir() {
+			row[2] = reportCell{}
+		} else {
+			size += snap.SizeBytes
+		}
+		t.Rows = append(t.Rows, row)
+
+	}
+
+	t.Summary = fmt.Sprintf("%d entries, %s.", len(t.Rows), FormatSize(size, SizeBinary, 2))
+	t.dropEmptyColumns()
+
+	return t
+
+}
+
+// WriteMarkdown writes the Files as a Markdown document: title as a heading
+// ("Scan report" if it is empty), a summary line, and a table of the entries,
+// e.g. for a CI job summary or an audit ticket. Columns which are empty for
+// every entry, such as the checksums of a scan made without them, are left
+// out.
+func (files Files) WriteMarkdown(w io.Writer, title string) error {
+	return files.table(title).writeMarkdown(w)
+}
+
+// WriteHTML writes the Files as a self-contained HTML page, with the same
+// content as WriteMarkdown, whose columns are sorted by clicking their
+// headers. The page loads no external resources.
+func (files Files) WriteHTML(w io.Writer, title string) error {
+	return files.table(title).writeHTML(w)
+}
+
+// table returns the reportTable of the Diff: one row per Event (see Events),
+// with the previous path of a renamed entry, and the sizes and checksums
+// before and after the change.
+func (d Diff) table(title string) *reportTable {
+
+	t := &reportTable{
+		Title:   reportTitle(title, "Diff report"),
+		Headers: []string{"Change", "Path", "Previous path", "Old size", "New size", "Old checksum", "New checksum"},
+	}
+
+	counts := make(map[EventKind]int)
+	for _, e := range d.Events() {
+
+		counts[e.Kind]++
+
+		row := []reportCell{cell(e.Kind.String()), cell(e.Path()), {}, {}, {}, {}, {}}
+		if e.Old != nil {
+			row[3], row[5] = sizeCell(e.Old.SizeBytes), cell(auditChecksum(e.Old))
+		}
+		if e.New != nil {
+			row[4], row[6] = sizeCell(e.New.SizeBytes), cell(auditChecksum(e.New))
+		}
+		if e.Kind == EventRenamed {
+			row[2] = cell(e.Old.FullPath())
+		}
+		t.Rows = append(t.Rows, row)
+
+	}
+
+	t.Summary = fmt.Sprintf("%d created, %d modified, %d deleted, %d renamed.",
+		counts[EventCreated], counts[EventModified], counts[EventDeleted], counts[EventRenamed])
+	t.dropEmptyColumns()
+
+	return t
+
+}
+
+// WriteMarkdown writes the Diff as a Markdown document: title as a heading
+// ("Diff report" if it is empty), the count of each kind of Event, and a table
+// of the Events with the sizes and checksums before and after each change.
+func (d Diff) WriteMarkdown(w io.Writer, title string) error {
+	return d.table(title).writeMarkdown(w)
+}
+
+// WriteHTML writes the Diff as a self-contained HTML page with sortable
+// columns, with the same content as WriteMarkdown.
+func (d Diff) WriteHTML(w io.Writer, title string) error {
+	return d.table(title).writeHTML(w)
+}
+
+// table returns the reportTable of the VerificationReport: one row per result,
+// with the compared checksums of a mismatch.
+func (r *VerificationReport) table(title string) *reportTable {
+
+	t := &reportTable{
+		Title:   reportTitle(title, "Verification report"),
+		Headers: []string{"Status", "Path", "Algorithm", "Expected", "Actual"},
+		Summary: fmt.Sprintf("%d files: %d OK, %d mismatch, %d missing, %d unreadable (%s).",
+			r.Total(), r.OK, r.Mismatch, r.Missing, r.Unreadable, r.Duration.Round(time.Microsecond)),
+	}
+
+	for _, res := range r.Results {
+		row := []reportCell{cell(string(res.Status)), cell(res.Path), {}, {}, {}}
+		if res.Status == VerifyMismatch {
+			row[2], row[3], row[4] = cell(res.Algorithm), cell(res.Expected), cell(res.Actual)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	t.dropEmptyColumns()
+
+	return t
+
+}
+
+// WriteMarkdown writes the report as a Markdown document: title as a heading
+// ("Verification report" if it is empty), the summary line of WriteText, and
+// a table of the results with the compared checksums of each mismatch.
+func (r *VerificationReport) WriteMarkdown(w io.Writer, title string) error {
+	return r.table(title).writeMarkdown(w)
+}
+
+// WriteHTML writes the report as a self-contained HTML page with sortable
+// columns, with the same content as WriteMarkdown.
+func (r *VerificationReport) WriteHTML(w io.Writer, title string) error {
+	return r.table(title).writeHTML(w)
+}
@@ -0,0 +1,49 @@
+package objectify
+
+import (
+	"fmt"
+)
+
+// RootInfo is the capacity of the filesystem hosting a scan root, returned by
+// StatRoot and recorded in ScanResult.Root.
+type RootInfo struct {
+
+	// Path is the path the capacity was read for.
+	Path string
+
+	// Total is the size of the filesystem in bytes.
+	Total int64
+
+	// Free is the number of free bytes, including blocks reserved for the
+	// superuser.
+	Free int64
+
+	// Available is the number of free bytes available to unprivileged users,
+	// as in the Avail column of df.
+	Available int64
+}
+
+// String returns a one-line summary of the RootInfo, e.g.:
+//
+//	1.82 TiB total, 640.00 GiB free, 540.00 GiB available
+func (r RootInfo) String() string {
+
+	return fmt.Sprintf("%s total, %s free, %s available", FormatSize(r.Total, SizeBinary, 2),
+		FormatSize(r.Free, SizeBinary, 2), FormatSize(r.Available, SizeBinary, 2))
+
+}
+
+// StatRoot returns the capacity of the filesystem hosting path, e.g. to check
+// that a backup destination can hold the Bytes of a ScanEstimate. An error
+// wrapping errors.ErrUnsupported is returned on platforms where it cannot be
+// read.
+func StatRoot(path string) (*RootInfo, error) {
+
+	total, free, avail, err := diskSpace(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &RootInfo{Path: path, Total: total, Free: free, Available: avail}, nil
+
+}
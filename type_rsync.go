@@ -0,0 +1,200 @@
+package objectify
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// DefaultBlockSize is the block size used by Signature for sizes less than 1.
+const DefaultBlockSize = 4096
+
+// BlockSignature is the signature of one block of a file: the rsync rolling
+// checksum of the block, which is cheap to update one byte at a time, and the
+// SHA256 of the block, which confirms a match.
+type BlockSignature struct {
+	Weak   uint32
+	Strong []byte
+}
+
+// Signature holds the rsync-style block signatures of a file's content, cut
+// into blocks of BlockSize bytes (the last block may be shorter). Size is the
+// size of the content.
+type Signature struct {
+	BlockSize int
+	Size      int64
+	Blocks    []BlockSignature
+}
+
+// DeltaKind is the kind of a DeltaOp.
+type DeltaKind int
+
+const (
+	// DeltaCopy reuses the content at OldOffset of the old file.
+	DeltaCopy DeltaKind = iota
+
+	// DeltaData must be transferred from the current file.
+	DeltaData
+)
+
+// String returns the name of the DeltaKind.
+func (k DeltaKind) String() string {
+
+	switch k {
+	case DeltaCopy:
+		return "copy"
+	case DeltaData:
+		return "data"
+	}
+
+	return fmt.Sprintf("DeltaKind(%d)", int(k))
+
+}
+
+// DeltaOp describes Size bytes at Offset of the current file: either a copy of
+// the bytes at OldOffset of the old file, or data to transfer.
+type DeltaOp struct {
+	Kind      DeltaKind
+	Offset    int64
+	Size      int64
+	OldOffset int64
+}
+
+// DeltaOps is the list of operations which rebuilds the current content of a
+// file from an old snapshot, in order of their Offset in the current file.
+type DeltaOps []DeltaOp
+
+// DataSize returns the number of bytes the DeltaOps need from the current
+// file, that is, the bytes a sync tool must transfer.
+func (d DeltaOps) DataSize() int64 {
+
+	var n int64
+	for _, op := range d {
+		if op.Kind == DeltaData {
+			n += op.Size
+		}
+	}
+
+	return n
+
+}
+
+// Signature reads the file and returns the block signatures of its content,
+// using blocks of blockSize bytes, or DefaultBlockSize if blockSize is less
+// than 1. Like ComputeSHA256, it returns an error if the file does not exist,
+// is not readable, or cannot be read. Signatures of two snapshots of a file
+// can be compared with Delta.
+func (fo *FileObj) Signature(blockSize int) (Signature, error) {
+
+	switch {
+	case !fo.IsExists:
+		return Signature{}, fmt.Errorf("%w: %s", fs.ErrNotExist, fo.FullPath())
+	case !fo.IsReadable:
+		return Signature{}, fmt.Errorf("%w: %s", fs.ErrPermission, fo.FullPath())
+	}
+
+	if blockSize < 1 {
+		blockSize = DefaultBlockSize
+	}
+
+	release := fo.options().acquireHash()
+	defer release()
+
+	f, err := fo.openHash()
+	if err != nil {
+		return Signature{}, err
+	}
+	defer f.Close()
+
+	sig, err := signatureOf(f, blockSize)
+	if err != nil {
+		return Signature{}, fmt.Errorf("%s: %w", fo.FullPath(), err)
+	}
+	fo.options().stats.addHashed(sig.Size)
+
+	return sig, nil
+
+}
+
+// signatureOf reads r to the end and returns its Signature.
+func signatureOf(r io.Reader, blockSize int) (Signature, error) {
+
+	sig := Signature{BlockSize: blockSize}
+	block := make([]byte, blockSize)
+	for {
+
+		n, err := io.ReadFull(r, block)
+		if n > 0 {
+			strong := sha256.Sum256(block[:n])
+			sig.Blocks = append(sig.Blocks, BlockSignature{Weak: rollingChecksum(block[:n]), Strong: strong[:]})
+			sig.Size += int64(n)
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return sig, nil
+		}
+		if err != nil {
+			return Signature{}, err
+		}
+
+	}
+
+}
+
+// rollingChecksum returns the rsync weak checksum of block: the low 16 bits
+// hold the sum of the bytes, and the high 16 bits the sum of the running sums.
+// Sliding the block one byte forward only needs the byte leaving and the byte
+// entering it.
+func rollingChecksum(block []byte) uint32 {
+
+	var a, b uint32
+	for i, c := range block {
+		a += uint32(c)
+		b += uint32(len(block)-i) * uint32(c)
+	}
+
+	return a&0xffff | b<<16
+
+}
+
+// Delta compares the Signatures of an old and a current snapshot of a file and
+// returns the DeltaOps which rebuild the current content: each block of cur
+// whose content appears as a block of old (anywhere in it) is copied, and the
+// rest is data. Blocks are matched by their rolling checksum first and confirmed by
+// their SHA256. Adjacent operations are merged. Returns ErrBlockSize if the
+// signatures were made with different block sizes.
+func Delta(old, cur Signature) (DeltaOps, error) {
+
+	if old.BlockSize != cur.BlockSize && len(old.Blocks) > 0 && len(cur.Blocks) > 0 {
+		return nil, fmt.Errorf("%w: %d and %d", ErrBlockSize, old.BlockSize, cur.BlockSize)
+	}
+
+	byWeak := make(map[uint32][]int)
+	for i, b := range old.Blocks {
+		byWeak[b.Weak] = append(byWeak[b.Weak], i)
+	}
+
+	var d DeltaOps
+	for i, b := range cur.Blocks {
+
+		op := DeltaOp{Kind: DeltaData, Offset: int64(i) * int64(cur.BlockSize)}
+		op.Size = min(int64(cur.BlockSize), cur.Size-op.Offset)
+		for _, j := range byWeak[b.Weak] {
+			if string(old.Blocks[j].Strong) == string(b.Strong) {
+				op.Kind, op.OldOffset = DeltaCopy, int64(j)*int64(old.BlockSize)
+				break
+			}
+		}
+
+		if n := len(d); n > 0 && d[n-1].Kind == op.Kind && (op.Kind == DeltaData || d[n-1].OldOffset+d[n-1].Size == op.OldOffset) {
+			d[n-1].Size += op.Size
+			continue
+		}
+		d = append(d, op)
+
+	}
+
+	return d, nil
+
+}
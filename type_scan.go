@@ -0,0 +1,321 @@
+package objectify
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ScanStats reports the work done by a scan, for tuning the concurrency
+// options with real numbers.
+type ScanStats struct {
+
+	// Duration is the wall time of the scan.
+	Duration time.Duration
+
+	// Entries is the number of FileObjs returned.
+	Entries int
+
+	// FilesPerSecond is Entries divided by Duration.
+	FilesPerSecond float64
+
+	// BytesHashed is the number of bytes read to compute checksums. A file
+	// hashed with both MD5 and SHA256 is counted twice; checksums taken from
+	// a HashCache or from the backend are not counted.
+	BytesHashed int64
+
+	// Syscalls is an estimate of the filesystem calls made: each directory
+	// open, read, and close, each stat, readlink, and extended attribute read,
+	// and each open, read (of up to 32 KiB), and close while hashing.
+	Syscalls int64
+
+	// PeakQueueDepth is the largest number of discovered entries waiting for a
+	// worker at once (see WithQueueDepth). It is 0 for sequential scans.
+	PeakQueueDepth int
+
+	// Dirs is the number of directories read, the root included, and
+	// AvgEntriesPerDir the average number of entries read from each, before
+	// any were skipped. MaxEntriesPerDir is the most entries read from one
+	// directory, WidestDir.
+	Dirs             int
+	AvgEntriesPerDir float64
+	MaxEntriesPerDir int
+	WidestDir        string
+
+	// MaxDepth is the number of directories between the root and the deepest
+	// directory read, 0 for non-recursive scans. DeepestPaths holds the first
+	// MaxDeepestPaths directories found at MaxDepth, sorted. Together with
+	// WidestDir, they point at the parts of pathological trees which break
+	// other tools.
+	MaxDepth     int
+	DeepestPaths []string
+}
+
+// MaxDeepestPaths is the most directories listed in ScanStats.DeepestPaths.
+const MaxDeepestPaths = 10
+
+// String returns a one-line summary of the ScanStats, e.g.:
+//
+//	1200 entries in 1.5s (800.0 files/s), 1.20 GiB hashed, 9800 syscalls, peak queue 16, 40 dirs (30.0 entries/dir, max 200), depth 3
+func (s ScanStats) String() string {
+
+	return fmt.Sprintf("%d entries in %s (%.1f files/s), %s hashed, %d syscalls, peak queue %d, %d dirs (%.1f entries/dir, max %d), depth %d",
+		s.Entries, s.Duration.Round(time.Millisecond), s.FilesPerSecond, FormatSize(s.BytesHashed, SizeBinary, 2),
+		s.Syscalls, s.PeakQueueDepth, s.Dirs, s.AvgEntriesPerDir, s.MaxEntriesPerDir, s.MaxDepth)
+
+}
+
+// ScanResult is returned by Scan and ScanFS.
+type ScanResult struct {
+	Files Files
+	Stats ScanStats
+
+	// Case is the case sensitivity of the root directory, probed with
+	// ProbeCase (or ProbeCaseFS), so that Files of different scans can be
+	// compared with CaseSensitivity.Key. It is CaseUnknown if it could not be
+	// probed.
+	Case CaseSensitivity
+
+	// Root is the capacity of the filesystem hosting the root, read with
+	// StatRoot. It is nil for ScanFS, and where it cannot be read.
+	Root *RootInfo
+}
+
+// Scan works like Path, but also returns the ScanStats of the scan.
+func Scan(rootPath string, s Sets, opts ...Option) (*ScanResult, error) {
+
+	return scan(newOptions(opts...), rootPath, s)
+
+}
+
+// ScanFS works like PathFS, but also returns the ScanStats of the scan.
+func ScanFS(fsys fs.FS, rootPath string, s Sets, opts ...Option) (*ScanResult, error) {
+
+	o := newOptions(opts...)
+	o.fsys = fsys
+
+	return scan(o, rootPath, s)
+
+}
+
+// scan runs a path worker with a fresh set of counters and collects the stats.
+func scan(o *options, rootPath string, s Sets) (*ScanResult, error) {
+
+	o.stats = &scanCounters{}
+
+	start := time.Now()
+	files, err := run(newPathWorker(rootPath, s, o))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &ScanResult{
+		Files: files,
+		Stats: ScanStats{
+			Duration:       time.Since(start),
+			Entries:        len(files),
+			BytesHashed:    o.stats.bytesHashed.Load(),
+			Syscalls:       o.stats.syscalls.Load(),
+			PeakQueueDepth: int(o.stats.peakQueue.Load()),
+		},
+	}
+	if secs := r.Stats.Duration.Seconds(); secs > 0 {
+		r.Stats.FilesPerSecond = float64(r.Stats.Entries) / secs
+	}
+	o.stats.dirStats(&r.Stats)
+	r.Case, _ = probeCase(&options{fsys: o.fsys, filesystem: o.filesystem}, rootPath)
+	if o.onOS() {
+		r.Root, _ = StatRoot(rootPath)
+	}
+
+	return r, nil
+
+}
+
+// ScanEstimate is the expected cost of a scan, returned by EstimateScan and
+// EstimateScanFS.
+type ScanEstimate struct {
+
+	// Entries is the number of FileObjs a scan would return.
+	Entries int
+
+	// Bytes is the total size of the regular files among them.
+	Bytes int64
+
+	// BytesToHash is the number of bytes a scan would read to compute the
+	// checksums of the Sets, counted once per checksum as in ScanStats; it is
+	// 0 if the Sets have no checksums. Checksums found in a HashCache are not
+	// subtracted.
+	BytesToHash int64
+}
+
+// String returns a one-line summary of the ScanEstimate, e.g.:
+//
+//	1200 entries, 1.20 GiB, 2.40 GiB to hash
+func (e ScanEstimate) String() string {
+
+	return fmt.Sprintf("%d entries, %s, %s to hash",
+		e.Entries, FormatSize(e.Bytes, SizeBinary, 2), FormatSize(e.BytesToHash, SizeBinary, 2))
+
+}
+
+// EstimateScan walks rootPath like Path, with the same options, but only
+// stats the entries, and returns the number of entries a scan would return and
+// the bytes it would hash. It is much cheaper than the scan itself, so CLIs can
+// show an accurate progress denominator, and users can decide whether to
+// enable checksums before paying for them. Archive members are not counted.
+func EstimateScan(rootPath string, s Sets, opts ...Option) (ScanEstimate, error) {
+
+	return estimate(newPathWorker(rootPath, s, newOptions(opts...)))
+
+}
+
+// EstimateScanFS works like EstimateScan, but reads rootPath from fsys instead
+// of the OS filesystem. See PathFS.
+func EstimateScanFS(fsys fs.FS, rootPath string, s Sets, opts ...Option) (ScanEstimate, error) {
+
+	o := newOptions(opts...)
+	o.fsys = fsys
+
+	return estimate(newPathWorker(rootPath, s, o))
+
+}
+
+// estimate runs the worker's directory walk and stats each entry.
+func estimate(w *worker) (ScanEstimate, error) {
+
+	var e ScanEstimate
+
+	if !w.validate() {
+		return e, fmt.Errorf("StartingPath is not correct: %s", w.RootPath)
+	}
+
+	if w.opts.oneFileSystem && w.opts.fsys == nil {
+		w.rootDev, w.hasRootDev = deviceOf(w.opts.sys(), w.RootPath)
+	}
+
+	// hashes is the number of times the content of each file is read.
+	hashes := int64(0)
+	for _, on := range []bool{w.setter.ChecksumMD5, w.setter.ChecksumSHA256, w.opts.hmacKey != nil} {
+		if on {
+			hashes++
+		}
+	}
+
+	err := w.readDir(w.RootPath, func(p string) error {
+
+		e.Entries++
+		if info, ok := w.opts.statPath(p); ok && info.Mode().IsRegular() {
+			e.Bytes += info.Size()
+			e.BytesToHash += hashes * info.Size()
+		}
+
+		return nil
+
+	}, 0)
+
+	return e, err
+
+}
+
+// scanCounters accumulates the counts reported in ScanStats. A nil
+// *scanCounters ignores all counts, so scans made without Scan pay nothing.
+type scanCounters struct {
+	bytesHashed atomic.Int64
+	syscalls    atomic.Int64
+	peakQueue   atomic.Int64
+
+	// dirMu guards the directory counts, which are updated as each
+	// directory is read.
+	dirMu      sync.Mutex
+	dirs       int
+	dirEntries int64
+	maxEntries int
+	widestDir  string
+	maxDepth   int
+	deepest    []string
+}
+
+// addSyscalls adds n to the syscall count.
+func (c *scanCounters) addSyscalls(n int64) {
+
+	if c != nil {
+		c.syscalls.Add(n)
+	}
+
+}
+
+// addHashed records that size bytes were read and hashed, along with the
+// estimated open, read, and close calls.
+func (c *scanCounters) addHashed(size int64) {
+
+	if c != nil {
+		c.bytesHashed.Add(size)
+		c.syscalls.Add(3 + size/hashReadSize)
+	}
+
+}
+
+// observeQueue records a queue depth, keeping the largest.
+func (c *scanCounters) observeQueue(depth int) {
+
+	if c == nil {
+		return
+	}
+
+	for {
+		peak := c.peakQueue.Load()
+		if int64(depth) <= peak || c.peakQueue.CompareAndSwap(peak, int64(depth)) {
+			return
+		}
+	}
+
+}
+
+// observeDir records that entries entries were read from dir, depth
+// directories below the root.
+func (c *scanCounters) observeDir(dir string, depth, entries int) {
+
+	if c == nil {
+		return
+	}
+
+	c.dirMu.Lock()
+	defer c.dirMu.Unlock()
+
+	c.dirs++
+	c.dirEntries += int64(entries)
+	if entries > c.maxEntries || c.widestDir == EMPTY {
+		c.maxEntries, c.widestDir = entries, dir
+	}
+
+	switch {
+	case depth > c.maxDepth:
+		c.maxDepth, c.deepest = depth, []string{dir}
+	case depth == c.maxDepth && len(c.deepest) < MaxDeepestPaths:
+		c.deepest = append(c.deepest, dir)
+	}
+
+}
+
+// dirStats sets the directory counts of s.
+func (c *scanCounters) dirStats(s *ScanStats) {
+
+	c.dirMu.Lock()
+	defer c.dirMu.Unlock()
+
+	s.Dirs, s.MaxEntriesPerDir, s.WidestDir, s.MaxDepth = c.dirs, c.maxEntries, c.widestDir, c.maxDepth
+	if c.dirs > 0 {
+		s.AvgEntriesPerDir = float64(c.dirEntries) / float64(c.dirs)
+	}
+
+	s.DeepestPaths = append([]string(nil), c.deepest...)
+	sort.Strings(s.DeepestPaths)
+
+}
+
+// hashReadSize is the buffer size io.Copy uses when hashing a file.
+const hashReadSize = 32 * 1024
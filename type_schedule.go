@@ -0,0 +1,105 @@
+package objectify
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Schedule is the order in which the entries of a scan are objectified.
+type Schedule int
+
+const (
+	// ScheduleWalk objectifies entries in the order they are found, while
+	// the directories are still being read. It is the default.
+	ScheduleWalk Schedule = iota
+
+	// ScheduleSmallFirst objectifies the smallest files first, so most
+	// entries are ready early.
+	ScheduleSmallFirst
+
+	// ScheduleLargeFirst objectifies the largest files first, so with
+	// WithConcurrency a huge file is hashed alongside the small ones instead
+	// of holding up the end of the scan.
+	ScheduleLargeFirst
+)
+
+// String returns the name of the Schedule.
+func (s Schedule) String() string {
+
+	switch s {
+	case ScheduleWalk:
+		return "walk"
+	case ScheduleSmallFirst:
+		return "small-first"
+	case ScheduleLargeFirst:
+		return "large-first"
+	}
+
+	return fmt.Sprintf("Schedule(%d)", int(s))
+
+}
+
+// collectScheduled runs walk to completion, stat'ing each path it emits, then
+// objectifies the paths in the order of the schedule option. The FileObjs are
+// returned in the order the paths were emitted, as by collect.
+func (w *worker) collectScheduled(walk func(emit func(string) error) error) (Files, error) {
+
+	type entry struct {
+		path string
+		size int64
+	}
+
+	var entries []entry
+	err := walk(func(p string) error {
+		if w.cp.skip(p) {
+			return nil
+		}
+		e := entry{path: p}
+		if info, ok := w.opts.statPath(p); ok {
+			e.size = info.Size()
+		}
+		w.opts.stats.addSyscalls(1)
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := entries[order[i]].size, entries[order[j]].size
+		if w.opts.schedule == ScheduleLargeFirst {
+			return a > b
+		}
+		return a < b
+	})
+
+	scheduled, err := w.dispatch(func(emit func(string) error) error {
+		for _, i := range order {
+			if err := emit(entries[i].path); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	// Put the FileObjs back in walk order. If dispatch stopped early, the
+	// entries it did not reach are left out.
+	files := make(Files, len(entries))
+	for j, fo := range scheduled {
+		files[order[j]] = fo
+	}
+	kept := files[:0]
+	for _, fo := range files {
+		if fo != nil {
+			kept = append(kept, fo)
+		}
+	}
+
+	return kept, err
+
+}
@@ -0,0 +1,340 @@
+package objectify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JSONSchemaVersion is the version of JSONSchema. It is incremented whenever a
+// field is added to or changed in the JSON encoding of a FileObj, so exports
+// can be matched with the schema they were written against.
+const JSONSchemaVersion = 1
+
+// JSONSchema is the JSON Schema (draft 2020-12) of a FileObj as encoded by
+// MarshalJSON. An encoded Files is an array of such objects, and an NDJSON
+// export holds one per line. Use ValidateJSON or ValidateNDJSON to check data
+// against it in Go, or publish it to consumers in other languages to validate
+// exports and generate types.
+const JSONSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "urn:objectify:fileobj:v1",
+  "title": "FileObj",
+  "description": "A directory entry as encoded by objectify's FileObj.MarshalJSON, version 1.",
+  "type": "object",
+  "required": ["root", "filename", "size_bytes", "mode", "uid", "gid", "allocated_bytes", "is_link", "is_readable", "is_exists"],
+  "additionalProperties": false,
+  "properties": {
+    "root": {"type": "string", "description": "The parent directory of the entry."},
+    "filename": {"type": "string", "description": "The base name of the entry."},
+    "container": {"type": "string", "description": "The full path of the archive holding an archive member."},
+    "size_bytes": {"type": "integer", "minimum": 0, "description": "The size of the file in bytes."},
+    "allocated_bytes": {"type": "integer", "minimum": -1, "description": "The space allocated on disk in bytes, or -1 if unknown."},
+    "mode": {"$ref": "#/$defs/mode"},
+    "perm": {"type": "integer", "minimum": 0, "maximum": 4294967295, "description": "The permission bits, including setuid, setgid, and sticky, as an io/fs FileMode."},
+    "uid": {"type": "integer", "minimum": -1, "description": "The numeric owner, or -1 if unknown."},
+    "gid": {"type": "integer", "minimum": -1, "description": "The numeric group, or -1 if unknown."},
+    "mod_time": {"type": "string", "format": "date-time", "description": "The modification time of the entry."},
+    "updated_at": {"type": "string", "format": "date-time", "description": "When the entry was last read."},
+    "md5": {"type": "string", "pattern": "^[0-9a-f]{32}$"},
+    "sha256": {"$ref": "#/$defs/sha256"},
+    "hmac_sha256": {"$ref": "#/$defs/sha256"},
+    "decompressed_sha256": {"$ref": "#/$defs/sha256"},
+    "image_hash": {"type": "string", "pattern": "^[0-9a-f]{16}$", "description": "The perceptual difference hash of an image."},
+    "chunks": {"type": "array", "items": {"$ref": "#/$defs/chunk"}},
+    "streams": {"type": "array", "items": {"$ref": "#/$defs/stream"}},
+    "width": {"type": "integer", "minimum": 0},
+    "height": {"type": "integer", "minimum": 0},
+    "compression": {"type": "string", "enum": ["gzip", "zstd", "xz", "bzip2", "lz4"]},
+    "etag": {"type": "string"},
+    "content_type": {"type": "string"},
+    "target": {"type": "string", "description": "The target of a symlink."},
+    "target_final": {"type": "string", "description": "The final target of a chain of symlinks."},
+    "link_path": {"type": "string", "description": "The literal contents of a symlink."},
+    "reparse_target": {"type": "string", "description": "The target of a Windows junction, mount point, or symlink."},
+    "is_link": {"type": "boolean"},
+    "is_readable": {"type": "boolean"},
+    "is_exists": {"type": "boolean"},
+    "xattrs": {"type": "object", "additionalProperties": {"type": ["string", "null"], "contentEncoding": "base64"}},
+    "error": {"type": "string", "description": "The first error encountered reading the entry."},
+    "sets": {"$ref": "#/$defs/sets"},
+    "tags": {"type": "object", "additionalProperties": {"type": "string"}},
+    "meta": {"type": "object", "additionalProperties": {"type": "string"}}
+  },
+  "$defs": {
+    "sha256": {"type": "string", "pattern": "^[0-9a-f]{64}$"},
+    "mode": {
+      "type": "string",
+      "enum": ["", "dir", "link", "regular_file", "temp_file", "fifo_pipe", "unix_socket", "device_file", "irregular_file", "other", "unknown"]
+    },
+    "chunk": {
+      "type": "object",
+      "required": ["offset", "size", "sha256"],
+      "additionalProperties": false,
+      "properties": {
+        "offset": {"type": "integer", "minimum": 0},
+        "size": {"type": "integer", "minimum": 0},
+        "sha256": {"$ref": "#/$defs/sha256"}
+      }
+    },
+    "stream": {
+      "type": "object",
+      "required": ["name", "size"],
+      "additionalProperties": false,
+      "properties": {
+        "name": {"type": "string"},
+        "size": {"type": "integer", "minimum": 0},
+        "sha256": {"$ref": "#/$defs/sha256"}
+      }
+    },
+    "sets": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "Size": {"type": "boolean"},
+        "Modes": {"type": "boolean"},
+        "ChecksumMD5": {"type": "boolean"},
+        "ChecksumSHA256": {"type": "boolean"},
+        "LinkTarget": {"type": "boolean"},
+        "LinkTargetFinal": {"type": "boolean"},
+        "XAttrs": {"type": "boolean"},
+        "Dimensions": {"type": "boolean"},
+        "DocProps": {"type": "boolean"},
+        "Compression": {"type": "boolean"}
+      }
+    }
+  }
+}`
+
+// ValidateJSON checks that data, a FileObj or an array of them as encoded by
+// json.Marshal, matches JSONSchema. An error wrapping ErrSchemaMismatch names
+// the first offending value by its JSON Pointer, e.g. "/3/sha256".
+func ValidateJSON(data []byte) error {
+
+	v, err := decodeJSONValue(data)
+	if err != nil {
+		return err
+	}
+
+	if items, ok := v.([]any); ok {
+		for i, item := range items {
+			if err := validateSchema(jsonSchema(), item, fmt.Sprintf("/%d", i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return validateSchema(jsonSchema(), v, EMPTY)
+
+}
+
+// ValidateNDJSON checks that every non-empty line read from r is a FileObj
+// matching JSONSchema. An error wrapping ErrSchemaMismatch names the line and
+// the offending value.
+func ValidateNDJSON(r io.Reader) error {
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	for n := 1; sc.Scan(); n++ {
+
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		v, err := decodeJSONValue(line)
+		if err == nil {
+			err = validateSchema(jsonSchema(), v, EMPTY)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+
+	}
+
+	return sc.Err()
+
+}
+
+// decodeJSONValue decodes data into generic values, keeping numbers as
+// json.Number so integers can be told apart.
+func decodeJSONValue(data []byte) (any, error) {
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSchemaMismatch, err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("%w: data after the JSON value", ErrSchemaMismatch)
+	}
+
+	return v, nil
+
+}
+
+// jsonSchema returns JSONSchema, parsed once.
+var jsonSchema = sync.OnceValue(func() map[string]any {
+
+	var s map[string]any
+	if err := json.Unmarshal([]byte(JSONSchema), &s); err != nil {
+		panic(err)
+	}
+
+	return s
+
+})
+
+// pointerEscaper escapes an object key for a JSON Pointer (RFC 6901).
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// schemaPatterns caches the compiled patterns of JSONSchema.
+var schemaPatterns sync.Map
+
+// validateSchema checks v against the schema s, a node of JSONSchema, and
+// returns an error wrapping ErrSchemaMismatch for the first mismatch. at is
+// the JSON Pointer of v. Only the keywords used by JSONSchema are supported.
+func validateSchema(s map[string]any, v any, at string) error {
+
+	if ref, ok := s["$ref"].(string); ok {
+		defs, _ := jsonSchema()["$defs"].(map[string]any)
+		s, _ = defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+	}
+
+	mismatch := func(format string, args ...any) error {
+		if at == EMPTY {
+			at = "/"
+		}
+		return fmt.Errorf("%w: %s: %s", ErrSchemaMismatch, at, fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := s["type"]; ok && !schemaTypeOf(t, v) {
+		return mismatch("want %v", t)
+	}
+	if enum, ok := s["enum"].([]any); ok && !slices.Contains(enum, v) {
+		return mismatch("%v is not one of %v", v, enum)
+	}
+
+	switch v := v.(type) {
+
+	case string:
+
+		if p, ok := s["pattern"].(string); ok {
+			re, _ := schemaPatterns.Load(p)
+			if re == nil {
+				re, _ = schemaPatterns.LoadOrStore(p, regexp.MustCompile(p))
+			}
+			if !re.(*regexp.Regexp).MatchString(v) {
+				return mismatch("%q does not match %s", v, p)
+			}
+		}
+		if s["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				return mismatch("%q is not a date-time", v)
+			}
+		}
+
+	case json.Number:
+
+		n, _ := v.Float64()
+		if min, ok := s["minimum"].(float64); ok && n < min {
+			return mismatch("%s is less than %v", v, min)
+		}
+		if max, ok := s["maximum"].(float64); ok && n > max {
+			return mismatch("%s is greater than %v", v, max)
+		}
+
+	case map[string]any:
+
+		required, _ := s["required"].([]any)
+		for _, key := range required {
+			if _, ok := v[key.(string)]; !ok {
+				return mismatch("missing %q", key)
+			}
+		}
+
+		props, _ := s["properties"].(map[string]any)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			sub, ok := props[k].(map[string]any)
+			if !ok {
+				switch extra := s["additionalProperties"].(type) {
+				case bool:
+					if !extra {
+						return mismatch("unknown property %q", k)
+					}
+					continue
+				case map[string]any:
+					sub = extra
+				default:
+					continue
+				}
+			}
+			if err := validateSchema(sub, v[k], at+"/"+pointerEscaper.Replace(k)); err != nil {
+				return err
+			}
+		}
+
+	case []any:
+
+		if items, ok := s["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validateSchema(items, item, fmt.Sprintf("%s/%d", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+
+	}
+
+	return nil
+
+}
+
+// schemaTypeOf returns true if v has the type t, a JSON Schema type name or
+// a list of them.
+func schemaTypeOf(t, v any) bool {
+
+	if names, ok := t.([]any); ok {
+		return slices.ContainsFunc(names, func(name any) bool { return schemaTypeOf(name, v) })
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case json.Number:
+		if t == "integer" {
+			_, err := v.Int64()
+			return err == nil
+		}
+		return t == "number"
+	case map[string]any:
+		return t == "object"
+	case []any:
+		return t == "array"
+	}
+
+	return false
+
+}
@@ -1,5 +1,9 @@
 package objectify
 
+import (
+	"fmt"
+)
+
 // Sets fields are flags for FileObj fields which can be optionally populated.
 type Sets struct {
 	Size            bool
@@ -8,6 +12,23 @@ type Sets struct {
 	ChecksumSHA256  bool
 	LinkTarget      bool
 	LinkTargetFinal bool
+
+	// XAttrs populates the extended attributes of entries on the OS filesystem,
+	// on platforms which support them (Linux, macOS, FreeBSD, and NetBSD).
+	XAttrs bool
+
+	// Dimensions populates the Width and Height of PNG, JPEG, GIF, and WebP
+	// images, read from their headers.
+	Dimensions bool
+
+	// DocProps populates the title, author, and page or sheet count of PDF,
+	// DOCX, and XLSX files in the Meta field (see MetaTitle and the other
+	// Meta keys).
+	DocProps bool
+
+	// Compression populates the Compression of each file: gzip, zstd, xz,
+	// bzip2, or lz4, detected by its magic bytes.
+	Compression bool
 }
 
 // SetsAll returns a Sets object with all fields set to true.
@@ -19,6 +40,10 @@ func SetsAll() Sets {
 		ChecksumSHA256:  true,
 		LinkTarget:      true,
 		LinkTargetFinal: true,
+		XAttrs:          true,
+		Dimensions:      true,
+		DocProps:        true,
+		Compression:     true,
 	}
 }
 
@@ -47,7 +72,161 @@ func SetsAllSHA256() Sets {
 	return s
 }
 
+// SetsFast returns a Sets object with only Size and Modes set, for scans which
+// only need stat metadata.
+func SetsFast() Sets {
+	return Sets{
+		Size:  true,
+		Modes: true,
+	}
+}
+
+// SetsSync returns a Sets object with the fields needed to synchronize a tree:
+// Size, Modes, ChecksumSHA256, LinkTarget, and LinkTargetFinal.
+func SetsSync() Sets {
+	return Sets{
+		Size:            true,
+		Modes:           true,
+		ChecksumSHA256:  true,
+		LinkTarget:      true,
+		LinkTargetFinal: true,
+	}
+}
+
+// SetsSecurity returns a Sets object for integrity monitoring: Modes (which
+// includes permissions and ownership), XAttrs, and ChecksumSHA256.
+func SetsSecurity() Sets {
+	return Sets{
+		Modes:          true,
+		ChecksumSHA256: true,
+		XAttrs:         true,
+	}
+}
+
 // SetsNone returns a Sets object with all fields set to false.
 func SetsNone() Sets {
 	return Sets{}
 }
+
+// Validate returns an error wrapping ErrInvalidSets if the Sets contain a
+// contradictory combination. Link targets are only resolved for entries which
+// are known to be symlinks, which requires Modes, so LinkTarget and
+// LinkTargetFinal are rejected without it.
+func (s Sets) Validate() error {
+
+	if s.LinkTarget && !s.Modes {
+		return fmt.Errorf("%w: LinkTarget requires Modes", ErrInvalidSets)
+	}
+	if s.LinkTargetFinal && !s.Modes {
+		return fmt.Errorf("%w: LinkTargetFinal requires Modes", ErrInvalidSets)
+	}
+
+	return nil
+
+}
+
+// SetsBuilder builds a Sets with chained calls, e.g.:
+//
+//	s, err := NewSets().WithSize().WithModes().WithSHA256().WithLinkTargets().Build()
+type SetsBuilder struct {
+	s Sets
+}
+
+// NewSets returns a SetsBuilder with every field unset.
+func NewSets() *SetsBuilder {
+	return &SetsBuilder{}
+}
+
+// WithSize sets Size.
+func (b *SetsBuilder) WithSize() *SetsBuilder {
+	b.s.Size = true
+	return b
+}
+
+// WithModes sets Modes.
+func (b *SetsBuilder) WithModes() *SetsBuilder {
+	b.s.Modes = true
+	return b
+}
+
+// WithMD5 sets ChecksumMD5.
+func (b *SetsBuilder) WithMD5() *SetsBuilder {
+	b.s.ChecksumMD5 = true
+	return b
+}
+
+// WithSHA256 sets ChecksumSHA256.
+func (b *SetsBuilder) WithSHA256() *SetsBuilder {
+	b.s.ChecksumSHA256 = true
+	return b
+}
+
+// WithChecksums sets ChecksumMD5 and ChecksumSHA256.
+func (b *SetsBuilder) WithChecksums() *SetsBuilder {
+	return b.WithMD5().WithSHA256()
+}
+
+// WithLinkTarget sets LinkTarget. Modes must also be set.
+func (b *SetsBuilder) WithLinkTarget() *SetsBuilder {
+	b.s.LinkTarget = true
+	return b
+}
+
+// WithLinkTargetFinal sets LinkTargetFinal. Modes must also be set.
+func (b *SetsBuilder) WithLinkTargetFinal() *SetsBuilder {
+	b.s.LinkTargetFinal = true
+	return b
+}
+
+// WithLinkTargets sets LinkTarget and LinkTargetFinal. Modes must also be set.
+func (b *SetsBuilder) WithLinkTargets() *SetsBuilder {
+	return b.WithLinkTarget().WithLinkTargetFinal()
+}
+
+// WithXAttrs sets XAttrs.
+func (b *SetsBuilder) WithXAttrs() *SetsBuilder {
+	b.s.XAttrs = true
+	return b
+}
+
+// WithDimensions sets Dimensions.
+func (b *SetsBuilder) WithDimensions() *SetsBuilder {
+	b.s.Dimensions = true
+	return b
+}
+
+// WithDocProps sets DocProps.
+func (b *SetsBuilder) WithDocProps() *SetsBuilder {
+	b.s.DocProps = true
+	return b
+}
+
+// WithCompression sets Compression.
+func (b *SetsBuilder) WithCompression() *SetsBuilder {
+	b.s.Compression = true
+	return b
+}
+
+// Build returns the Sets, or an error from Sets.Validate.
+func (b *SetsBuilder) Build() (Sets, error) {
+
+	if err := b.s.Validate(); err != nil {
+		return Sets{}, err
+	}
+
+	return b.s, nil
+
+}
+
+// MustBuild is like Build but panics if the Sets are invalid. It is intended
+// for package-level variables.
+func (b *SetsBuilder) MustBuild() Sets {
+
+	s, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+
+}
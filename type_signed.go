@@ -0,0 +1,65 @@
+package objectify
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// manifestSignaturePrefix starts the line holding the signature of a signed
+// manifest. It is a comment to ParseManifest and to "sha256sum -c".
+const manifestSignaturePrefix = "# ed25519 signature: "
+
+// Sign returns the manifest's Bytes followed by a signature block: a comment
+// line holding the base64 ed25519 signature of the text before it. Publish the
+// signed manifest, so consumers holding the public key can check it with
+// VerifySignedManifest; "sha256sum -c" still reads it, skipping the comment.
+func (m *Manifest) Sign(priv ed25519.PrivateKey) ([]byte, error) {
+
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid ed25519 private key length %d", len(priv))
+	}
+
+	data := m.Bytes()
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data))
+
+	return append(data, manifestSignaturePrefix+sig+"\n"...), nil
+
+}
+
+// VerifySignedManifest reads a manifest signed by Manifest.Sign from r, checks
+// the signature block on its last line against pub, and returns the listed
+// files parsed with ParseManifest. If the signature block is missing or does
+// not match the text before it, ErrInvalidSignature is returned and the
+// manifest is not parsed. The returned Files can then be checked against the
+// disk with Files.Verify.
+func VerifySignedManifest(pub ed25519.PublicKey, r io.Reader) (Files, error) {
+
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key length %d", len(pub))
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	body, block := data, []byte(nil)
+	if i := bytes.LastIndex(data, []byte(manifestSignaturePrefix)); i >= 0 && (i == 0 || data[i-1] == '\n') {
+		body, block = data[:i], data[i+len(manifestSignaturePrefix):]
+	}
+	block = bytes.TrimRight(block, "\r\n")
+	if len(block) == 0 || bytes.ContainsAny(block, "\r\n") {
+		return nil, ErrInvalidSignature
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(string(block))
+	if err != nil || !ed25519.Verify(pub, body, sig) {
+		return nil, ErrInvalidSignature
+	}
+
+	return ParseManifest(bytes.NewReader(body))
+
+}
@@ -0,0 +1,95 @@
+package objectify
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// signedManifest returns a manifest of two scanned files, relative to their
+// directory, signed with a new key, and the public key.
+func signedManifest(t *testing.T) ([]byte, ed25519.PublicKey) {
+
+	dir := dedupeTree(t, map[string]string{"a": "first", "b": "second"})
+	var files Files
+	for _, name := range []string{"a", "b"} {
+		fo, err := File(filepath.Join(dir, name), Sets{ChecksumMD5: true, ChecksumSHA256: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, fo)
+	}
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := (&Manifest{Files: files, Base: dir}).Sign(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed, pub
+
+}
+
+func TestVerifySignedManifest(t *testing.T) {
+
+	signed, pub := signedManifest(t)
+
+	files, err := VerifySignedManifest(pub, bytes.NewReader(signed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].SHA256 == nil || files[0].MD5 == nil {
+		t.Fatalf("verified %d entries, want 2 with both checksums", len(files))
+	}
+
+	// The signature block is a comment to ParseManifest.
+	parsed, err := ParseManifest(bytes.NewReader(signed))
+	if err != nil || len(parsed) != 2 {
+		t.Errorf("ParseManifest of the signed manifest: %d entries, %v", len(parsed), err)
+	}
+
+}
+
+func TestVerifySignedManifestTampered(t *testing.T) {
+
+	signed, pub := signedManifest(t)
+	body := signed[:bytes.LastIndex(signed, []byte(manifestSignaturePrefix))]
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// flip changes the byte at i of a copy of the signed manifest.
+	flip := func(i int) []byte {
+		b := bytes.Clone(signed)
+		if b[i] == '0' {
+			b[i] = '1'
+		} else {
+			b[i] = '0'
+		}
+		return b
+	}
+
+	for _, tc := range []struct {
+		name string
+		pub  ed25519.PublicKey
+		data []byte
+	}{
+		{"checksum", pub, flip(bytes.Index(signed, []byte(" = ")) + 3)},
+		{"path", pub, bytes.Replace(signed, []byte("(a)"), []byte("(c)"), 1)},
+		{"signature", pub, flip(len(signed) - 5)},
+		{"appended line", pub, append(bytes.Clone(signed), "SHA256 (c) = 00\n"...)},
+		{"unsigned", pub, body},
+		{"other key", otherPub, signed},
+	} {
+		if _, err := VerifySignedManifest(tc.pub, bytes.NewReader(tc.data)); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: err = %v, want ErrInvalidSignature", tc.name, err)
+		}
+	}
+
+}
@@ -0,0 +1,118 @@
+package objectify
+
+import (
+	"fmt"
+)
+
+// SizeUnits selects the units used by FormatSize.
+type SizeUnits int
+
+const (
+	// SizeBinary formats sizes in powers of 1024 (KiB, MiB, GiB, ...).
+	SizeBinary SizeUnits = iota
+
+	// SizeSI formats sizes in powers of 1000 (KB, MB, GB, ...).
+	SizeSI
+)
+
+// FormatSize returns a human-readable representation of bytes in the given
+// units, with precision digits after the decimal point (e.g. "1.50 MiB" or
+// "1.57 MB"). Sizes under one kilobyte are always written as whole bytes
+// ("500 B"). A negative precision is treated as 0.
+func FormatSize(bytes int64, units SizeUnits, precision int) string {
+
+	unit, suffix := int64(1024), "iB"
+	if units == SizeSI {
+		unit, suffix = 1000, "B"
+	}
+	if precision < 0 {
+		precision = 0
+	}
+
+	if bytes < unit && bytes > -unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := unit, 0
+	for n := bytes / unit; n >= unit || n <= -unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.*f %c%s", precision, float64(bytes)/float64(div), "KMGTPE"[exp], suffix)
+
+}
+
+// DiskUsage is the space used by Files, returned by Files.DiskUsage.
+type DiskUsage struct {
+
+	// Files is the number of files counted.
+	Files int
+
+	// Apparent is the sum of their SizeBytes, as reported by
+	// du --apparent-size.
+	Apparent int64
+
+	// Allocated is the sum of their AllocatedBytes, as reported by du. It is
+	// less than Apparent when files are sparse, and more when they are padded
+	// to whole blocks. Files whose AllocatedBytes is not available are counted
+	// with their SizeBytes.
+	Allocated int64
+
+	// Unallocated is the number of files whose AllocatedBytes was not
+	// available.
+	Unallocated int
+}
+
+// String returns a one-line summary of the DiskUsage, e.g.:
+//
+//	1200 files, 1.20 GiB apparent, 1.10 GiB allocated
+func (u DiskUsage) String() string {
+
+	return fmt.Sprintf("%d files, %s apparent, %s allocated", u.Files,
+		FormatSize(u.Apparent, SizeBinary, 2), FormatSize(u.Allocated, SizeBinary, 2))
+
+}
+
+// DiskUsage returns the apparent and allocated size of the FileObjs, with du
+// semantics: a file with several hard links in the Files is counted once (as
+// far as the scan recorded which files are linked; FileObjs read from a
+// snapshot are counted by path), as are repeated paths. Archive members take
+// no space on disk of their own and are not counted. The sizes are those
+// recorded with Sets.Size.
+func (files Files) DiskUsage() DiskUsage {
+
+	var u DiskUsage
+
+	seenPaths := make(map[string]bool)
+	seenIDs := make(map[[2]uint64]bool)
+	for _, fo := range files {
+
+		if fo == nil || fo.Container != EMPTY || seenPaths[fo.FullPath()] {
+			continue
+		}
+		seenPaths[fo.FullPath()] = true
+
+		if fo.info != nil && fo.options().fsys == nil {
+			if id, ok := fileID(fo.info); ok {
+				if seenIDs[id] {
+					continue
+				}
+				seenIDs[id] = true
+			}
+		}
+
+		u.Files++
+		u.Apparent += fo.SizeBytes
+		if fo.AllocatedBytes >= 0 {
+			u.Allocated += fo.AllocatedBytes
+		} else {
+			u.Allocated += fo.SizeBytes
+			u.Unallocated++
+		}
+
+	}
+
+	return u
+
+}
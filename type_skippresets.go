@@ -0,0 +1,93 @@
+package objectify
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// SkipPreset is a set of built-in exclusion lists for WithSkipPresets. Presets
+// can be combined with |.
+type SkipPreset uint8
+
+const (
+	// SkipOSJunk skips the metadata files and directories operating systems
+	// leave behind: .DS_Store, AppleDouble "._" files, .Spotlight-V100,
+	// .Trashes, .fseventsd, and .TemporaryItems on macOS; Thumbs.db,
+	// ehthumbs.db, desktop.ini, $RECYCLE.BIN, and System Volume Information
+	// on Windows; and .directory and .Trash-* on Linux desktops.
+	SkipOSJunk SkipPreset = 1 << iota
+
+	// SkipEditorTemp skips the swap, backup, and lock files of editors: Vim's
+	// *.swp, *.swo, and *.swx, backups ending in ~, and Emacs' .#* lock and
+	// #*# auto-save files.
+	SkipEditorTemp
+
+	// SkipHidden skips hidden files and directories, i.e. those whose name
+	// starts with a dot, such as .git. The Windows hidden attribute is not
+	// consulted.
+	SkipHidden
+
+	// SkipJunk combines SkipOSJunk and SkipEditorTemp.
+	SkipJunk = SkipOSJunk | SkipEditorTemp
+)
+
+// osJunkNames are the names skipped by SkipOSJunk, in lower case, since
+// Windows names are matched without regard to case.
+var osJunkNames = map[string]bool{
+	".ds_store":                 true,
+	".spotlight-v100":           true,
+	".trashes":                  true,
+	".fseventsd":                true,
+	".temporaryitems":           true,
+	"thumbs.db":                 true,
+	"ehthumbs.db":               true,
+	"desktop.ini":               true,
+	"$recycle.bin":              true,
+	"system volume information": true,
+	".directory":                true,
+}
+
+// Matches returns true if an entry with the base name name is skipped by the
+// presets.
+func (p SkipPreset) Matches(name string) bool {
+
+	if p&SkipHidden != 0 && strings.HasPrefix(name, ".") && name != "." && name != ".." {
+		return true
+	}
+
+	if p&SkipOSJunk != 0 {
+		if osJunkNames[strings.ToLower(name)] || strings.HasPrefix(name, "._") || strings.HasPrefix(name, ".Trash-") {
+			return true
+		}
+	}
+
+	if p&SkipEditorTemp != 0 {
+		switch {
+		case strings.HasSuffix(name, "~"),
+			strings.HasPrefix(name, ".#"),
+			len(name) > 2 && strings.HasPrefix(name, "#") && strings.HasSuffix(name, "#"):
+			return true
+		}
+		switch filepath.Ext(name) {
+		case ".swp", ".swo", ".swx":
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// WithSkipPresets skips the entries matched by the presets p (see SkipPreset),
+// so common exclusion lists need not be rewritten by every consumer, e.g.
+// WithSkipPresets(SkipJunk). Directories matched by a preset are skipped with
+// their contents. It adds a SkipFunc.
+func WithSkipPresets(p SkipPreset) Option {
+	if p == 0 {
+		return nil
+	}
+	return WithSkipFunc(func(_ string, d fs.DirEntry) bool {
+		return p.Matches(d.Name())
+	})
+}
@@ -0,0 +1,221 @@
+package objectify
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// StorageReport lists files of a scan grouped by their directory, answering
+// storage cleanup questions such as which files are largest (Files.Largest) or
+// have not been modified in a long time (Files.Stale).
+type StorageReport struct {
+
+	// Dirs holds the directories of the listed files, those whose files hold
+	// the most bytes first.
+	Dirs []StorageDir
+
+	// Files is the number of files listed, and Bytes the sum of their
+	// SizeBytes.
+	Files int
+	Bytes int64
+}
+
+// StorageDir is a directory of a StorageReport and its listed files.
+type StorageDir struct {
+	Dir   string
+	Files Files
+
+	// Bytes is the sum of the SizeBytes of Files.
+	Bytes int64
+}
+
+// Largest returns a StorageReport of the n largest files by SizeBytes, with
+// the files of each directory sorted largest first. Directories, symlinks, and
+// repeated paths are ignored. Every file is listed if n is not positive.
+func (files Files) Largest(n int) *StorageReport {
+
+	largest := files.regular()
+	sort.SliceStable(largest, func(i, j int) bool {
+		return largest[i].SizeBytes > largest[j].SizeBytes
+	})
+	if n > 0 && len(largest) > n {
+		largest = largest[:n]
+	}
+
+	return newStorageReport(largest)
+
+}
+
+// Stale returns a StorageReport of the files whose recorded modification time
+// is more than d in the past (see FileObj.OlderThan), with the files of each
+// directory sorted oldest first. Directories, symlinks, and repeated paths are
+// ignored.
+func (files Files) Stale(d time.Duration) *StorageReport {
+
+	var stale Files
+	for _, fo := range files.regular() {
+		if fo.OlderThan(d) {
+			stale = append(stale, fo)
+		}
+	}
+	sort.SliceStable(stale, func(i, j int) bool {
+		return stale[i].modTime.Before(stale[j].modTime)
+	})
+
+	return newStorageReport(stale)
+
+}
+
+// regular returns the entries which are not directories or symlinks, without
+// repeated paths, in their original order.
+func (files Files) regular() Files {
+
+	var kept Files
+	seen := make(map[string]bool)
+	for _, fo := range files {
+		if fo == nil || fo.Mode.IsDir() || fo.isSymlink() || seen[fo.FullPath()] {
+			continue
+		}
+		seen[fo.FullPath()] = true
+		kept = append(kept, fo)
+	}
+
+	return kept
+
+}
+
+// newStorageReport groups files by their Root, keeping their order within
+// each directory.
+func newStorageReport(files Files) *StorageReport {
+
+	r := &StorageReport{}
+	byDir := make(map[string]int)
+	for _, fo := range files {
+
+		i, ok := byDir[fo.Root]
+		if !ok {
+			i = len(r.Dirs)
+			byDir[fo.Root] = i
+			r.Dirs = append(r.Dirs, StorageDir{Dir: fo.Root})
+		}
+		r.Dirs[i].Files = append(r.Dirs[i].Files, fo)
+		r.Dirs[i].Bytes += fo.SizeBytes
+
+		r.Files++
+		r.Bytes += fo.SizeBytes
+
+	}
+
+	sort.SliceStable(r.Dirs, func(i, j int) bool {
+		if r.Dirs[i].Bytes != r.Dirs[j].Bytes {
+			return r.Dirs[i].Bytes > r.Dirs[j].Bytes
+		}
+		return r.Dirs[i].Dir < r.Dirs[j].Dir
+	})
+
+	return r
+
+}
+
+// storageModTime returns the modification time of fo in RFC 3339 format, or
+// an empty string if none was recorded.
+func storageModTime(fo *FileObj) string {
+
+	modTime := fo.ModTime()
+	if modTime.IsZero() {
+		return EMPTY
+	}
+
+	return modTime.Format(time.RFC3339)
+
+}
+
+// WriteText writes the report as aligned text: for each directory, a header
+// with its number of files and bytes, then the size, modification time, and
+// path of each file, followed by a summary line with the totals.
+func (r *StorageReport) WriteText(w io.Writer) error {
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, d := range r.Dirs {
+		fmt.Fprintf(tw, "%s (%d files, %s)\n", d.Dir, len(d.Files), FormatSize(d.Bytes, SizeBinary, 2))
+		for _, fo := range d.Files {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", FormatSize(fo.SizeBytes, SizeBinary, 2), storageModTime(fo), fo.FullPath())
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%d files in %d directories, %s (%d bytes)\n",
+		r.Files, len(r.Dirs), FormatSize(r.Bytes, SizeBinary, 2), r.Bytes)
+
+	return err
+
+}
+
+// WriteJSON writes the report as an indented JSON object with the totals and
+// the directories, each with its bytes and the path, size, and modification
+// time of its files.
+func (r *StorageReport) WriteJSON(w io.Writer) error {
+
+	type file struct {
+		Path      string `json:"path"`
+		SizeBytes int64  `json:"size_bytes"`
+		ModTime   string `json:"mod_time,omitempty"`
+	}
+
+	type dir struct {
+		Dir   string `json:"dir"`
+		Bytes int64  `json:"bytes"`
+		Files []file `json:"files"`
+	}
+
+	dirs := make([]dir, 0, len(r.Dirs))
+	for _, d := range r.Dirs {
+		files := make([]file, len(d.Files))
+		for i, fo := range d.Files {
+			files[i] = file{fo.FullPath(), fo.SizeBytes, storageModTime(fo)}
+		}
+		dirs = append(dirs, dir{d.Dir, d.Bytes, files})
+	}
+
+	type totals struct {
+		Files int   `json:"files"`
+		Dirs  int   `json:"dirs"`
+		Bytes int64 `json:"bytes"`
+	}
+
+	out := struct {
+		Totals totals `json:"totals"`
+		Dirs   []dir  `json:"dirs"`
+	}{totals{r.Files, len(r.Dirs), r.Bytes}, dirs}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(out)
+
+}
+
+// WriteCSV writes the report as CSV with a header row and one row per file:
+// its directory, path, size, and modification time.
+func (r *StorageReport) WriteCSV(w io.Writer) error {
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"dir", "path", "size_bytes", "mod_time"})
+	for _, d := range r.Dirs {
+		for _, fo := range d.Files {
+			_ = cw.Write([]string{d.Dir, fo.FullPath(), strconv.FormatInt(fo.SizeBytes, 10), storageModTime(fo)})
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+
+}
@@ -0,0 +1,70 @@
+package objectify
+
+import (
+	"fmt"
+	"os"
+)
+
+// Stream is an NTFS alternate data stream of a file, such as the
+// Zone.Identifier stream recording where a download came from: its Name
+// (without the leading colon and the :$DATA type), its Size in bytes, and the
+// SHA256 of its content if WithAlternateStreams was asked to hash them.
+type Stream struct {
+	Name   string
+	Size   int64
+	SHA256 []byte
+}
+
+// setStreams sets the Streams field to the alternate data streams of a regular
+// file on the OS filesystem when the streams option is set, hashing them if
+// the hashStreams option is set. Otherwise, the field is cleared. Streams only
+// exist on Windows, so it is always cleared elsewhere. Returns an error if the
+// streams cannot be listed or read.
+func (fo *FileObj) setStreams() error {
+
+	fo.Streams = nil
+
+	o := fo.options()
+	if !o.streams || !o.onOS() || !fo.IsExists || fo.info == nil || !fo.info.Mode().IsRegular() {
+		return nil
+	}
+
+	streams, err := readStreams(fo.ioPath())
+	o.stats.addSyscalls(1)
+	if err != nil {
+		return fmt.Errorf("%s: listing streams: %w", fo.FullPath(), err)
+	}
+
+	if o.hashStreams && fo.hashable() {
+
+		release := o.acquireHash()
+		defer release()
+
+		for i := range streams {
+			if streams[i].SHA256, err = streamSHA256(fo.ioPath(), streams[i].Name); err != nil {
+				return fmt.Errorf("%s: reading stream %s: %w", fo.FullPath(), streams[i].Name, err)
+			}
+			o.stats.addHashed(streams[i].Size)
+		}
+
+	}
+
+	fo.Streams = streams
+
+	return nil
+
+}
+
+// streamSHA256 returns the SHA256 of the content of the stream name of the
+// file at path, which is opened as path:name.
+func streamSHA256(path, name string) ([]byte, error) {
+
+	f, err := os.Open(path + ":" + name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return calcSHA256(f)
+
+}
@@ -0,0 +1,72 @@
+package objectify
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncs are the functions available to the templates of
+// Files.WriteTemplate, besides the text/template builtins.
+var templateFuncs = template.FuncMap{
+	"size": func(n int64) string {
+		return FormatSize(n, SizeBinary, 2)
+	},
+	"sizeSI": func(n int64) string {
+		return FormatSize(n, SizeSI, 2)
+	},
+	"rfc3339": func(t time.Time) string {
+		if t.IsZero() {
+			return EMPTY
+		}
+		return t.Format(time.RFC3339)
+	},
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// WriteTemplate executes the text/template tmpl once for each FileObj, in
+// order, writing the output to w, e.g. with "{{.FullPath}} {{.ChecksumSHA256}}".
+// The template's dot is the *FileObj, so its exported fields and methods such
+// as FullPath, ModTime, and Tag are available, along with these functions:
+//
+//   - size and sizeSI format a number of bytes, as SizeString and SizeStringSI
+//     do, e.g. {{size .SizeBytes}}.
+//   - rfc3339 formats a time in RFC 3339, or as "" for the zero time, e.g.
+//     {{rfc3339 .ModTime}}.
+//   - json encodes a value as JSON, e.g. {{json .Tags}}.
+//
+// A newline is written after each entry unless tmpl ends with one. nil
+// entries are skipped. An error parsing tmpl, or executing it for an entry,
+// is returned; output already written is not undone.
+func (files Files) WriteTemplate(w io.Writer, tmpl string) error {
+
+	t, err := template.New("objectify").Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	newline := !strings.HasSuffix(tmpl, "\n")
+
+	bw := bufio.NewWriter(w)
+	for _, fo := range files {
+		if fo == nil {
+			continue
+		}
+		if err := t.Execute(bw, fo); err != nil {
+			return err
+		}
+		if newline {
+			if err := bw.WriteByte('\n'); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+
+}
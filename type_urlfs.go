@@ -0,0 +1,195 @@
+package objectify
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// urlFS is a single-file fs.FS backed by an HTTP(S) URL. Stat issues a HEAD
+// request and Open issues a GET request, so the body is only downloaded when
+// checksums are requested. While caching is set, by FileFromURL, the result
+// of the first HEAD request is reused; afterwards each Stat, e.g. by
+// FileObj.Update and HasChanged, makes a request.
+type urlFS struct {
+	url    string
+	name   string
+	client *http.Client
+
+	mu      sync.Mutex
+	caching bool
+	info    *urlInfo
+}
+
+// newURLFS returns a urlFS for rawURL. The single file is named after the last
+// element of the URL path, or "index" if the path has none.
+func newURLFS(rawURL string, client *http.Client) (*urlFS, error) {
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme: %q", u.Scheme)
+	}
+
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		name = "index"
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &urlFS{
+		url:    rawURL,
+		name:   name,
+		client: client,
+	}, nil
+
+}
+
+// cache makes Stat reuse the result of its first request if on is true, and
+// drops that result otherwise.
+func (u *urlFS) cache(on bool) {
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.caching = on
+	if !on {
+		u.info = nil
+	}
+
+}
+
+// Open issues a GET request for the URL. The response body is returned as the
+// file, whose fs.FileInfo is the one cached by Stat, or else is built from
+// the headers of the response.
+func (u *urlFS) Open(name string) (fs.File, error) {
+
+	if name != u.name {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	resp, err := u.client.Get(u.url)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		_ = resp.Body.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fmt.Errorf("GET %s: %s", u.url, resp.Status)}
+	}
+
+	u.mu.Lock()
+	info := u.info
+	u.mu.Unlock()
+	if info == nil {
+		info = u.infoFrom(resp)
+	}
+
+	return &urlFile{resp: resp, info: info}, nil
+
+}
+
+// Stat issues a HEAD request for the URL and returns an fs.FileInfo built from
+// the Content-Length, Last-Modified, ETag, and Content-Type headers.
+func (u *urlFS) Stat(name string) (fs.FileInfo, error) {
+
+	if name != u.name {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.caching && u.info != nil {
+		return u.info, nil
+	}
+
+	resp, err := u.client.Head(u.url)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fmt.Errorf("HEAD %s: %s", u.url, resp.Status)}
+	}
+
+	info := u.infoFrom(resp)
+	if u.caching {
+		u.info = info
+	}
+
+	return info, nil
+
+}
+
+// infoFrom returns the urlInfo of the Content-Length, Last-Modified, ETag, and
+// Content-Type headers of resp.
+func (u *urlFS) infoFrom(resp *http.Response) *urlInfo {
+
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	mod, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return &urlInfo{
+		name:        u.name,
+		size:        size,
+		modTime:     mod,
+		etag:        strings.Trim(strings.TrimPrefix(resp.Header.Get("ETag"), "W/"), `"`),
+		contentType: resp.Header.Get("Content-Type"),
+	}
+
+}
+
+// urlFile is the fs.File returned by urlFS.Open.
+type urlFile struct {
+	resp *http.Response
+	info fs.FileInfo
+}
+
+func (f *urlFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *urlFile) Read(p []byte) (int, error) {
+	return f.resp.Body.Read(p)
+}
+
+func (f *urlFile) Close() error {
+	return f.resp.Body.Close()
+}
+
+// urlInfo implements fs.FileInfo, ETagInfo, and ContentTypeInfo for a URL.
+// A size of 0 is recorded if the server did not report a Content-Length.
+type urlInfo struct {
+	name        string
+	size        int64
+	modTime     time.Time
+	etag        string
+	contentType string
+}
+
+func (i *urlInfo) Name() string        { return i.name }
+func (i *urlInfo) Size() int64         { return i.size }
+func (i *urlInfo) Mode() fs.FileMode   { return 0444 }
+func (i *urlInfo) ModTime() time.Time  { return i.modTime }
+func (i *urlInfo) IsDir() bool         { return false }
+func (i *urlInfo) Sys() any            { return nil }
+func (i *urlInfo) ETag() string        { return i.etag }
+func (i *urlInfo) ContentType() string { return i.contentType }
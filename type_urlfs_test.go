@@ -0,0 +1,79 @@
+package objectify
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// urlServer serves body, modified at modTime, and counts the HEAD requests.
+type urlServer struct {
+	mu      sync.Mutex
+	body    string
+	modTime time.Time
+	heads   int
+}
+
+func (s *urlServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method == http.MethodHead {
+		s.heads++
+	}
+	w.Header().Set("Content-Length", fmt.Sprint(len(s.body)))
+	w.Header().Set("Last-Modified", s.modTime.UTC().Format(http.TimeFormat))
+	if r.Method == http.MethodGet {
+		_, _ = w.Write([]byte(s.body))
+	}
+
+}
+
+// set replaces the body and modification time.
+func (s *urlServer) set(body string, modTime time.Time) {
+
+	s.mu.Lock()
+	s.body, s.modTime = body, modTime
+	s.mu.Unlock()
+
+}
+
+func TestFileFromURLUpdate(t *testing.T) {
+
+	s := &urlServer{body: "first", modTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	fo, err := FileFromURL(srv.URL+"/file", Sets{Size: true, ChecksumSHA256: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	heads := s.heads
+	s.mu.Unlock()
+	if heads != 1 {
+		t.Errorf("FileFromURL made %d HEAD requests, want 1", heads)
+	}
+	if fo.HasChanged() {
+		t.Error("HasChanged() = true before the resource changed")
+	}
+
+	s.set("second version", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if !fo.HasChanged() {
+		t.Fatal("HasChanged() = false after the resource changed")
+	}
+	fo.Update()
+
+	if fo.SizeBytes != int64(len("second version")) {
+		t.Errorf("SizeBytes = %d, want %d", fo.SizeBytes, len("second version"))
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256([]byte("second version"))); fo.ChecksumSHA256 != want {
+		t.Errorf("ChecksumSHA256 = %s, want %s", fo.ChecksumSHA256, want)
+	}
+
+}
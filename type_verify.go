@@ -0,0 +1,251 @@
+package objectify
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+)
+
+// VerifyStatus is the outcome of verifying a single file.
+type VerifyStatus string
+
+const (
+	// VerifyOK means every recorded checksum matches the file's content.
+	VerifyOK VerifyStatus = "OK"
+
+	// VerifyMismatch means a recorded checksum does not match the file's content.
+	VerifyMismatch VerifyStatus = "MISMATCH"
+
+	// VerifyMissing means the file no longer exists.
+	VerifyMissing VerifyStatus = "MISSING"
+
+	// VerifyUnreadable means the file exists but its content could not be read.
+	VerifyUnreadable VerifyStatus = "UNREADABLE"
+)
+
+// VerifyResult is the verification outcome of a single file. Algorithm,
+// Expected, and Actual describe the checksum which was compared; for a
+// mismatch, they describe the first checksum which did not match.
+type VerifyResult struct {
+	Path      string       `json:"path"`
+	Status    VerifyStatus `json:"status"`
+	Algorithm string       `json:"algorithm,omitempty"`
+	Expected  string       `json:"expected,omitempty"`
+	Actual    string       `json:"actual,omitempty"`
+}
+
+// VerificationReport is returned by Files.Verify and VerifyManifest. Results
+// are in the order the files were listed, and the counts tally each status.
+type VerificationReport struct {
+	Results []VerifyResult
+
+	OK         int
+	Mismatch   int
+	Missing    int
+	Unreadable int
+
+	// Duration is how long verification took.
+	Duration time.Duration
+}
+
+// Verify re-reads each FileObj which has a recorded MD5 or SHA256 checksum and
+// compares the recorded checksums with the file's current content. Entries
+// without recorded checksums (such as directories) are not included in the
+// report. Files from PathFS or FileFS are re-read from the same fs.FS. A
+// HashCache set on the original scan is not consulted. Recorded HMACs are
+// checked when the FileObj was scanned with WithHMACKey.
+func (files Files) Verify() *VerificationReport {
+
+	start := time.Now()
+	report := &VerificationReport{}
+
+	for _, fo := range files {
+		if fo == nil || (fo.SHA256 == nil && fo.MD5 == nil) {
+			continue
+		}
+		report.add(verifyFileObj(fo))
+	}
+
+	report.Duration = time.Since(start)
+
+	return report
+
+}
+
+// VerifyManifest reads a checksum manifest with ParseManifest and verifies the
+// listed files (see Files.Verify). Relative paths in the manifest are resolved
+// against base, which is usually the manifest's directory.
+func VerifyManifest(r io.Reader, base string) (*VerificationReport, error) {
+
+	files, err := ParseManifest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fo := range files {
+		if !filepath.IsAbs(fo.Root) {
+			fo.Root = filepath.Join(base, fo.Root)
+		}
+	}
+
+	return files.Verify(), nil
+
+}
+
+// verifyFileObj checks the recorded checksums of fo against a fresh read of
+// the file.
+func verifyFileObj(fo *FileObj) VerifyResult {
+
+	res := VerifyResult{Path: fo.FullPath()}
+
+	o := *fo.options()
+	o.hashCache = nil
+	o.scanRoot = EMPTY
+
+	sets := Sets{ChecksumSHA256: fo.SHA256 != nil, ChecksumMD5: fo.MD5 != nil}
+	cur := newFileObj(fo.ioPath(), sets, &o)
+
+	switch {
+	case cur == nil || !cur.IsExists:
+		res.Status = VerifyMissing
+		return res
+	case !cur.IsReadable || cur.Err != nil:
+		res.Status = VerifyUnreadable
+		return res
+	}
+
+	checks := []struct {
+		algo          string
+		expected, got []byte
+	}{
+		{"SHA256", fo.SHA256, cur.SHA256},
+		{"MD5", fo.MD5, cur.MD5},
+	}
+	if o.hmacKey != nil {
+		checks = append(checks, struct {
+			algo          string
+			expected, got []byte
+		}{"HMAC-SHA256", fo.HMAC, cur.HMAC})
+	}
+
+	res.Status = VerifyOK
+	for _, c := range checks {
+
+		if c.expected == nil {
+			continue
+		}
+
+		if res.Algorithm == EMPTY || !bytes.Equal(c.expected, c.got) {
+			res.Algorithm = c.algo
+			res.Expected = hex.EncodeToString(c.expected)
+			res.Actual = hex.EncodeToString(c.got)
+		}
+		if !bytes.Equal(c.expected, c.got) {
+			res.Status = VerifyMismatch
+			break
+		}
+
+	}
+
+	return res
+
+}
+
+// add appends a result and updates the counts.
+func (r *VerificationReport) add(res VerifyResult) {
+
+	r.Results = append(r.Results, res)
+
+	switch res.Status {
+	case VerifyOK:
+		r.OK++
+	case VerifyMismatch:
+		r.Mismatch++
+	case VerifyMissing:
+		r.Missing++
+	case VerifyUnreadable:
+		r.Unreadable++
+	}
+
+}
+
+// Total returns the number of files verified.
+func (r *VerificationReport) Total() int {
+	return r.OK + r.Mismatch + r.Missing + r.Unreadable
+}
+
+// Passed returns true if every file verified OK.
+func (r *VerificationReport) Passed() bool {
+	return r.OK == r.Total()
+}
+
+// Failures returns the results whose status is not VerifyOK.
+func (r *VerificationReport) Failures() []VerifyResult {
+
+	var failed []VerifyResult
+	for _, res := range r.Results {
+		if res.Status != VerifyOK {
+			failed = append(failed, res)
+		}
+	}
+
+	return failed
+
+}
+
+// WriteText writes one line per result followed by a summary line, e.g.:
+//
+//	OK        /srv/data/a.txt
+//	MISMATCH  /srv/data/b.txt  SHA256 expected 2cf24dba… got 486ea462…
+//	2 files: 1 OK, 1 mismatch, 0 missing, 0 unreadable (1.2ms)
+func (r *VerificationReport) WriteText(w io.Writer) error {
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, res := range r.Results {
+		if res.Status == VerifyMismatch {
+			fmt.Fprintf(tw, "%s\t%s\t%s expected %s got %s\n", res.Status, res.Path, res.Algorithm, res.Expected, res.Actual)
+		} else {
+			fmt.Fprintf(tw, "%s\t%s\t\n", res.Status, res.Path)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%d files: %d OK, %d mismatch, %d missing, %d unreadable (%s)\n",
+		r.Total(), r.OK, r.Mismatch, r.Missing, r.Unreadable, r.Duration.Round(time.Microsecond))
+
+	return err
+
+}
+
+// WriteJSON writes the report as an indented JSON object with the counts, the
+// duration in seconds, and the results.
+func (r *VerificationReport) WriteJSON(w io.Writer) error {
+
+	results := r.Results
+	if results == nil {
+		results = []VerifyResult{}
+	}
+
+	out := struct {
+		Total      int            `json:"total"`
+		OK         int            `json:"ok"`
+		Mismatch   int            `json:"mismatch"`
+		Missing    int            `json:"missing"`
+		Unreadable int            `json:"unreadable"`
+		Duration   float64        `json:"duration_seconds"`
+		Results    []VerifyResult `json:"results"`
+	}{r.Total(), r.OK, r.Mismatch, r.Missing, r.Unreadable, r.Duration.Seconds(), results}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(out)
+
+}
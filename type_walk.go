@@ -0,0 +1,87 @@
+package objectify
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// WalkFunc is called by Walk and WalkFS with each FileObj as soon as it is
+// objectified. Returning SkipDir skips the rest of the entry's directory,
+// returning Stop ends the walk, and returning any other error ends the walk
+// and is returned by Walk.
+type WalkFunc func(fo *FileObj) error
+
+// Walk objectifies the entries under rootPath like Path, but instead of
+// collecting them, calls fn with each FileObj as soon as it is ready, in the
+// order the directories return them, like filepath.WalkDir. Memory use does
+// not grow with the size of the tree, and fn can end the walk early with
+// Stop, or skip the rest of a directory with SkipDir. Use WithSkipFunc to
+// prune directories before they are read.
+//
+// Entries are objectified one at a time, so WithConcurrency and
+// WithSortedPaths have no effect. With WithArchiveMembers, the members of an
+// archive are passed after it. An error reading rootPath is returned; errors
+// reading subdirectories cause them to be skipped.
+func Walk(rootPath string, s Sets, fn WalkFunc, opts ...Option) error {
+
+	return walk(newPathWorker(rootPath, s, newOptions(opts...)), fn)
+
+}
+
+// WalkFS works like Walk, but reads rootPath from fsys instead of the OS
+// filesystem. See PathFS.
+func WalkFS(fsys fs.FS, rootPath string, s Sets, fn WalkFunc, opts ...Option) error {
+
+	o := newOptions(opts...)
+	o.fsys = fsys
+
+	return walk(newPathWorker(rootPath, s, o), fn)
+
+}
+
+// walk runs the worker's directory walk and calls fn with each FileObj, and
+// with the members of archives if the archives option is set.
+func walk(w *worker, fn WalkFunc) error {
+
+	if !w.validate() {
+		return fmt.Errorf("StartingPath is not correct: %s", w.RootPath)
+	}
+	w.opts.tune(w.RootPath)
+
+	if w.opts.oneFileSystem && w.opts.fsys == nil {
+		w.rootDev, w.hasRootDev = deviceOf(w.opts.sys(), w.RootPath)
+	}
+
+	release, err := w.holdRoot()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	err = w.readDir(w.RootPath, func(p string) error {
+
+		files := Files{w.objectify(p)}
+		if w.opts.archives {
+			files = append(files, w.archiveMembers(files[0], 1)...)
+		}
+		if w.opts.relativePaths {
+			w.relativize(files)
+		}
+
+		for _, fo := range files {
+			if err := fn(fo); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	}, 0)
+	if errors.Is(err, Stop) {
+		return nil
+	}
+
+	return err
+
+}
@@ -1,31 +1,62 @@
 package objectify
 
 import (
+	"errors"
+	"io"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
 )
 
+// readDirBatch is the number of directory entries read at a time.
+const readDirBatch = 1024
+
 // worker represents a worker that performs operations on files and directories.
 type worker struct {
 	RootPath       string
 	singleFileMode bool
 	setter         Sets
+	opts           *options
+
+	// rootDev is the device ID of RootPath, recorded when the
+	// oneFileSystem option is set and the device ID is available.
+	rootDev    uint64
+	hasRootDev bool
+
+	// relOpts maps the options of scanned FileObjs to copies which resolve
+	// relative Roots against the scan root, for the relativePaths option.
+	relOpts map[*options]*options
+
+	// cp checkpoints the progress of the scan, if the journal option is set.
+	cp *checkpoint
+
+	// rootDir is RootPath, held open during a hardened scan of the OS
+	// filesystem, which directories are opened relative to (see holdRoot).
+	rootDir *os.File
 }
 
-// newPathWorker creates a new instance of the worker struct with the provided startPath and Sets.
-// It returns a pointer to the worker.
-func newPathWorker(startPath string, s Sets) *worker {
+// newPathWorker creates a new instance of the worker struct with the provided startPath, Sets,
+// and options. It returns a pointer to the worker.
+func newPathWorker(startPath string, s Sets, o *options) *worker {
 	return &worker{
 		RootPath:       startPath,
 		singleFileMode: false,
 		setter:         s,
+		opts:           o,
 	}
 }
 
-func newFileWorker(path string, s Sets) *worker {
+// newFileWorker creates a new instance of the worker struct in single file mode with the
+// provided path, Sets, and options. It returns a pointer to the worker.
+func newFileWorker(path string, s Sets, o *options) *worker {
 	return &worker{
 		RootPath:       path,
 		singleFileMode: true,
 		setter:         s,
+		opts:           o,
 	}
 }
 
@@ -40,33 +71,298 @@ func (w *worker) validate() bool {
 		return false
 	}
 
+	if w.opts.fsys != nil && !fs.ValidPath(w.RootPath) {
+		return false
+	}
+
 	if w.singleFileMode {
-		return isFile(w.RootPath)
+		return w.opts.isFile(w.RootPath)
 	}
 
 	return true
 
 }
 
-// hasEntries checks if the worker's RootPath directory has any non-directory entries.
-// If reading the directory fails (due to an error), it returns false.
-// If the directory is empty (no entries), it returns false.
-// It iterates over each directory entry and if any of them is not a directory,
-// it returns true.
-// If all directory entries are directories, it returns false.
-func (w *worker) hasEntries() bool {
+// hasEntries checks if the worker's RootPath directory has any entries which
+// readDir would emit: non-directory entries which are not skipped by the
+// SkipFuncs, the oneFileSystem option, or the entTypes options (see keeps).
+// If reading the directory fails, it returns false and the error.
+// The directory is read in batches, and reading stops at the first such entry,
+// in which case it returns true.
+func (w *worker) hasEntries() (bool, error) {
 
-	dirents, err := os.ReadDir(w.RootPath)
-	if err != nil {
-		return false
+	found := false
+	err := w.readDirBatches(w.RootPath, func(dirents []fs.DirEntry) bool {
+		for _, ent := range dirents {
+			entPath := w.join(w.RootPath, ent.Name())
+			if ent.IsDir() || !w.onRootDevice(ent) || w.skips(entPath, ent) || !w.keeps(entPath, ent) {
+				continue
+			}
+			found = true
+			return false
+		}
+		return true
+	})
+
+	return found, err
+
+}
+
+// readDir reads the entries of dir and calls emit with the path of each
+// non-directory entry, in the order the directory returns them. The entries
+// are read in batches of readDirBatch, so huge directories are streamed rather
+// than loaded at once. depth is the number of directories between RootPath
+// and dir, 0 for RootPath.
+//
+// If the worker is recursive, it descends into subdirectories, but never into
+// symlinked directories, NTFS junctions, or mount points, which are skipped so
+// that junction loops cannot make the walk recurse forever. Entries on another
+// device than RootPath (with the oneFileSystem option), entries for which a
+// SkipFunc returns true, and entries of a kind excluded by the entTypes options
+// are skipped too.
+//
+// An error reading the root directory is returned, while subdirectories which
+// cannot be read are skipped. If emit returns SkipDir, the rest of the
+// directory is skipped; any other error stops the walk and is returned.
+func (w *worker) readDir(dir string, emit func(string) error, depth int) error {
+
+	var emitErr error
+	entries := 0
+	err := w.readDirBatches(dir, func(dirents []fs.DirEntry) bool {
+
+		entries += len(dirents)
+		for _, ent := range dirents {
+
+			entPath := w.join(dir, ent.Name())
+
+			if !w.onRootDevice(ent) || w.skips(entPath, ent) {
+				continue
+			}
+
+			if ent.IsDir() {
+				if w.opts.recursive {
+					emitErr = w.readDir(entPath, emit, depth+1)
+					if emitErr != nil {
+						return false
+					}
+				}
+				continue
+			}
+			if !w.keeps(entPath, ent) {
+				continue
+			}
+
+			if err := emit(entPath); err != nil {
+				if !errors.Is(err, SkipDir) {
+					emitErr = err
+				}
+				return false
+			}
+
+		}
+
+		return true
+
+	})
+	if err == nil {
+		w.opts.stats.observeDir(dir, depth, entries)
+	}
+	if emitErr != nil {
+		return emitErr
 	}
+	if err != nil && depth == 0 {
+		return err
+	}
+
+	return nil
+
+}
+
+// keeps returns true if the non-directory entry ent at entPath is emitted by
+// readDir: it is not a symlink which leads to a directory, a junction or mount
+// point, or of a kind excluded by the entTypes options.
+func (w *worker) keeps(entPath string, ent fs.DirEntry) bool {
 
-	if len(dirents) == 0 {
+	if ent.Type()&os.ModeSymlink != 0 {
+		w.opts.stats.addSyscalls(1)
+		if w.leadsToDir(entPath) {
+			return false
+		}
+	}
+	if w.isMountPoint(entPath, ent) {
 		return false
 	}
 
-	for _, ent := range dirents {
-		if !ent.IsDir() {
+	return w.opts.wantsKind(getEntModeWithInfo(ent.Type()).Kind())
+
+}
+
+// collect runs walk and objectifies each path it emits, returning the
+// FileObjs in the order the paths were emitted. When the concurrency option
+// is greater than 1, paths are queued to that many goroutines while walk
+// continues, unless the schedule option reorders them (see collectScheduled).
+func (w *worker) collect(walk func(emit func(string) error) error) (Files, error) {
+
+	if w.opts.schedule != ScheduleWalk {
+		return w.collectScheduled(walk)
+	}
+
+	return w.dispatch(walk)
+
+}
+
+// dispatch objectifies each path emitted by walk, as described by collect.
+// When the hash pool is used (see hashPool), the concurrency goroutines only
+// stat the entries, and feed those whose content must be read to hashWorkers
+// goroutines, so entries keep being stat'ed while every hash worker is busy.
+func (w *worker) dispatch(walk func(emit func(string) error) error) (Files, error) {
+
+	files := Files{}
+	pool := w.hashPool()
+
+	if w.opts.concurrency <= 1 && !pool {
+		err := walk(func(p string) error {
+			if err := w.opts.ctxErr(); err != nil {
+				return err
+			}
+			if w.cp.skip(p) {
+				return nil
+			}
+			files = append(files, w.objectify(p))
+			return w.cp.progress(files[len(files)-1])
+		})
+		return files, err
+	}
+
+	type job struct {
+		idx  int
+		path string
+	}
+	type result struct {
+		idx int
+		fo  *FileObj
+	}
+
+	jobs := make(chan job, w.opts.queueDepth)
+	hashJobs := make(chan result, w.opts.queueDepth)
+	results := make(chan result, w.opts.concurrency)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < max(w.opts.concurrency, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if !pool {
+					results <- result{idx: j.idx, fo: w.objectify(j.path)}
+					continue
+				}
+				fo := w.stat(j.path)
+				if fo != nil && fo.IsExists {
+					hashJobs <- result{idx: j.idx, fo: fo}
+					continue
+				}
+				if fo != nil {
+					fo.ctx = nil
+				}
+				results <- result{idx: j.idx, fo: fo}
+			}
+		}()
+	}
+
+	hashWG := &sync.WaitGroup{}
+	if pool {
+		for i := 0; i < w.opts.hashWorkers; i++ {
+			hashWG.Add(1)
+			go func() {
+				defer hashWG.Done()
+				for r := range hashJobs {
+					_ = r.fo.updateContent()
+					r.fo.ctx = nil
+					results <- r
+				}
+			}()
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for r := range results {
+			for len(files) <= r.idx {
+				files = append(files, nil)
+			}
+			files[r.idx] = r.fo
+			_ = w.cp.progress(r.fo)
+		}
+	}()
+
+	n := 0
+	err := walk(func(p string) error {
+		if err := w.opts.ctxErr(); err != nil {
+			return err
+		}
+		if w.cp.skip(p) {
+			return nil
+		}
+		if err := w.cp.failed(); err != nil {
+			return err
+		}
+		jobs <- job{idx: n, path: p}
+		w.opts.stats.observeQueue(len(jobs))
+		n++
+		return nil
+	})
+
+	close(jobs)
+	wg.Wait()
+	close(hashJobs)
+	hashWG.Wait()
+	close(results)
+	<-done
+
+	return files, err
+
+}
+
+// hashPool returns true if the content of the entries is read by a pool of
+// hashWorkers goroutines, separate from those which stat them. It is used
+// when WithHashWorkers is set, except with WithPerFileTimeout, which limits
+// the time spent on each entry as a whole, and with the hardened option, since
+// entries are then opened relative to their parent directory, which is only
+// held while they are stat'ed (see objectify).
+func (w *worker) hashPool() bool {
+	return w.opts.hashWorkers > 0 && w.opts.perFileTimeout == 0 && !w.opts.hardened
+}
+
+// stat returns the FileObj of the path p, populated except for the fields read
+// from its content, which updateContent sets.
+func (w *worker) stat(p string) *FileObj {
+
+	fo := newFileObjPaths(p, w.setter, w.opts)
+	if fo == nil {
+		return nil
+	}
+
+	fo.statOnly, fo.ctx = true, w.opts.ctx
+	_ = fo.update()
+	fo.statOnly = false
+
+	return fo
+
+}
+
+// skips returns true if any SkipFunc provided through WithSkipFunc returns
+// true for the directory entry, or if the dotfilesOnly option is set and it is
+// an entry of RootPath whose name does not start with a dot.
+func (w *worker) skips(path string, ent fs.DirEntry) bool {
+
+	if w.opts.dotfilesOnly && !strings.HasPrefix(ent.Name(), ".") && path == w.join(w.RootPath, ent.Name()) {
+		return true
+	}
+
+	for _, fn := range w.opts.skipFuncs {
+		if fn(path, ent) {
 			return true
 		}
 	}
@@ -74,3 +370,212 @@ func (w *worker) hasEntries() bool {
 	return false
 
 }
+
+// onRootDevice returns true if the oneFileSystem option is not set, or if the
+// directory entry is on the same device as RootPath. If either device ID cannot
+// be determined, it returns true.
+func (w *worker) onRootDevice(ent fs.DirEntry) bool {
+
+	if !w.opts.oneFileSystem || !w.hasRootDev {
+		return true
+	}
+
+	info, err := ent.Info()
+	w.opts.stats.addSyscalls(1)
+	if err != nil {
+		return true
+	}
+
+	dev, ok := deviceID(info)
+	if !ok {
+		return true
+	}
+
+	return dev == w.rootDev
+
+}
+
+// join joins path elements using path.Join when reading from an fs.FS,
+// or filepath.Join otherwise.
+func (w *worker) join(elem ...string) string {
+
+	if w.opts.fsys != nil {
+		return path.Join(elem...)
+	}
+
+	return filepath.Join(elem...)
+
+}
+
+// leadsToDir returns true if the symlink at the specified path leads to a
+// directory. On the OS filesystem, linkLeadsToDir is used. For an fs.FS,
+// fs.Stat (which follows links, if the fs.FS supports them) is used.
+func (w *worker) leadsToDir(p string) bool {
+
+	if w.opts.fsys == nil {
+		return linkLeadsToDir(w.opts.sys(), p)
+	}
+
+	info, err := fs.Stat(w.opts.fsys, p)
+	return err == nil && info.IsDir()
+
+}
+
+// isMountPoint returns true if the directory entry at path p is an NTFS
+// junction or volume mount point on the OS filesystem. They are reported as
+// symlinks or, since Go 1.23, as irregular files, so only such entries are
+// examined.
+func (w *worker) isMountPoint(p string, ent fs.DirEntry) bool {
+
+	if !w.opts.onOS() || ent.Type()&(os.ModeSymlink|os.ModeIrregular) == 0 {
+		return false
+	}
+	w.opts.stats.addSyscalls(1)
+
+	return isMountPoint(p)
+
+}
+
+// readDirBatches opens dir (in the fs.FS, or on the OS filesystem) and calls fn
+// with its entries, readDirBatch at a time, until the directory is exhausted or
+// fn returns false. Entries are passed in the order the directory returns them.
+// If the opened fs.FS directory does not implement fs.ReadDirFile, fs.ReadDir is
+// used and fn is called once with every entry, as it is with the entries
+// returned by the ReadDir of a FileSystem set by WithFileSystem.
+func (w *worker) readDirBatches(dir string, fn func([]fs.DirEntry) bool) error {
+
+	var f fs.File
+	var err error
+
+	switch {
+	case w.opts.fsys != nil:
+		f, err = w.opts.fsys.Open(dir)
+	case w.opts.filesystem != nil:
+		dirents, err := w.opts.filesystem.ReadDir(dir)
+		w.opts.stats.addSyscalls(1)
+		if err != nil {
+			return err
+		}
+		fn(dirents)
+		return nil
+	default:
+		f, err = w.openDir(dir)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w.opts.stats.addSyscalls(2)
+
+	rdf, ok := f.(fs.ReadDirFile)
+	if !ok {
+		dirents, err := fs.ReadDir(w.opts.fsys, dir)
+		w.opts.stats.addSyscalls(1)
+		if err != nil {
+			return err
+		}
+		fn(dirents)
+		return nil
+	}
+
+	return w.readBatches(rdf, fn)
+
+}
+
+// holdRoot opens RootPath as the worker's rootDir when the hardened option is
+// set for a scan of the OS filesystem, on platforms with openat. Directories
+// are then opened relative to it with openDirAt, one element at a time without
+// following symlinks, and each entry is stat'ed and opened relative to its
+// parent directory (see objectify), so renaming directories or swapping them
+// for symlinks during the scan cannot redirect it to other files than those
+// it stat'ed. The returned function closes the rootDir.
+func (w *worker) holdRoot() (func(), error) {
+
+	if !w.opts.hardened || !w.opts.onOS() || !atSupported {
+		return func() {}, nil
+	}
+
+	dir, err := openRootDir(w.RootPath)
+	if err != nil {
+		return nil, err
+	}
+	w.rootDir = dir
+
+	return func() {
+		w.rootDir = nil
+		_ = dir.Close()
+	}, nil
+
+}
+
+// openDir opens the directory dir on the OS filesystem, relative to the
+// rootDir if it is held.
+func (w *worker) openDir(dir string) (*os.File, error) {
+
+	if w.rootDir == nil {
+		return os.Open(dir)
+	}
+
+	rel, err := filepath.Rel(w.RootPath, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return openDirAt(w.rootDir, rel, dir)
+
+}
+
+// objectify returns the FileObj of the path p. If the rootDir is held, the
+// parent directory of p is opened relative to it and held while the FileObj is
+// populated, so it is stat'ed with lstatAt and opened with openAt; if the
+// parent directory cannot be opened, its error is recorded on the FileObj.
+func (w *worker) objectify(p string) *FileObj {
+
+	if w.rootDir == nil {
+		return newFileObj(p, w.setter, w.opts)
+	}
+
+	fo := newFileObjPaths(p, w.setter, w.opts)
+	if fo == nil {
+		return nil
+	}
+
+	dir, err := w.openDir(fo.Root)
+	w.opts.stats.addSyscalls(1)
+	if err != nil {
+		fo.Err = err
+		fo.timestamp()
+		return fo
+	}
+	defer dir.Close()
+
+	fo.dir = dir
+	fo = fo.populate()
+	fo.dir = nil
+
+	return fo
+
+}
+
+// readBatches calls fn with the entries of the open directory d, readDirBatch
+// at a time, until the directory is exhausted or fn returns false.
+func (w *worker) readBatches(d fs.ReadDirFile, fn func([]fs.DirEntry) bool) error {
+
+	for {
+
+		dirents, err := d.ReadDir(readDirBatch)
+		w.opts.stats.addSyscalls(1)
+		if len(dirents) > 0 && !fn(dirents) {
+			return nil
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+	}
+
+}
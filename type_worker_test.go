@@ -0,0 +1,150 @@
+package objectify
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingFS is the OS filesystem, except that opening a regular file blocks
+// until release is closed. It records the paths which were stat'ed.
+type blockingFS struct {
+	OSFileSystem
+	release chan struct{}
+
+	mu      sync.Mutex
+	statted map[string]bool
+}
+
+func (b *blockingFS) Lstat(name string) (fs.FileInfo, error) {
+
+	b.mu.Lock()
+	b.statted[name] = true
+	b.mu.Unlock()
+
+	return os.Lstat(name)
+
+}
+
+func (b *blockingFS) Open(name string) (fs.File, error) {
+
+	if info, err := os.Stat(name); err == nil && info.Mode().IsRegular() {
+		<-b.release
+	}
+
+	return os.Open(name)
+
+}
+
+func (b *blockingFS) countStatted(paths []string) int {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := 0
+	for _, p := range paths {
+		if b.statted[p] {
+			n++
+		}
+	}
+
+	return n
+
+}
+
+func TestHashWorkersStatWhileHashing(t *testing.T) {
+
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i < 6; i++ {
+		p := filepath.Join(dir, fmt.Sprintf("file%d", i))
+		if err := os.WriteFile(p, []byte(p), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+
+	sys := &blockingFS{release: make(chan struct{}), statted: make(map[string]bool)}
+
+	type scan struct {
+		files Files
+		err   error
+	}
+	done := make(chan scan, 1)
+	go func() {
+		files, err := Path(dir, Sets{ChecksumSHA256: true},
+			WithFileSystem(sys), WithStatWorkers(2), WithHashWorkers(1), WithSortedPaths())
+		done <- scan{files, err}
+	}()
+
+	// Every hash worker is blocked reading a file, but the stat workers must
+	// still stat every entry.
+	deadline := time.Now().Add(5 * time.Second)
+	for sys.countStatted(paths) < len(paths) {
+		if time.Now().After(deadline) {
+			close(sys.release)
+			t.Fatalf("stat'ed %d of %d entries while hashing was blocked", sys.countStatted(paths), len(paths))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(sys.release)
+	s := <-done
+	if s.err != nil {
+		t.Fatal(s.err)
+	}
+	if len(s.files) != len(paths) {
+		t.Fatalf("got %d files, want %d", len(s.files), len(paths))
+	}
+
+	for i, fo := range s.files {
+		if fo.FullPath() != paths[i] {
+			t.Errorf("files[%d] = %s, want %s", i, fo.FullPath(), paths[i])
+		}
+		want := sha256.Sum256([]byte(paths[i]))
+		if fmt.Sprintf("%x", want) != fo.ChecksumSHA256 {
+			t.Errorf("%s: SHA256 = %q, want %x", fo.FullPath(), fo.ChecksumSHA256, want)
+		}
+	}
+
+}
+
+func TestPathNoEntriesFiltered(t *testing.T) {
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.log"), []byte("a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	skipLogs := WithSkipFunc(func(path string, ent fs.DirEntry) bool {
+		return filepath.Ext(path) == ".log"
+	})
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		want error
+	}{
+		{"unfiltered", nil, nil},
+		{"skipped", []Option{skipLogs}, ErrNoEntries},
+		{"kind filtered", []Option{WithEntTypes(EntKindLink)}, ErrNoEntries},
+		{"allow empty", []Option{skipLogs, WithAllowEmpty()}, nil},
+	} {
+		files, err := Path(dir, Sets{}, tc.opts...)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+		if tc.want == nil && files == nil {
+			t.Errorf("%s: nil Files", tc.name)
+		}
+	}
+
+}
@@ -6,41 +6,23 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
 const (
 	EMPTY = ""
 )
 
-// attemptOpen opens a file at the specified path and returns true
-// if successful, false otherwise.
-func attemptOpen(path string) bool {
-
-	var opens = true
-
-	f, err := os.Open(path)
-	defer func(f *os.File) {
-		cErr := f.Close()
-		if cErr != nil {
-			opens = false
-		}
-	}(f)
-	if err != nil {
-		opens = false
-	}
-
-	return opens
-
-}
-
 // attemptStat returns the fs.FileInfo of the file at the specified path
-// using os.Lstat. If the operation is successful, it returns the FileInfo and true.
+// using the Lstat of sys. If the operation is successful, it returns the FileInfo and true.
 // Otherwise, it returns nil and false.
-func attemptStat(path string) (fs.FileInfo, bool) {
+func attemptStat(sys FileSystem, path string) (fs.FileInfo, bool) {
 
-	info, err := os.Lstat(path)
+	info, err := sys.Lstat(path)
 	if err != nil || info == nil {
 		return nil, false
 	}
@@ -49,169 +31,193 @@ func attemptStat(path string) (fs.FileInfo, bool) {
 
 }
 
-// calcSHA256 calculates the SHA256 hash of the content of the provided file.
-// It returns nil if the file is nil or if an error occurs during the hashing process.
+// calcSHA256 calculates the SHA256 hash of the content of the provided reader.
+// It returns nil if the reader is nil, and the error if reading fails.
 // Otherwise, it returns the SHA256 hash as a byte array.
-func calcSHA256(f *os.File) []byte {
+func calcSHA256(f io.Reader) ([]byte, error) {
 
 	if f == nil {
-		return nil
+		return nil, nil
 	}
 
 	hash := sha256.New()
-	_, err := io.Copy(hash, f)
-	if err != nil {
-		return nil
+	if _, err := io.Copy(hash, f); err != nil {
+		return nil, err
 	}
-	return hash.Sum(nil)
+	return hash.Sum(nil), nil
 
 }
 
-// calcMD5 calculates the MD5 hash of the content of the provided file.
-// It returns nil if the file is nil or if an error occurs during the hashing process.
+// calcMD5 calculates the MD5 hash of the content of the provided reader.
+// It returns nil if the reader is nil, and the error if reading fails.
 // Otherwise, it returns the MD5 hash as a byte array.
-func calcMD5(f *os.File) []byte {
+func calcMD5(f io.Reader) ([]byte, error) {
 
 	if f == nil {
-		return nil
+		return nil, nil
 	}
 
 	hash := md5.New()
 	if _, err := io.Copy(hash, f); err != nil {
-		return nil
+		return nil, err
 	}
-	return hash.Sum(nil)
+	return hash.Sum(nil), nil
 
 }
 
-// getSHA256 opens the file at the specified path and calculates
-// the SHA256 hash of its content. It returns the SHA256 hash as a
-// byte array, the hash as a hexadecimal string, and any error that occurs.
-// If the file cannot be opened, it returns nil for the hash and an error.
-// If there is an error during the hashing process, it returns nil for
-// the hash and the error.
-func getSHA256(path string) ([]byte, string, error) {
-
-	f, err := os.Open(path)
-	defer func(f *os.File) {
-		cErr := f.Close()
-		if cErr != nil {
-			err = cErr
-		}
-	}(f)
+// deviceOf returns the device ID of the filesystem hosting the specified path
+// in sys, following symlinks, and a bool indicating if the device ID is available.
+func deviceOf(sys FileSystem, path string) (uint64, bool) {
+
+	info, err := statFollow(sys, path)
 	if err != nil {
-		return nil, EMPTY, err
+		return 0, false
 	}
 
-	sum := calcSHA256(f)
+	return deviceID(info)
+
+}
 
-	return sum, fmt.Sprintf("%x", sum), nil
+// fileURI returns the file:// URL of the specified path. The path is made
+// absolute and slash-separated, and is percent-encoded by url.URL. Windows
+// drive paths gain a leading slash (file:///C:/dir), and UNC paths become the
+// URL's host (file://server/share/dir).
+func fileURI(p string) string {
+
+	p = filepath.ToSlash(pathAbsSafe(p))
+
+	u := url.URL{Scheme: "file", Path: p}
+	switch {
+	case strings.HasPrefix(p, "//"):
+		host, rest, _ := strings.Cut(strings.TrimPrefix(p, "//"), "/")
+		u.Host, u.Path = host, "/"+rest
+	case !strings.HasPrefix(p, "/"):
+		u.Path = "/" + p
+	}
+
+	return u.String()
 
 }
 
-// getMD5 opens the file at the specified path and calculates
-// the MD5 hash of its content. It returns the MD5 hash as a
-// byte array, the hash as a hexadecimal string, and any error that occurs.
-// If the file cannot be opened, it returns nil for the hash and an error.
-// If there is an error during the hashing process, it returns nil for
-// the hash and the error.
-func getMD5(path string) ([]byte, string, error) {
-
-	f, err := os.Open(path)
-	defer func(f *os.File) {
-		cErr := f.Close()
-		if cErr != nil {
-			err = cErr
-		}
-	}(f)
+// getsTarget returns the target of a symbolic link at the specified path in
+// sys (see followLinks) and a bool indicating if the retrieval was successful.
+func getsTarget(sys FileSystem, path string) (string, bool) {
+
+	target, err := followLinks(sys, path)
 	if err != nil {
-		return nil, EMPTY, err
+		return target, false
 	}
 
-	sum := calcMD5(f)
-
-	return sum, fmt.Sprintf("%x", sum), nil
+	return target, true
 
 }
 
-// getsTarget returns the target of a symbolic link at the specified path
-// and a bool indicating if the retrieval was successful.
-func getsTarget(path string) (string, bool) {
+// getsLinkPath returns the literal contents of the symbolic link at the specified
+// path using the Readlink of sys, and a bool indicating if the retrieval was successful.
+// The returned path is not resolved, so it may be relative or dangling.
+func getsLinkPath(sys FileSystem, path string) (string, bool) {
 
-	target, err := filepath.EvalSymlinks(path)
+	target, err := sys.Readlink(path)
 	if err != nil {
-		return target, false
+		return EMPTY, false
 	}
 
 	return target, true
 
 }
 
-// getsFinalTarget returns the final target of a symbolic link and a bool
-// indicating if the operation is successful. It takes the path of the symlink
-// and the fs.FileInfo of the symlink itself. If the fs.FileInfo is nil, it will
-// return an empty string and false. If the symlink is a directory, it will return
-// an empty string and false. If the symlink points to another symlink, it will
-// recursively evaluate the target until it reaches the final target. If any error
-// occurs during evaluation, it will return an empty string and false.
-func getsFinalTarget(path string, info fs.FileInfo) (string, bool) {
+// getsFinalTarget returns the final target of a symbolic link and an error. It takes
+// the path of the symlink, the fs.FileInfo of the symlink itself, and the maximum
+// number of links to follow. Each link is read with the Readlink of sys and followed
+// one hop at a time. If a link is visited twice, or more than maxHops links are followed,
+// ErrSymlinkCycle is returned. If the fs.FileInfo is nil or the final target is a
+// directory, an empty string and an error are returned.
+func getsFinalTarget(sys FileSystem, path string, info fs.FileInfo, maxHops int) (string, error) {
 
 	if info == nil {
-		return EMPTY, false
+		return EMPTY, fmt.Errorf("no file info for %s", path)
 	}
 
-	if info.Mode()&os.ModeSymlink != 0 {
+	visited := make(map[string]bool)
+	current := path
 
-		target, err := filepath.EvalSymlinks(path)
-		if err != nil {
-			return EMPTY, false
+	for hops := 0; info.Mode()&os.ModeSymlink != 0; hops++ {
+
+		if visited[current] {
+			return EMPTY, fmt.Errorf("%w: %s revisits %s", ErrSymlinkCycle, path, current)
 		}
+		if hops >= maxHops {
+			return EMPTY, fmt.Errorf("%w: %s exceeds %d hops", ErrSymlinkCycle, path, maxHops)
+		}
+		visited[current] = true
 
-		info, _ := attemptStat(target)
+		link, err := sys.Readlink(current)
+		if err != nil {
+			return EMPTY, err
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(current), link)
+		}
+		current = filepath.Clean(link)
 
-		return getsFinalTarget(target, info)
+		info, err = sys.Lstat(current)
+		if err != nil {
+			return EMPTY, err
+		}
 
 	}
 
 	if info.IsDir() {
-		return EMPTY, false
+		return EMPTY, fmt.Errorf("final target is a directory: %s", current)
 	}
 
-	return path, true
+	return current, nil
 
 }
 
 // isFile checks if the specified path corresponds to a file. It uses the
-// attemptStat function to get the fs.FileInfo of the path, and then returns
-// true if the info is not nil and represents a non-directory file. Otherwise,
-// it returns false.
-func isFile(path string) bool {
+// statPath method to get the fs.FileInfo of the path (in the options' fs.FS,
+// or on disk), and then returns true if the info is not nil and represents a
+// non-directory file. Otherwise, it returns false.
+func (o *options) isFile(path string) bool {
 
-	info, _ := attemptStat(path)
+	info, _ := o.statPath(path)
 	return info != nil && !info.IsDir()
 
 }
 
-// isReadable checks if a file at the specified path is readable by attempting
-// to obtain its file information using the attemptStat function. If the file
-// information is successfully obtained, it then attempts to open the file using
-// the attemptOpen function. If both operations are successful, it returns true,
-// indicating that the file is readable. Otherwise, it returns false.
-func isReadable(path string) bool {
+// isReadable reports whether the entry described by info can be opened for reading
+// by the current process. The decision is made from the permission bits and owner
+// recorded in info (see canRead), so no file is opened. If info describes a symlink,
+// the link is followed in sys (see statFollow) and the target's permissions are
+// checked instead; a broken link is never readable.
+func isReadable(sys FileSystem, path string, info fs.FileInfo) bool {
+
+	if info == nil {
+		return false
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := statFollow(sys, path)
+		if err != nil {
+			return false
+		}
+		info = target
+	}
 
-	return attemptOpen(path)
+	return canRead(info)
 
 }
 
-// linkLeadsToDir checks if the specified symbolic link leads to a directory. It first attempts to
-// retrieve the FileInfo using the attemptStat function. If the FileInfo is not found,
+// linkLeadsToDir checks if the specified symbolic link in sys leads to a directory. It first
+// attempts to retrieve the FileInfo using the attemptStat function. If the FileInfo is not found,
 // it returns false. If the FileInfo represents a directory, it returns true. If the FileInfo
-// represents a symbolic link, it uses filepath.EvalSymlinks to evaluate the target path.
+// represents a symbolic link, it uses followLinks to evaluate the target path.
 // If an error occurs during the evaluation, it returns false. Otherwise, it recursively
 // calls linkLeadsToDir on the target path. If none of the conditions are met, it returns false.
-func linkLeadsToDir(path string) bool {
+func linkLeadsToDir(sys FileSystem, path string) bool {
 
-	info, ok := attemptStat(path)
+	info, ok := attemptStat(sys, path)
 	if !ok {
 		return false
 	}
@@ -222,12 +228,12 @@ func linkLeadsToDir(path string) bool {
 
 	if info.Mode()&os.ModeSymlink != 0 {
 
-		target, err := filepath.EvalSymlinks(path)
+		target, err := followLinks(sys, path)
 		if err != nil {
 			return false
 		}
 
-		return linkLeadsToDir(target)
+		return linkLeadsToDir(sys, target)
 
 	}
 
@@ -235,6 +241,39 @@ func linkLeadsToDir(path string) bool {
 
 }
 
+// open opens the file at the specified path in the options' fs.FS, or on disk
+// through its FileSystem. With the hardened option, files on the OS
+// filesystem are opened with openNoFollow, with the faults of WithFaults.
+func (o *options) open(path string) (fs.File, error) {
+
+	switch {
+	case o.hardened && o.onOS():
+		return faultFS{faults: o.faults}.openWith(path, func() (fs.File, error) {
+			return openNoFollow(path)
+		})
+	case o.fsys != nil:
+		return o.fsys.Open(path)
+	}
+
+	return o.sys().Open(path)
+
+}
+
+// pathBaseSplitFS extracts the directory and file components from the specified
+// slash-separated fs.FS path. If the path is empty, it returns empty strings for
+// both directory and file.
+func pathBaseSplitFS(p string) (dir, file string) {
+
+	if p == EMPTY {
+		return EMPTY, EMPTY
+	}
+
+	p = path.Clean(p)
+
+	return path.Dir(p), path.Base(p)
+
+}
+
 // pathBaseSplit extracts the directory and file components from the specified path.
 // If the path is empty, it returns empty strings for both directory and file.
 func pathBaseSplit(path string) (dir, file string) {
@@ -266,22 +305,20 @@ func pathAbsSafe(path string) string {
 
 }
 
-// sizeString returns the formatted string representation of the size in bytes.
-// It converts the given size in bytes to a human-readable format (e.g., KB, MB, GB, etc.).
-func sizeString(bytes int64) string {
-
-	var unit = int64(1024)
+// statPath returns the fs.FileInfo of the specified path and true if successful.
+// Without an fs.FS, attemptStat (the Lstat of the FileSystem) is used. Otherwise
+// fs.Stat is used, as an fs.FS has no notion of Lstat.
+func (o *options) statPath(path string) (fs.FileInfo, bool) {
 
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
+	if o.fsys == nil {
+		return attemptStat(o.sys(), path)
 	}
 
-	div, exp := unit, 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
+	info, err := fs.Stat(o.fsys, path)
+	if err != nil || info == nil {
+		return nil, false
 	}
 
-	return fmt.Sprintf("%.2f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+	return info, true
 
 }
@@ -0,0 +1,34 @@
+//go:build !unix
+
+package objectify
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// atSupported reports whether entries can be resolved relative to a held
+// directory descriptor on this platform. Without openat, hardened scans open
+// entries by path with openNoFollow.
+const atSupported = false
+
+// openRootDir is not supported on this platform.
+func openRootDir(path string) (*os.File, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// openDirAt is not supported on this platform.
+func openDirAt(root *os.File, rel, name string) (*os.File, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// lstatAt is not supported on this platform.
+func lstatAt(dir *os.File, name string) (fs.FileInfo, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// openAt is not supported on this platform.
+func openAt(dir *os.File, name string, info fs.FileInfo) (fs.File, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,203 @@
+//go:build unix
+
+package objectify
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// atSupported reports whether entries can be resolved relative to a held
+// directory descriptor on this platform.
+const atSupported = true
+
+// dirFlags are the flags directories are opened with by openRootDir and
+// openDirAt.
+const dirFlags = unix.O_RDONLY | unix.O_DIRECTORY | unix.O_CLOEXEC
+
+// openRootDir opens the directory at path, which may be reached through
+// symlinks, to hold as the root which entries are resolved against.
+func openRootDir(path string) (*os.File, error) {
+
+	fd, err := unix.Open(path, dirFlags, 0)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: err}
+	}
+
+	return os.NewFile(uintptr(fd), path), nil
+
+}
+
+// at calls fn with the descriptor of dir. Once dir is closed, fn is not called
+// and an error is returned, so a descriptor number reused by another file is
+// never acted on (e.g. by an update abandoned by WithPerFileTimeout).
+func at(dir *os.File, fn func(fd int) error) error {
+
+	rc, err := dir.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var fnErr error
+	if err := rc.Control(func(fd uintptr) { fnErr = fn(int(fd)) }); err != nil {
+		return err
+	}
+
+	return fnErr
+
+}
+
+// openDirAt opens the directory rel, a path relative to root, one element at a
+// time with openat and O_NOFOLLOW, so that no element is reached through a
+// symlink, even one swapped in for a directory during the scan. The returned
+// directory is named name. An error wrapping ErrUnsafeFile is returned if an
+// element is a symlink or not a directory.
+func openDirAt(root *os.File, rel, name string) (*os.File, error) {
+
+	fd := -1
+	err := at(root, func(rootFD int) error {
+
+		var err error
+		if fd, err = unix.Openat(rootFD, ".", dirFlags, 0); err != nil {
+			return err
+		}
+
+		for _, elem := range strings.Split(rel, string(filepath.Separator)) {
+			if elem == EMPTY || elem == "." {
+				continue
+			}
+			if elem == ".." {
+				return unix.ELOOP
+			}
+			next, err := unix.Openat(fd, elem, dirFlags|unix.O_NOFOLLOW, 0)
+			_ = unix.Close(fd)
+			fd = next
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	})
+	if err != nil {
+		if fd >= 0 {
+			_ = unix.Close(fd)
+		}
+		if errors.Is(err, unix.ELOOP) || errors.Is(err, unix.ENOTDIR) {
+			return nil, fmt.Errorf("%w: %s is not a directory below %s", ErrUnsafeFile, name, root.Name())
+		}
+		return nil, &fs.PathError{Op: "openat", Path: name, Err: err}
+	}
+
+	return os.NewFile(uintptr(fd), name), nil
+
+}
+
+// lstatAt returns the fs.FileInfo of the entry name in dir, without following
+// a symlink, using fstatat.
+func lstatAt(dir *os.File, name string) (fs.FileInfo, error) {
+
+	info := &atInfo{name: name}
+	err := at(dir, func(fd int) error {
+		return unix.Fstatat(fd, name, &info.st, unix.AT_SYMLINK_NOFOLLOW)
+	})
+	if err != nil {
+		return nil, &fs.PathError{Op: "fstatat", Path: filepath.Join(dir.Name(), name), Err: err}
+	}
+
+	return info, nil
+
+}
+
+// openAt opens the regular file name in dir, using openat with the flags of
+// openNoFollow. If info is not nil, the opened file must be the file it
+// describes, as stat'ed by lstatAt, or an error wrapping ErrUnsafeFile is
+// returned.
+func openAt(dir *os.File, name string, info fs.FileInfo) (fs.File, error) {
+
+	path := filepath.Join(dir.Name(), name)
+
+	fd := -1
+	err := at(dir, func(dirFD int) error {
+		var err error
+		fd, err = unix.Openat(dirFD, name, unix.O_RDONLY|unix.O_NOFOLLOW|unix.O_CLOEXEC|unix.O_NONBLOCK, 0)
+		return err
+	})
+	if errors.Is(err, unix.ELOOP) {
+		return nil, fmt.Errorf("%w: %s is a symlink", ErrUnsafeFile, path)
+	}
+	if err != nil {
+		return nil, &fs.PathError{Op: "openat", Path: path, Err: err}
+	}
+
+	f := os.NewFile(uintptr(fd), path)
+	opened, err := f.Stat()
+	switch {
+	case err != nil:
+	case !opened.Mode().IsRegular():
+		err = fmt.Errorf("%w: %s is not a regular file", ErrUnsafeFile, path)
+	case info != nil && !sameStat(info, opened):
+		err = fmt.Errorf("%w: %s was replaced after it was stat'ed", ErrUnsafeFile, path)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return f, nil
+
+}
+
+// atInfo is the fs.FileInfo returned by lstatAt. Its Sys is a *unix.Stat_t.
+type atInfo struct {
+	name string
+	st   unix.Stat_t
+}
+
+func (i *atInfo) Name() string       { return i.name }
+func (i *atInfo) Size() int64        { return int64(i.st.Size) }
+func (i *atInfo) IsDir() bool        { return i.Mode().IsDir() }
+func (i *atInfo) Sys() any           { return &i.st }
+func (i *atInfo) ModTime() time.Time { return time.Unix(i.st.Mtim.Unix()) }
+
+// Mode converts the mode of the stat to an fs.FileMode, as os.Lstat does.
+func (i *atInfo) Mode() fs.FileMode {
+
+	m := uint32(i.st.Mode)
+	mode := fs.FileMode(m & 0777)
+
+	switch m & unix.S_IFMT {
+	case unix.S_IFBLK:
+		mode |= fs.ModeDevice
+	case unix.S_IFCHR:
+		mode |= fs.ModeDevice | fs.ModeCharDevice
+	case unix.S_IFDIR:
+		mode |= fs.ModeDir
+	case unix.S_IFIFO:
+		mode |= fs.ModeNamedPipe
+	case unix.S_IFLNK:
+		mode |= fs.ModeSymlink
+	case unix.S_IFSOCK:
+		mode |= fs.ModeSocket
+	}
+	if m&unix.S_ISGID != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if m&unix.S_ISUID != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if m&unix.S_ISVTX != 0 {
+		mode |= fs.ModeSticky
+	}
+
+	return mode
+
+}
@@ -0,0 +1,398 @@
+//go:build linux && iouring
+
+package objectify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// The io_uring ABI, from linux/io_uring.h.
+const (
+	ioringOpRead         = 22
+	ioringEnterGetEvents = 1 << 0
+	ioringFeatSingleMmap = 1 << 0
+	ioringOffSQRing      = 0
+	ioringOffCQRing      = 0x8000000
+	ioringOffSQEs        = 0x10000000
+	ringEntries          = 64
+	ringSQESize          = 64
+	ringCQESize          = 16
+	ringParamsSize       = 120
+)
+
+// ringParams is struct io_uring_params.
+type ringParams struct {
+	sqEntries    uint32
+	cqEntries    uint32
+	flags        uint32
+	sqThreadCPU  uint32
+	sqThreadIdle uint32
+	features     uint32
+	wqFd         uint32
+	resv         [3]uint32
+	sqOff        ringSQOffsets
+	cqOff        ringCQOffsets
+}
+
+// ringSQOffsets is struct io_sqring_offsets.
+type ringSQOffsets struct {
+	head, tail, ringMask, ringEntries, flags, dropped, array, resv1 uint32
+	userAddr                                                        uint64
+}
+
+// ringCQOffsets is struct io_cqring_offsets.
+type ringCQOffsets struct {
+	head, tail, ringMask, ringEntries, overflow, cqes, flags, resv1 uint32
+	userAddr                                                        uint64
+}
+
+// ringSQE is struct io_uring_sqe, as used for IORING_OP_READ.
+type ringSQE struct {
+	opcode      uint8
+	flags       uint8
+	ioprio      uint16
+	fd          int32
+	off         uint64
+	addr        uint64
+	len         uint32
+	rwFlags     uint32
+	userData    uint64
+	bufIndex    uint16
+	personality uint16
+	spliceFdIn  int32
+	addr3       uint64
+	pad         uint64
+}
+
+// ringCQE is struct io_uring_cqe.
+type ringCQE struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+// The structs must match the kernel's layout.
+var _ = [1]struct{}{}[unsafe.Sizeof(ringParams{})-ringParamsSize]
+var _ = [1]struct{}{}[unsafe.Sizeof(ringSQE{})-ringSQESize]
+var _ = [1]struct{}{}[unsafe.Sizeof(ringCQE{})-ringCQESize]
+
+// ringRead is a read submitted to the ring, completed when done is closed.
+type ringRead struct {
+	fd   int
+	buf  []byte
+	off  int64
+	n    int
+	err  error
+	done chan struct{}
+}
+
+// uring is an io_uring whose reads are submitted and reaped by a single
+// goroutine, serve, which batches the reads queued on reads.
+type uring struct {
+	fd    int
+	reads chan *ringRead
+
+	sqHead, sqTail *uint32
+	sqMask         uint32
+	sqArray        []uint32
+	sqes           []ringSQE
+
+	cqHead, cqTail *uint32
+	cqMask         uint32
+	cqes           []ringCQE
+}
+
+var (
+	sharedRing     *uring
+	sharedRingOnce sync.Once
+)
+
+// errRingFailed is returned for reads submitted after io_uring_enter failed,
+// which are then made with pread instead.
+var errRingFailed = errors.New("io_uring failed")
+
+// withRing returns f wrapped to be read through the shared io_uring, and
+// true, if f is an *os.File and the ring is available. Reads of the wrapped
+// file fail with the error of ctx once it is done, without waiting for the
+// ring.
+func withRing(f fs.File, ctx context.Context) (fs.File, bool) {
+
+	file, ok := f.(*os.File)
+	if !ok {
+		return f, false
+	}
+
+	sharedRingOnce.Do(func() {
+		if r, err := newRing(ringEntries); err == nil {
+			sharedRing = r
+			go r.serve()
+		}
+	})
+	if sharedRing == nil {
+		return f, false
+	}
+
+	return &ringFile{file: file, ring: sharedRing, ctx: ctx}, true
+
+}
+
+// newRing sets up an io_uring with the given number of submission entries
+// and maps its rings.
+func newRing(entries uint32) (*uring, error) {
+
+	var p ringParams
+	fd, _, errno := unix.Syscall(unix.SYS_IO_URING_SETUP, uintptr(entries), uintptr(unsafe.Pointer(&p)), 0)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	r := &uring{fd: int(fd), reads: make(chan *ringRead)}
+
+	sqSize := int(p.sqOff.array + p.sqEntries*4)
+	cqSize := int(p.cqOff.cqes + p.cqEntries*ringCQESize)
+	if p.features&ioringFeatSingleMmap != 0 {
+		sqSize = max(sqSize, cqSize)
+	}
+
+	sq, err := unix.Mmap(r.fd, ioringOffSQRing, sqSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED|unix.MAP_POPULATE)
+	if err != nil {
+		_ = unix.Close(r.fd)
+		return nil, err
+	}
+	cq := sq
+	if p.features&ioringFeatSingleMmap == 0 {
+		if cq, err = unix.Mmap(r.fd, ioringOffCQRing, cqSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED|unix.MAP_POPULATE); err != nil {
+			_ = unix.Munmap(sq)
+			_ = unix.Close(r.fd)
+			return nil, err
+		}
+	}
+	sqes, err := unix.Mmap(r.fd, ioringOffSQEs, int(p.sqEntries*ringSQESize), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED|unix.MAP_POPULATE)
+	if err != nil {
+		_ = unix.Munmap(sq)
+		if p.features&ioringFeatSingleMmap == 0 {
+			_ = unix.Munmap(cq)
+		}
+		_ = unix.Close(r.fd)
+		return nil, err
+	}
+
+	r.sqHead = (*uint32)(unsafe.Pointer(&sq[p.sqOff.head]))
+	r.sqTail = (*uint32)(unsafe.Pointer(&sq[p.sqOff.tail]))
+	r.sqMask = *(*uint32)(unsafe.Pointer(&sq[p.sqOff.ringMask]))
+	r.sqArray = unsafe.Slice((*uint32)(unsafe.Pointer(&sq[p.sqOff.array])), p.sqEntries)
+	r.sqes = unsafe.Slice((*ringSQE)(unsafe.Pointer(&sqes[0])), p.sqEntries)
+
+	r.cqHead = (*uint32)(unsafe.Pointer(&cq[p.cqOff.head]))
+	r.cqTail = (*uint32)(unsafe.Pointer(&cq[p.cqOff.tail]))
+	r.cqMask = *(*uint32)(unsafe.Pointer(&cq[p.cqOff.ringMask]))
+	r.cqes = unsafe.Slice((*ringCQE)(unsafe.Pointer(&cq[p.cqOff.cqes])), p.cqEntries)
+
+	return r, nil
+
+}
+
+// read reads into buf from the file descriptor fd at off through the ring.
+// If ctx is done first, it returns the error of ctx without waiting for the
+// read, which may still complete into buf later; buf must then not be used
+// again.
+func (r *uring) read(ctx context.Context, fd int, buf []byte, off int64) (int, error) {
+
+	req := &ringRead{fd: fd, buf: buf, off: off, done: make(chan struct{})}
+	select {
+	case r.reads <- req:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	select {
+	case <-req.done:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+
+	return req.n, req.err
+
+}
+
+// serve submits the queued reads in batches of up to the size of the
+// submission queue, and completes them as the kernel reports them. Reads
+// which fail with EAGAIN or EINTR are submitted again. If io_uring_enter
+// fails, the ring is abandoned: the reads in flight, and every later read,
+// fail with errRingFailed.
+func (r *uring) serve() {
+
+	defer func() {
+		for req := range r.reads {
+			req.err = errRingFailed
+			close(req.done)
+		}
+	}()
+
+	inFlight := make(map[uint64]*ringRead)
+	var retry []*ringRead
+	var next uint64
+
+	for {
+
+		// Wait for a read if none is in flight, then take every read queued
+		// meanwhile, as long as the ring has room.
+		var batch []*ringRead
+		batch, retry = retry, nil
+		if len(inFlight) == 0 && len(batch) == 0 {
+			batch = append(batch, <-r.reads)
+		}
+	queue:
+		for len(inFlight)+len(batch) < len(r.sqes) {
+			select {
+			case req := <-r.reads:
+				batch = append(batch, req)
+			default:
+				break queue
+			}
+		}
+
+		tail := *r.sqTail
+		for _, req := range batch {
+			next++
+			inFlight[next] = req
+			idx := tail & r.sqMask
+			r.sqes[idx] = ringSQE{
+				opcode:   ioringOpRead,
+				fd:       int32(req.fd),
+				off:      uint64(req.off),
+				addr:     uint64(uintptr(unsafe.Pointer(unsafe.SliceData(req.buf)))),
+				len:      uint32(len(req.buf)),
+				userData: next,
+			}
+			r.sqArray[idx] = idx
+			tail++
+		}
+		atomic.StoreUint32(r.sqTail, tail)
+
+		if err := r.enter(uint32(len(batch))); err != nil {
+			for _, req := range inFlight {
+				req.err = fmt.Errorf("%w: %w", errRingFailed, err)
+				close(req.done)
+			}
+			return
+		}
+
+		head := *r.cqHead
+		for ; head != atomic.LoadUint32(r.cqTail); head++ {
+
+			c := r.cqes[head&r.cqMask]
+			req, ok := inFlight[c.userData]
+			if !ok {
+				continue
+			}
+			delete(inFlight, c.userData)
+
+			switch {
+			case c.res >= 0:
+				req.n = int(c.res)
+			case unix.Errno(-c.res) == unix.EAGAIN || unix.Errno(-c.res) == unix.EINTR:
+				retry = append(retry, req)
+				continue
+			default:
+				req.err = unix.Errno(-c.res)
+			}
+			close(req.done)
+
+		}
+		atomic.StoreUint32(r.cqHead, head)
+
+	}
+
+}
+
+// enter submits n entries and waits for at least one completion. The kernel
+// may consume fewer entries than it is given, so the rest are submitted again
+// until all n are consumed. io_uring_enter only fails with EINTR when no entry
+// was submitted, so the same n is submitted again. If the kernel consumes no
+// entry without failing, enter fails with EBUSY rather than spin.
+func (r *uring) enter(n uint32) error {
+
+	for {
+		ret, _, errno := unix.Syscall6(unix.SYS_IO_URING_ENTER, uintptr(r.fd), uintptr(n), 1, ioringEnterGetEvents, 0, 0)
+		switch {
+		case errno == unix.EINTR:
+			continue
+		case errno != 0:
+			return errno
+		case uint32(ret) >= n:
+			return nil
+		case ret == 0:
+			return unix.EBUSY
+		}
+		n -= uint32(ret)
+	}
+
+}
+
+// ringFile is an *os.File whose reads go through the shared io_uring, at
+// the offset following the previous read. The ring reads into buf, which is
+// copied to the caller, so that a read abandoned when ctx is done never
+// writes to the caller's buffer after Read returns.
+type ringFile struct {
+	file *os.File
+	ring *uring
+	ctx  context.Context
+	off  int64
+	buf  []byte
+}
+
+func (f *ringFile) Stat() (fs.FileInfo, error) {
+	return f.file.Stat()
+}
+
+func (f *ringFile) Close() error {
+	return f.file.Close()
+}
+
+func (f *ringFile) Read(p []byte) (int, error) {
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if len(f.buf) < len(p) {
+		f.buf = make([]byte, len(p))
+	}
+	buf := f.buf[:len(p)]
+
+	n, err := f.ring.read(f.ctx, int(f.file.Fd()), buf, f.off)
+	runtime.KeepAlive(f.file)
+	if err != nil && f.ctx.Err() != nil {
+		// The abandoned read may still complete into buf.
+		f.buf = nil
+	}
+	if errors.Is(err, errRingFailed) {
+		n, err = f.file.ReadAt(p, f.off)
+		if n > 0 || err == io.EOF {
+			f.off += int64(n)
+			return n, err
+		}
+	}
+	if err != nil {
+		return 0, &fs.PathError{Op: "read", Path: f.file.Name(), Err: err}
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	copy(p, buf[:n])
+	f.off += int64(n)
+
+	return n, nil
+
+}
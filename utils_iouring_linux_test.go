@@ -0,0 +1,144 @@
+//go:build linux && iouring
+
+package objectify
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// ringTestFile writes size bytes of patterned content to a temporary file,
+// and returns its path and content.
+func ringTestFile(t *testing.T, size int) (string, []byte) {
+
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	path := filepath.Join(t.TempDir(), "ring")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path, content
+
+}
+
+func TestRingRead(t *testing.T) {
+
+	path, content := ringTestFile(t, 3*ringReadSize+17)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf, ok := withRing(f, context.Background())
+	if !ok {
+		_ = f.Close()
+		t.Skip("io_uring not available")
+	}
+	defer rf.Close()
+
+	got, err := io.ReadAll(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("read %d bytes through the ring, want the %d bytes of the file", len(got), len(content))
+	}
+
+}
+
+func TestRingReadCancel(t *testing.T) {
+
+	// A ring which takes reads but never completes them, as if the kernel
+	// hung, must not block a read past its ctx.
+	r := &uring{reads: make(chan *ringRead, 1)}
+	buf := make([]byte, 8)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := r.read(ctx, 0, buf, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("queued read: err = %v, want context.DeadlineExceeded", err)
+	}
+	req := <-r.reads
+
+	// A read nobody takes fails as soon as ctx is done.
+	r = &uring{reads: make(chan *ringRead)}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.read(cancelled, 0, buf, 0); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unserved read: err = %v, want context.Canceled", err)
+	}
+
+	// The abandoned read completing later writes to its own buffer.
+	copy(req.buf, "complete")
+	close(req.done)
+
+}
+
+func TestRingFileCancel(t *testing.T) {
+
+	path, _ := ringTestFile(t, 1024)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	rf := &ringFile{file: f, ring: &uring{reads: make(chan *ringRead, 1)}, ctx: ctx}
+
+	p := make([]byte, 64)
+	done := make(chan error, 1)
+	go func() {
+		_, err := rf.Read(p)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("err = %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Read blocked past the timeout of its ctx")
+	}
+
+	// The ring's buffer is not the caller's, and is dropped once abandoned.
+	req := <-rf.ring.reads
+	if &req.buf[0] == &p[0] {
+		t.Error("the ring reads into the caller's buffer")
+	}
+	if rf.buf != nil {
+		t.Error("the abandoned buffer is reused")
+	}
+
+}
+
+func TestRingFileObj(t *testing.T) {
+
+	path, content := ringTestFile(t, 4<<20)
+	want := sha256.Sum256(content)
+	for _, opts := range [][]Option{
+		{WithIOUring()},
+		{WithIOUring(), WithReadBuffer(4096)},
+		{WithIOUring(), WithPerFileTimeout(time.Minute)},
+	} {
+		fo, err := File(path, Sets{Size: true, ChecksumSHA256: true}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fo.Err != nil || !bytes.Equal(fo.SHA256, want[:]) {
+			t.Errorf("%d options: SHA256 %x, Err %v, want %x", len(opts), fo.SHA256, fo.Err, want)
+		}
+	}
+
+}
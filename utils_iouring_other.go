@@ -0,0 +1,13 @@
+//go:build !(linux && iouring)
+
+package objectify
+
+import (
+	"context"
+	"io/fs"
+)
+
+// withRing returns f and false, since the io_uring backend is not compiled in.
+func withRing(f fs.File, _ context.Context) (fs.File, bool) {
+	return f, false
+}
@@ -0,0 +1,69 @@
+//go:build !unix
+
+package objectify
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// canRead reports whether the entry described by info appears readable based on
+// its permission bits. Ownership information is not available on this platform,
+// so any read bit is accepted.
+func canRead(info fs.FileInfo) bool {
+	return info.Mode().Perm()&0444 != 0
+}
+
+// deviceID is not supported on this platform and always returns false.
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// ownerIDs is not supported on this platform and always returns -1 for both.
+func ownerIDs(info fs.FileInfo) (uid, gid int) {
+	return -1, -1
+}
+
+// allocatedSize is not supported on this platform and always returns false.
+func allocatedSize(info fs.FileInfo) (int64, bool) {
+	return 0, false
+}
+
+// fileID is not supported on this platform and always returns false.
+func fileID(info fs.FileInfo) ([2]uint64, bool) {
+	return [2]uint64{}, false
+}
+
+// openNoFollow opens the regular file at path for the hardened option. Without
+// O_NOFOLLOW on this platform, the entry is stat'ed without following links
+// before it is opened, and the opened file is checked to be the same file. An
+// error wrapping ErrUnsafeFile is returned for symlinks and other files which
+// are not regular, and for files replaced while being opened.
+func openNoFollow(path string) (fs.File, error) {
+
+	before, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !before.Mode().IsRegular() {
+		return nil, fmt.Errorf("%w: %s is not a regular file", ErrUnsafeFile, path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := f.Stat()
+	if err == nil && !os.SameFile(before, after) {
+		err = fmt.Errorf("%w: %s was replaced while being opened", ErrUnsafeFile, path)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return f, nil
+
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package objectify
+
+// isMountPoint returns true if the entry at path is an NTFS junction or volume
+// mount point. There are none on this platform.
+func isMountPoint(path string) bool {
+	return false
+}
+
+// reparseTarget returns the target of an NTFS reparse point. There are none on
+// this platform.
+func reparseTarget(path string) (string, bool) {
+	return EMPTY, false
+}
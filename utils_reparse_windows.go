@@ -0,0 +1,65 @@
+//go:build windows
+
+package objectify
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// reparseTag returns the reparse tag of the entry at path, and a bool
+// indicating if it is a reparse point. The entry itself is examined with
+// FindFirstFile; a reparse point is not followed.
+func reparseTag(path string) (uint32, bool) {
+
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, false
+	}
+
+	var data syscall.Win32finddata
+	h, err := syscall.FindFirstFile(p, &data)
+	if err != nil {
+		return 0, false
+	}
+	_ = syscall.FindClose(h)
+
+	if data.FileAttributes&syscall.FILE_ATTRIBUTE_REPARSE_POINT == 0 {
+		return 0, false
+	}
+
+	return data.Reserved0, true
+
+}
+
+// isMountPoint returns true if the entry at path is an NTFS junction or volume
+// mount point.
+func isMountPoint(path string) bool {
+
+	tag, ok := reparseTag(path)
+	return ok && tag == windows.IO_REPARSE_TAG_MOUNT_POINT
+
+}
+
+// reparseTarget returns the target of the entry at path if it is a name
+// surrogate reparse point (a junction, mount point, or symlink), as returned
+// by os.Readlink, and a bool indicating if it was read.
+func reparseTarget(path string) (string, bool) {
+
+	// Name surrogates are reparse points which refer to another named entity,
+	// marked by bit 29 of the tag.
+	tag, ok := reparseTag(path)
+	if !ok || tag&0x20000000 == 0 {
+		return EMPTY, false
+	}
+
+	target, err := os.Readlink(path)
+	if err != nil {
+		return EMPTY, false
+	}
+
+	return target, true
+
+}
@@ -0,0 +1,24 @@
+//go:build linux || darwin || freebsd || dragonfly || aix
+
+package objectify
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// diskSpace returns the total, free, and available bytes of the filesystem
+// hosting path, read with statfs.
+func diskSpace(path string) (total, free, avail int64, err error) {
+
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, 0, 0, err
+	}
+
+	bsize := int64(st.Bsize)
+
+	// Bavail is signed on some platforms, and negative once the blocks
+	// reserved for the superuser are in use.
+	return int64(st.Blocks) * bsize, int64(st.Bfree) * bsize, max(int64(st.Bavail), 0) * bsize, nil
+
+}
@@ -0,0 +1,24 @@
+//go:build openbsd
+
+package objectify
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// diskSpace returns the total, free, and available bytes of the filesystem
+// hosting path, read with statfs.
+func diskSpace(path string) (total, free, avail int64, err error) {
+
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, 0, 0, err
+	}
+
+	bsize := int64(st.F_bsize)
+
+	// F_bavail is negative once the blocks reserved for the superuser are in
+	// use.
+	return int64(st.F_blocks) * bsize, int64(st.F_bfree) * bsize, max(int64(st.F_bavail), 0) * bsize, nil
+
+}
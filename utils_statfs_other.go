@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd || dragonfly || aix || openbsd || netbsd || solaris || windows)
+
+package objectify
+
+import (
+	"errors"
+)
+
+// diskSpace is not supported on this platform.
+func diskSpace(path string) (total, free, avail int64, err error) {
+	return 0, 0, 0, errors.ErrUnsupported
+}
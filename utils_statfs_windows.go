@@ -0,0 +1,25 @@
+//go:build windows
+
+package objectify
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// diskSpace returns the total, free, and available bytes of the volume
+// hosting path, read with GetDiskFreeSpaceEx.
+func diskSpace(path string) (total, free, avail int64, err error) {
+
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	var a, t, f uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &a, &t, &f); err != nil {
+		return 0, 0, 0, err
+	}
+
+	return int64(t), int64(f), int64(a), nil
+
+}
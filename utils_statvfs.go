@@ -0,0 +1,22 @@
+//go:build netbsd || solaris
+
+package objectify
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// diskSpace returns the total, free, and available bytes of the filesystem
+// hosting path, read with statvfs.
+func diskSpace(path string) (total, free, avail int64, err error) {
+
+	var st unix.Statvfs_t
+	if err := unix.Statvfs(path, &st); err != nil {
+		return 0, 0, 0, err
+	}
+
+	frsize := int64(st.Frsize)
+
+	return int64(st.Blocks) * frsize, int64(st.Bfree) * frsize, int64(st.Bavail) * frsize, nil
+
+}
@@ -0,0 +1,39 @@
+//go:build darwin || freebsd || dragonfly
+
+package objectify
+
+import (
+	"bytes"
+
+	"golang.org/x/sys/unix"
+)
+
+// networkFSNames are the statfs type names of network filesystems.
+var networkFSNames = map[string]bool{
+	"nfs":     true,
+	"smbfs":   true,
+	"afpfs":   true,
+	"webdav":  true,
+	"cifs":    true,
+	"fusefs":  true,
+	"macfuse": true,
+}
+
+// detectStorage recognizes network mounts by the statfs type name of the
+// filesystem hosting path. Local storage is StorageUnknown, since its
+// rotational flag is not reported through statfs.
+func detectStorage(path string) (StorageKind, error) {
+
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return StorageUnknown, err
+	}
+
+	name, _, _ := bytes.Cut(st.Fstypename[:], []byte{0})
+	if networkFSNames[string(name)] {
+		return StorageNetwork, nil
+	}
+
+	return StorageUnknown, nil
+
+}
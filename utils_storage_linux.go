@@ -0,0 +1,68 @@
+//go:build linux
+
+package objectify
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// smb2SuperMagic is the statfs type of SMB2/3 mounts, which x/sys/unix does
+// not define.
+const smb2SuperMagic = 0xfe534d42
+
+// networkFSTypes are the statfs types of network filesystems. FUSE is
+// included since most FUSE filesystems (sshfs, rclone, s3fs) are remote.
+var networkFSTypes = map[uint32]bool{
+	unix.NFS_SUPER_MAGIC:  true,
+	unix.SMB_SUPER_MAGIC:  true,
+	unix.CIFS_SUPER_MAGIC: true,
+	smb2SuperMagic:        true,
+	unix.CODA_SUPER_MAGIC: true,
+	unix.AFS_FS_MAGIC:     true,
+	unix.V9FS_MAGIC:       true,
+	unix.CEPH_SUPER_MAGIC: true,
+	unix.FUSE_SUPER_MAGIC: true,
+}
+
+// detectStorage classifies the storage hosting path by the statfs type of its
+// filesystem and, for block devices, by the rotational flag of the device (or
+// of the disk holding the partition) in /sys/dev/block.
+func detectStorage(path string) (StorageKind, error) {
+
+	var sfs unix.Statfs_t
+	if err := unix.Statfs(path, &sfs); err != nil {
+		return StorageUnknown, err
+	}
+
+	switch t := uint32(sfs.Type); {
+	case networkFSTypes[t]:
+		return StorageNetwork, nil
+	case t == unix.TMPFS_MAGIC || t == unix.RAMFS_MAGIC:
+		return StorageSSD, nil
+	}
+
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return StorageUnknown, err
+	}
+
+	dev := fmt.Sprintf("/sys/dev/block/%d:%d", unix.Major(uint64(st.Dev)), unix.Minor(uint64(st.Dev)))
+	rot, err := os.ReadFile(dev + "/queue/rotational")
+	if err != nil {
+		rot, err = os.ReadFile(dev + "/../queue/rotational")
+	}
+	if err != nil {
+		return StorageUnknown, nil
+	}
+
+	if string(bytes.TrimSpace(rot)) == "1" {
+		return StorageHDD, nil
+	}
+
+	return StorageSSD, nil
+
+}
@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd || dragonfly || windows)
+
+package objectify
+
+import (
+	"errors"
+)
+
+// detectStorage is not supported on this platform.
+func detectStorage(path string) (StorageKind, error) {
+	return StorageUnknown, errors.ErrUnsupported
+}
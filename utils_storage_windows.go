@@ -0,0 +1,30 @@
+//go:build windows
+
+package objectify
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// detectStorage recognizes network drives and shares by the drive type of the
+// volume hosting path. Local volumes are StorageUnknown, since telling SSDs
+// from HDDs requires querying the device.
+func detectStorage(path string) (StorageKind, error) {
+
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return StorageUnknown, err
+	}
+
+	vol := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumePathName(p, &vol[0], uint32(len(vol))); err != nil {
+		return StorageUnknown, err
+	}
+
+	if windows.GetDriveType(&vol[0]) == windows.DRIVE_REMOTE {
+		return StorageNetwork, nil
+	}
+
+	return StorageUnknown, nil
+
+}
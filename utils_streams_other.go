@@ -0,0 +1,9 @@
+//go:build !windows
+
+package objectify
+
+// readStreams returns the alternate data streams of the file at path. There
+// are none on this platform.
+func readStreams(path string) ([]Stream, error) {
+	return nil, nil
+}
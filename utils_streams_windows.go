@@ -0,0 +1,70 @@
+//go:build windows
+
+package objectify
+
+import (
+	"errors"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	modKernel32          = windows.NewLazySystemDLL("kernel32.dll")
+	procFindFirstStreamW = modKernel32.NewProc("FindFirstStreamW")
+	procFindNextStreamW  = modKernel32.NewProc("FindNextStreamW")
+)
+
+// win32FindStreamData is the WIN32_FIND_STREAM_DATA filled by FindFirstStreamW
+// and FindNextStreamW.
+type win32FindStreamData struct {
+	StreamSize int64
+	StreamName [windows.MAX_PATH + 36]uint16
+}
+
+// readStreams returns the alternate data streams of the file at path, in the
+// order FindFirstStreamW and FindNextStreamW return them, without the default
+// unnamed stream. It returns nil if there are none, or if the filesystem does
+// not support them.
+func readStreams(path string) ([]Stream, error) {
+
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := procFindFirstStreamW.Find(); err != nil {
+		return nil, nil
+	}
+
+	var data win32FindStreamData
+	h, _, e := procFindFirstStreamW.Call(uintptr(unsafe.Pointer(p)), 0, uintptr(unsafe.Pointer(&data)), 0)
+	if windows.Handle(h) == windows.InvalidHandle {
+		if errors.Is(e, windows.ERROR_HANDLE_EOF) || errors.Is(e, windows.ERROR_INVALID_PARAMETER) {
+			return nil, nil
+		}
+		return nil, e
+	}
+	defer windows.FindClose(windows.Handle(h))
+
+	var streams []Stream
+	for {
+
+		// Names have the form :name:$type; the default stream is ::$DATA.
+		name := strings.TrimSuffix(strings.TrimPrefix(windows.UTF16ToString(data.StreamName[:]), ":"), ":$DATA")
+		if name != EMPTY {
+			streams = append(streams, Stream{Name: name, Size: data.StreamSize})
+		}
+
+		r, _, e := procFindNextStreamW.Call(h, uintptr(unsafe.Pointer(&data)))
+		if r == 0 {
+			if errors.Is(e, windows.ERROR_HANDLE_EOF) {
+				return streams, nil
+			}
+			return nil, e
+		}
+
+	}
+
+}
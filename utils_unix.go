@@ -0,0 +1,178 @@
+//go:build unix
+
+package objectify
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+var (
+	procIDsOnce sync.Once
+	procEUID    int
+	procGroups  map[uint32]bool
+)
+
+// loadProcIDs caches the effective uid and group memberships of the current
+// process, so permission checks don't issue extra syscalls for every entry.
+func loadProcIDs() {
+
+	procEUID = os.Geteuid()
+	procGroups = map[uint32]bool{uint32(os.Getegid()): true}
+
+	groups, err := os.Getgroups()
+	if err != nil {
+		return
+	}
+	for _, g := range groups {
+		procGroups[uint32(g)] = true
+	}
+
+}
+
+// canRead reports whether the current process has read permission on the entry
+// described by info. It compares the owner and group stored in the fs.FileInfo
+// against the process's effective uid and groups and checks the matching
+// permission bits. The superuser can always read. ACLs are not considered.
+func canRead(info fs.FileInfo) bool {
+
+	procIDsOnce.Do(loadProcIDs)
+
+	st, ok := sysStat(info)
+	if !ok {
+		return info.Mode().Perm()&0444 != 0
+	}
+
+	if procEUID == 0 {
+		return true
+	}
+
+	perm := info.Mode().Perm()
+	switch {
+	case int(st.uid) == procEUID:
+		return perm&0400 != 0
+	case procGroups[st.gid]:
+		return perm&0040 != 0
+	default:
+		return perm&0004 != 0
+	}
+
+}
+
+// deviceID returns the ID of the device hosting the entry described by info,
+// and a bool indicating if it is available.
+func deviceID(info fs.FileInfo) (uint64, bool) {
+
+	st, ok := sysStat(info)
+	if !ok {
+		return 0, false
+	}
+
+	return st.dev, true
+
+}
+
+// ownerIDs returns the numeric owner and group of the entry described by info,
+// or -1 for both if they are not available.
+func ownerIDs(info fs.FileInfo) (uid, gid int) {
+
+	st, ok := sysStat(info)
+	if !ok {
+		return -1, -1
+	}
+
+	return int(st.uid), int(st.gid)
+
+}
+
+// statIDs are the identifiers recorded in the Sys of an fs.FileInfo.
+type statIDs struct {
+	dev, ino uint64
+	uid, gid uint32
+}
+
+// sysStat returns the identifiers recorded in the Sys of info, which is a
+// *syscall.Stat_t for os.Lstat and a *unix.Stat_t for lstatAt, and a bool
+// indicating if they are available.
+func sysStat(info fs.FileInfo) (statIDs, bool) {
+
+	switch st := info.Sys().(type) {
+	case *syscall.Stat_t:
+		return statIDs{dev: uint64(st.Dev), ino: uint64(st.Ino), uid: uint32(st.Uid), gid: uint32(st.Gid)}, true
+	case *unix.Stat_t:
+		return statIDs{dev: uint64(st.Dev), ino: uint64(st.Ino), uid: uint32(st.Uid), gid: uint32(st.Gid)}, true
+	}
+
+	return statIDs{}, false
+
+}
+
+// allocatedSize returns the space allocated to the entry described by info,
+// from its count of 512-byte blocks, and a bool indicating if it is available.
+func allocatedSize(info fs.FileInfo) (int64, bool) {
+
+	switch st := info.Sys().(type) {
+	case *syscall.Stat_t:
+		return int64(st.Blocks) * 512, true
+	case *unix.Stat_t:
+		return int64(st.Blocks) * 512, true
+	}
+
+	return 0, false
+
+}
+
+// fileID returns the device and inode of the entry described by info, and a
+// bool indicating if they are available.
+func fileID(info fs.FileInfo) ([2]uint64, bool) {
+
+	st, ok := sysStat(info)
+	return [2]uint64{st.dev, st.ino}, ok
+
+}
+
+// sameStat returns true if a and b describe the same file, by device and
+// inode.
+func sameStat(a, b fs.FileInfo) bool {
+
+	sa, okA := sysStat(a)
+	sb, okB := sysStat(b)
+
+	return okA && okB && sa.dev == sb.dev && sa.ino == sb.ino
+
+}
+
+// openNoFollow opens the regular file at path for the hardened option. It is
+// opened with O_NOFOLLOW, so a symlink swapped in as its last element is not
+// followed, with O_CLOEXEC, so the descriptor does not leak into child
+// processes, and with O_NONBLOCK, so a FIFO cannot block the scan. The opened
+// file is then checked to be a regular file. An error wrapping ErrUnsafeFile
+// is returned for symlinks and other files which are not regular.
+func openNoFollow(path string) (fs.File, error) {
+
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOFOLLOW|syscall.O_CLOEXEC|syscall.O_NONBLOCK, 0)
+	if errors.Is(err, syscall.ELOOP) {
+		return nil, fmt.Errorf("%w: %s is a symlink", ErrUnsafeFile, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err == nil && !info.Mode().IsRegular() {
+		err = fmt.Errorf("%w: %s is not a regular file", ErrUnsafeFile, path)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return f, nil
+
+}
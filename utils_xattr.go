@@ -0,0 +1,84 @@
+//go:build linux || darwin || freebsd || netbsd
+
+package objectify
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// readXAttrs returns the extended attributes of the entry at path, without
+// following a final symlink. It returns nil if there are none, or if the
+// filesystem does not support them.
+func readXAttrs(path string) (map[string][]byte, error) {
+
+	names, err := xattrCall(func(dest []byte) (int, error) {
+		return unix.Llistxattr(path, dest)
+	})
+	if err != nil || len(names) == 0 {
+		return nil, ignoreXAttrErr(err)
+	}
+
+	attrs := make(map[string][]byte)
+	for _, name := range bytes.Split(bytes.TrimRight(names, "\x00"), []byte{0}) {
+
+		if len(name) == 0 {
+			continue
+		}
+
+		value, err := xattrCall(func(dest []byte) (int, error) {
+			return unix.Lgetxattr(path, string(name), dest)
+		})
+		if err != nil {
+			if errors.Is(err, xattrNoAttr) {
+				continue
+			}
+			return nil, err
+		}
+		attrs[string(name)] = value
+
+	}
+
+	return attrs, nil
+
+}
+
+// xattrCall calls fn once with a nil buffer to learn the required size, then
+// with a buffer of that size, retrying if the value grew in between.
+func xattrCall(fn func(dest []byte) (int, error)) ([]byte, error) {
+
+	for {
+
+		size, err := fn(nil)
+		if err != nil || size == 0 {
+			return nil, err
+		}
+
+		buf := make([]byte, size)
+		n, err := fn(buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return buf[:n], nil
+
+	}
+
+}
+
+// ignoreXAttrErr returns nil for errors meaning extended attributes are not
+// supported by the filesystem.
+func ignoreXAttrErr(err error) error {
+
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		return nil
+	}
+
+	return err
+
+}
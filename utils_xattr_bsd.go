@@ -0,0 +1,10 @@
+//go:build darwin || freebsd || netbsd
+
+package objectify
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// xattrNoAttr is the error returned when an extended attribute does not exist.
+const xattrNoAttr = unix.ENOATTR
@@ -0,0 +1,8 @@
+package objectify
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// xattrNoAttr is the error returned when an extended attribute does not exist.
+const xattrNoAttr = unix.ENODATA
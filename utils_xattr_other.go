@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd && !netbsd
+
+package objectify
+
+// readXAttrs is not supported on this platform and always returns nil.
+func readXAttrs(path string) (map[string][]byte, error) {
+	return nil, nil
+}
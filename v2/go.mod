@@ -0,0 +1,11 @@
+module github.com/orme292/objectify/v2
+
+go 1.22.0
+
+require github.com/orme292/objectify v0.0.0-20261016134935-778ac07eaf59
+
+require (
+	golang.org/x/sync v0.10.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
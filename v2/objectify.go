@@ -0,0 +1,320 @@
+// Package objectify is the v2 API of objectify, shipped alongside v1 as its own
+// module (github.com/orme292/objectify/v2) so callers can migrate one call at a
+// time. It is a thin layer over v1, so the two can be mixed freely while
+// migrating:
+//
+//   - Path, File, PathFS, and FileFS take a context.Context, which stops the
+//     scan when it is done and cancels the reads of the files being hashed,
+//     and only variadic Options. v1's Sets become an internal detail: the
+//     fields to populate are chosen with WithFields, and default to
+//     DefaultFields.
+//   - A FileObj exposes the entry through methods (Path, Size, ModTime, MD5,
+//     SHA256, Err, and Snapshot for the rest) rather than v1's exported fields
+//     and Sets API, and Update returns the error of the update instead of
+//     leaving it in Err.
+//     Files.Err joins the errors recorded on the entries of a scan.
+//   - Every v1 option still applies through WithOptions, until its v2
+//     equivalent lands.
+//
+// To migrate, replace
+//
+//	files, err := objf.Path(root, objf.SetsAllSHA256(), objf.WithRecursive())
+//
+// with
+//
+//	files, err := objectify.Path(ctx, root,
+//		objectify.WithFields(objectify.FieldsAll&^objectify.FieldMD5),
+//		objectify.WithOptions(objf.WithRecursive()))
+//
+// Code which still takes v1 types is reached with Files.V1 and FileObj.V1, and
+// v1 results are brought over with FromV1 and Wrap.
+package objectify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	objf "github.com/orme292/objectify"
+)
+
+// Field is a set of FileObj fields to populate, replacing v1's Sets.
+type Field uint16
+
+const (
+	FieldSize Field = 1 << iota
+	FieldModes
+	FieldMD5
+	FieldSHA256
+	FieldLinkTarget
+	FieldLinkTargetFinal
+	FieldXAttrs
+	FieldDimensions
+	FieldDocProps
+	FieldCompression
+
+	// FieldsAll populates every field, like v1's SetsAll.
+	FieldsAll = FieldSize | FieldModes | FieldMD5 | FieldSHA256 | FieldLinkTarget | FieldLinkTargetFinal |
+		FieldXAttrs | FieldDimensions | FieldDocProps | FieldCompression
+
+	// DefaultFields populates the stat metadata, like v1's SetsFast.
+	DefaultFields = FieldSize | FieldModes
+)
+
+// sets returns the v1 Sets of the Field.
+func (f Field) sets() objf.Sets {
+
+	return objf.Sets{
+		Size:            f&FieldSize != 0,
+		Modes:           f&FieldModes != 0,
+		ChecksumMD5:     f&FieldMD5 != 0,
+		ChecksumSHA256:  f&FieldSHA256 != 0,
+		LinkTarget:      f&FieldLinkTarget != 0,
+		LinkTargetFinal: f&FieldLinkTargetFinal != 0,
+		XAttrs:          f&FieldXAttrs != 0,
+		Dimensions:      f&FieldDimensions != 0,
+		DocProps:        f&FieldDocProps != 0,
+		Compression:     f&FieldCompression != 0,
+	}
+
+}
+
+// config holds the settings applied by Options.
+type config struct {
+	fields Field
+	opts   []objf.Option
+}
+
+// Option configures a scan.
+type Option func(*config)
+
+// WithFields populates the fields f instead of DefaultFields.
+func WithFields(f Field) Option {
+	return func(c *config) {
+		c.fields = f
+	}
+}
+
+// WithOptions applies v1 options (e.g. objf.WithRecursive()) to the scan.
+func WithOptions(opts ...objf.Option) Option {
+	return func(c *config) {
+		c.opts = append(c.opts, opts...)
+	}
+}
+
+// newConfig returns the config of opts, with the v1 options stopping the scan
+// once ctx is done (see v1's WithContext).
+func newConfig(ctx context.Context, opts []Option) *config {
+
+	c := &config{fields: DefaultFields}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+
+	c.opts = append(c.opts, objf.WithContext(ctx))
+
+	return c
+
+}
+
+// Path objectifies the entries of the directory root, like v1's Path. When
+// ctx is done, the remaining entries are skipped, the reads of the files
+// being hashed are cancelled, and ctx's error is returned.
+func Path(ctx context.Context, root string, opts ...Option) (Files, error) {
+
+	return path(ctx, opts, func(c *config) (objf.Files, error) {
+		return objf.Path(root, c.fields.sets(), c.opts...)
+	})
+
+}
+
+// PathFS works like Path, but reads root from fsys, like v1's PathFS.
+func PathFS(ctx context.Context, fsys fs.FS, root string, opts ...Option) (Files, error) {
+
+	return path(ctx, opts, func(c *config) (objf.Files, error) {
+		return objf.PathFS(fsys, root, c.fields.sets(), c.opts...)
+	})
+
+}
+
+// File objectifies the file at path, like v1's File. Unlike v1, an error is
+// returned whenever no FileObj is.
+func File(ctx context.Context, path string, opts ...Option) (*FileObj, error) {
+
+	return file(ctx, path, opts, func(c *config) (*objf.FileObj, error) {
+		return objf.File(path, c.fields.sets(), c.opts...)
+	})
+
+}
+
+// FileFS works like File, but reads path from fsys, like v1's FileFS.
+func FileFS(ctx context.Context, fsys fs.FS, path string, opts ...Option) (*FileObj, error) {
+
+	return file(ctx, path, opts, func(c *config) (*objf.FileObj, error) {
+		return objf.FileFS(fsys, path, c.fields.sets(), c.opts...)
+	})
+
+}
+
+// path runs the v1 scan of Path or PathFS.
+func path(ctx context.Context, opts []Option, scan func(*config) (objf.Files, error)) (Files, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	files, err := scan(newConfig(ctx, opts))
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return FromV1(files), nil
+
+}
+
+// file runs the v1 scan of File or FileFS.
+func file(ctx context.Context, p string, opts []Option, scan func(*config) (*objf.FileObj, error)) (*FileObj, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fo, err := scan(newConfig(ctx, opts))
+	switch {
+	case err != nil:
+		return nil, err
+	case fo == nil:
+		return nil, fmt.Errorf("%w: %s", fs.ErrNotExist, p)
+	}
+
+	return Wrap(fo), nil
+
+}
+
+// FileObj is a directory entry, wrapping a v1 FileObj.
+type FileObj struct {
+	v1 *objf.FileObj
+}
+
+// Wrap returns the v2 FileObj of the v1 FileObj fo, or nil if fo is nil. The
+// two share their state.
+func Wrap(fo *objf.FileObj) *FileObj {
+
+	if fo == nil {
+		return nil
+	}
+
+	return &FileObj{v1: fo}
+
+}
+
+// V1 returns the v1 FileObj the FileObj wraps, which shares its state.
+func (fo *FileObj) V1() *objf.FileObj {
+	return fo.v1
+}
+
+// Snapshot returns a frozen view of every field of the entry (see v1's
+// FileSnapshot).
+func (fo *FileObj) Snapshot() objf.FileSnapshot {
+	return fo.v1.Snapshot()
+}
+
+// Path returns the full path of the entry.
+func (fo *FileObj) Path() string {
+	return fo.v1.FullPath()
+}
+
+// Size returns the size of the entry in bytes, if FieldSize was populated.
+func (fo *FileObj) Size() int64 {
+	return fo.v1.Snapshot().SizeBytes
+}
+
+// ModTime returns the modification time of the entry as last read.
+func (fo *FileObj) ModTime() time.Time {
+	return fo.v1.ModTime()
+}
+
+// MD5 returns the MD5 checksum of the entry, if FieldMD5 was populated.
+func (fo *FileObj) MD5() []byte {
+	return fo.v1.MD5Sum()
+}
+
+// SHA256 returns the SHA256 checksum of the entry, if FieldSHA256 was
+// populated.
+func (fo *FileObj) SHA256() []byte {
+	return fo.v1.SHA256Sum()
+}
+
+// Err returns the error recorded while the entry was last read, if any. Like
+// v1's Err field, it is not guarded against a concurrent Update.
+func (fo *FileObj) Err() error {
+	return fo.v1.Err
+}
+
+// Update reads the entry again if it has changed since it was last read (see
+// v1's Update), and returns the error recorded by that update, if any.
+func (fo *FileObj) Update(ctx context.Context) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	fo.v1.Update()
+
+	return fo.Err()
+
+}
+
+// Files is a list of FileObjs.
+type Files []*FileObj
+
+// FromV1 returns the v2 Files of the v1 Files files, which share their state.
+func FromV1(files objf.Files) Files {
+
+	out := make(Files, len(files))
+	for i, fo := range files {
+		out[i] = Wrap(fo)
+	}
+
+	return out
+
+}
+
+// V1 returns the v1 Files of the Files, e.g. for Diff or the report and
+// export methods, which share their state.
+func (files Files) V1() objf.Files {
+
+	out := make(objf.Files, len(files))
+	for i, fo := range files {
+		if fo != nil {
+			out[i] = fo.v1
+		}
+	}
+
+	return out
+
+}
+
+// Err returns the errors recorded on the entries while they were read, joined
+// with errors.Join, or nil if there were none.
+func (files Files) Err() error {
+
+	var errs []error
+	for _, fo := range files {
+		if fo == nil {
+			continue
+		}
+		if err := fo.Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+
+}
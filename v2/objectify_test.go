@@ -0,0 +1,88 @@
+package objectify
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	objf "github.com/orme292/objectify"
+)
+
+// cancelFS is the OS filesystem, except that the first read of a file's
+// content cancels the scan. It counts the reads.
+type cancelFS struct {
+	objf.OSFileSystem
+	cancel context.CancelFunc
+	reads  atomic.Int64
+}
+
+func (c *cancelFS) Open(name string) (fs.File, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cancelFile{File: f, sys: c}, nil
+
+}
+
+type cancelFile struct {
+	*os.File
+	sys *cancelFS
+}
+
+func (f *cancelFile) Read(p []byte) (int, error) {
+
+	f.sys.reads.Add(1)
+	f.sys.cancel()
+
+	return f.File.Read(p[:min(len(p), 16)])
+
+}
+
+func TestPathCancelsHashing(t *testing.T) {
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "big"), make([]byte, 1<<16), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sys := &cancelFS{cancel: cancel}
+
+	_, err := Path(ctx, dir, WithFields(FieldSHA256), WithOptions(objf.WithFileSystem(sys)))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Path() error = %v, want %v", err, context.Canceled)
+	}
+	if n := sys.reads.Load(); n != 1 {
+		t.Errorf("hashing made %d reads after the scan was cancelled, want it stopped after 1", n-1)
+	}
+
+}
+
+func TestFileObjMethods(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("objectify"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fo, err := File(context.Background(), path, WithFields(DefaultFields|FieldSHA256))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fo.Path() != path || fo.Size() != 9 || fo.ModTime().IsZero() || len(fo.SHA256()) != 32 || fo.Err() != nil {
+		t.Errorf("Path = %s, Size = %d, ModTime = %v, SHA256 = %x, Err = %v", fo.Path(), fo.Size(), fo.ModTime(), fo.SHA256(), fo.Err())
+	}
+	if fo.V1().FullPath() != path {
+		t.Errorf("V1().FullPath() = %s, want %s", fo.V1().FullPath(), path)
+	}
+
+}
@@ -0,0 +1,401 @@
+// Package webdav provides an fs.FS view of a WebDAV collection, so NAS devices and
+// other servers which expose WebDAV can be objectified with objectify.PathFS and
+// diffed or verified against local scans. Directory listings are made with
+// PROPFIND (Depth: 1), and during a scan by FS.Path the size, modification time,
+// ETag, and content type reported for each resource are reused for its FileObj.
+// File content is only requested (with GET) when the Sets request checksums.
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	objf "github.com/orme292/objectify"
+	"github.com/orme292/objectify/internal/fserr"
+	"github.com/orme292/objectify/internal/remotefs"
+)
+
+// propfindBody requests the properties used to build each fs.FileInfo.
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop>
+<d:resourcetype/><d:getcontentlength/><d:getlastmodified/><d:getetag/><d:getcontenttype/>
+</d:prop></d:propfind>`
+
+// Config holds the connection settings for a WebDAV server.
+type Config struct {
+
+	// Username and Password are sent using HTTP basic authentication
+	// when Username is not empty.
+	Username string
+	Password string
+
+	// HTTPClient is used for all requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// FS is an fs.FS rooted at a WebDAV collection.
+type FS struct {
+	base *url.URL
+	cfg  Config
+	ctx  context.Context
+
+	// infos caches the fs.FileInfo of the resources listed during a scan by
+	// Path. It is nil outside a scan, so the FileObjs of a scan which are
+	// updated later see the current state of their resource.
+	mu    *sync.Mutex
+	infos map[string]*fileInfo
+}
+
+// New returns an FS rooted at the collection at baseURL.
+func New(baseURL string, cfg Config) (*FS, error) {
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("webdav: unsupported URL scheme: %q", u.Scheme)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+
+	return &FS{
+		base: u,
+		cfg:  cfg,
+		ctx:  context.Background(),
+		mu:   &sync.Mutex{},
+	}, nil
+
+}
+
+// WithContext returns a shallow copy of the FS whose requests use ctx.
+func (f *FS) WithContext(ctx context.Context) *FS {
+
+	c := *f
+	c.ctx = ctx
+	return &c
+
+}
+
+// Path objectifies the resources of dir, relative to the FS root, using
+// objectify.PathFS. An empty dir scans the root collection. The properties
+// of the listings are reused for the FileObjs while the scan runs;
+// FileObj.Update and HasChanged make a PROPFIND request afterwards.
+func (f *FS) Path(dir string, s objf.Sets, opts ...objf.Option) (objf.Files, error) {
+
+	dir = strings.Trim(dir, "/")
+	if dir == "" {
+		dir = "."
+	}
+
+	scan := *f
+	scan.mu = &sync.Mutex{}
+	scan.infos = make(map[string]*fileInfo)
+	defer scan.endScan()
+
+	return objf.PathFS(&scan, dir, s, opts...)
+
+}
+
+// endScan drops the listing cache of a scan, and stops caching.
+func (f *FS) endScan() {
+
+	f.mu.Lock()
+	f.infos = nil
+	f.mu.Unlock()
+
+}
+
+// Open opens the named resource. For a non-collection, the GET request is
+// made on the first Read.
+func (f *FS) Open(name string) (fs.File, error) {
+
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fserr.Unwrap(err)}
+	}
+
+	if info.IsDir() {
+		return remotefs.NewDir(name, info, f.ReadDir), nil
+	}
+
+	return remotefs.NewFile(name, info, f.get), nil
+
+}
+
+// Stat returns the fs.FileInfo of the named resource. During a scan by Path,
+// values recorded by an earlier ReadDir are reused; otherwise a PROPFIND
+// (Depth: 0) request is made.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	f.mu.Lock()
+	cached, ok := f.infos[name]
+	f.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	responses, err := f.propfind(name, "0")
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	if len(responses) == 0 {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	info := responses[0].info(path.Base(name))
+	f.cache(name, info)
+
+	return info, nil
+
+}
+
+// ReadDir lists the members of the named collection, sorted by name. During a
+// scan by Path, the properties of each member are cached for later Stat calls.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	responses, err := f.propfind(name, "1")
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	self := strings.TrimSuffix(f.resolve(name).Path, "/")
+	entries := make([]fs.DirEntry, 0, len(responses))
+
+	for _, r := range responses {
+
+		p, err := r.path()
+		if err != nil || strings.TrimSuffix(p, "/") == self {
+			continue
+		}
+
+		base := path.Base(strings.TrimSuffix(p, "/"))
+		info := r.info(base)
+		if name == "." {
+			f.cache(base, info)
+		} else {
+			f.cache(name+"/"+base, info)
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+
+}
+
+// cache records the fs.FileInfo of a resource name, during a scan.
+func (f *FS) cache(name string, info *fileInfo) {
+
+	f.mu.Lock()
+	if f.infos != nil {
+		f.infos[name] = info
+	}
+	f.mu.Unlock()
+
+}
+
+// do builds and sends a request for the named resource.
+func (f *FS) do(method, name string, headers map[string]string, body io.Reader) (*http.Response, error) {
+
+	req, err := http.NewRequestWithContext(f.ctx, method, f.resolve(name).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if f.cfg.Username != "" {
+		req.SetBasicAuth(f.cfg.Username, f.cfg.Password)
+	}
+
+	return f.cfg.HTTPClient.Do(req)
+
+}
+
+// get starts a GET request for the resource content.
+func (f *FS) get(name string) (io.ReadCloser, error) {
+
+	resp, err := f.do(http.MethodGet, name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("webdav: GET %s: %s", name, resp.Status)
+	}
+
+	return resp.Body, nil
+
+}
+
+// propfind makes a PROPFIND request for the named resource with the given depth.
+// fs.ErrNotExist is returned for a missing resource.
+func (f *FS) propfind(name, depth string) ([]response, error) {
+
+	headers := map[string]string{
+		"Depth":        depth,
+		"Content-Type": "application/xml; charset=utf-8",
+	}
+
+	resp, err := f.do("PROPFIND", name, headers, strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fs.ErrNotExist
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("webdav: PROPFIND %s: %s", name, resp.Status)
+	}
+
+	ms := &multistatus{}
+	if err := xml.NewDecoder(resp.Body).Decode(ms); err != nil {
+		return nil, err
+	}
+
+	return ms.Responses, nil
+
+}
+
+// resolve returns the URL of the named resource.
+func (f *FS) resolve(name string) *url.URL {
+
+	u := *f.base
+	if name != "." {
+		u.Path = path.Join(f.base.Path, name)
+	}
+
+	return &u
+
+}
+
+// multistatus is a PROPFIND response body.
+type multistatus struct {
+	Responses []response `xml:"DAV: response"`
+}
+
+// response is a single resource in a multistatus body.
+type response struct {
+	Href     string `xml:"DAV: href"`
+	Propstat []struct {
+		Status string `xml:"DAV: status"`
+		Prop   struct {
+			ResourceType struct {
+				Collection *struct{} `xml:"DAV: collection"`
+			} `xml:"DAV: resourcetype"`
+			ContentLength string `xml:"DAV: getcontentlength"`
+			LastModified  string `xml:"DAV: getlastmodified"`
+			ETag          string `xml:"DAV: getetag"`
+			ContentType   string `xml:"DAV: getcontenttype"`
+		} `xml:"DAV: prop"`
+	} `xml:"DAV: propstat"`
+}
+
+// path returns the unescaped URL path of the response's href.
+func (r response) path() (string, error) {
+
+	u, err := url.Parse(r.Href)
+	if err != nil {
+		return "", err
+	}
+
+	return u.Path, nil
+
+}
+
+// info returns the fs.FileInfo built from the response's successful properties,
+// using base as its name.
+func (r response) info(base string) *fileInfo {
+
+	fi := &fileInfo{name: base}
+
+	for _, ps := range r.Propstat {
+
+		if !strings.Contains(ps.Status, " 200 ") {
+			continue
+		}
+
+		if ps.Prop.ResourceType.Collection != nil {
+			fi.dir = true
+		}
+		if ps.Prop.ContentLength != "" {
+			fi.size, _ = strconv.ParseInt(ps.Prop.ContentLength, 10, 64)
+		}
+		if ps.Prop.LastModified != "" {
+			fi.modTime, _ = http.ParseTime(ps.Prop.LastModified)
+		}
+		if ps.Prop.ETag != "" {
+			fi.etag = strings.Trim(strings.TrimPrefix(ps.Prop.ETag, "W/"), `"`)
+		}
+		if ps.Prop.ContentType != "" {
+			fi.contentType = ps.Prop.ContentType
+		}
+
+	}
+
+	return fi
+
+}
+
+// fileInfo implements fs.FileInfo, objectify.ETagInfo, and
+// objectify.ContentTypeInfo for a WebDAV resource.
+type fileInfo struct {
+	name        string
+	size        int64
+	modTime     time.Time
+	etag        string
+	contentType string
+	dir         bool
+}
+
+func (fi *fileInfo) Name() string        { return fi.name }
+func (fi *fileInfo) Size() int64         { return fi.size }
+func (fi *fileInfo) ModTime() time.Time  { return fi.modTime }
+func (fi *fileInfo) IsDir() bool         { return fi.dir }
+func (fi *fileInfo) Sys() any            { return nil }
+func (fi *fileInfo) ETag() string        { return fi.etag }
+func (fi *fileInfo) ContentType() string { return fi.contentType }
+
+// Mode returns fs.ModeDir for collections and a read-only mode for other resources.
+func (fi *fileInfo) Mode() fs.FileMode {
+
+	if fi.dir {
+		return fs.ModeDir | 0555
+	}
+
+	return 0444
+
+}
@@ -0,0 +1,257 @@
+package webdav
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	objf "github.com/orme292/objectify"
+)
+
+// davServer serves files, keyed by their slash-separated path, from a WebDAV
+// collection at /dav/. Directories are implied by the paths. Every request
+// blocks while hang is set.
+type davServer struct {
+	mu      sync.Mutex
+	files   map[string]string
+	modTime time.Time
+	hang    chan struct{}
+}
+
+func (s *davServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	s.mu.Lock()
+	hang := s.hang
+	s.mu.Unlock()
+	if hang != nil {
+		select {
+		case <-hang:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, p, ok := r.BasicAuth(); !ok || u != "me" || p != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/dav"), "/")
+
+	switch r.Method {
+	case http.MethodGet:
+		body, ok := s.files[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, body)
+	case "PROPFIND":
+		s.propfind(w, name, r.Header.Get("Depth"))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+
+}
+
+// propfind writes the multistatus body for the resource name, and for its
+// members if depth is "1".
+func (s *davServer) propfind(w http.ResponseWriter, name, depth string) {
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?><d:multistatus xmlns:d="DAV:">`)
+	resource := func(p string, dir bool, size int) {
+		href := "/dav/" + p
+		typ := ""
+		if dir {
+			href += "/"
+			typ = "<d:collection/>"
+		}
+		fmt.Fprintf(&b, `<d:response><d:href>%s</d:href><d:propstat><d:status>HTTP/1.1 200 OK</d:status><d:prop>`+
+			`<d:resourcetype>%s</d:resourcetype><d:getcontentlength>%d</d:getcontentlength>`+
+			`<d:getlastmodified>%s</d:getlastmodified><d:getetag>"%s-%d"</d:getetag></d:prop></d:propstat></d:response>`,
+			strings.Replace(href, "//", "/", 1), typ, size, s.modTime.UTC().Format(http.TimeFormat), p, size)
+	}
+
+	if body, ok := s.files[name]; ok {
+		resource(name, false, len(body))
+	} else {
+		prefix := name + "/"
+		if name == "" {
+			prefix = ""
+		}
+		members := make(map[string]int)
+		for p, body := range s.files {
+			rest, ok := strings.CutPrefix(p, prefix)
+			if !ok {
+				continue
+			}
+			if dir, _, nested := strings.Cut(rest, "/"); nested {
+				members[prefix+dir] = -1
+			} else {
+				members[p] = len(body)
+			}
+		}
+		if len(members) == 0 && name != "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		resource(name, true, 0)
+		if depth == "1" {
+			for p, size := range members {
+				resource(p, size < 0, max(size, 0))
+			}
+		}
+	}
+
+	b.WriteString(`</d:multistatus>`)
+	w.WriteHeader(http.StatusMultiStatus)
+	_, _ = io.WriteString(w, b.String())
+
+}
+
+// set replaces the content of a file, and moves the modification time of
+// every file to modTime.
+func (s *davServer) set(name, body string, modTime time.Time) {
+
+	s.mu.Lock()
+	s.files[name], s.modTime = body, modTime
+	s.mu.Unlock()
+
+}
+
+// newDAVServer starts a davServer for files, and returns an FS for it.
+func newDAVServer(t *testing.T, files map[string]string) (*FS, *davServer) {
+
+	s := &davServer{files: files, modTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	f, err := New(srv.URL+"/dav", Config{Username: "me", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f, s
+
+}
+
+func TestReadDirAndOpen(t *testing.T) {
+
+	f, _ := newDAVServer(t, map[string]string{"a.txt": "alpha", "sub/b.txt": "beta"})
+
+	entries, err := f.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, fmt.Sprintf("%s:%t", e.Name(), e.IsDir()))
+	}
+	if got := strings.Join(names, " "); got != "a.txt:false sub:true" {
+		t.Errorf("ReadDir(.) = %s, want a.txt:false sub:true", got)
+	}
+
+	data, err := fs.ReadFile(f, "sub/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "beta" {
+		t.Errorf("ReadFile(sub/b.txt) = %q, want beta", data)
+	}
+
+	info, err := f.Stat("sub/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 4 || info.(objf.ETagInfo).ETag() != "sub/b.txt-4" {
+		t.Errorf("Stat(sub/b.txt) = %d bytes, ETag %q", info.Size(), info.(objf.ETagInfo).ETag())
+	}
+
+	if _, err := f.Stat("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat(missing) error = %v, want %v", err, fs.ErrNotExist)
+	}
+
+}
+
+func TestPath(t *testing.T) {
+
+	files := map[string]string{"a.txt": "alpha", "sub/b.txt": "beta", "sub/c.txt": "gamma"}
+	f, s := newDAVServer(t, files)
+
+	scanned, err := f.Path("", objf.Sets{Size: true, ChecksumSHA256: true}, objf.WithRecursive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, fo := range scanned {
+		got = append(got, fo.FullPath())
+		want := fmt.Sprintf("%x", sha256.Sum256([]byte(files[fo.FullPath()])))
+		if fo.ChecksumSHA256 != want {
+			t.Errorf("%s: SHA256 = %s, want %s", fo.FullPath(), fo.ChecksumSHA256, want)
+		}
+	}
+	sort.Strings(got)
+	if strings.Join(got, " ") != "a.txt sub/b.txt sub/c.txt" {
+		t.Errorf("scanned %q", got)
+	}
+
+	// The listings are reused during the scan, but not once it is done.
+	var a *objf.FileObj
+	for _, fo := range scanned {
+		if fo.FullPath() == "a.txt" {
+			a = fo
+		}
+	}
+	s.set("a.txt", "alpha, edited", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if !a.HasChanged() {
+		t.Fatal("HasChanged() = false after the file changed")
+	}
+	a.Update()
+	if a.SizeBytes != int64(len("alpha, edited")) {
+		t.Errorf("SizeBytes = %d after Update, want %d", a.SizeBytes, len("alpha, edited"))
+	}
+
+}
+
+func TestWithContext(t *testing.T) {
+
+	f, s := newDAVServer(t, map[string]string{"a.txt": "alpha"})
+	hang := make(chan struct{})
+	defer close(hang)
+	s.mu.Lock()
+	s.hang = hang
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := f.WithContext(ctx).Stat("a.txt")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Stat() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stat() did not return once its context was done")
+	}
+
+}